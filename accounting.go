@@ -0,0 +1,87 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- ACCOUNTING ---
+
+// accountsCommand exports the sales for the bookkeeping, mapped to
+// accounts:
+//
+//	accounts [-from D] [-to D] [-csv]
+//
+// Without -csv it prints the totals per account; with -csv every line is
+// written as a journal entry.
+func accountsCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("accounts", flag.ExitOnError)
+	dates := addDateRangeFlags(fs)
+	asCSV := fs.Bool("csv", false, "write every sale line as CSV")
+	fs.Parse(args)
+	from, to, err := dates.parse()
+	if err != nil {
+		return err
+	}
+	sales := s.SalesBetween(from, to)
+
+	if *asCSV {
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"date", "sale", "account", "category", "item", "quantity", "net", "tax", "gross", "tax_class", "tax_rate"})
+		for _, sale := range sales {
+			for _, l := range sale.Lines {
+				category, account := cfg.LineAccount(l)
+				w.Write([]string{
+					sale.Time.Format(time.DateOnly), strconv.Itoa(sale.ID), account, category, l.Name,
+					strconv.Itoa(l.Quantity), ui.Money(l.Net()), ui.Money(l.Tax()), ui.Money(l.Gross()),
+					l.TaxClass, strconv.FormatFloat(l.TaxRate, 'f', -1, 64),
+				})
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	type total struct {
+		account, category string
+		net, tax, gross   float64
+	}
+	var totals []*total
+	byAccount := map[[2]string]*total{}
+	for _, sale := range sales {
+		for _, l := range sale.Lines {
+			category, account := cfg.LineAccount(l)
+			t := byAccount[[2]string{account, category}]
+			if t == nil {
+				t = &total{account: account, category: category}
+				byAccount[[2]string{account, category}] = t
+				totals = append(totals, t)
+			}
+			t.net += l.Net()
+			t.tax += l.Tax()
+			t.gross += l.Gross()
+		}
+	}
+	slices.SortFunc(totals, func(a, b *total) int {
+		return cmp.Or(cmp.Compare(a.account, b.account), cmp.Compare(a.category, b.category))
+	})
+
+	p := ui.PrintLocale
+	fmt.Printf("%s %s\n\n", p.T("accounts"), dates)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", p.T("account"), p.T("category"), p.T("net"), p.T("tax"), p.T("gross"))
+	for _, t := range totals {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", t.account, t.category, p.Number(t.net), p.Number(t.tax), p.Number(t.gross))
+	}
+	return w.Flush()
+}
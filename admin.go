@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- ADMIN VIEWS ---
+
+// adminCommand runs the admin TUI.
+func adminCommand(cfg ui.Config, s *store.Store, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: admin")
+	}
+	_, err := tea.NewProgram(ui.NewAdminModel(cfg, s), tea.WithAltScreen()).Run()
+	return err
+}
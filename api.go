@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- HTTP API ---
+
+// apiHandler serves the endpoints for external tools:
+//
+//	GET  /inventory               every beverage with its stock
+//	GET  /inventory/{name}        one beverage
+//	POST /inventory/{name}/stock  applies the StockAdjustment in the body
+//	POST /sales                   books the Sale in the body, returns it with its ID;
+//	                              lines without a tax class are priced by the till.
+//	                              With an Idempotency-Key header, retries are
+//	                              answered with the first response
+//	                              rather than booked again.
+//
+// Every request works on a store of its own, which update keeps consistent
+// with the till and other requests through the file lock.
+func apiHandler(path string, cfg *atomic.Pointer[ui.Config]) *http.ServeMux {
+	storeFor := func(r *http.Request) *store.Store { return storeForRequest(path, cfg, r) }
+	inventory := func(s *store.Store, b domain.Beverage) store.InventoryItem {
+		return store.InventoryItem{
+			Beverage:  b,
+			Available: b.AvailableFrom(s.Beverages),
+			Low:       b.IsLowStock(cfg.Load().LowStock),
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /inventory", func(w http.ResponseWriter, r *http.Request) {
+		s := storeFor(r)
+		if err := s.Reload(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items := make([]store.InventoryItem, len(s.Beverages))
+		for i, b := range s.Beverages {
+			items[i] = inventory(s, b)
+		}
+		writeJSON(w, items)
+	})
+	mux.HandleFunc("GET /inventory/{name}", func(w http.ResponseWriter, r *http.Request) {
+		s := storeFor(r)
+		if err := s.Reload(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		i := s.BeverageIndex(r.PathValue("name"))
+		if i < 0 {
+			http.Error(w, fmt.Sprintf("unknown beverage %q", r.PathValue("name")), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, inventory(s, s.Beverages[i]))
+	})
+	mux.HandleFunc("POST /inventory/{name}/stock", func(w http.ResponseWriter, r *http.Request) {
+		var adj store.StockAdjustment
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&adj); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s := storeFor(r)
+		if err := s.AdjustStock(r.PathValue("name"), adj.Delta); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, inventory(s, s.Beverages[s.BeverageIndex(r.PathValue("name"))]))
+	})
+	mux.HandleFunc("POST /sales", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var sale domain.Sale
+		if err := json.Unmarshal(body, &sale); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s := storeFor(r)
+		if err := s.Reload(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := completeSale(&sale, s, *cfg.Load(), time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if key := r.Header.Get(store.IdempotencyHeader); key != "" {
+			response, replayed, err := s.RecordSaleOnce(key, store.RequestHash(body), sale, time.Now())
+			switch {
+			case errors.Is(err, store.ErrKeyReused):
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if replayed {
+				w.Header().Set(store.ReplayedHeader, "true")
+			}
+			w.Write(append(response, '\n'))
+			return
+		}
+		booked, err := s.RecordSale(sale)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, booked)
+	})
+	return mux
+}
+
+// completeSale fills in what a script posting a sale may leave out: the
+// time, and the price and tax of lines that only name a beverage and a
+// quantity. Sales from the till come complete and are left as they are.
+func completeSale(sale *domain.Sale, s *store.Store, cfg ui.Config, now time.Time) error {
+	if len(sale.Lines) == 0 {
+		return fmt.Errorf("the sale has no lines")
+	}
+	if sale.Time.IsZero() {
+		sale.Time = now
+	}
+	if n := utf8.RuneCountInString(sale.Note); n > domain.MaxNoteLength {
+		return fmt.Errorf("the note has %d characters, at most %d are allowed", n, domain.MaxNoteLength)
+	}
+	// A member pays the prices of their tier.
+	tier := ""
+	if i := s.MemberIndex(sale.Member); i >= 0 {
+		tier = cfg.TierOf(s.Members[i])
+	}
+	for i := range sale.Lines {
+		line := &sale.Lines[i]
+		if line.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity %d of %s", line.Quantity, line.Name)
+		}
+		if line.TaxClass != "" {
+			continue
+		}
+		b := s.BeverageIndex(line.Name)
+		if b < 0 {
+			return fmt.Errorf("unknown beverage %q", line.Name)
+		}
+		line.UnitPrice = s.Beverages[b].TierPrice(tier)
+		cfg.Classify(line, s.Beverages[b])
+	}
+	return nil
+}
+
+// storeForRequest returns a store of its own for a request. Changes are
+// audited as made by the client; terminals in client mode name their user.
+func storeForRequest(path string, cfg *atomic.Pointer[ui.Config], r *http.Request) *store.Store {
+	s := &store.Store{Path: path}
+	s.Configure(cfg.Load().StoreOptions())
+	s.Actor = "api " + r.RemoteAddr
+	if actor := r.Header.Get(store.ActorHeader); actor != "" {
+		s.Actor = actor + " via " + r.RemoteAddr
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// serveAPI runs the API next to the TUI until the program exits.
+func serveAPI(addr string, cfg ui.Config, s *store.Store) error {
+	if s.Remote != nil {
+		return fmt.Errorf("-listen serves the local store; with server.url set, use the server's API instead")
+	}
+	listener, err := listen(addr)
+	if err != nil {
+		return err
+	}
+	var current atomic.Pointer[ui.Config]
+	current.Store(&cfg)
+	srv := &http.Server{Handler: requireToken(&current, apiHandler(s.Path, &current))}
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			fmt.Fprintf(os.Stderr, "api: %v\n", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- BANK IMPORT ---
+
+// importBankCommand books the top-ups of a bank statement:
+//
+//	member import [-dry-run | -yes] <statement.csv>
+//
+// The matched transfers are shown for review first, with -dry-run only
+// that; -yes books them without asking, e.g. from a script.
+func importBankCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("member import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only show how the transfers would be booked")
+	yes := fs.Bool("yes", false, "book the matched transfers without review")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: member import [-dry-run | -yes] <statement.csv>")
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	transfers, err := store.ReadBankStatement(f, cfg.Bank)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	s.MatchBankTransfers(transfers)
+
+	switch {
+	case *dryRun:
+		return store.WriteBankTransfers(os.Stdout, transfers)
+	case !*yes:
+		result, err := tea.NewProgram(ui.NewBankReview(transfers)).Run()
+		if err != nil {
+			return err
+		}
+		review := result.(ui.BankReview)
+		if !review.Confirmed {
+			fmt.Println("Nothing booked.")
+			return nil
+		}
+		transfers = review.SelectedTransfers()
+	}
+	n, total, err := s.BookBankTransfers(transfers)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Booked %d top-ups, %.2f in total.\n", n, total)
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- MENU BOARD ---
+
+// boardCommand shows the menu board full screen, or with -html writes it
+// as a page for a digital signage player, rewriting it every poll interval
+// with -watch.
+func boardCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("board", flag.ExitOnError)
+	htmlPath := fs.String("html", "", "write the board to this HTML file instead of showing it")
+	watch := fs.Bool("watch", false, "with -html, keep the file up to date")
+	fs.Parse(args)
+
+	if *htmlPath == "" {
+		p := tea.NewProgram(ui.NewBoardModel(cfg, s, time.Now()), tea.WithAltScreen())
+		_, err := p.Run()
+		return err
+	}
+	for {
+		if err := ui.WriteBoardHTML(*htmlPath, ui.BuildMenu(cfg, s, time.Now()), ui.StorePollInterval); err != nil {
+			return err
+		}
+		if !*watch {
+			return nil
+		}
+		time.Sleep(ui.StorePollInterval)
+		if err := s.Reload(); err != nil {
+			return err
+		}
+	}
+}
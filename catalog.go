@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- CATALOG IMPORT ---
+
+// importCatalogCommand merges a beverage catalog into the inventory:
+//
+//	stock import [-dry-run | -yes] <catalog.csv>
+//
+// The catalog has a header naming its columns: name, and any of price,
+// stock, barcode and category. What would be added and changed is shown
+// for review first, with -dry-run only that; -yes merges it without
+// asking.
+func importCatalogCommand(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("stock import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only show what would be added and changed")
+	yes := fs.Bool("yes", false, "merge the catalog without review")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: stock import [-dry-run | -yes] <catalog.csv>")
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	changes, err := s.ReadCatalog(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	switch {
+	case *dryRun:
+		return store.WriteCatalogChanges(os.Stdout, changes)
+	case !*yes:
+		result, err := tea.NewProgram(ui.NewCatalogReview(changes)).Run()
+		if err != nil {
+			return err
+		}
+		review := result.(ui.CatalogReview)
+		if !review.Confirmed {
+			fmt.Println("Nothing merged.")
+			return nil
+		}
+		changes = review.SelectedChanges()
+	}
+	added, changed, err := s.MergeCatalog(changes)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %d beverages and changed %d.\n", added, changed)
+	return nil
+}
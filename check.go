@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- CONSISTENCY CHECK ---
+
+// checkCommand runs the consistency check now:
+//
+//	check
+//
+// With consistency.dir set, the members' balances are checked against the
+// latest snapshot, and the result is kept as today's, as by the nightly
+// check of serve and the kiosk. Divergences fail the command, so a cron
+// job or systemd timer notices them, too.
+func checkCommand(cfg ui.Config, s *store.Store, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: check")
+	}
+	var snap store.Snapshot
+	var err error
+	if cfg.Consistency.Dir == "" {
+		snap = s.CheckConsistency(nil, time.Now())
+	} else {
+		snap, err = s.NightlyCheck(cfg.Consistency, cfg.Mail, time.Now())
+	}
+	for _, d := range snap.Divergences {
+		fmt.Println(d)
+	}
+	if err != nil {
+		return err
+	}
+	if len(snap.Divergences) > 0 {
+		return fmt.Errorf("the store diverges from its history, see above")
+	}
+	fmt.Printf("%d sales and %d members check out.\n", snap.Sales, len(snap.Openings))
+	return nil
+}
+
+// checkNightly runs the consistency check of the store at path whenever
+// it is due, until done is closed.
+func checkNightly(path string, cfg *atomic.Pointer[ui.Config], done <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(time.Minute):
+		case <-done:
+			return
+		}
+		c, now := cfg.Load(), time.Now()
+		if !c.Consistency.Due(now) {
+			continue
+		}
+		s, err := store.Open(path)
+		if err == nil {
+			var snap store.Snapshot
+			snap, err = s.NightlyCheck(c.Consistency, c.Mail, now)
+			for _, d := range snap.Divergences {
+				fmt.Fprintf(os.Stderr, "consistency: %s\n", d)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "consistency check: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- COFFEE MACHINE ---
+
+type coffeeBiller struct {
+	store   *store.Store
+	cfg     ui.Config
+	log     io.Writer
+	session *cardSession
+}
+
+// read books a new counter reading and bills the shots since the previous
+// one to the member of the open session, if any.
+func (c *coffeeBiller) read(counter int, now time.Time) error {
+	if len(c.store.CoffeeReadings) > 0 && counter == c.store.LastCoffeeCounter() {
+		return nil
+	}
+	return c.store.Update(func() error { return c.bookReading(counter, now) })
+}
+
+func (c *coffeeBiller) bookReading(counter int, now time.Time) error {
+	reading := store.CounterReading{Time: now, Counter: counter, Shots: c.store.CoffeeShots(counter)}
+	if member := c.session.active(now); reading.Shots > 0 && member != "" {
+		err := c.store.BookSale(c.shotSale(member, reading.Shots, now))
+		if err != nil {
+			fmt.Fprintf(c.log, "billing %d shot(s) to %s failed: %v\n", reading.Shots, member, err)
+		} else {
+			reading.Billed = reading.Shots
+			reading.Member = member
+			fmt.Fprintf(c.log, "billed %d shot(s) to %s\n", reading.Shots, member)
+		}
+	} else if reading.Shots > 0 {
+		fmt.Fprintf(c.log, "%d unbilled shot(s)\n", reading.Shots)
+	}
+	c.store.CoffeeReadings = append(c.store.CoffeeReadings, reading)
+	return nil
+}
+
+func (c *coffeeBiller) shotSale(member string, shots int, now time.Time) domain.Sale {
+	line := domain.SaleLine{Name: c.cfg.Coffee.Beverage, Quantity: shots}
+	if i := c.store.BeverageIndex(line.Name); i >= 0 {
+		line.UnitPrice = c.store.Beverages[i].Price
+		c.cfg.Classify(&line, c.store.Beverages[i])
+	}
+	return domain.Sale{Time: now, Member: member, Lines: []domain.SaleLine{line}}
+}
+
+// fetchCounter polls the machine's counter endpoint.
+func fetchCounter(url string) (int, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("counter endpoint returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return 0, err
+	}
+	return parseCounter(body)
+}
+
+func parseCounter(body []byte) (int, error) {
+	if n, err := strconv.Atoi(strings.TrimSpace(string(body))); err == nil {
+		return n, nil
+	}
+	var v struct {
+		Shots *int `json:"shots"`
+	}
+	if err := json.Unmarshal(body, &v); err != nil || v.Shots == nil {
+		return 0, fmt.Errorf("unrecognized counter response %q", body)
+	}
+	return *v.Shots, nil
+}
+
+// coffeeCommand runs the coffee biller, or prints the reconciliation report:
+//
+//	coffee
+//	coffee report [-from YYYY-MM-DD] [-to YYYY-MM-DD]
+func coffeeCommand(cfg ui.Config, load configLoader, s *store.Store, args []string) error {
+	if len(args) > 0 && args[0] == "report" {
+		return coffeeReport(s, args[1:])
+	}
+	if i := s.BeverageIndex(cfg.Coffee.Beverage); i < 0 {
+		return fmt.Errorf("coffee beverage %q is not in the inventory", cfg.Coffee.Beverage)
+	}
+	listener, err := listen(cfg.Coffee.Listen)
+	if err != nil {
+		return err
+	}
+	if cfg.Coffee.CounterURL == "" && listener == nil {
+		return fmt.Errorf("neither coffee.counter_url nor coffee.listen is configured")
+	}
+	d := startDaemon(load)
+	defer d.stop()
+
+	c := &coffeeBiller{store: s, cfg: cfg, log: os.Stderr}
+	c.session = &cardSession{store: s, timeout: cfg.Coffee.SessionTimeout.Duration, log: os.Stderr}
+	counters := make(chan int)
+	tokens := make(chan string)
+	errs := make(chan error, 1)
+	go ui.ScanLines(os.Stdin, tokens)
+
+	if cfg.Coffee.CounterURL != "" {
+		go func() {
+			for {
+				n, err := fetchCounter(cfg.Coffee.CounterURL)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "polling counter: %v\n", err)
+				} else {
+					counters <- n
+				}
+				time.Sleep(cfg.Coffee.PollInterval.Duration)
+			}
+		}()
+	}
+	if listener != nil {
+		// A counter of -1 stands for "one more shot" from a body-less POST.
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /shot", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(io.LimitReader(r.Body, 4096))
+			n := -1
+			if len(strings.TrimSpace(string(body))) > 0 {
+				var err error
+				if n, err = parseCounter(body); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			counters <- n
+			w.WriteHeader(http.StatusNoContent)
+		})
+		go func() { errs <- http.Serve(listener, mux) }()
+	}
+
+	d.ready()
+	for {
+		select {
+		case n := <-counters:
+			if n < 0 {
+				if len(s.CoffeeReadings) == 0 {
+					// Without a baseline the first shot would be lost.
+					if err := c.read(0, time.Now()); err != nil {
+						return err
+					}
+				}
+				n = s.LastCoffeeCounter() + 1
+			}
+			if err := c.read(n, time.Now()); err != nil {
+				return err
+			}
+		case token, ok := <-tokens:
+			if !ok {
+				if d.managed {
+					// Under systemd stdin is usually /dev/null.
+					tokens = nil
+					continue
+				}
+				return nil
+			}
+			if token = strings.TrimSpace(token); token != "" {
+				c.session.tap(token, time.Now())
+			}
+		case <-d.hup:
+			cfg, err := d.reload(s)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "reload: %v\n", err)
+				continue
+			}
+			c.cfg = cfg
+			c.session.timeout = cfg.Coffee.SessionTimeout.Duration
+		case <-d.term:
+			return nil
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+// coffeeReport compares the shots counted by the machine with the shots
+// billed to members over a date range.
+func coffeeReport(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("coffee report", flag.ExitOnError)
+	dates := addDateRangeFlags(fs)
+	fs.Parse(args)
+	from, to, err := dates.parse()
+	if err != nil {
+		return err
+	}
+
+	counted, billed := 0, 0
+	perMember := map[string]int{}
+	for _, r := range s.CoffeeReadings {
+		if r.Time.Before(from) || !r.Time.Before(to) {
+			continue
+		}
+		counted += r.Shots
+		billed += r.Billed
+		if r.Member != "" {
+			perMember[r.Member] += r.Billed
+		}
+	}
+
+	fmt.Printf("Coffee reconciliation %s\n\n", dates)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Shots counted\t%d\n", counted)
+	fmt.Fprintf(w, "Shots billed\t%d\n", billed)
+	fmt.Fprintf(w, "Unbilled\t%d\n", counted-billed)
+	if len(perMember) > 0 {
+		fmt.Fprintln(w, "\nMember\tShots")
+		ids := make([]string, 0, len(perMember))
+		for id := range perMember {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Fprintf(w, "%s\t%d\n", id, perMember[id])
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- COMMANDS ---
+
+// dateRange is the -from/-to pair of flags shared by the reports. Both ends
+// are inclusive days.
+type dateRange struct{ from, to *string }
+
+func addDateRangeFlags(fs *flag.FlagSet) dateRange {
+	today := time.Now().Format(time.DateOnly)
+	return dateRange{
+		from: fs.String("from", today, "first day of the report (YYYY-MM-DD)"),
+		to:   fs.String("to", today, "last day of the report (YYYY-MM-DD)"),
+	}
+}
+
+// parse returns the range as [from, to), i.e. to is the start of the day
+// after the last day.
+func (d dateRange) parse() (time.Time, time.Time, error) {
+	from, err := time.ParseInLocation(time.DateOnly, *d.from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -from: %w", err)
+	}
+	to, err := time.ParseInLocation(time.DateOnly, *d.to, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -to: %w", err)
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+func (d dateRange) String() string { return *d.from + " – " + *d.to }
+
+// decimalFlag defines a flag for an amount, which takes either decimal
+// mark like every amount typed in, see ui.ParseDecimal.
+func decimalFlag(fs *flag.FlagSet, name, usage string) *float64 {
+	v := new(float64)
+	fs.Func(name, usage, func(s string) (err error) {
+		*v, err = ui.ParseDecimal(s)
+		return err
+	})
+	return v
+}
+
+// taxReport prints the tax collected per class for the sales in the given
+// date range; both ends are inclusive days.
+func taxReport(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("tax-report", flag.ExitOnError)
+	dates := addDateRangeFlags(fs)
+	fs.Parse(args)
+	from, to, err := dates.parse()
+	if err != nil {
+		return err
+	}
+
+	return ui.WriteTaxReport(os.Stdout, dates.String(), s.SalesBetween(from, to))
+}
+
+// bannerCommand sets or clears the banner shown on all kiosks.
+func bannerCommand(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("banner", flag.ExitOnError)
+	expires := fs.String("expires", "", "when the banner disappears (YYYY-MM-DD HH:MM), defaults to 24h from now")
+	clear := fs.Bool("clear", false, "remove the current banner")
+	fs.Parse(args)
+
+	if *clear {
+		return s.SetBanner(nil)
+	}
+	if fs.NArg() == 0 {
+		if msg := s.ActiveBanner(time.Now()); msg != "" {
+			fmt.Printf("%s (until %s)\n", msg, s.Banner.Expires.Format("2006-01-02 15:04"))
+		} else {
+			fmt.Println("No banner set.")
+		}
+		return nil
+	}
+
+	until := time.Now().Add(24 * time.Hour)
+	if *expires != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04", *expires, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -expires: %w", err)
+		}
+		until = t
+	}
+	return s.SetBanner(&store.Banner{Message: strings.Join(fs.Args(), " "), Expires: until})
+}
+
+// lockdownCommand shows or sets the emergency lockdown of all kiosks.
+func lockdownCommand(s *store.Store, args []string) error {
+	if len(args) == 0 {
+		if s.Lockdown == store.LockdownNone {
+			fmt.Println("No lockdown active.")
+		} else {
+			fmt.Printf("Lockdown: %s\n", s.Lockdown)
+		}
+		return nil
+	}
+	mode, err := store.ParseLockdown(args[0])
+	if err != nil {
+		return err
+	}
+	return s.SetLockdown(mode)
+}
+
+// priceCommand changes the price of a beverage, or its price for a price
+// tier: price [-tier T] <name> <amount>.
+func priceCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("price", flag.ExitOnError)
+	tier := fs.String("tier", "", "set the price for this price tier")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: price [-tier T] <name> <amount>")
+	}
+	price, err := ui.ParseDecimal(fs.Arg(1))
+	if err != nil || price < 0 {
+		return fmt.Errorf("invalid price %q", fs.Arg(1))
+	}
+	if *tier == "" {
+		return s.SetPrice(fs.Arg(0), price)
+	}
+	if !slices.Contains(cfg.PriceTiers, *tier) {
+		return fmt.Errorf("unknown price tier %q; the config lists %v", *tier, cfg.PriceTiers)
+	}
+	return s.SetTierPrice(fs.Arg(0), *tier, price)
+}
+
+// bundleCommand sells a number of a beverage for a price of its own, or
+// stops selling that bundle: bundle [-remove] <name> <quantity> [price].
+func bundleCommand(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	remove := fs.Bool("remove", false, "stop selling the bundle of this quantity")
+	fs.Parse(args)
+	if fs.NArg() != 3 && !(*remove && fs.NArg() == 2) {
+		return fmt.Errorf("usage: bundle [-remove] <name> <quantity> [price]")
+	}
+	qty, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("invalid quantity %q", fs.Arg(1))
+	}
+	if *remove {
+		return s.SetBundle(fs.Arg(0), qty, -1)
+	}
+	price, err := ui.ParseDecimal(fs.Arg(2))
+	if err != nil || price < 0 {
+		return fmt.Errorf("invalid price %q", fs.Arg(2))
+	}
+	return s.SetBundle(fs.Arg(0), qty, price)
+}
+
+// memberCommand manages member tabs:
+//
+//	member list
+//	member add [-token T] [-email ADDR] [-tier T] <id> <name...>
+//	member topup <id> <amount>
+//	member transfer <from> <to> <amount> [note...]
+//	member history <id>
+//	member import [-dry-run | -yes] <statement.csv>
+//	member sync [-dry-run]
+//
+// Inactive members are marked as such in the list; they were deactivated
+// in the member directory the members are synced from.
+func memberCommand(cfg ui.Config, s *store.Store, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tName\tBalance\t")
+		for _, m := range s.Members {
+			if m.IsGuest() {
+				continue // see guests report
+			}
+			status := ""
+			if m.Inactive {
+				status = "inactive"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%s\n", m.ID, m.Name, m.Balance, status)
+		}
+		return w.Flush()
+	case "add":
+		fs := flag.NewFlagSet("member add", flag.ExitOnError)
+		token := fs.String("token", "", "card/RFID token identifying the member")
+		email := fs.String("email", "", "address to mail the member's receipts to")
+		tier := fs.String("tier", "", "price tier of the member, instead of member_tier")
+		fs.Parse(args[1:])
+		if fs.NArg() < 2 {
+			return fmt.Errorf("usage: member add [-token T] [-email ADDR] [-tier T] <id> <name>")
+		}
+		if *tier != "" && !slices.Contains(cfg.PriceTiers, *tier) {
+			return fmt.Errorf("unknown price tier %q; the config lists %v", *tier, cfg.PriceTiers)
+		}
+		return s.AddMember(domain.Member{ID: fs.Arg(0), Name: strings.Join(fs.Args()[1:], " "), Token: *token, Email: *email, Tier: *tier})
+	case "topup":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: member topup <id> <amount>")
+		}
+		amount, err := ui.ParseDecimal(args[2])
+		if err != nil || amount <= 0 {
+			return fmt.Errorf("invalid amount %q", args[2])
+		}
+		return s.TopUp(args[1], amount)
+	case "transfer":
+		if len(args) < 4 {
+			return fmt.Errorf("usage: member transfer <from> <to> <amount> [note...]")
+		}
+		amount, err := ui.ParseDecimal(args[3])
+		if err != nil || amount <= 0 {
+			return fmt.Errorf("invalid amount %q", args[3])
+		}
+		return s.Transfer(args[1], args[2], amount, strings.Join(args[4:], " "))
+	case "import":
+		return importBankCommand(cfg, s, args[1:])
+	case "sync":
+		return syncMembersCommand(cfg, s, args[1:])
+	case "history":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: member history <id>")
+		}
+		i := s.MemberIndex(args[1])
+		if i < 0 {
+			return fmt.Errorf("unknown member %q", args[1])
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Time\tAmount\t")
+		for _, e := range s.MemberHistory(args[1]) {
+			fmt.Fprintf(w, "%s\t%+.2f\t%s\n", e.Time.Format("2006-01-02 15:04"), e.Amount, e.Description)
+		}
+		fmt.Fprintf(w, "Balance\t%.2f\t\n", s.Members[i].Balance)
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown member command %q", args[0])
+}
+
+// syncMembersCommand syncs the members from the member directory; with
+// -dry-run it only shows what would change. Run it from cron to keep the
+// members in step.
+func syncMembersCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("member sync", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only show what would change")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: member sync [-dry-run]")
+	}
+	if !cfg.Directory.Enabled() {
+		return fmt.Errorf("no member directory configured; set directory.source")
+	}
+	entries, err := cfg.Directory.Entries()
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		return store.WriteMemberChanges(os.Stdout, s.PlanMemberSync(entries))
+	}
+	changes, err := s.SyncMembers(entries)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteMemberChanges(os.Stdout, changes); err != nil {
+		return err
+	}
+	fmt.Printf("Synced %d members from the directory, %d changed.\n", len(entries), len(changes))
+	return nil
+}
+
+// sellCommand books a sale without the TUI, for scripts and other systems:
+//
+//	sell [-member ID] [-voucher CODE] [-note TEXT] <beverage> [quantity] [<beverage> <quantity>...]
+//
+// Beverages are sold at their current price.
+func sellCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("sell", flag.ExitOnError)
+	member := fs.String("member", "", "charge the sale to this member's tab")
+	voucher := fs.String("voucher", "", "pay the sale with this voucher, as far as it goes")
+	note := fs.String("note", "", "note for the sale, printed on the receipt")
+	fs.Parse(args)
+	items := fs.Args()
+	if len(items) == 1 {
+		items = append(items, "1")
+	}
+	if len(items) == 0 || len(items)%2 != 0 {
+		return fmt.Errorf("usage: sell [-member ID] [-voucher CODE] [-note TEXT] <beverage> [quantity] [<beverage> <quantity>...]")
+	}
+	sale := domain.Sale{Member: *member, Voucher: *voucher, Note: strings.TrimSpace(*note)}
+	for i := 0; i < len(items); i += 2 {
+		qty, err := strconv.Atoi(items[i+1])
+		if err != nil {
+			return fmt.Errorf("invalid quantity %q", items[i+1])
+		}
+		sale.Lines = append(sale.Lines, domain.SaleLine{Name: items[i], Quantity: qty})
+	}
+	if err := completeSale(&sale, s, cfg, time.Now()); err != nil {
+		return err
+	}
+	sale, err := s.RecordSale(sale)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Sale #%d: €%.2f\n", sale.ID, sale.Total())
+	if sale.Voucher != "" {
+		fmt.Printf("Paid €%.2f with voucher %s, €%.2f due\n", sale.VoucherAmount, sale.Voucher, sale.Due())
+	}
+	return nil
+}
+
+// stockCommand prints the inventory, or merges a catalog into it:
+//
+//	stock [-low]
+//	stock import [-dry-run | -yes] <catalog.csv>
+func stockCommand(cfg ui.Config, s *store.Store, args []string) error {
+	if len(args) > 0 && args[0] == "import" {
+		return importCatalogCommand(s, args[1:])
+	}
+	fs := flag.NewFlagSet("stock", flag.ExitOnError)
+	low := fs.Bool("low", false, "only list beverages that are low on stock")
+	fs.Parse(args)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Beverage\tPrice\tStock\tAvailable")
+	for _, b := range s.Beverages {
+		if *low && !b.IsLowStock(cfg.LowStock) {
+			continue
+		}
+		stock := b.StockLabel()
+		if b.IsRecipe() {
+			stock = "recipe"
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%s\t%d\n", b.Key(), b.Price, stock, b.AvailableFrom(s.Beverages))
+	}
+	return w.Flush()
+}
+
+// salesReport prints what was sold per beverage in a date range, best
+// sellers first.
+func salesReport(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	dates := addDateRangeFlags(fs)
+	today := fs.Bool("today", false, "report today's sales, whatever -from and -to say")
+	fs.Parse(args)
+	if *today {
+		*dates.from = time.Now().Format(time.DateOnly)
+		*dates.to = *dates.from
+	}
+	from, to, err := dates.parse()
+	if err != nil {
+		return err
+	}
+	return ui.WriteSalesReport(os.Stdout, dates.String(), s.SalesBetween(from, to))
+}
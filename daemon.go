@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- DAEMON ---
+
+// configLoader reads the config again, the same way main did at startup.
+type configLoader func() (ui.Config, error)
+
+// daemon ties a long-running command (coffee, keg run, vend) to its
+// service manager. Under systemd it reports readiness and watchdog pings
+// through sd_notify; everywhere, SIGHUP reloads the config and store, and
+// SIGINT/SIGTERM stop the command cleanly.
+//
+// A reload applies everything the command reads per event, like prices,
+// slots, pour sizes and timeouts. Devices and listen addresses are only
+// opened at startup and need a restart.
+type daemon struct {
+	load    configLoader
+	managed bool // started by systemd with a notify socket
+	hup     chan os.Signal
+	term    chan os.Signal
+	done    chan struct{}
+}
+
+func startDaemon(load configLoader) *daemon {
+	d := &daemon{
+		load:    load,
+		managed: os.Getenv("NOTIFY_SOCKET") != "",
+		hup:     make(chan os.Signal, 1),
+		term:    make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+	signal.Notify(d.hup, syscall.SIGHUP)
+	signal.Notify(d.term, os.Interrupt, syscall.SIGTERM)
+	if interval, ok := watchdogInterval(); ok {
+		go d.watchdog(interval / 2)
+	}
+	return d
+}
+
+// ready tells systemd that startup is complete.
+func (d *daemon) ready() { d.notify("READY=1") }
+
+// reload reads the config and the store again.
+func (d *daemon) reload(s *store.Store) (ui.Config, error) {
+	d.notify("RELOADING=1")
+	defer d.ready()
+	cfg, err := d.load()
+	if err != nil {
+		return cfg, err
+	}
+	s.Configure(cfg.StoreOptions())
+	return cfg, s.Reload()
+}
+
+func (d *daemon) stop() {
+	d.notify("STOPPING=1")
+	close(d.done)
+	signal.Stop(d.hup)
+	signal.Stop(d.term)
+}
+
+func (d *daemon) notify(state string) {
+	if err := sdNotify(state); err != nil {
+		fmt.Fprintf(os.Stderr, "sd_notify: %v\n", err)
+	}
+}
+
+func (d *daemon) watchdog(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.notify("WATCHDOG=1")
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// sdNotify sends a state update to the service manager, if there is one
+// listening. See sd_notify(3).
+func sdNotify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+	if addr[0] == '@' {
+		addr = "\x00" + addr[1:] // abstract socket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// watchdogInterval returns the watchdog timeout systemd expects pings
+// within, if the watchdog is enabled for this process.
+func watchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// systemdListeners returns the sockets passed by systemd socket activation,
+// or nil when the process wasn't socket activated. See
+// sd_listen_fds(3).
+func systemdListeners() ([]net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	// Children shouldn't think the sockets are meant for them.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	const firstFD = 3
+	listeners := make([]net.Listener, 0, n)
+	for fd := firstFD; fd < firstFD+n; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket activation fd %d: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listen returns the socket systemd passed in, or else listens on addr. It
+// returns nil without either.
+func listen(addr string) (net.Listener, error) {
+	listeners, err := systemdListeners()
+	if err != nil {
+		return nil, err
+	}
+	if len(listeners) > 0 {
+		for _, l := range listeners[1:] {
+			l.Close()
+		}
+		return listeners[0], nil
+	}
+	if addr == "" {
+		return nil, nil
+	}
+	return net.Listen("tcp", addr)
+}
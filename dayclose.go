@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- DAY CLOSE ---
+
+// closeDayCommand closes a day, by default today:
+//
+//	close-day [YYYY-MM-DD]
+func closeDayCommand(s *store.Store, args []string) error {
+	day := time.Now()
+	if len(args) > 1 {
+		return fmt.Errorf("usage: close-day [YYYY-MM-DD]")
+	}
+	if len(args) == 1 {
+		d, err := time.ParseInLocation(time.DateOnly, args[0], time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid day: %w", err)
+		}
+		day = d
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	c, err := s.CloseDay(day)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Closed %s: %d sales, €%.2f.\n", c.Day, c.Sales, c.Revenue)
+	return nil
+}
+
+// voidCommand voids a sale. After the undo grace, someone other than the
+// one voiding has to approve:
+//
+//	void [-approved-by NAME] <sale id>
+func voidCommand(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("void", flag.ExitOnError)
+	approvedBy := fs.String("approved-by", "", "the second person approving the void")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: void [-approved-by NAME] <sale id>")
+	}
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid sale id %q", fs.Arg(0))
+	}
+	if err := s.VoidSale(id, *approvedBy); err != nil {
+		return err
+	}
+	fmt.Printf("Sale #%d voided.\n", id)
+	return nil
+}
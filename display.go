@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- CUSTOMER DISPLAY ---
+
+// runDisplay shows the customer display full screen until it is quit.
+func runDisplay(cfg ui.Config, s *store.Store) error {
+	m, err := ui.NewDisplayModel(cfg, s)
+	if err != nil {
+		return err
+	}
+	_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+// runPopout shows the pop-out of the till, its cart or stats as show says,
+// until it is quit.
+func runPopout(cfg ui.Config, s *store.Store, show string) error {
+	m, err := ui.NewPopoutModel(cfg, s, show)
+	if err != nil {
+		return err
+	}
+	_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+// tillSessions keeps the latest session of every till for the customer
+// displays. Sessions live in memory only; a till sends its session again
+// with its next change.
+type tillSessions struct {
+	mu       sync.Mutex
+	sessions map[string]store.TillSession
+	// changed is closed and replaced on every update.
+	changed chan struct{}
+}
+
+func newTillSessions() *tillSessions {
+	return &tillSessions{sessions: map[string]store.TillSession{}, changed: make(chan struct{})}
+}
+
+// put stores the session in the body as the latest of its till.
+func (t *tillSessions) put(w http.ResponseWriter, r *http.Request) {
+	var s store.TillSession
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		http.Error(w, "invalid session: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.Till = r.PathValue("till")
+	t.mu.Lock()
+	s.Version = t.sessions[s.Till].Version + 1
+	t.sessions[s.Till] = s
+	close(t.changed)
+	t.changed = make(chan struct{})
+	t.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// get answers with the session of the till once its version differs from
+// ?since=, or with the session as it is after store.EventsTimeout.
+func (t *tillSessions) get(w http.ResponseWriter, r *http.Request) {
+	till := r.PathValue("till")
+	since, err := strconv.Atoi(r.URL.Query().Get("since"))
+	if err != nil {
+		since = -1
+	}
+	deadline := time.After(store.EventsTimeout)
+	for {
+		t.mu.Lock()
+		s, changed := t.sessions[till], t.changed
+		t.mu.Unlock()
+		s.Till = till
+		if s.Version != since {
+			writeJSON(w, s)
+			return
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			writeJSON(w, s)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// --- AVAILABILITY ---
+
+// Availability is when a beverage is sold: during any of its Windows.
+// Outside of them the shop shows it locked with Note, or not at all if
+// Hide is set, like a special that only exists for an event.
+type Availability struct {
+	Windows []Window `json:"windows"`
+	Note    string   `json:"note,omitempty"`
+	Hide    bool     `json:"hide,omitempty"`
+}
+
+// Window is a stretch of time a beverage is sold in. Days are weekdays
+// ("mon", "tue", ...) and Dates days of the calendar ("2026-10-31"); a
+// window without either applies every day. From and Until are times of
+// day like "18:00"; a window ending before it starts runs past midnight
+// into the next day, and one without times lasts all day. A window that
+// doesn't parse never applies, so a typo locks the beverage rather than
+// selling it when it shouldn't be.
+type Window struct {
+	Days  []string `json:"days,omitempty"`
+	Dates []string `json:"dates,omitempty"`
+	From  string   `json:"from,omitempty"`
+	Until string   `json:"until,omitempty"`
+}
+
+// AvailableAt reports whether b is sold at t.
+func (b Beverage) AvailableAt(t time.Time) bool {
+	if b.Available == nil {
+		return true
+	}
+	return slices.ContainsFunc(b.Available.Windows, func(w Window) bool { return w.contains(t) })
+}
+
+// HiddenAt reports whether b is left out of the shop at t.
+func (b Beverage) HiddenAt(t time.Time) bool {
+	return b.Available != nil && b.Available.Hide && !b.AvailableAt(t)
+}
+
+// AvailabilityNote explains when b is sold: its Note, or else its windows.
+func (b Beverage) AvailabilityNote() string {
+	if b.Available == nil {
+		return ""
+	}
+	if b.Available.Note != "" {
+		return b.Available.Note
+	}
+	windows := make([]string, len(b.Available.Windows))
+	for i, w := range b.Available.Windows {
+		windows[i] = w.String()
+	}
+	return strings.Join(windows, ", ")
+}
+
+// CheckAvailable returns an error naming the first line that isn't sold
+// at t.
+func CheckAvailable(inventory []Beverage, lines []SaleLine, t time.Time) error {
+	for _, line := range lines {
+		if i := IndexOf(inventory, line.Name); i >= 0 && !inventory[i].AvailableAt(t) {
+			return fmt.Errorf("%s isn't sold now (%s)", line.Name, inventory[i].AvailabilityNote())
+		}
+	}
+	return nil
+}
+
+func (w Window) contains(t time.Time) bool {
+	from, until := 0, 24*60
+	var err error
+	if w.From != "" {
+		if from, err = minuteOfDay(w.From); err != nil {
+			return false
+		}
+	}
+	if w.Until != "" {
+		if until, err = minuteOfDay(w.Until); err != nil {
+			return false
+		}
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if from <= until {
+		return from <= minute && minute < until && w.onDay(t)
+	}
+	// Past midnight, the window belongs to the day it started on.
+	return minute >= from && w.onDay(t) || minute < until && w.onDay(t.AddDate(0, 0, -1))
+}
+
+// onDay reports whether the window applies to the day of t.
+func (w Window) onDay(t time.Time) bool {
+	weekday := strings.ToLower(t.Weekday().String()[:3])
+	date := t.Format(time.DateOnly)
+	return (len(w.Days) == 0 || slices.ContainsFunc(w.Days, func(d string) bool { return strings.EqualFold(d, weekday) })) &&
+		(len(w.Dates) == 0 || slices.Contains(w.Dates, date))
+}
+
+// String describes the window, e.g. "fri,sat 20:00-02:00".
+func (w Window) String() string {
+	var parts []string
+	if len(w.Days) > 0 {
+		parts = append(parts, strings.Join(w.Days, ","))
+	}
+	if len(w.Dates) > 0 {
+		parts = append(parts, strings.Join(w.Dates, ","))
+	}
+	if w.From != "" || w.Until != "" {
+		parts = append(parts, fmt.Sprintf("%s-%s", cmp.Or(w.From, "00:00"), cmp.Or(w.Until, "24:00")))
+	}
+	return strings.Join(parts, " ")
+}
+
+// minuteOfDay parses a time of day like "18:30" into minutes since
+// midnight. "24:00" ends a window at midnight.
+func minuteOfDay(clock string) (int, error) {
+	if clock == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
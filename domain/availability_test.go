@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAvailableAt(t *testing.T) {
+	// Friday and Saturday nights, into the small hours.
+	late := Beverage{Name: "Beer", Available: &Availability{Windows: []Window{
+		{Days: []string{"fri", "sat"}, From: "20:00", Until: "02:00"},
+	}}}
+	event := Beverage{Name: "Glühwein", Available: &Availability{Hide: true, Windows: []Window{
+		{Dates: []string{"2024-12-24"}},
+	}}}
+	at := func(day, clock string) time.Time {
+		t, err := time.Parse(time.DateOnly+" 15:04", day+" "+clock)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	tests := []struct {
+		b    Beverage
+		t    time.Time
+		want bool
+	}{
+		{late, at("2024-03-08", "19:59"), false}, // Friday
+		{late, at("2024-03-08", "20:00"), true},
+		{late, at("2024-03-09", "01:30"), true}, // still Friday night
+		{late, at("2024-03-10", "01:30"), true}, // Saturday night
+		{late, at("2024-03-11", "01:30"), false},
+		{late, at("2024-03-09", "02:00"), false},
+		{event, at("2024-12-24", "00:00"), true},
+		{event, at("2024-12-25", "12:00"), false},
+		{testInventory[0], at("2024-12-25", "12:00"), true},
+	}
+	for _, tt := range tests {
+		if got := tt.b.AvailableAt(tt.t); got != tt.want {
+			t.Errorf("%s at %s: got %v, want %v", tt.b.Name, tt.t.Format(time.DateTime), got, tt.want)
+		}
+	}
+	if !event.HiddenAt(at("2024-12-25", "12:00")) || late.HiddenAt(at("2024-03-08", "12:00")) {
+		t.Error("only beverages that ask to be hidden are")
+	}
+}
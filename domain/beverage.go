@@ -0,0 +1,100 @@
+// Package domain holds what the till sells and books: beverages and their
+// stock, carts, sales with their tax, and members with a tab. It knows
+// nothing about where they are kept or how they are shown.
+package domain
+
+// --- DATA ---
+// Beverage is an item in the inventory. Stock is counted in Unit; each item
+// sold takes Portion of it (1 if unset) and costs Price; Cost is what it
+// costs the bar, net of tax, as last invoiced by the supplier. Barcode is
+// the EAN printed on it, if it has one.
+type Beverage struct {
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Cost     float64 `json:"cost,omitempty"`
+	Stock    float64 `json:"stock"`
+	Unit     Unit    `json:"unit,omitempty"`
+	Portion  float64 `json:"portion,omitempty"`
+	LowStock float64 `json:"low_stock,omitempty"`
+	TaxClass string  `json:"tax_class,omitempty"`
+	Category string  `json:"category,omitempty"`
+	Barcode  string  `json:"barcode,omitempty"`
+	// Size is the container the beverage comes in, e.g. "0.33 l" or
+	// "crate", and Volume what it holds in liters. A drink sold in several
+	// sizes is a beverage for each, under the same name and a size of its
+	// own; see Key. The volume is only recorded.
+	Size   string  `json:"size,omitempty"`
+	Volume float64 `json:"volume,omitempty"`
+	// Image is a PNG, JPEG or GIF file shown next to the selected
+	// beverage on terminals that can draw images.
+	Image string `json:"image,omitempty"`
+	// Recipe makes this a composite beverage: it has no stock of its own
+	// and selling it consumes the ingredients instead.
+	Recipe []Ingredient `json:"recipe,omitempty"`
+	// Available limits the beverage to certain days and hours, e.g. beer
+	// only after 18:00 on weekdays. Without it, it is always sold.
+	Available *Availability `json:"available,omitempty"`
+	// Tiers are the prices for price tiers other than the regular one, by
+	// tier, e.g. {"member": 1.20, "staff": 0.90}.
+	Tiers map[string]float64 `json:"tiers,omitempty"`
+	// Bundles sell a number of the beverage for a price of their own,
+	// e.g. a crate of 20 for 25.00, see Lines.
+	Bundles []Bundle `json:"bundles,omitempty"`
+}
+
+// Key tells the beverage apart from the other sizes of the same drink:
+// its name, with its size if it has one, e.g. "Club-Mate 0.5 l". Carts,
+// sale lines and the stock refer to beverages by it, so no two beverages
+// of an inventory may have the same, see DuplicateKey.
+func (b Beverage) Key() string {
+	if b.Size != "" {
+		return b.Name + " " + b.Size
+	}
+	return b.Name
+}
+
+// DuplicateKey returns a key that more than one beverage of inventory has,
+// like "Club-Mate 0.5 l" for a drink of that name and Club-Mate in the size
+// "0.5 l"; "" if there is none.
+func DuplicateKey(inventory []Beverage) string {
+	seen := make(map[string]bool, len(inventory))
+	for _, b := range inventory {
+		if seen[b.Key()] {
+			return b.Key()
+		}
+		seen[b.Key()] = true
+	}
+	return ""
+}
+
+// Bundle is a quantity of a beverage sold at a price of its own.
+type Bundle struct {
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+// TierPrice is the price for tier; tiers the beverage has no price for pay
+// the regular one.
+func (b Beverage) TierPrice(tier string) float64 {
+	if price, ok := b.Tiers[tier]; ok {
+		return price
+	}
+	return b.Price
+}
+
+// --- LOW STOCK ---
+
+// IsLowStock reports whether the beverage is at or below its low-stock
+// threshold. LowStock is in the beverage's unit; beverages without one fall
+// back to defaultItems, counted in items sold. Recipes have no stock of
+// their own; their ingredients are checked instead.
+func (b Beverage) IsLowStock(defaultItems float64) bool {
+	if b.IsRecipe() {
+		return false
+	}
+	threshold := b.LowStock
+	if threshold <= 0 {
+		threshold = defaultItems * b.PortionSize()
+	}
+	return threshold > 0 && b.Stock <= threshold
+}
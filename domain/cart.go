@@ -0,0 +1,62 @@
+package domain
+
+import "slices"
+
+// --- CART ---
+
+// Cart is what is about to be sold: the quantity of each beverage, by name.
+type Cart map[string]int
+
+// HasItems reports whether anything is in the cart.
+func (c Cart) HasItems() bool {
+	for _, qty := range c {
+		if qty > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Lines returns the sale lines for each beverage of inventory in the cart,
+// in the inventory's order and at its prices, see Beverage.Lines. Tax
+// classes and accounts are up to the caller.
+func (c Cart) Lines(inventory []Beverage) []SaleLine {
+	var lines []SaleLine
+	for _, b := range inventory {
+		if qty := c[b.Key()]; qty > 0 {
+			lines = append(lines, b.Lines(qty)...)
+		}
+	}
+	return lines
+}
+
+// Lines returns the sale lines for qty of b: a line for each of its
+// bundles that fits, the biggest first, and one at the regular price for
+// the rest. Bundles that don't come out cheaper than the regular price,
+// e.g. during a happy hour, are left out.
+func (b Beverage) Lines(qty int) []SaleLine {
+	bundles := slices.Clone(b.Bundles)
+	slices.SortFunc(bundles, func(x, y Bundle) int { return y.Quantity - x.Quantity })
+	var lines []SaleLine
+	for _, bundle := range bundles {
+		if bundle.Quantity < 2 || qty < bundle.Quantity || bundle.Price >= b.Price*float64(bundle.Quantity) {
+			continue
+		}
+		n := qty / bundle.Quantity * bundle.Quantity
+		lines = append(lines, SaleLine{Name: b.Key(), Quantity: n, UnitPrice: bundle.Price / float64(bundle.Quantity), Bundle: bundle.Quantity})
+		qty -= n
+	}
+	if qty > 0 {
+		lines = append(lines, SaleLine{Name: b.Key(), Quantity: qty, UnitPrice: b.Price})
+	}
+	return lines
+}
+
+// Total is what the cart costs at the inventory's prices.
+func (c Cart) Total(inventory []Beverage) float64 {
+	total := 0.0
+	for _, line := range c.Lines(inventory) {
+		total += line.UnitPrice * float64(line.Quantity)
+	}
+	return total
+}
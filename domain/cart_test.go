@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+var testInventory = []Beverage{
+	{Name: "Club-Mate", Price: 1.50, Stock: 24},
+	{Name: "Rum", Price: 3.00, Stock: 700, Unit: UnitMilliliter, Portion: 40},
+	{Name: "Tschunk", Price: 5.00, Recipe: []Ingredient{{Name: "Club-Mate", Amount: 1}, {Name: "Rum", Amount: 40}}},
+	{Name: "Water", Price: 0.50, Stock: 100},
+}
+
+func TestCartLines(t *testing.T) {
+	cart := Cart{"Water": 3, "Club-Mate": 2, "Rum": 0}
+	lines := cart.Lines(testInventory)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %v", len(lines), lines)
+	}
+	// In inventory order, without the empty line.
+	if lines[0].Name != "Club-Mate" || lines[0].Quantity != 2 || lines[0].UnitPrice != 1.50 {
+		t.Errorf("first line = %+v", lines[0])
+	}
+	if lines[1].Name != "Water" || lines[1].Quantity != 3 || lines[1].UnitPrice != 0.50 {
+		t.Errorf("second line = %+v", lines[1])
+	}
+}
+
+func TestCartTotal(t *testing.T) {
+	tests := []struct {
+		cart Cart
+		want float64
+	}{
+		{Cart{}, 0},
+		{Cart{"Water": 1}, 0.50},
+		{Cart{"Club-Mate": 2, "Tschunk": 1, "Water": 3}, 9.50},
+		// Names that aren't in the inventory cost nothing.
+		{Cart{"Espresso": 2, "Water": 2}, 1.00},
+	}
+	for _, tt := range tests {
+		if got := tt.cart.Total(testInventory); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%v.Total() = %.2f, want %.2f", tt.cart, got, tt.want)
+		}
+	}
+}
+
+func TestBeverageLinesBundles(t *testing.T) {
+	b := Beverage{Name: "Club-Mate", Price: 1.50, Bundles: []Bundle{{Quantity: 6, Price: 8}, {Quantity: 20, Price: 25}, {Quantity: 4, Price: 6}}}
+	tests := []struct {
+		qty  int
+		want []int // size of each line's bundle, then its quantity
+	}{
+		{5, []int{0, 5}},
+		{6, []int{6, 6}},
+		{27, []int{20, 20, 6, 6, 0, 1}},
+		// The bundle of 4 costs what 4 do at the regular price.
+		{45, []int{20, 40, 0, 5}},
+	}
+	for _, tt := range tests {
+		var got []int
+		total := 0.0
+		for _, l := range b.Lines(tt.qty) {
+			got = append(got, l.Bundle, l.Quantity)
+			total += l.Gross()
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Lines(%d) = %v, want %v", tt.qty, got, tt.want)
+		}
+		if tt.qty == 27 && math.Abs(total-34.50) > 1e-9 {
+			t.Errorf("Lines(27) cost %.2f, want 34.50", total)
+		}
+	}
+}
+
+func TestCartHasItems(t *testing.T) {
+	if (Cart{"Water": 0}).HasItems() {
+		t.Error("a cart of zero quantities has items")
+	}
+	if !(Cart{"Water": 0, "Rum": 1}).HasItems() {
+		t.Error("a cart with a rum has no items")
+	}
+}
+
+func TestStockNeededResolvesRecipes(t *testing.T) {
+	cart := Cart{"Club-Mate": 1, "Tschunk": 2}
+	needed, err := StockNeeded(testInventory, cart.Lines(testInventory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if needed["Club-Mate"] != 3 || needed["Rum"] != 80 || len(needed) != 2 {
+		t.Errorf("needed = %v, want 3 Club-Mate and 80 ml rum", needed)
+	}
+}
+
+func TestCheckStock(t *testing.T) {
+	if _, err := CheckStock(testInventory, Cart{"Tschunk": 17}.Lines(testInventory)); err != nil {
+		t.Errorf("17 Tschunk: %v", err)
+	}
+	// 18 take 720 ml of the 700 ml of rum.
+	if _, err := CheckStock(testInventory, Cart{"Tschunk": 18}.Lines(testInventory)); err == nil {
+		t.Error("18 Tschunk fit into the stock")
+	}
+}
+
+func TestSaleTotals(t *testing.T) {
+	sale := Sale{Lines: []SaleLine{
+		{Name: "Club-Mate", Quantity: 2, UnitPrice: 1.50, TaxRate: 19},
+		{Name: "Water", Quantity: 1, UnitPrice: 0.50, TaxRate: 7},
+	}}
+	if got := sale.Total(); got != 3.50 {
+		t.Errorf("Total() = %.2f, want 3.50", got)
+	}
+	if l := sale.Lines[0]; l.Net() != 2.52 || l.Tax() != 0.48 {
+		t.Errorf("3.00 at 19%%: net %.2f, tax %.2f, want 2.52 and 0.48", l.Net(), l.Tax())
+	}
+}
+
+func TestDuplicateKey(t *testing.T) {
+	tests := []struct {
+		inventory []Beverage
+		want      string
+	}{
+		{[]Beverage{{Name: "Club-Mate", Size: "0.5 l"}, {Name: "Club-Mate", Size: "0.33 l"}}, ""},
+		// The volume is only recorded; it doesn't tell a beverage apart.
+		{[]Beverage{{Name: "Club-Mate"}, {Name: "Club-Mate", Volume: 0.5}}, "Club-Mate"},
+		{[]Beverage{{Name: "Club-Mate 0.5 l"}, {Name: "Club-Mate", Size: "0.5 l"}}, "Club-Mate 0.5 l"},
+	}
+	for _, tt := range tests {
+		if got := DuplicateKey(tt.inventory); got != tt.want {
+			t.Errorf("DuplicateKey(%+v) = %q, want %q", tt.inventory, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// --- MEMBERS ---
+
+// Member is someone with a tab at the bar. Balance is the member's credit;
+// purchases charged to the tab lower it and may take it below zero down to
+// the configured tab limit.
+type Member struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Token   string  `json:"token,omitempty"` // card/RFID identifier
+	Email   string  `json:"email,omitempty"` // where receipts are mailed
+	Tier    string  `json:"tier,omitempty"`  // price tier, see Beverage.Tiers
+	Balance float64 `json:"balance"`
+	// Event is set for the guests of an event, see guestsCommand; their
+	// wristbands stop working at Expires.
+	Event   string    `json:"event,omitempty"`
+	Expires time.Time `json:"expires,omitzero"`
+	// Synced is set for members that come from the member directory, see
+	// store.DirectoryConfig; the directory deactivates them by Inactive.
+	// Inactive members can't charge their tab anymore.
+	Synced   bool `json:"synced,omitempty"`
+	Inactive bool `json:"inactive,omitempty"`
+}
+
+// LedgerEntry is a change of a member's balance other than a sale. A
+// transfer between members is booked as a pair of entries, one per side,
+// that share the Transfer ID and name each other as Counterparty.
+type LedgerEntry struct {
+	Time         time.Time `json:"time"`
+	Member       string    `json:"member"`
+	Kind         string    `json:"kind"` // LedgerTopUp or LedgerTransfer
+	Amount       float64   `json:"amount"`
+	Transfer     int       `json:"transfer,omitempty"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Note         string    `json:"note,omitempty"`
+}
+
+// --- GUEST WRISTBANDS ---
+
+func (m Member) IsGuest() bool { return m.Event != "" }
+
+func (m Member) Expired(now time.Time) bool {
+	return !m.Expires.IsZero() && !now.Before(m.Expires)
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// --- RECIPES ---
+
+// Ingredient is one component of a composite beverage. Amount is in the
+// ingredient's own stock unit, e.g. 40 for 4cl of a rum stocked in ml.
+type Ingredient struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+func (b Beverage) IsRecipe() bool { return len(b.Recipe) > 0 }
+
+// AvailableFrom is how many items can be sold given the inventory. For
+// recipes that is limited by the scarcest ingredient; a missing ingredient
+// makes the recipe unavailable. Stock sold below zero counts as none.
+func (b Beverage) AvailableFrom(inventory []Beverage) int {
+	if !b.IsRecipe() {
+		return max(0, b.available())
+	}
+	n := math.MaxInt
+	for _, ing := range b.Recipe {
+		i := IndexOf(inventory, ing.Name)
+		if i < 0 || ing.Amount <= 0 {
+			return 0
+		}
+		n = min(n, int(math.Floor(inventory[i].Stock/ing.Amount+1e-9)))
+	}
+	return max(0, n)
+}
+
+// StockNeeded adds up how much of each stocked beverage the lines take,
+// resolving recipes into their ingredients.
+func StockNeeded(inventory []Beverage, lines []SaleLine) (map[string]float64, error) {
+	needed := map[string]float64{}
+	for _, line := range lines {
+		if line.Untracked {
+			continue
+		}
+		i := IndexOf(inventory, line.Name)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown beverage %q", line.Name)
+		}
+		b := inventory[i]
+		if !b.IsRecipe() {
+			needed[b.Key()] += float64(line.Quantity) * b.PortionSize()
+			continue
+		}
+		for _, ing := range b.Recipe {
+			j := IndexOf(inventory, ing.Name)
+			if j < 0 {
+				return nil, fmt.Errorf("%s needs %s, which is not in the inventory", b.Key(), ing.Name)
+			}
+			if inventory[j].IsRecipe() {
+				return nil, fmt.Errorf("%s: ingredient %s is itself a recipe", b.Key(), ing.Name)
+			}
+			needed[ing.Name] += float64(line.Quantity) * ing.Amount
+		}
+	}
+	return needed, nil
+}
+
+// CheckStock returns an error naming the first beverage the lines need
+// more of than there is in stock.
+func CheckStock(inventory []Beverage, lines []SaleLine) (map[string]float64, error) {
+	needed, err := StockNeeded(inventory, lines)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(needed))
+	for name := range needed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if needed[name] > inventory[IndexOf(inventory, name)].Stock+1e-9 {
+			return nil, fmt.Errorf("not enough %s in stock", name)
+		}
+	}
+	return needed, nil
+}
+
+func IndexOf(inventory []Beverage, name string) int {
+	for i, b := range inventory {
+		if b.Key() == name {
+			return i
+		}
+	}
+	return -1
+}
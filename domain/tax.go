@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- SALES & TAX ---
+
+// Sale is a completed checkout. Prices are gross (tax included), as they are
+// shown on the shelf; net and tax amounts are derived from the line's rate.
+type Sale struct {
+	ID    int        `json:"id"`
+	Time  time.Time  `json:"time"`
+	Lines []SaleLine `json:"lines"`
+	// Member is the ID of the member whose tab was charged, if any.
+	Member string `json:"member,omitempty"`
+	// Voids is the ID of the sale of a closed day this one reverses.
+	Voids int `json:"voids,omitempty"`
+	// Voucher is the code of the voucher that paid VoucherAmount of the
+	// sale; the rest is Due.
+	Voucher       string  `json:"voucher,omitempty"`
+	VoucherAmount float64 `json:"voucher_amount,omitempty"`
+	// Card is the payment on the card terminal, for a sale paid by card.
+	Card *CardPayment `json:"card,omitempty"`
+	// Link is the payment through a payment link, for a sale paid that way.
+	Link *LinkPayment `json:"link,omitempty"`
+	// Rounding is what the cash paid for the sale was rounded by, see
+	// CashRounding.
+	Rounding float64 `json:"rounding,omitempty"`
+	// Note is what was noted for the sale at checkout, like who pays for
+	// it or the event it was for; it is printed on the receipt.
+	Note string `json:"note,omitempty"`
+}
+
+// MaxNoteLength is how many characters the note of a sale may have.
+const MaxNoteLength = 80
+
+// CardPayment is a payment on the card terminal: the provider's reference
+// and how it ended.
+type CardPayment struct {
+	Provider  string `json:"provider"`
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+}
+
+// LinkPayment is a payment through a payment link, like PayPal: what the
+// link is called and the reference the customer paid with.
+type LinkPayment struct {
+	Method    string `json:"method"`
+	Reference string `json:"reference"`
+}
+
+type SaleLine struct {
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	TaxClass  string  `json:"tax_class"`
+	TaxRate   float64 `json:"tax_rate"`
+	// Unit and Portion record how much stock each item took, as the
+	// beverage was set up at the time of the sale.
+	Unit    Unit    `json:"unit,omitempty"`
+	Portion float64 `json:"portion,omitempty"`
+	// Category and Account are where the line is booked, see Category.
+	Category   string `json:"category,omitempty"`
+	Account    string `json:"account,omitempty"`
+	NonRevenue bool   `json:"non_revenue,omitempty"`
+	// Untracked lines don't correspond to an inventory item, e.g. a vend
+	// from a slot that isn't mapped to a beverage.
+	Untracked bool `json:"untracked,omitempty"`
+	// ListPrice is the regular unit price of a line an admin sold at
+	// UnitPrice instead, e.g. a dented can at half price.
+	ListPrice float64 `json:"list_price,omitempty"`
+	// Bundle is the size of the bundle the line was sold in, e.g. 20 for
+	// a crate of 20; the line's quantity is a multiple of it.
+	Bundle int `json:"bundle,omitempty"`
+}
+
+// amount is the stock the line took, e.g. "750 g", or "" for pieces.
+func (l SaleLine) amount() string {
+	if l.Untracked || (l.Unit == UnitPiece && l.Portion <= 1) {
+		return ""
+	}
+	return l.Unit.Format(float64(l.Quantity) * l.Portion)
+}
+
+// BundlePrice is what one bundle of a line sold in bundles costs.
+func (l SaleLine) BundlePrice() float64 { return RoundCents(l.UnitPrice * float64(l.Bundle)) }
+
+func (l SaleLine) Gross() float64 { return l.UnitPrice * float64(l.Quantity) }
+func (l SaleLine) Net() float64   { return RoundCents(l.Gross() / (1 + l.TaxRate/100)) }
+func (l SaleLine) Tax() float64   { return RoundCents(l.Gross() - l.Net()) }
+
+// Items lists what was sold, e.g. "2x Club-Mate, 1x Beer".
+func (s Sale) Items() string {
+	items := make([]string, len(s.Lines))
+	for i, l := range s.Lines {
+		items[i] = fmt.Sprintf("%dx %s", l.Quantity, l.Name)
+	}
+	return strings.Join(items, ", ")
+}
+
+func (s Sale) Total() float64 {
+	total := 0.0
+	for _, l := range s.Lines {
+		total += l.Gross()
+	}
+	return total
+}
+
+func RoundCents(v float64) float64 { return math.Round(v*100) / 100 }
+
+// CashRounding is what amount changes by when it is rounded to the nearest
+// multiple of step, for paying in cash where the smallest coins are out of
+// use: with a step of 0.05, 1.12 is paid as 1.10 and 1.13 as 1.15. A step
+// of 0 doesn't round.
+func CashRounding(amount, step float64) float64 {
+	if step <= 0 {
+		return 0
+	}
+	return RoundCents(math.Round(RoundCents(amount)/step)*step - amount)
+}
+
+// CashDue is what is paid in cash for a sale that is paid in cash: what is
+// due, rounded.
+func (s Sale) CashDue() float64 { return RoundCents(s.Due() + s.Rounding) }
+
+// TaxSummary is the amount collected for a single tax class.
+type TaxSummary struct {
+	Class string
+	Rate  float64
+	Net   float64
+	Tax   float64
+	Gross float64
+}
+
+// SummarizeTax groups the revenue lines of the given sales by tax class and
+// rate. The result is sorted by descending rate.
+func SummarizeTax(sales []Sale) []TaxSummary {
+	type key struct {
+		class string
+		rate  float64
+	}
+	byClass := map[key]*TaxSummary{}
+	for _, sale := range sales {
+		for _, l := range sale.Lines {
+			if l.NonRevenue {
+				continue
+			}
+			k := key{l.TaxClass, l.TaxRate}
+			sum, ok := byClass[k]
+			if !ok {
+				sum = &TaxSummary{Class: l.TaxClass, Rate: l.TaxRate}
+				byClass[k] = sum
+			}
+			sum.Net += l.Net()
+			sum.Tax += l.Tax()
+			sum.Gross += l.Gross()
+		}
+	}
+	summaries := make([]TaxSummary, 0, len(byClass))
+	for _, sum := range byClass {
+		summaries = append(summaries, *sum)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Rate != summaries[j].Rate {
+			return summaries[i].Rate > summaries[j].Rate
+		}
+		return summaries[i].Class < summaries[j].Class
+	})
+	return summaries
+}
+
+// Label is the line's name with the amount of stock it took, if that
+// isn't just pieces.
+func (l SaleLine) Label() string {
+	if amount := l.amount(); amount != "" {
+		return l.Name + " (" + amount + ")"
+	}
+	return l.Name
+}
+
+// --- STATS ---
+
+// Revenue is the sale's total without lines that aren't revenue, like
+// deposits.
+func (s Sale) Revenue() float64 {
+	total := 0.0
+	for _, l := range s.Lines {
+		if !l.NonRevenue {
+			total += l.Gross()
+		}
+	}
+	return total
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"math"
+	"strconv"
+)
+
+// --- UNITS ---
+
+// Unit is what a beverage's stock is counted in. The empty unit counts
+// pieces, like bottles or cans.
+type Unit string
+
+const (
+	UnitPiece      Unit = ""
+	UnitLiter      Unit = "l"
+	UnitMilliliter Unit = "ml"
+	UnitKilogram   Unit = "kg"
+	UnitGram       Unit = "g"
+)
+
+// countable reports whether the unit counts whole items rather than
+// measuring an amount.
+func (u Unit) countable() bool {
+	switch u {
+	case UnitLiter, UnitMilliliter, UnitKilogram, UnitGram:
+		return false
+	}
+	return true
+}
+
+// Format renders an amount in the unit, e.g. "24", "2.5 l" or "750 g".
+func (u Unit) Format(amount float64) string {
+	if u.countable() {
+		s := strconv.FormatFloat(amount, 'f', -1, 64)
+		if u == UnitPiece {
+			return s
+		}
+		return s + " " + string(u)
+	}
+	return strconv.FormatFloat(math.Round(amount*1000)/1000, 'f', -1, 64) + " " + string(u)
+}
+
+// PortionSize is how much stock one sold item consumes.
+func (b Beverage) PortionSize() float64 {
+	if b.Portion <= 0 {
+		return 1
+	}
+	return b.Portion
+}
+
+// available is how many items can still be sold from the stock.
+func (b Beverage) available() int {
+	// The epsilon keeps 0.3 l / 0.1 l from coming out as 2.
+	return int(math.Floor(b.Stock/b.PortionSize() + 1e-9))
+}
+
+// SizeLabel describes the portion sold, e.g. "250 g", or "" for single
+// pieces.
+func (b Beverage) SizeLabel() string {
+	if b.Unit == UnitPiece && b.PortionSize() == 1 {
+		return ""
+	}
+	return b.Unit.Format(b.PortionSize())
+}
+
+// StockLabel renders the stock in the beverage's unit.
+func (b Beverage) StockLabel() string { return b.Unit.Format(b.Stock) }
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// --- VOUCHERS ---
+
+// Voucher is a gift card. Its code was sold for Value, of which Balance is
+// left to spend; Sale is the ID of the sale it was sold with.
+type Voucher struct {
+	Code    string    `json:"code"`
+	Value   float64   `json:"value"`
+	Balance float64   `json:"balance"`
+	Issued  time.Time `json:"issued"`
+	Sale    int       `json:"sale,omitempty"`
+	Note    string    `json:"note,omitempty"`
+}
+
+// Due is what is left to pay of the sale after the voucher.
+func (s Sale) Due() float64 { return RoundCents(s.Total() - s.VoucherAmount) }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- STATE EXPORT ---
+
+// The export is the whole state of the till as one JSON document, for
+// moving to another system, analysing it elsewhere or feeding other club
+// tools. Unlike the store file, its layout is kept stable: fields are only
+// ever added, and a change that can't be made that way gets a new format.
+
+// exportFormat names the layout of the export.
+const exportFormat = "bubbletender-export/1"
+
+type stateExport struct {
+	Format   string    `json:"format"`
+	Version  string    `json:"version"`
+	Exported time.Time `json:"exported"`
+	// Inventory is every beverage, with what its stock and recipes leave
+	// available.
+	Inventory []exportBeverage `json:"inventory"`
+	// Members are the members and guests with their tabs; Owed is what
+	// the club owes them all together, less what they owe it.
+	Members  []exportMember       `json:"members"`
+	Owed     float64              `json:"owed"`
+	Ledger   []domain.LedgerEntry `json:"ledger"`
+	Vouchers []domain.Voucher     `json:"vouchers"`
+	Sales    []exportSale         `json:"sales"`
+	Restocks []store.Restock      `json:"restocks"`
+	Shifts   []store.Shift        `json:"shifts"`
+	Closes   []store.DayClose     `json:"closes"`
+
+	// WriteOffs is the stock that left the inventory without being sold.
+	WriteOffs []store.WriteOff `json:"write_offs"`
+}
+
+type exportBeverage struct {
+	domain.Beverage
+	Available int `json:"available"`
+}
+
+type exportMember struct {
+	domain.Member
+	Guest bool `json:"guest,omitempty"`
+	// Sales and Spent are the sales charged to the tab and their total.
+	Sales int     `json:"sales"`
+	Spent float64 `json:"spent"`
+}
+
+// exportSale is a sale with its totals worked out, so that tools reading
+// the export don't have to know how tax and vouchers are booked.
+type exportSale struct {
+	domain.Sale
+	Total float64 `json:"total"`
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+	Due   float64 `json:"due"`
+}
+
+// exportCommand writes the export:
+//
+//	export [-o FILE] [-redact]
+//
+// With -redact, the members' names and card tokens are left out, and so
+// are the notes of the sales, for analysis by people who needn't know who
+// drank what.
+func exportCommand(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "", "write to this file instead of the terminal")
+	redact := fs.Bool("redact", false, "leave out the members' names and tokens")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: export [-o FILE] [-redact]")
+	}
+
+	e := stateExport{Format: exportFormat, Version: store.CurrentVersion(), Exported: time.Now(),
+		Inventory: []exportBeverage{}, Members: []exportMember{}, Sales: []exportSale{},
+		Ledger: nonNil(s.Ledger), Vouchers: nonNil(s.Vouchers), Restocks: nonNil(s.Restocks),
+		WriteOffs: nonNil(s.WriteOffs), Shifts: nonNil(s.Shifts), Closes: nonNil(s.Closes)}
+	for _, b := range s.Beverages {
+		e.Inventory = append(e.Inventory, exportBeverage{b, b.AvailableFrom(s.Beverages)})
+	}
+	tabs := map[string]*exportMember{}
+	for _, m := range s.Members {
+		if *redact {
+			m.Name, m.Token = "", ""
+		}
+		e.Members = append(e.Members, exportMember{Member: m, Guest: m.IsGuest()})
+		e.Owed += m.Balance
+	}
+	for i := range e.Members {
+		tabs[e.Members[i].ID] = &e.Members[i]
+	}
+	for _, sale := range s.Sales {
+		if *redact {
+			sale.Note = ""
+		}
+		x := exportSale{Sale: sale, Total: sale.Total(), Due: sale.Due()}
+		for _, l := range sale.Lines {
+			x.Net += l.Net()
+			x.Tax += l.Tax()
+		}
+		x.Net, x.Tax = domain.RoundCents(x.Net), domain.RoundCents(x.Tax)
+		e.Sales = append(e.Sales, x)
+		if m := tabs[sale.Member]; m != nil {
+			m.Sales++
+			m.Spent = domain.RoundCents(m.Spent + sale.Total())
+		}
+	}
+	e.Owed = domain.RoundCents(e.Owed)
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0o600)
+}
+
+// nonNil keeps empty lists as [] in the export rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- GUEST WRISTBANDS ---
+
+// guestsCommand manages the guests of an event:
+//
+//	guests create [-credit X] [-expires D] <event> <count>
+//	guests expire [-at D] <event>
+//	guests report <event>
+//
+// create writes the new guests as CSV (id, token, credit) for printing the
+// wristbands.
+func guestsCommand(s *store.Store, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: guests create|expire|report ...")
+	}
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("guests create", flag.ExitOnError)
+		credit := decimalFlag(fs, "credit", "prepaid credit on every wristband")
+		expires := fs.String("expires", "", "when the wristbands stop working (YYYY-MM-DD HH:MM)")
+		fs.Parse(args[1:])
+		if fs.NArg() != 2 {
+			return fmt.Errorf("usage: guests create [-credit X] [-expires D] <event> <count>")
+		}
+		count, err := strconv.Atoi(fs.Arg(1))
+		if err != nil {
+			return fmt.Errorf("invalid count %q", fs.Arg(1))
+		}
+		var until time.Time
+		if *expires != "" {
+			if until, err = time.ParseInLocation("2006-01-02 15:04", *expires, time.Local); err != nil {
+				return fmt.Errorf("invalid -expires: %w", err)
+			}
+		}
+		guests, err := s.CreateGuests(fs.Arg(0), count, *credit, until)
+		if err != nil {
+			return err
+		}
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"id", "token", "credit"})
+		for _, g := range guests {
+			w.Write([]string{g.ID, g.Token, ui.Money(g.Balance)})
+		}
+		w.Flush()
+		return w.Error()
+	case "expire":
+		fs := flag.NewFlagSet("guests expire", flag.ExitOnError)
+		at := fs.String("at", "", "when the wristbands stop working (YYYY-MM-DD HH:MM), defaults to now")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: guests expire [-at D] <event>")
+		}
+		until := time.Now()
+		if *at != "" {
+			t, err := time.ParseInLocation("2006-01-02 15:04", *at, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid -at: %w", err)
+			}
+			until = t
+		}
+		n, err := s.ExpireGuests(fs.Arg(0), until)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d wristbands of %s expire at %s.\n", n, fs.Arg(0), until.Format("2006-01-02 15:04"))
+		return nil
+	case "report":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: guests report <event>")
+		}
+		return s.WriteGuestReport(os.Stdout, args[1])
+	}
+	return fmt.Errorf("unknown guests command %q", args[0])
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- SUPPLIER INVOICES ---
+
+// importInvoiceCommand updates the cost prices from a supplier invoice:
+//
+//	restock invoice [-dry-run | -yes [-prices]] <invoice.csv|.txt|.pdf>
+//
+// The matched lines are shown for review first, where the suggested retail
+// prices can be taken, with -dry-run only that; -yes updates the costs
+// without asking, and with -prices takes every suggested price, too. PDF
+// invoices are read with pdftotext, from poppler.
+func importInvoiceCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("restock invoice", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only show how the costs and prices would change")
+	yes := fs.Bool("yes", false, "update the costs without review")
+	prices := fs.Bool("prices", false, "with -yes, take the suggested prices, too")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: restock invoice [-dry-run | -yes [-prices]] <invoice.csv|.txt|.pdf>")
+	}
+	r, err := openInvoice(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	invoice, err := store.ReadInvoice(r, cfg.Supplier)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	s.MatchInvoice(invoice, cfg.Supplier, func(b domain.Beverage) float64 {
+		var line domain.SaleLine
+		cfg.Classify(&line, b)
+		return line.TaxRate
+	})
+
+	reprice := map[string]bool{}
+	switch {
+	case *dryRun:
+		return store.WriteInvoice(os.Stdout, invoice)
+	case !*yes:
+		result, err := tea.NewProgram(ui.NewInvoiceReview(invoice)).Run()
+		if err != nil {
+			return err
+		}
+		review := result.(ui.InvoiceReview)
+		if !review.Confirmed {
+			fmt.Println("Nothing updated.")
+			return nil
+		}
+		reprice = review.Reprice()
+	case *prices:
+		for _, l := range invoice {
+			if l.Reprices() {
+				reprice[l.Beverage] = true
+			}
+		}
+	}
+	costs, changed, err := s.UpdateCosts(invoice, reprice)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Updated %d costs and %d prices.\n", costs, changed)
+	return nil
+}
+
+// openInvoice reads an invoice file, PDFs as the text pdftotext makes of
+// them.
+func openInvoice(path string) (io.Reader, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".pdf") {
+		data, err := os.ReadFile(path)
+		return bytes.NewReader(data), err
+	}
+	out, err := exec.Command("pdftotext", "-layout", path, "-").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: converting with pdftotext: %w", path, err)
+	}
+	return bytes.NewReader(out), nil
+}
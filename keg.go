@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- KEGS ---
+
+// kegTracker accumulates flow meter pulses into pours.
+type kegTracker struct {
+	store   *store.Store
+	cfg     ui.Config
+	log     io.Writer
+	session *cardSession
+
+	pulses   map[string]float64   // pulses of the pour in progress per tap
+	lastFlow map[string]time.Time // last pulse per tap
+}
+
+func (k *kegTracker) flow(tap string, pulses float64, now time.Time) {
+	k.pulses[tap] += pulses
+	k.lastFlow[tap] = now
+}
+
+// finishPours books every pour that has been idle for long enough.
+func (k *kegTracker) finishPours(now time.Time) error {
+	for tap, last := range k.lastFlow {
+		if now.Sub(last) < k.cfg.Kegs.PourIdle.Duration {
+			continue
+		}
+		liters := k.pulses[tap] / k.cfg.Kegs.PulsesPerLiter
+		delete(k.pulses, tap)
+		delete(k.lastFlow, tap)
+		if err := k.pour(tap, liters, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *kegTracker) pour(tap string, liters float64, now time.Time) error {
+	return k.store.Update(func() error { return k.bookPour(tap, liters, now) })
+}
+
+func (k *kegTracker) bookPour(tap string, liters float64, now time.Time) error {
+	i := k.store.KegIndex(tap)
+	if i < 0 {
+		fmt.Fprintf(k.log, "%.2f l poured from tap %s, which has no keg\n", liters, tap)
+		return nil
+	}
+	keg := &k.store.Kegs[i]
+	keg.Remaining = math.Max(0, keg.Remaining-liters)
+	pour := store.Pour{Time: now, Tap: tap, Liters: liters}
+
+	size, ok := store.ClosestPourSize(k.cfg.Kegs.PourSizes, liters)
+	if ok {
+		pour.Size = size.Name
+	}
+	if member := k.session.active(now); ok && member != "" {
+		line := domain.SaleLine{Name: keg.Beverage + " " + size.Name, Quantity: 1, UnitPrice: size.Price, Untracked: true}
+		var beverage domain.Beverage
+		if b := k.store.BeverageIndex(keg.Beverage); b >= 0 {
+			beverage = k.store.Beverages[b]
+		}
+		k.cfg.Classify(&line, beverage)
+		if err := k.store.BookSale(domain.Sale{Time: now, Member: member, Lines: []domain.SaleLine{line}}); err != nil {
+			fmt.Fprintf(k.log, "billing %s to %s failed: %v\n", line.Name, member, err)
+		} else {
+			pour.Member = member
+			fmt.Fprintf(k.log, "billed %s (%.2f l) to %s\n", line.Name, liters, member)
+		}
+	} else {
+		fmt.Fprintf(k.log, "unbilled pour of %.2f l from tap %s\n", liters, tap)
+	}
+	if keg.Remaining <= k.cfg.Kegs.AlertLiters {
+		fmt.Fprintf(k.log, "keg on tap %s (%s) nearly empty: %.1f l left\n", tap, keg.Beverage, keg.Remaining)
+	}
+	k.store.Pours = append(k.store.Pours, pour)
+	return nil
+}
+
+type flowMsg struct {
+	tap    string
+	pulses float64
+}
+
+// parseFlowLine parses a "<tap> <pulses>" line from a serial flow meter.
+func parseFlowLine(line string) (flowMsg, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return flowMsg{}, false
+	}
+	pulses, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || pulses < 0 {
+		return flowMsg{}, false
+	}
+	return flowMsg{tap: fields[0], pulses: pulses}, true
+}
+
+// kegCommand manages kegs and runs the flow meter listener:
+//
+//	keg list
+//	keg connect <tap> <beverage> <liters>
+//	keg run
+func kegCommand(cfg ui.Config, load configLoader, s *store.Store, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Tap\tBeverage\tRemaining\tCapacity")
+		for _, k := range s.Kegs {
+			fmt.Fprintf(w, "%s\t%s\t%.1f l\t%.1f l\n", k.Tap, k.Beverage, k.Remaining, k.Capacity)
+		}
+		return w.Flush()
+	case "connect":
+		if len(args) != 4 {
+			return fmt.Errorf("usage: keg connect <tap> <beverage> <liters>")
+		}
+		liters, err := ui.ParseDecimal(args[3])
+		if err != nil || liters <= 0 {
+			return fmt.Errorf("invalid volume %q", args[3])
+		}
+		keg := store.Keg{Tap: args[1], Beverage: args[2], Capacity: liters, Remaining: liters}
+		return s.Update(func() error {
+			if i := s.KegIndex(keg.Tap); i >= 0 {
+				s.Kegs[i] = keg
+			} else {
+				s.Kegs = append(s.Kegs, keg)
+			}
+			s.Audit(store.AuditEntry{Event: "keg", Beverage: keg.Beverage, Quantity: liters, Detail: "tap " + keg.Tap})
+			return nil
+		})
+	case "run":
+		return runKegs(cfg, load, s)
+	}
+	return fmt.Errorf("unknown keg command %q", args[0])
+}
+
+// runKegs listens to the flow meters until the source or stdin closes.
+// Member cards are read from stdin like for the coffee machine.
+func runKegs(cfg ui.Config, load configLoader, s *store.Store) error {
+	if cfg.Kegs.PulsesPerLiter <= 0 {
+		return fmt.Errorf("kegs.pulses_per_liter must be positive")
+	}
+	k := &kegTracker{
+		store:    s,
+		cfg:      cfg,
+		log:      os.Stderr,
+		session:  &cardSession{store: s, timeout: cfg.Kegs.SessionTimeout.Duration, log: os.Stderr},
+		pulses:   map[string]float64{},
+		lastFlow: map[string]time.Time{},
+	}
+
+	flows := make(chan flowMsg)
+	errs := make(chan error, 1)
+	switch cfg.Kegs.Source {
+	case "serial":
+		port, err := os.Open(cfg.Kegs.Device)
+		if err != nil {
+			return err
+		}
+		defer port.Close()
+		lines := make(chan string)
+		go ui.ScanLines(port, lines)
+		go func() {
+			for line := range lines {
+				if msg, ok := parseFlowLine(line); ok {
+					flows <- msg
+				}
+			}
+			errs <- fmt.Errorf("flow meter device %s closed", cfg.Kegs.Device)
+		}()
+	case "mqtt":
+		client, err := store.DialMQTT(cfg.Kegs.MQTT)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		go func() {
+			errs <- client.Subscribe(cfg.Kegs.Topic, func(topic string, payload []byte) {
+				pulses, err := strconv.ParseFloat(strings.TrimSpace(string(payload)), 64)
+				if err == nil && pulses >= 0 {
+					flows <- flowMsg{tap: path.Base(topic), pulses: pulses}
+				}
+			})
+		}()
+	default:
+		return fmt.Errorf("unknown kegs.source %q (use serial or mqtt)", cfg.Kegs.Source)
+	}
+
+	d := startDaemon(load)
+	defer d.stop()
+	tokens := make(chan string)
+	go ui.ScanLines(os.Stdin, tokens)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	d.ready()
+	for {
+		select {
+		case msg := <-flows:
+			k.flow(msg.tap, msg.pulses, time.Now())
+		case now := <-ticker.C:
+			if err := k.finishPours(now); err != nil {
+				return err
+			}
+		case token, ok := <-tokens:
+			if !ok {
+				if d.managed {
+					tokens = nil
+					continue
+				}
+				return nil
+			}
+			if token = strings.TrimSpace(token); token != "" {
+				k.session.tap(token, time.Now())
+			}
+		case <-d.hup:
+			cfg, err := d.reload(s)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "reload: %v\n", err)
+				continue
+			}
+			if cfg.Kegs.PulsesPerLiter <= 0 {
+				fmt.Fprintln(os.Stderr, "reload: kegs.pulses_per_liter must be positive, keeping the old config")
+				continue
+			}
+			k.cfg = cfg
+			k.session.timeout = cfg.Kegs.SessionTimeout.Duration
+		case <-d.term:
+			// Book whatever is being poured right now rather than losing it.
+			for tap := range k.lastFlow {
+				k.lastFlow[tap] = time.Time{}
+			}
+			return k.finishPours(time.Now())
+		case err := <-errs:
+			return err
+		}
+	}
+}
@@ -1,289 +1,191 @@
 package main
 
 import (
+	"cmp"
+	"flag"
 	"fmt"
 	"os"
-	"strings"
 
-	"github.com/charmbracelet/bubbles/table"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
 )
 
-// --- DATA ---
-type Beverage struct {
-	Name  string
-	Price float64
-	Stock int
-}
-
-var ourBeverages = []Beverage{
-	{Name: "Club-Mate", Price: 1.50, Stock: 24},
-	{Name: "Espresso", Price: 1.00, Stock: 50},
-	{Name: "Fritz-Kola", Price: 2.00, Stock: 12},
-	{Name: "Water", Price: 0.50, Stock: 100},
-	{Name: "Beer", Price: 2.50, Stock: 6},
-}
-
-func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
-	border := lipgloss.RoundedBorder()
-	border.BottomLeft = left
-	border.Bottom = middle
-	border.BottomRight = right
-	return border
-}
-
-var (
-	inactiveTabBorder = tabBorderWithBottom("┴", "─", "┴")
-	activeTabBorder   = tabBorderWithBottom("┘", " ", "└")
-	docStyle          = lipgloss.NewStyle().Padding(1, 2, 1, 2)
-	highlightColor    = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
-	inactiveTabStyle  = lipgloss.NewStyle().Border(inactiveTabBorder, true).BorderForeground(highlightColor).Padding(0, 1)
-	activeTabStyle    = inactiveTabStyle.Border(activeTabBorder, true)
-	windowStyle       = lipgloss.NewStyle().BorderForeground(highlightColor).Padding(2, 0).Align(lipgloss.Center).Border(lipgloss.NormalBorder()).UnsetBorderTop()
-)
+// --- MAIN ---
 
-// --- MODEL ---
-
-type model struct {
-	beverages     []Beverage
-	table         table.Model
-	cart          map[int]int
-	isCheckingOut bool
-	activeTab     int
-	width         int
-	height        int
-}
-
-func initialModel() model {
-	columns := []table.Column{
-		{Title: "Name", Width: 20},
-		{Title: "Price", Width: 10},
-		{Title: "Stock", Width: 10},
-		{Title: "Qty", Width: 5},
-	}
-	cart := make(map[int]int)
-	rows := []table.Row{}
-	for i, beverage := range ourBeverages {
-		row := table.Row{
-			beverage.Name,
-			fmt.Sprintf("€%.2f", beverage.Price),
-			fmt.Sprintf("%d", beverage.Stock),
-			fmt.Sprintf("%d", cart[i]),
+func main() {
+	configPath := flag.String("config", "bubbletender.json", "path to the config file")
+	dataPath := flag.String("data", "bubbletender-data.json", "path to the data store")
+	listenAddr := flag.String("listen", "", "serve the HTTP API on this address next to the TUI, e.g. :8080")
+	accessibility := flag.String("accessibility", "", "display mode: high-contrast, colorblind or plain, overriding the config")
+	display := flag.Bool("display", false, "show the cart of the till named by server.till, for a screen facing the customer")
+	popout := flag.String("popout", "", "show the cart or the stats of the till named by server.till in a second terminal: cart or stats")
+	queue := flag.Bool("queue", false, "show the order queue for preparing drinks, see the queue config")
+	var overrides ui.ConfigOverrides
+	flag.Var(&overrides, "set", "override a config key, e.g. -set kegs.mqtt.broker=mqtt:1883 (repeatable)")
+	for env, name := range ui.EnvFlags {
+		if value, ok := os.LookupEnv(env); ok {
+			flag.Set(name, value)
 		}
-		rows = append(rows, row)
 	}
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(7),
-	)
-	s := table.DefaultStyles()
-	s.Header = s.Header.BorderStyle(lipgloss.NormalBorder()).BorderBottom(true)
-	s.Selected = s.Selected.Foreground(lipgloss.Color("229")).Background(lipgloss.Color("57")).Bold(false)
-	t.SetStyles(s)
+	flag.Parse()
 
-	return model{
-		beverages:     ourBeverages,
-		table:         t,
-		cart:          cart,
-		isCheckingOut: false,
-		activeTab:     0,
-	}
-}
-
-func (m model) Init() tea.Cmd { return nil }
-
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		return m, nil
-	}
-
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch keypress := msg.String(); keypress {
-		case "ctrl+c", "q":
-			return m, tea.Quit
+	load := func() (ui.Config, error) {
+		cfg, err := ui.LoadConfig(*configPath)
+		if err != nil {
+			return cfg, err
 		}
-
-		switch keypress := msg.String(); keypress {
-		case "s":
-			m.activeTab = 0 // Shop
-			m.isCheckingOut = false
-		case "c":
-			m.activeTab = 1 // Cart
-			m.isCheckingOut = false
+		if err := cfg.ApplyOverrides(os.Environ(), overrides); err != nil {
+			return cfg, err
 		}
-
-		switch m.activeTab {
-		case 0: // Shop Tab
-			switch msg.String() {
-			case "+", "=", "right":
-				cursor := m.table.Cursor()
-				if m.cart[cursor] < m.beverages[cursor].Stock {
-					m.cart[cursor]++
-				}
-			case "-", "left":
-				cursor := m.table.Cursor()
-				if m.cart[cursor] > 0 {
-					m.cart[cursor]--
-				}
-			}
-			rows := []table.Row{}
-			for i, beverage := range m.beverages {
-				row := table.Row{
-					beverage.Name,
-					fmt.Sprintf("€%.2f", beverage.Price),
-					fmt.Sprintf("%d", beverage.Stock),
-					fmt.Sprintf("%d", m.cart[i]),
-				}
-				rows = append(rows, row)
-			}
-			m.table.SetRows(rows)
-			m.table, cmd = m.table.Update(msg)
-
-		case 1: // Cart Tab
-			if m.isCheckingOut {
-				switch msg.String() {
-				case "y":
-					return m, tea.Quit
-				case "n", "esc":
-					m.isCheckingOut = false
-				}
-			} else {
-				if msg.String() == "enter" {
-					hasItems := false
-					for _, qty := range m.cart {
-						if qty > 0 {
-							hasItems = true
-							break
-						}
-					}
-					if hasItems {
-						m.isCheckingOut = true
-					}
-				}
-			}
+		if *accessibility != "" {
+			cfg.Accessibility = ui.Accessibility(*accessibility)
+		}
+		if err := cfg.Validate(); err != nil {
+			return cfg, err
 		}
+		cfg.RelaxTimeLimits()
+		return cfg, nil
 	}
-
-	return m, cmd
-}
-
-// --- VIEWS ---
-
-func (m model) View() string {
-	var mainContent string
-	var helpText string
-
-	// --- 1. Generate the Main Content String ---
-	switch m.activeTab {
-	case 1: // Cart
-		mainContent = m.cartView()
-	default: // Shop
-		mainContent = m.table.View()
-		helpText = "\n\nUse ←/→ to change quantity.\nPress 'c' to view cart, 'q' to quit."
+	cfg, err := load()
+	if err != nil {
+		fmt.Printf("Alas, there's been an error loading the config: %v", err)
+		os.Exit(1)
 	}
-
-	// Render the content inside its styled window
-	renderedContent := windowStyle.Render(mainContent + helpText)
-
-	// --- 2. Measure the Content's Width ---
-	contentWidth := lipgloss.Width(renderedContent)
-
-	// --- 3. Render the Tabs to Match the Width ---
-	tabs := []string{"Shop [s]", "Cart [c]"}
-	renderedTabs := []string{}
-
-	// Create styled tab strings
-	for i, t := range tabs {
-		var style lipgloss.Style
-		isFirst, isLast, isActive := i == 0, i == len(tabs)-1, i == m.activeTab
-		if isActive {
-			style = activeTabStyle
-		} else {
-			style = inactiveTabStyle
+	assets := ui.AssetFS(cfg.AssetsDir)
+	locale := cmp.Or(cfg.Locale, ui.EnvLocale(assets))
+	if err := ui.ApplyAssets(assets, locale, cmp.Or(cfg.ReceiptLocale, locale)); err != nil {
+		fmt.Printf("Alas, there's been an error loading the assets: %v", err)
+		os.Exit(1)
+	}
+	t, err := cfg.ThemeConfig().Resolve()
+	if err != nil {
+		fmt.Printf("Alas, there's been an error loading the theme: %v", err)
+		os.Exit(1)
+	}
+	terminal := cfg.Accessibility.Adapt(cfg.Terminal.Detect())
+	ui.ApplyTerminal(terminal)
+	ui.ApplyTheme(t)
+	var s *store.Store
+	cached := false
+	switch {
+	case cfg.Server.URL != "" && flag.NArg() == 0:
+		s, err = store.OpenRemote(cfg.Server, cfg.TabLimit)
+	case flag.NArg() == 0:
+		// The kiosk starts from the cache and loads the rest meanwhile.
+		if s, cached = store.OpenCached(*dataPath); !cached {
+			s, err = store.Open(*dataPath)
 		}
-		border, _, _, _, _ := style.GetBorder()
-		if isFirst && isActive {
-			border.BottomLeft = "│"
-		} else if isFirst && !isActive {
-			border.BottomLeft = "├"
-		} else if isLast && isActive {
-			border.BottomRight = "│"
-		} else if isLast && !isActive {
-			border.BottomRight = "┤"
+	default:
+		s, err = store.Open(*dataPath)
+	}
+	if err != nil {
+		fmt.Printf("Alas, there's been an error opening the store: %v", err)
+		os.Exit(1)
+	}
+	s.Configure(cfg.StoreOptions())
+	s.Actor = store.LocalActor(cmp.Or(flag.Arg(0), "till"))
+	if s.Remote != nil {
+		s.Remote.Actor = s.Actor
+	}
+	// Let webhooks and events that are still being delivered finish before
+	// exiting.
+	defer store.DeliveriesPending.Wait()
+
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "tax-report":
+			err = taxReport(s, flag.Args()[1:])
+		case "banner":
+			err = bannerCommand(s, flag.Args()[1:])
+		case "lockdown":
+			err = lockdownCommand(s, flag.Args()[1:])
+		case "price":
+			err = priceCommand(cfg, s, flag.Args()[1:])
+		case "bundle":
+			err = bundleCommand(s, flag.Args()[1:])
+		case "member":
+			err = memberCommand(cfg, s, flag.Args()[1:])
+		case "close-day":
+			err = closeDayCommand(s, flag.Args()[1:])
+		case "void":
+			err = voidCommand(s, flag.Args()[1:])
+		case "shift":
+			err = shiftCommand(cfg, s, flag.Args()[1:])
+		case "pretix":
+			err = pretixCommand(cfg, s, flag.Args()[1:])
+		case "guests":
+			err = guestsCommand(s, flag.Args()[1:])
+		case "voucher":
+			err = voucherCommand(s, flag.Args()[1:])
+		case "vend":
+			err = vendCommand(cfg, load, s, flag.Args()[1:])
+		case "coffee":
+			err = coffeeCommand(cfg, load, s, flag.Args()[1:])
+		case "keg":
+			err = kegCommand(cfg, load, s, flag.Args()[1:])
+		case "sell":
+			err = sellCommand(cfg, s, flag.Args()[1:])
+		case "stock":
+			err = stockCommand(cfg, s, flag.Args()[1:])
+		case "report":
+			err = salesReport(s, flag.Args()[1:])
+		case "ranking":
+			err = rankingCommand(s, flag.Args()[1:])
+		case "valuation":
+			err = valuationCommand(cfg, s, flag.Args()[1:])
+		case "accounts":
+			err = accountsCommand(cfg, s, flag.Args()[1:])
+		case "restock":
+			err = restockCommand(cfg, s, flag.Args()[1:])
+		case "writeoff":
+			err = writeOffCommand(s, flag.Args()[1:])
+		case "seed":
+			err = seedCommand(cfg, s, flag.Args()[1:])
+		case "serve":
+			err = serveCommand(cfg, load, s, flag.Args()[1:])
+		case "board":
+			err = boardCommand(cfg, s, flag.Args()[1:])
+		case "admin":
+			err = adminCommand(cfg, s, flag.Args()[1:])
+		case "year-end":
+			err = yearEndCommand(cfg, s, flag.Args()[1:])
+		case "check":
+			err = checkCommand(cfg, s, flag.Args()[1:])
+		case "export":
+			err = exportCommand(s, flag.Args()[1:])
+		case "update":
+			err = updateCommand(cfg, flag.Args()[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", flag.Arg(0))
 		}
-		style = style.Border(border)
-		renderedTabs = append(renderedTabs, style.Render(t))
+		if err != nil {
+			fmt.Printf("Alas, there's been an error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	// Calculate the width of the tabs and create a filler
-	tabsWidth := lipgloss.Width(renderedTabs[0]) + lipgloss.Width(renderedTabs[1])
-	fillerWidth := contentWidth - tabsWidth
-
-	// Create a style for the filler that only has a bottom border
-	fillerStyle := lipgloss.NewStyle().
-		BorderStyle(inactiveTabBorder).
-		BorderBottom(true).
-		BorderForeground(highlightColor).
-		Width(fillerWidth)
-
-	// Join the tabs and filler
-	tabsRow := lipgloss.JoinHorizontal(lipgloss.Bottom, renderedTabs[0], fillerStyle.Render(""), renderedTabs[1])
-
-	// --- 4. Combine and Center ---
-	finalView := lipgloss.JoinVertical(lipgloss.Left, tabsRow, renderedContent)
-
-	return lipgloss.Place(
-		m.width,
-		m.height,
-		lipgloss.Center,
-		lipgloss.Center,
-		finalView,
-	)
-}
-
-func (m model) cartView() string {
-	var s strings.Builder
-	s.WriteString("Your Current Order:\n\n")
-
-	totalPrice := 0.0
-	hasItems := false
-	for i, quantity := range m.cart {
-		if quantity > 0 {
-			hasItems = true
-			beverage := m.beverages[i]
-			itemPrice := beverage.Price * float64(quantity)
-			totalPrice += itemPrice
-			s.WriteString(fmt.Sprintf("  %dx %-20s @ €%.2f each = €%.2f\n",
-				quantity, beverage.Name, beverage.Price, itemPrice))
+	if *display || *queue || *popout != "" {
+		run := runDisplay
+		switch {
+		case *queue:
+			run = runQueue
+		case *popout != "":
+			run = func(cfg ui.Config, s *store.Store) error { return runPopout(cfg, s, *popout) }
+		}
+		if err := run(cfg, s); err != nil {
+			fmt.Printf("Alas, there's been an error: %v\n", err)
+			os.Exit(1)
 		}
+		return
 	}
-
-	if !hasItems {
-		s.WriteString("  Your cart is empty!\n\n\nGo to the 'Shop' tab to add items.")
-	} else {
-		s.WriteString("\n  -------------------------------------------\n")
-		s.WriteString(fmt.Sprintf("  Total: €%.2f\n", totalPrice))
-		if m.isCheckingOut {
-			s.WriteString("\n\nConfirm purchase? (y/n)\n(Press 'esc' or 'n' to cancel checkout)")
-		} else {
-			s.WriteString("\n\nPress 'enter' to checkout.")
+	if *listenAddr != "" {
+		if err := serveAPI(*listenAddr, cfg, s); err != nil {
+			fmt.Printf("Alas, there's been an error starting the API: %v", err)
+			os.Exit(1)
 		}
 	}
-	return s.String()
-}
-
-func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen(), tea.WithMouseCellMotion())
-	if _, err := p.Run(); err != nil {
+	if err := ui.Run(cfg, s, terminal, cached, ui.WithConfigReload(*configPath, load)); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- MEMBERS ---
+
+// cardSession remembers the member who tapped their card last, so that
+// things measured shortly after (espresso shots, beer pours) can be billed
+// to them.
+type cardSession struct {
+	store   *store.Store
+	timeout time.Duration
+	log     io.Writer
+
+	member  string
+	expires time.Time
+}
+
+// tap opens a session for the member carrying token.
+func (c *cardSession) tap(token string, now time.Time) {
+	member, ok := c.store.MemberByToken(token)
+	if !ok {
+		fmt.Fprintf(c.log, "unknown card %q\n", token)
+		return
+	}
+	c.member = member.ID
+	c.expires = now.Add(c.timeout)
+	fmt.Fprintf(c.log, "billing to %s until %s\n", member.Name, c.expires.Format("15:04:05"))
+}
+
+// active returns the member of the open session, or "" once it expired.
+func (c *cardSession) active(now time.Time) string {
+	if c.member == "" || !now.Before(c.expires) {
+		return ""
+	}
+	return c.member
+}
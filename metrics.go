@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- METRICS ---
+
+// writeMetrics writes the store in the Prometheus text format. Sold items
+// and revenue are counted from the sales history, so they only go down
+// when a sale is voided.
+func writeMetrics(w io.Writer, s *store.Store) {
+	fmt.Fprintln(w, "# HELP bubbletender_stock Current stock of a beverage, in its unit.")
+	fmt.Fprintln(w, "# TYPE bubbletender_stock gauge")
+	for _, b := range s.Beverages {
+		if b.IsRecipe() {
+			continue
+		}
+		fmt.Fprintf(w, "bubbletender_stock{beverage=%s,unit=%s} %g\n", labelValue(b.Key()), labelValue(cmp.Or(string(b.Unit), "piece")), b.Stock)
+	}
+	fmt.Fprintln(w, "# HELP bubbletender_available Portions of a beverage that can be sold right now.")
+	fmt.Fprintln(w, "# TYPE bubbletender_available gauge")
+	for _, b := range s.Beverages {
+		fmt.Fprintf(w, "bubbletender_available{beverage=%s} %d\n", labelValue(b.Key()), b.AvailableFrom(s.Beverages))
+	}
+
+	sold := map[string]int{}
+	revenue := map[string]float64{}
+	for _, sale := range s.Sales {
+		for _, l := range sale.Lines {
+			sold[l.Name] += l.Quantity
+			if !l.NonRevenue {
+				revenue[l.Name] += l.Gross()
+			}
+		}
+	}
+	names := make([]string, 0, len(sold))
+	for name := range sold {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	fmt.Fprintln(w, "# HELP bubbletender_sales_total Sales booked.")
+	fmt.Fprintln(w, "# TYPE bubbletender_sales_total counter")
+	fmt.Fprintf(w, "bubbletender_sales_total %d\n", len(s.Sales))
+	fmt.Fprintln(w, "# HELP bubbletender_items_sold_total Items sold per beverage.")
+	fmt.Fprintln(w, "# TYPE bubbletender_items_sold_total counter")
+	for _, name := range names {
+		fmt.Fprintf(w, "bubbletender_items_sold_total{beverage=%s} %d\n", labelValue(name), sold[name])
+	}
+	fmt.Fprintln(w, "# HELP bubbletender_revenue_euros_total Gross revenue per beverage.")
+	fmt.Fprintln(w, "# TYPE bubbletender_revenue_euros_total counter")
+	for _, name := range names {
+		fmt.Fprintf(w, "bubbletender_revenue_euros_total{beverage=%s} %.2f\n", labelValue(name), revenue[name])
+	}
+}
+
+// labelValue quotes a label value the way the text format wants it.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
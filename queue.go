@@ -0,0 +1,20 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- ORDER QUEUE ---
+
+// runQueue shows the order queue full screen until it is quit.
+func runQueue(cfg ui.Config, s *store.Store) error {
+	if !cfg.Queue.Enabled {
+		return errors.New("the order queue is off; set queue.enabled in the config")
+	}
+	_, err := tea.NewProgram(ui.NewQueueModel(s), tea.WithAltScreen()).Run()
+	return err
+}
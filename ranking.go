@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- TOP SELLERS AND SLOW MOVERS ---
+
+// rankingCommand prints or exports the ranking for a date range:
+//
+//	ranking [-from D] [-to D] [-csv] [-o FILE]
+func rankingCommand(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("ranking", flag.ExitOnError)
+	dates := addDateRangeFlags(fs)
+	asCSV := fs.Bool("csv", false, "write the ranking as CSV")
+	output := fs.String("o", "", "write to this file instead of the terminal")
+	fs.Parse(args)
+	from, to, err := dates.parse()
+	if err != nil {
+		return err
+	}
+	ranks := store.RankBeverages(s.Beverages, s.SalesBetween(from, to))
+
+	write := func(out io.Writer) error {
+		if *asCSV {
+			return ui.WriteRankingCSV(out, ranks)
+		}
+		return ui.WriteRankingReport(out, dates.String(), ranks)
+	}
+	if *output == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- RESTOCK ---
+
+// restockCommand books deliveries and lists them:
+//
+//	restock add [-cost C] [-best-before D] <beverage> <quantity>
+//	restock list [-from D] [-to D]
+//	restock expiring [-days N]
+//	restock invoice [-dry-run | -yes [-prices]] <invoice.csv|.txt|.pdf>
+func restockCommand(cfg ui.Config, s *store.Store, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("restock add", flag.ExitOnError)
+		cost := decimalFlag(fs, "cost", "total purchase cost of the delivery")
+		bestBefore := fs.String("best-before", "", "best-before date printed on the delivery (YYYY-MM-DD)")
+		fs.Parse(args[1:])
+		if fs.NArg() != 2 {
+			return fmt.Errorf("usage: restock add [-cost C] [-best-before D] <beverage> <quantity>")
+		}
+		qty, err := ui.ParseDecimal(fs.Arg(1))
+		if err != nil || qty <= 0 {
+			return fmt.Errorf("invalid quantity %q", fs.Arg(1))
+		}
+		if *cost < 0 {
+			return fmt.Errorf("invalid cost %.2f", *cost)
+		}
+		r := store.Restock{Time: time.Now(), Beverage: fs.Arg(0), Quantity: qty, Cost: domain.RoundCents(*cost)}
+		if *bestBefore != "" {
+			if r.BestBefore, err = time.ParseInLocation(time.DateOnly, *bestBefore, time.Local); err != nil {
+				return fmt.Errorf("invalid -best-before: %w", err)
+			}
+		}
+		return s.Restock(r)
+	case "invoice":
+		return importInvoiceCommand(cfg, s, args[1:])
+	case "expiring":
+		fs := flag.NewFlagSet("restock expiring", flag.ExitOnError)
+		days := fs.Int("days", cfg.ExpiryDays, "list what expires within this many days")
+		fs.Parse(args[1:])
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Best before\tBeverage\tQuantity\t")
+		now := time.Now()
+		for _, b := range s.ExpiringBatches(now, *days) {
+			expired := ""
+			if b.Expired(now) {
+				expired = "expired"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", b.BestBefore.Format(time.DateOnly), b.Beverage, b.Unit.Format(b.Quantity), expired)
+		}
+		return w.Flush()
+	case "list":
+		fs := flag.NewFlagSet("restock list", flag.ExitOnError)
+		dates := addDateRangeFlags(fs)
+		fs.Parse(args[1:])
+		from, to, err := dates.parse()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Time\tBeverage\tQuantity\tCost\tBest before")
+		var total float64
+		for _, r := range s.RestocksBetween(from, to) {
+			cost := ""
+			if r.Cost > 0 {
+				cost = fmt.Sprintf("%.2f", r.Cost)
+			}
+			bestBefore := ""
+			if !r.BestBefore.IsZero() {
+				bestBefore = r.BestBefore.Format(time.DateOnly)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Time.Format("2006-01-02 15:04"), r.Beverage, r.Unit.Format(r.Quantity), cost, bestBefore)
+			total += r.Cost
+		}
+		fmt.Fprintf(w, "Total\t\t\t%.2f\t\n", total)
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown restock command %q", args[0])
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- SAMPLE DATA ---
+
+var sampleMembers = []domain.Member{
+	{ID: "ada", Name: "Ada Lovelace", Token: "04A1B2C3"},
+	{ID: "grace", Name: "Grace Hopper", Token: "04D4E5F6"},
+	{ID: "linus", Name: "Linus Torvalds", Token: "04112233"},
+	{ID: "margaret", Name: "Margaret Hamilton", Token: "04445566"},
+	{ID: "ken", Name: "Ken Thompson", Token: "04778899"},
+}
+
+// seedCommand fills an empty store with the default catalog, a few members
+// and some weeks of sales and deliveries, so there is something to look at
+// in every view and report before real data exists.
+func seedCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	weeks := fs.Int("weeks", 3, "how many weeks of history to generate")
+	seed := fs.Uint64("seed", 1, "random seed; the same seed gives the same data")
+	force := fs.Bool("force", false, "replace a store that already has data")
+	fs.Parse(args)
+	if *weeks < 1 {
+		return fmt.Errorf("-weeks must be at least 1")
+	}
+
+	rng := rand.New(rand.NewPCG(*seed, *seed))
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	err := s.Update(func() error {
+		if !*force && (len(s.Sales) > 0 || len(s.Members) > 0) {
+			return fmt.Errorf("the store already has data; use -force to replace it")
+		}
+		*s = store.Store{Path: s.Path, TabLimit: s.TabLimit, StockPolicy: s.StockPolicy}
+		s.Beverages = slices.Clone(store.DefaultBeverages)
+		for _, m := range sampleMembers {
+			m.Balance = float64(10 + 5*rng.IntN(5))
+			s.Members = append(s.Members, m)
+		}
+		full := slices.Clone(store.DefaultBeverages)
+
+		start := today.AddDate(0, 0, -7**weeks)
+		for day := start; day.Before(today); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == time.Monday {
+				seedRestock(s, full, day.Add(10*time.Hour), rng)
+			}
+			// Busier towards the weekend.
+			sales := 3 + rng.IntN(4)
+			if day.Weekday() == time.Friday || day.Weekday() == time.Saturday {
+				sales += 6
+			}
+			for range sales {
+				at := day.Add(16*time.Hour + time.Duration(rng.IntN(9*60))*time.Minute)
+				seedSale(cfg, s, at, rng)
+			}
+		}
+		slices.SortFunc(s.Sales, func(a, b domain.Sale) int { return a.Time.Compare(b.Time) })
+		for i := range s.Sales {
+			s.Sales[i].ID = i + 1
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Seeded %d beverages, %d members, %d sales and %d deliveries.\n",
+		len(s.Beverages), len(s.Members), len(s.Sales), len(s.Restocks))
+	return nil
+}
+
+// seedRestock tops every stocked beverage back up to its default level.
+func seedRestock(s *store.Store, full []domain.Beverage, at time.Time, rng *rand.Rand) {
+	for i, b := range s.Beverages {
+		missing := full[i].Stock - b.Stock
+		if b.IsRecipe() || missing <= 0 {
+			continue
+		}
+		s.Beverages[i].Stock += missing
+		cost := domain.RoundCents(missing / b.PortionSize() * b.Price * (0.4 + 0.2*rng.Float64()))
+		s.Restocks = append(s.Restocks, store.Restock{Time: at, Beverage: b.Key(), Quantity: missing, Unit: b.Unit, Cost: cost})
+	}
+}
+
+// seedSale books a random sale of one to three beverages, charged to a
+// member's tab now and then. Sales that run into empty stock or a full tab
+// are dropped, like they would be at the till.
+func seedSale(cfg ui.Config, s *store.Store, at time.Time, rng *rand.Rand) {
+	sale := domain.Sale{Time: at}
+	for _, i := range rng.Perm(len(s.Beverages))[:1+rng.IntN(3)] {
+		b := s.Beverages[i]
+		line := domain.SaleLine{
+			Name:      b.Key(),
+			Quantity:  1 + rng.IntN(2),
+			UnitPrice: b.Price,
+		}
+		cfg.Classify(&line, b)
+		sale.Lines = append(sale.Lines, line)
+	}
+	if rng.IntN(3) == 0 {
+		m := &s.Members[rng.IntN(len(s.Members))]
+		if m.Balance < 0 {
+			m.Balance = domain.RoundCents(m.Balance + 20)
+		}
+		sale.Member = m.ID
+	}
+	_ = s.BookSale(sale)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/subtle"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- CLIENT/SERVER ---
+
+// serveCommand shares the store with client terminals. Besides the API of
+// apiHandler it serves:
+//
+//	GET    /store            the whole store as JSON
+//	GET    /events?since=V   waits until the store version differs from V
+//	GET    /metrics          stock and sales for Prometheus
+//	DELETE /sales/{id}       voids a sale; ?approved_by=NAME after the undo grace
+//	POST   /orders/{sale}    ?status=S moves the sale's order in the queue on to S
+//	PUT    /sessions/{till}  keeps the till's store.TillSession for customer displays
+//	GET    /sessions/{till}  its session; ?since=V waits until its version differs from V
+//	POST   /pretix           pretix webhook, see pretixQueue; without the token
+//
+// It also runs the nightly consistency check, see checkCommand.
+func serveCommand(cfg ui.Config, load configLoader, st *store.Store, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("listen", cfg.Server.Listen, "address to listen on")
+	fs.Parse(args)
+
+	listener, err := listen(*addr)
+	if err != nil {
+		return err
+	}
+	if listener == nil {
+		return fmt.Errorf("no listen address; set server.listen or -listen")
+	}
+	// Write out the default inventory of a new store, which the requests
+	// below read from disk.
+	if err := st.Update(func() error { return nil }); err != nil {
+		return err
+	}
+	d := startDaemon(load)
+	defer d.stop()
+
+	// Requests run concurrently; every one works on a store of its own,
+	// which update keeps consistent through the file lock.
+	var current atomic.Pointer[ui.Config]
+	current.Store(&cfg)
+	go checkNightly(st.Path, &current, d.done)
+	go checkReleases(&current, d.done)
+	storeFor := func(r *http.Request) *store.Store { return storeForRequest(st.Path, &current, r) }
+	mux := apiHandler(st.Path, &current)
+	mux.HandleFunc("GET /store", func(w http.ResponseWriter, r *http.Request) {
+		s := storeFor(r)
+		if err := s.Reload(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, s)
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		s := storeFor(r)
+		if err := s.Reload(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, s)
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		deadline := time.After(store.EventsTimeout)
+		for {
+			if v := store.Version(st.Path); v != since {
+				fmt.Fprint(w, v)
+				return
+			}
+			select {
+			case <-time.After(250 * time.Millisecond):
+			case <-deadline:
+				fmt.Fprint(w, since)
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("POST /orders/{sale}", func(w http.ResponseWriter, r *http.Request) {
+		sale, err := strconv.Atoi(r.PathValue("sale"))
+		if err != nil {
+			http.Error(w, "invalid sale id", http.StatusBadRequest)
+			return
+		}
+		status := store.OrderStatus(r.URL.Query().Get("status"))
+		if err := storeFor(r).SetOrderStatus(sale, status, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	sessions := newTillSessions()
+	mux.HandleFunc("PUT /sessions/{till}", sessions.put)
+	mux.HandleFunc("GET /sessions/{till}", sessions.get)
+	mux.HandleFunc("DELETE /sales/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "invalid sale id", http.StatusBadRequest)
+			return
+		}
+		if err := storeFor(r).VoidSale(id, r.URL.Query().Get("approved_by")); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	root := http.NewServeMux()
+	root.Handle("/", requireToken(&current, mux))
+	if cfg.Pretix.Enabled() {
+		// pretix can't send the token; the queue trusts it with nothing but
+		// an order code.
+		root.HandleFunc("POST /pretix", startPretixQueue(st.Path, &current).webhook)
+	}
+	srv := &http.Server{Handler: root}
+	errs := make(chan error, 1)
+	go func() { errs <- srv.Serve(listener) }()
+	fmt.Fprintf(os.Stderr, "serving %s on %s\n", st.Path, listener.Addr())
+	d.ready()
+	for {
+		select {
+		case <-d.hup:
+			cfg, err := d.reload(st)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "reload: %v\n", err)
+				continue
+			}
+			current.Store(&cfg)
+		case <-d.term:
+			return srv.Close()
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+// requireToken lets requests through if they carry the configured token.
+func requireToken(cfg *atomic.Pointer[ui.Config], next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := cfg.Load().Server.Token
+		want := []byte("Bearer " + token)
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
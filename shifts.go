@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- SHIFTS ---
+
+// shiftCommand opens and closes shifts:
+//
+//	shift open <float>
+//	shift close [-yes] <counted> [note...]
+//	shift status
+//	shift list [-from D] [-to D]
+//
+// With a checklist configured, closing ticks it off first; -yes records
+// every task as done without asking.
+func shiftCommand(cfg ui.Config, st *store.Store, args []string) error {
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+	amount := func(s string) (float64, error) {
+		v, err := ui.ParseDecimal(s)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		return v, nil
+	}
+	switch args[0] {
+	case "open":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: shift open <float>")
+		}
+		float, err := amount(args[1])
+		if err != nil {
+			return err
+		}
+		sh, err := st.OpenShift(float)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Shift #%d opened with a float of %.2f.\n", sh.ID, sh.Float)
+		return nil
+	case "close":
+		fs := flag.NewFlagSet("shift close", flag.ExitOnError)
+		yes := fs.Bool("yes", false, "record the checklist as done without asking")
+		fs.Parse(args[1:])
+		if fs.NArg() < 1 {
+			return fmt.Errorf("usage: shift close [-yes] <counted> [note...]")
+		}
+		counted, err := amount(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if st.CurrentShift() < 0 {
+			return fmt.Errorf("no shift is open")
+		}
+		checklist := ui.NewChecklist(cfg.Checklist).Items()
+		switch {
+		case len(checklist) == 0:
+		case *yes:
+			for i := range checklist {
+				checklist[i].Done = true
+			}
+		default:
+			result, err := tea.NewProgram(ui.NewChecklist(cfg.Checklist)).Run()
+			if err != nil {
+				return err
+			}
+			list := result.(ui.Checklist)
+			if !list.Confirmed {
+				fmt.Println("The shift is still open.")
+				return nil
+			}
+			checklist = list.Items()
+		}
+		sh, err := st.CloseShift(counted, strings.Join(fs.Args()[1:], " "), checklist)
+		if err != nil {
+			return err
+		}
+		return st.WriteShiftReport(os.Stdout, sh)
+	case "status":
+		i := st.CurrentShift()
+		if i < 0 {
+			fmt.Println("No shift open.")
+			return nil
+		}
+		return st.WriteShiftReport(os.Stdout, st.Shifts[i])
+	case "list":
+		fs := flag.NewFlagSet("shift list", flag.ExitOnError)
+		dates := addDateRangeFlags(fs)
+		fs.Parse(args[1:])
+		from, to, err := dates.parse()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Shift\tOpened\tClosed\tFloat\tExpected\tCounted\tVariance\tChecklist\t")
+		for _, sh := range st.Shifts {
+			if sh.Opened.Before(from) || !sh.Opened.Before(to) {
+				continue
+			}
+			if sh.Open() {
+				fmt.Fprintf(w, "#%d\t%s\topen\t%.2f\t\t\t\t\t\n", sh.ID, sh.Opened.Format("2006-01-02 15:04"), sh.Float)
+				continue
+			}
+			checklist := ""
+			if len(sh.Checklist) > 0 {
+				checklist = fmt.Sprintf("%d/%d", store.ChecklistDone(sh.Checklist), len(sh.Checklist))
+			}
+			fmt.Fprintf(w, "#%d\t%s\t%s\t%.2f\t%.2f\t%.2f\t%+.2f\t%s\t\n", sh.ID, sh.Opened.Format("2006-01-02 15:04"), sh.Closed.Format("15:04"),
+				sh.Float, sh.Expected, sh.Counted, sh.Variance(), checklist)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown shift command %q", args[0])
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// --- AUDIT LOG ---
+
+// AuditEntry is a line of the audit log. Which fields are set depends on
+// the event.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	Event    string    `json:"event"`
+	Beverage string    `json:"beverage,omitempty"`
+	Member   string    `json:"member,omitempty"`
+	Sale     int       `json:"sale,omitempty"`
+	// From and To are the old and new value of what changed, like a price
+	// or a quantity in the cart.
+	From     *float64 `json:"from,omitempty"`
+	To       *float64 `json:"to,omitempty"`
+	Quantity float64  `json:"quantity,omitempty"`
+	Amount   float64  `json:"amount,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// AuditLog is an append-only file of JSON lines. Every entry is a single
+// write to a file opened for appending, so processes sharing the log
+// don't interleave their lines.
+type AuditLog struct {
+	path string
+}
+
+func openAuditLog(path string) *AuditLog {
+	if path == "" {
+		return nil
+	}
+	return &AuditLog{path: path}
+}
+
+func (a *AuditLog) Write(entries ...AuditEntry) {
+	if a == nil || len(entries) == 0 {
+		return
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err == nil {
+		_, err = f.WriteString(b.String())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit log: %v\n", err)
+	}
+}
+
+// LocalActor names who is at work in this process, e.g. "pi@fridge (sell)".
+func LocalActor(command string) string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s@%s (%s)", name, host, command)
+}
+
+// Audit notes a change for the audit log. It is written once update has
+// saved the change, so failed changes don't show up.
+func (s *Store) Audit(e AuditEntry) {
+	if s.AuditLog == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Actor = s.Actor
+	s.audited = append(s.audited, e)
+}
@@ -0,0 +1,298 @@
+package store
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- BANK IMPORT ---
+
+// BankConfig describes the CSV statements of the bar's bank account, for
+// booking the members' top-up transfers. The columns are found by their
+// header; every one left empty is looked for under the names most banks
+// use.
+type BankConfig struct {
+	// Delimiter separates the fields; it is guessed from the header if
+	// empty.
+	Delimiter       string `json:"delimiter,omitempty"`
+	DateColumn      string `json:"date_column,omitempty"`
+	AmountColumn    string `json:"amount_column,omitempty"`
+	ReferenceColumn string `json:"reference_column,omitempty"`
+	SenderColumn    string `json:"sender_column,omitempty"`
+	// DateFormat is a Go time layout, "02.01.2006" by default.
+	DateFormat string `json:"date_format,omitempty"`
+}
+
+// bankColumns are the usual headers of each column, lower case.
+var bankColumns = map[string][]string{
+	"date":      {"buchungstag", "buchungsdatum", "datum", "date", "booking date", "transaction date"},
+	"amount":    {"betrag", "betrag (eur)", "betrag (€)", "umsatz", "amount", "amount (eur)"},
+	"reference": {"verwendungszweck", "reference", "description", "purpose", "payment reference"},
+	"sender":    {"beguenstigter/zahlungspflichtiger", "name zahlungsbeteiligter", "auftraggeber", "zahlungspflichtiger", "payer", "name", "counterparty"},
+}
+
+// BankTransfer is a credit from the statement and what it is booked as.
+type BankTransfer struct {
+	Date      time.Time
+	Amount    float64
+	Reference string
+	Sender    string
+	// Member is the member the transfer tops up, if one matched.
+	Member string
+	// Problem says why the transfer isn't booked, if it isn't.
+	Problem string
+	// Note marks the ledger entry, so a statement imported twice books
+	// every transfer once.
+	Note string
+}
+
+func (t BankTransfer) Bookable() bool { return t.Member != "" && t.Problem == "" }
+
+// ReadBankStatement reads the credits from a bank statement. Lines before
+// the header, like the account details many banks put there, are skipped.
+// Statements that aren't UTF-8 are taken to be Latin-1.
+func ReadBankStatement(r io.Reader, cfg BankConfig) ([]BankTransfer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	lines := textLines(data)
+	var cols map[string]int
+	var header int
+	for i, line := range lines {
+		if cols = bankHeader(line, cfg); cols != nil {
+			header = i
+			break
+		}
+	}
+	if cols == nil {
+		return nil, errors.New("no header with a date and an amount column found")
+	}
+	delim := []rune(cmp.Or(cfg.Delimiter, guessDelimiter(lines[header])))[0]
+	cr := csv.NewReader(strings.NewReader(strings.Join(lines[header+1:], "\n")))
+	cr.Comma, cr.FieldsPerRecord, cr.LazyQuotes = delim, -1, true
+
+	layout := cmp.Or(cfg.DateFormat, "02.01.2006")
+	var transfers []BankTransfer
+	alike := map[string]int{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		if field("date") == "" {
+			// Trailers like the closing balance have no date.
+			continue
+		}
+		date, err := time.ParseInLocation(layout, field("date"), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q (set bank.date_format)", field("date"))
+		}
+		amount, err := parseBankAmount(field("amount"))
+		if err != nil {
+			return nil, err
+		}
+		if amount <= 0 {
+			continue
+		}
+		t := BankTransfer{Date: date, Amount: amount, Reference: field("reference"), Sender: field("sender")}
+		row := fmt.Sprintf("%s\x00%.2f\x00%s\x00%s", t.Date.Format(time.DateOnly), t.Amount, t.Reference, t.Sender)
+		h := fnv.New64a()
+		io.WriteString(h, row)
+		// Transfers alike in every column, like a top-up sent twice in a
+		// day, are told apart by which of them it is.
+		if n := alike[row]; n > 0 {
+			fmt.Fprintf(h, "\x00%d", n)
+		}
+		alike[row]++
+		t.Note = fmt.Sprintf("bank %s %x", t.Date.Format(time.DateOnly), h.Sum64())
+		transfers = append(transfers, t)
+	}
+	return transfers, nil
+}
+
+// textLines splits an exported file into its lines, without a BOM.
+// Files that aren't UTF-8 are taken to be Latin-1.
+func textLines(data []byte) []string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		data = []byte(string(runes))
+	}
+	return strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+}
+
+// bankHeader returns the columns of line if it is the header, or nil.
+func bankHeader(line string, cfg BankConfig) map[string]int {
+	configured := map[string]string{"date": cfg.DateColumn, "amount": cfg.AmountColumn, "reference": cfg.ReferenceColumn, "sender": cfg.SenderColumn}
+	cols := headerColumns(line, cfg.Delimiter, bankColumns, configured)
+	if _, ok := cols["date"]; !ok {
+		return nil
+	}
+	if _, ok := cols["amount"]; !ok {
+		return nil
+	}
+	return cols
+}
+
+// headerColumns finds the columns of a header line by their usual names,
+// or by the configured one where there is one.
+func headerColumns(line, delimiter string, usual map[string][]string, configured map[string]string) map[string]int {
+	cr := csv.NewReader(strings.NewReader(line))
+	cr.Comma = []rune(cmp.Or(delimiter, guessDelimiter(line)))[0]
+	cr.LazyQuotes = true
+	fields, err := cr.Read()
+	if err != nil {
+		return nil
+	}
+	cols := map[string]int{}
+	for name, names := range usual {
+		if configured[name] != "" {
+			names = []string{strings.ToLower(configured[name])}
+		}
+		for i, f := range fields {
+			if _, found := cols[name]; !found && slices.Contains(names, strings.ToLower(strings.TrimSpace(f))) {
+				cols[name] = i
+			}
+		}
+	}
+	return cols
+}
+
+// guessDelimiter picks whichever of ; , and tab the line has most of.
+func guessDelimiter(line string) string {
+	best := ";"
+	for _, d := range []string{",", "\t"} {
+		if strings.Count(line, d) > strings.Count(line, best) {
+			best = d
+		}
+	}
+	return best
+}
+
+// parseBankAmount reads amounts like "1.234,56", "1,234.56", "-12,50 €"
+// or "+20.00 EUR": a separator with one or two digits after it is the
+// decimal one, all others group thousands.
+func parseBankAmount(s string) (float64, error) {
+	clean := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == ',' || r == '-' || r == '+' {
+			return r
+		}
+		return -1
+	}, s)
+	noSeparators := strings.NewReplacer(".", "", ",", "")
+	if i := strings.LastIndexAny(clean, ".,"); i >= 0 && len(clean)-i-1 <= 2 {
+		clean = noSeparators.Replace(clean[:i]) + "." + clean[i+1:]
+	} else {
+		clean = noSeparators.Replace(clean)
+	}
+	v, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return v, nil
+}
+
+// MatchBankTransfers finds the member each transfer is for: the one whose
+// ID the reference has as a word or, failing that, whose name is the
+// sender's. Guests, transfers matching several members and ones booked by
+// an earlier import aren't booked.
+func (s *Store) MatchBankTransfers(transfers []BankTransfer) {
+	for i := range transfers {
+		t := &transfers[i]
+		words := strings.FieldsFunc(strings.ToLower(t.Reference), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+		})
+		var byID, byName []string
+		for _, m := range s.Members {
+			if m.IsGuest() {
+				continue
+			}
+			if slices.Contains(words, strings.ToLower(m.ID)) {
+				byID = append(byID, m.ID)
+			}
+			if m.Name != "" && strings.EqualFold(m.Name, t.Sender) {
+				byName = append(byName, m.ID)
+			}
+		}
+		matches := byID
+		if len(matches) == 0 {
+			matches = byName
+		}
+		switch {
+		case s.hasLedgerNote(t.Note):
+			t.Problem = "already booked"
+		case len(matches) == 0:
+			t.Problem = "no member in the reference"
+		case len(matches) > 1:
+			t.Problem = "matches " + strings.Join(matches, ", ")
+		}
+		if len(matches) > 0 {
+			t.Member = matches[0]
+		}
+	}
+}
+
+// BookBankTransfers tops up the members of the bookable transfers. It
+// returns how many it booked and their total.
+func (s *Store) BookBankTransfers(transfers []BankTransfer) (int, float64, error) {
+	n, total := 0, 0.0
+	err := s.Update(func() error {
+		n, total = 0, 0
+		for _, t := range transfers {
+			if !t.Bookable() || s.hasLedgerNote(t.Note) {
+				continue
+			}
+			i := s.MemberIndex(t.Member)
+			if i < 0 {
+				return fmt.Errorf("unknown member %q", t.Member)
+			}
+			s.Members[i].Balance = domain.RoundCents(s.Members[i].Balance + t.Amount)
+			s.Ledger = append(s.Ledger, domain.LedgerEntry{Time: time.Now(), Member: t.Member, Kind: LedgerTopUp, Amount: t.Amount, Note: t.Note})
+			s.Audit(AuditEntry{Event: "topup", Member: t.Member, Amount: t.Amount, Detail: t.Reference})
+			n++
+			total += t.Amount
+		}
+		return nil
+	})
+	return n, domain.RoundCents(total), err
+}
+
+func WriteBankTransfers(out io.Writer, transfers []BankTransfer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Date\tAmount\tMember\tReference\t")
+	for _, t := range transfers {
+		member := t.Member
+		if t.Problem != "" {
+			member = "(" + t.Problem + ")"
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%s\t%s\t\n", t.Date.Format(time.DateOnly), t.Amount, member, t.Reference)
+	}
+	return w.Flush()
+}
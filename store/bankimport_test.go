@@ -0,0 +1,175 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+func TestParseBankAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   float64
+	}{
+		{"1.234,56", 1234.56},
+		{"1,234.56", 1234.56},
+		{"-12,50 €", -12.50},
+		{"+20.00 EUR", 20},
+		{"20", 20},
+		{"20,5", 20.50},
+		// A separator with three digits after it groups thousands.
+		{"1.000", 1000},
+		{"1,000", 1000},
+		{"1.234.567,89", 1234567.89},
+	}
+	for _, tt := range tests {
+		if got, err := parseBankAmount(tt.amount); err != nil || got != tt.want {
+			t.Errorf("parseBankAmount(%q) = %v, %v, want %v", tt.amount, got, err, tt.want)
+		}
+	}
+	for _, amount := range []string{"", "EUR", "1-2"} {
+		if got, err := parseBankAmount(amount); err == nil {
+			t.Errorf("parseBankAmount(%q) = %v, want an error", amount, got)
+		}
+	}
+}
+
+func TestReadBankStatement(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		cfg       BankConfig
+	}{
+		{"german, after the account details", "Kontonummer;DE02120300000000202051\n\n" +
+			"Buchungstag;Verwendungszweck;Auftraggeber;Betrag\n" +
+			"01.10.2026;Aufladung alice;Alice Liddell;20,00\n" +
+			"02.10.2026;Miete;Vermieter;-500,00\n" +
+			"03.10.2026;Bob;Bob;1.234,56\n" +
+			";Endsaldo;;754,56\n", BankConfig{}},
+		{"english, with commas", "Date,Amount,Payment reference,Payer\n" +
+			"01.10.2026,20.00,Aufladung alice,Alice Liddell\n" +
+			"02.10.2026,-500.00,Miete,Vermieter\n" +
+			"03.10.2026,\"1,234.56\",Bob,Bob\n", BankConfig{}},
+		{"configured columns and date format", "When;How much;What;Who\n" +
+			"2026-10-01;20,00;Aufladung alice;Alice Liddell\n" +
+			"2026-10-03;1234,56;Bob;Bob\n",
+			BankConfig{DateColumn: "When", AmountColumn: "How much", ReferenceColumn: "what", SenderColumn: "Who", DateFormat: "2006-01-02"}},
+		{"latin-1", "Buchungstag;Verwendungszweck;Beguenstigter/Zahlungspflichtiger;Betrag\n" +
+			"01.10.2026;Aufladung alice;Alice Liddell;20,00\n" +
+			"03.10.2026;Bob;Bob;1.234,56 \xa4\n", BankConfig{}},
+	}
+	for _, tt := range tests {
+		transfers, err := ReadBankStatement(strings.NewReader(tt.statement), tt.cfg)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		// Only the credits are read, without the closing balance.
+		if len(transfers) != 2 {
+			t.Errorf("%s: %d transfers, want 2: %+v", tt.name, len(transfers), transfers)
+			continue
+		}
+		first, second := transfers[0], transfers[1]
+		if first.Amount != 20 || first.Reference != "Aufladung alice" || first.Sender != "Alice Liddell" || first.Date.Day() != 1 {
+			t.Errorf("%s: first transfer %+v", tt.name, first)
+		}
+		if second.Amount != 1234.56 || second.Sender != "Bob" || second.Date.Day() != 3 {
+			t.Errorf("%s: second transfer %+v", tt.name, second)
+		}
+	}
+
+	for name, statement := range map[string]string{
+		"no header": "01.10.2026;Aufladung alice;20,00\n",
+		"bad date":  "Datum;Betrag\n2026-10-01;20,00\n",
+	} {
+		if _, err := ReadBankStatement(strings.NewReader(statement), BankConfig{}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestMatchBankTransfers(t *testing.T) {
+	s := Memory(nil)
+	s.Members = []domain.Member{
+		{ID: "alice", Name: "Alice Liddell"},
+		{ID: "bob", Name: "Bob"},
+		{ID: "bob2", Name: "Bob"},
+		{ID: "camp-1", Name: "Alice Liddell", Event: "camp"},
+	}
+	transfers := []BankTransfer{
+		{Amount: 20, Reference: "Aufladung ALICE", Sender: "Someone"},
+		// Without an ID in the reference, the sender's name counts.
+		{Amount: 20, Reference: "Getränke", Sender: "alice liddell"},
+		{Amount: 20, Reference: "Getränke", Sender: "Bob"},
+		{Amount: 20, Reference: "alice+bob", Sender: "Alice Liddell"},
+		{Amount: 20, Reference: "malice", Sender: "Mallory"},
+		// Guests aren't topped up by transfer.
+		{Amount: 20, Reference: "camp-1", Sender: "Guest"},
+	}
+	s.MatchBankTransfers(transfers)
+	want := []struct{ member, problem string }{
+		{"alice", ""},
+		{"alice", ""},
+		{"bob", "matches bob, bob2"},
+		{"alice", "matches alice, bob"},
+		{"", "no member in the reference"},
+		{"", "no member in the reference"},
+	}
+	for i, w := range want {
+		if transfers[i].Member != w.member || transfers[i].Problem != w.problem {
+			t.Errorf("transfer %d (%s from %s): member %q, problem %q; want %q, %q",
+				i, transfers[i].Reference, transfers[i].Sender, transfers[i].Member, transfers[i].Problem, w.member, w.problem)
+		}
+	}
+}
+
+func TestImportBankStatementTwice(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.Update(func() error {
+		s.Members = []domain.Member{{ID: "alice", Name: "Alice Liddell"}, {ID: "bob", Name: "Bob"}}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Alice sent the same top-up twice that day.
+	statement := "Buchungstag;Verwendungszweck;Auftraggeber;Betrag\n" +
+		"01.10.2026;Aufladung alice;Alice Liddell;20,00\n" +
+		"01.10.2026;Aufladung alice;Alice Liddell;20,00\n" +
+		"01.10.2026;Aufladung bob;Bob;15,00\n"
+	read := func() []BankTransfer {
+		transfers, err := ReadBankStatement(strings.NewReader(statement), BankConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.MatchBankTransfers(transfers)
+		return transfers
+	}
+
+	transfers := read()
+	if transfers[0].Note == transfers[1].Note {
+		t.Errorf("both of Alice's transfers are %q", transfers[0].Note)
+	}
+	for _, tr := range transfers {
+		if !tr.Bookable() {
+			t.Errorf("%+v isn't bookable", tr)
+		}
+	}
+	if n, total, err := s.BookBankTransfers(transfers); err != nil || n != 3 || total != 55 {
+		t.Fatalf("first import booked %d for %.2f, %v; want 3 for 55.00", n, total, err)
+	}
+
+	transfers = read()
+	for _, tr := range transfers {
+		if tr.Problem != "already booked" {
+			t.Errorf("imported again, %+v isn't already booked", tr)
+		}
+	}
+	if n, _, err := s.BookBankTransfers(transfers); err != nil || n != 0 {
+		t.Errorf("second import booked %d, %v; want none", n, err)
+	}
+	if s.Members[0].Balance != 40 || s.Members[1].Balance != 15 || len(s.Ledger) != 3 {
+		t.Errorf("balances %.2f and %.2f with %d ledger entries, want 40.00 and 15.00 with 3",
+			s.Members[0].Balance, s.Members[1].Balance, len(s.Ledger))
+	}
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- STARTUP CACHE ---
+
+// shopCache is what the shop screen needs, saved next to the store. The
+// sales history makes the store slow to load on a Pi; with the cache the
+// kiosk starts right away and loads the rest in the background.
+type shopCache struct {
+	// Version is the Version of the store the cache was taken from;
+	// a cache that doesn't match is ignored.
+	Version   string            `json:"version"`
+	Beverages []domain.Beverage `json:"beverages"`
+	Banner    *Banner           `json:"banner,omitempty"`
+	Lockdown  Lockdown          `json:"lockdown,omitempty"`
+}
+
+func cachePath(path string) string { return path + ".cache" }
+
+// writeCache saves the shop cache of the store just saved. The cache is a
+// shortcut only, so failing to write it isn't an error.
+func (s *Store) writeCache() {
+	data, err := json.Marshal(shopCache{Version: Version(s.Path), Beverages: s.Beverages, Banner: s.Banner, Lockdown: s.Lockdown})
+	if err != nil {
+		return
+	}
+	tmp := cachePath(s.Path) + ".tmp"
+	if os.WriteFile(tmp, data, 0o644) == nil {
+		os.Rename(tmp, cachePath(s.Path))
+	}
+}
+
+// OpenCached returns a store holding just what the cache has, or
+// false if there is no cache matching the store on disk.
+func OpenCached(path string) (*Store, bool) {
+	data, err := os.ReadFile(cachePath(path))
+	if err != nil {
+		return nil, false
+	}
+	var c shopCache
+	if json.Unmarshal(data, &c) != nil || c.Version == "" || c.Version != Version(path) {
+		return nil, false
+	}
+	return &Store{Path: path, Beverages: c.Beverages, Banner: c.Banner, Lockdown: c.Lockdown}, true
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// --- CARD TERMINAL ---
+
+// CardConfig connects the till to a card terminal through the API of its
+// provider: choosing card at checkout sends the amount to the terminal,
+// and the sale is booked once the customer has paid there.
+type CardConfig struct {
+	// Provider is "sumup" or "stripe"; empty for no card terminal.
+	Provider string `json:"provider,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+	// Reader is the terminal's ID with the provider.
+	Reader string `json:"reader,omitempty"`
+	// Merchant is the SumUp merchant code; Stripe doesn't need one.
+	Merchant string `json:"merchant,omitempty"`
+	// Currency defaults to EUR.
+	Currency string `json:"currency,omitempty"`
+	// URL replaces the provider's API, e.g. with a test server.
+	URL string `json:"url,omitempty"`
+}
+
+const (
+	CardSumUp  = "sumup"
+	CardStripe = "stripe"
+)
+
+func (c CardConfig) Enabled() bool { return c.Provider != "" }
+
+func (c CardConfig) Validate() error {
+	switch c.Provider {
+	case "":
+		return nil
+	case CardSumUp:
+		if c.Merchant == "" {
+			return errors.New("card: sumup needs the merchant code")
+		}
+	case CardStripe:
+	default:
+		return fmt.Errorf("card: provider must be %q or %q, not %q", CardSumUp, CardStripe, c.Provider)
+	}
+	if c.APIKey == "" || c.Reader == "" {
+		return errors.New("card: api_key and reader are needed")
+	}
+	return nil
+}
+
+// CardStatus is how a payment on the terminal is going.
+type CardStatus string
+
+const (
+	CardPending    CardStatus = "pending"
+	CardSuccessful CardStatus = "successful"
+	CardFailed     CardStatus = "failed"
+)
+
+// CardTerminal takes payments on a card terminal. Payments finish on
+// their own time: Charge only puts the amount on the terminal, and Status
+// tells whether the customer has paid yet.
+type CardTerminal interface {
+	// Charge puts amount on the terminal and returns the reference of the
+	// payment.
+	Charge(amount float64, description string) (string, error)
+	// Status reports how the payment is going and, once it failed, why.
+	Status(ref string) (CardStatus, string, error)
+	// Cancel takes the payment off the terminal, if it isn't paid yet.
+	Cancel(ref string) error
+}
+
+// Terminal is the terminal the config connects to.
+func (c CardConfig) Terminal() (CardTerminal, error) {
+	switch c.Provider {
+	case CardSumUp:
+		return sumUp{c}, nil
+	case CardStripe:
+		return stripeTerminal{c}, nil
+	}
+	return nil, errors.New("no card terminal configured")
+}
+
+var cardClient = &http.Client{Timeout: 30 * time.Second}
+
+// minorUnits is amount in cents.
+func minorUnits(amount float64) int64 { return int64(math.Round(amount * 100)) }
+
+// call sends a request to the provider's API and decodes its JSON answer
+// into v, unless v is nil. A body that is url.Values is sent as a form,
+// anything else as JSON.
+func (c CardConfig) call(method, base, path string, body, v any) error {
+	var r io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case url.Values:
+		r, contentType = strings.NewReader(b.Encode()), "application/x-www-form-urlencoded"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		r, contentType = strings.NewReader(string(data)), "application/json"
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(cmp.Or(c.URL, base), "/")+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := cardClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &cardError{status: resp.StatusCode, msg: fmt.Sprintf("%s: %s %s", c.Provider, resp.Status, strings.TrimSpace(string(msg)))}
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type cardError struct {
+	status int
+	msg    string
+}
+
+func (e *cardError) Error() string { return e.msg }
+
+// sumUp takes payments on a SumUp Solo through the readers of the cloud
+// API.
+type sumUp struct{ CardConfig }
+
+const sumUpURL = "https://api.sumup.com"
+
+func (s sumUp) reader() string {
+	return "/v0.1/merchants/" + url.PathEscape(s.Merchant) + "/readers/" + url.PathEscape(s.Reader)
+}
+
+func (s sumUp) Charge(amount float64, description string) (string, error) {
+	body := map[string]any{
+		"total_amount": map[string]any{"value": minorUnits(amount), "currency": cmp.Or(s.Currency, "EUR"), "minor_unit": 2},
+		"description":  description,
+	}
+	var resp struct {
+		Data struct {
+			ID string `json:"client_transaction_id"`
+		} `json:"data"`
+	}
+	if err := s.call(http.MethodPost, sumUpURL, s.reader()+"/checkout", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Data.ID, nil
+}
+
+func (s sumUp) Status(ref string) (CardStatus, string, error) {
+	var tx struct {
+		Status string `json:"status"`
+	}
+	err := s.call(http.MethodGet, sumUpURL, "/v2.1/merchants/"+url.PathEscape(s.Merchant)+"/transactions?client_transaction_id="+url.QueryEscape(ref), nil, &tx)
+	var ce *cardError
+	if errors.As(err, &ce) && ce.status == http.StatusNotFound {
+		// The transaction appears once the card is presented.
+		return CardPending, "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	switch tx.Status {
+	case "SUCCESSFUL":
+		return CardSuccessful, "", nil
+	case "FAILED", "CANCELLED":
+		return CardFailed, strings.ToLower(tx.Status), nil
+	}
+	return CardPending, "", nil
+}
+
+func (s sumUp) Cancel(string) error {
+	return s.call(http.MethodPost, sumUpURL, s.reader()+"/terminate", nil, nil)
+}
+
+// stripeTerminal takes payments on a Stripe Terminal reader as payment
+// intents that the reader processes.
+type stripeTerminal struct{ CardConfig }
+
+const stripeURL = "https://api.stripe.com"
+
+func (s stripeTerminal) Charge(amount float64, description string) (string, error) {
+	var intent struct {
+		ID string `json:"id"`
+	}
+	err := s.call(http.MethodPost, stripeURL, "/v1/payment_intents", url.Values{
+		"amount":                 {fmt.Sprint(minorUnits(amount))},
+		"currency":               {strings.ToLower(cmp.Or(s.Currency, "EUR"))},
+		"payment_method_types[]": {"card_present"},
+		"capture_method":         {"automatic"},
+		"description":            {description},
+	}, &intent)
+	if err != nil {
+		return "", err
+	}
+	err = s.call(http.MethodPost, stripeURL, "/v1/terminal/readers/"+url.PathEscape(s.Reader)+"/process_payment_intent",
+		url.Values{"payment_intent": {intent.ID}}, nil)
+	return intent.ID, err
+}
+
+func (s stripeTerminal) Status(ref string) (CardStatus, string, error) {
+	var intent struct {
+		Status    string `json:"status"`
+		LastError *struct {
+			Message string `json:"message"`
+		} `json:"last_payment_error"`
+	}
+	if err := s.call(http.MethodGet, stripeURL, "/v1/payment_intents/"+url.PathEscape(ref), nil, &intent); err != nil {
+		return "", "", err
+	}
+	switch {
+	case intent.Status == "succeeded":
+		return CardSuccessful, "", nil
+	case intent.Status == "canceled":
+		return CardFailed, "canceled", nil
+	case intent.LastError != nil:
+		return CardFailed, intent.LastError.Message, nil
+	}
+	return CardPending, "", nil
+}
+
+func (s stripeTerminal) Cancel(string) error {
+	return s.call(http.MethodPost, stripeURL, "/v1/terminal/readers/"+url.PathEscape(s.Reader)+"/cancel_action", url.Values{}, nil)
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- CATALOG IMPORT ---
+
+// A catalog kept in a spreadsheet is merged into the inventory from CSV,
+// with the columns found by their header. Beverages it names that aren't
+// in the inventory yet are added; those that are take what it says and
+// keep whatever it leaves empty or has no column for. The sizes of a drink
+// are told apart by a size column, see domain.Beverage.Key.
+
+// catalogColumns are the usual headers of each column, lower case.
+var catalogColumns = map[string][]string{
+	"name":     {"name", "beverage", "getränk", "artikel", "bezeichnung", "product"},
+	"size":     {"size", "größe", "gebinde", "inhalt", "container"},
+	"price":    {"price", "preis", "vk", "verkaufspreis"},
+	"stock":    {"stock", "bestand", "quantity", "menge"},
+	"barcode":  {"barcode", "ean", "gtin", "strichcode"},
+	"category": {"category", "kategorie", "warengruppe"},
+}
+
+// catalogRow is what a line of the catalog sets; nil is left as it is.
+type catalogRow struct {
+	name, size        string
+	price, stock      *float64
+	barcode, category *string
+}
+
+// CatalogChange is what merging a line of the catalog does to the
+// inventory.
+type CatalogChange struct {
+	// Beverage is the beverage as it will be.
+	Beverage domain.Beverage
+	New      bool
+	// Fields are the changes to a beverage that is already there.
+	Fields []FieldChange
+	// Problem says why the line isn't merged, if it isn't.
+	Problem string
+	row     catalogRow
+}
+
+// FieldChange is a field that changes, with its values shown as text.
+type FieldChange struct {
+	Field, From, To string
+}
+
+func (c CatalogChange) Mergeable() bool { return c.Problem == "" && (c.New || len(c.Fields) > 0) }
+
+func (c CatalogChange) String() string {
+	if c.New {
+		b := c.Beverage
+		s := fmt.Sprintf("%.2f, %s", b.Price, b.Unit.Format(b.Stock))
+		if b.Category != "" {
+			s += ", " + b.Category
+		}
+		if b.Barcode != "" {
+			s += ", " + b.Barcode
+		}
+		return s
+	}
+	var fields []string
+	for _, f := range c.Fields {
+		fields = append(fields, fmt.Sprintf("%s %s → %s", f.Field, cmp.Or(f.From, "-"), cmp.Or(f.To, "-")))
+	}
+	return strings.Join(fields, ", ")
+}
+
+// ReadCatalog reads a catalog and works out what merging it changes, in
+// the order it lists the beverages.
+func (s *Store) ReadCatalog(r io.Reader) ([]CatalogChange, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	lines := textLines(data)
+	var cols map[string]int
+	var header int
+	for i, line := range lines {
+		cols = headerColumns(line, "", catalogColumns, nil)
+		if _, ok := cols["name"]; ok {
+			header = i
+			break
+		}
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, errors.New("no header with a name column found")
+	}
+	cr := csv.NewReader(strings.NewReader(strings.Join(lines[header+1:], "\n")))
+	cr.Comma, cr.FieldsPerRecord, cr.LazyQuotes = []rune(guessDelimiter(lines[header]))[0], -1, true
+
+	var changes []CatalogChange
+	seen := map[string]bool{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) (string, bool) {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				v := strings.TrimSpace(rec[i])
+				return v, v != ""
+			}
+			return "", false
+		}
+		row := catalogRow{}
+		row.name, _ = field("name")
+		row.size, _ = field("size")
+		if row.name == "" {
+			continue
+		}
+		for name, dst := range map[string]**float64{"price": &row.price, "stock": &row.stock} {
+			if v, ok := field(name); ok {
+				n, err := parseBankAmount(v)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("%s: invalid %s %q", row.name, name, v)
+				}
+				*dst = &n
+			}
+		}
+		for name, dst := range map[string]**string{"barcode": &row.barcode, "category": &row.category} {
+			if v, ok := field(name); ok {
+				*dst = &v
+			}
+		}
+		c := s.mergeCatalogRow(row)
+		if key := strings.ToLower(c.Beverage.Key()); seen[key] {
+			c.Problem = "listed twice"
+		} else {
+			seen[key] = true
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// mergeCatalogRow works out what a line of the catalog changes.
+func (s *Store) mergeCatalogRow(row catalogRow) CatalogChange {
+	c := CatalogChange{row: row, Beverage: domain.Beverage{Name: row.name, Size: row.size}, New: true}
+	if i := s.BeverageIndex(c.Beverage.Key()); i >= 0 {
+		c.Beverage, c.New = s.Beverages[i], false
+	}
+	b := &c.Beverage
+	change := func(field, from, to string) {
+		if from != to {
+			c.Fields = append(c.Fields, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	if row.price != nil {
+		change("price", fmt.Sprintf("%.2f", b.Price), fmt.Sprintf("%.2f", *row.price))
+		b.Price = domain.RoundCents(*row.price)
+	}
+	if row.stock != nil {
+		if b.IsRecipe() {
+			c.Problem = "made from a recipe, has no stock"
+		}
+		change("stock", b.Unit.Format(b.Stock), b.Unit.Format(*row.stock))
+		b.Stock = *row.stock
+	}
+	if row.barcode != nil {
+		change("barcode", b.Barcode, *row.barcode)
+		b.Barcode = *row.barcode
+		for _, other := range s.Beverages {
+			if b.Barcode != "" && other.Barcode == b.Barcode && !strings.EqualFold(other.Key(), b.Key()) {
+				c.Problem = "barcode of " + other.Key()
+			}
+		}
+	}
+	if row.category != nil {
+		change("category", b.Category, *row.category)
+		b.Category = *row.category
+	}
+	if c.New && row.price == nil {
+		c.Problem = "new, but without a price"
+	}
+	return c
+}
+
+// MergeCatalog merges the mergeable changes into the inventory, against
+// the beverages as they are by then. It returns how many beverages it
+// added and changed.
+func (s *Store) MergeCatalog(changes []CatalogChange) (added, changed int, err error) {
+	err = s.Update(func() error {
+		added, changed = 0, 0
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		for _, c := range changes {
+			if !c.Mergeable() {
+				continue
+			}
+			c = s.mergeCatalogRow(c.row)
+			if !c.Mergeable() {
+				continue
+			}
+			if c.row.price != nil && s.Lockdown != LockdownNone {
+				if i := s.BeverageIndex(c.Beverage.Key()); i < 0 || differ(s.Beverages[i].Price, c.Beverage.Price) {
+					return errPriceFreeze
+				}
+			}
+			if c.New {
+				s.Beverages = append(s.Beverages, c.Beverage)
+				s.Audit(AuditEntry{Event: "catalog", Beverage: c.Beverage.Key(), Detail: "added: " + c.String()})
+				added++
+				continue
+			}
+			i := s.BeverageIndex(c.Beverage.Key())
+			if old, price := s.Beverages[i].Price, c.Beverage.Price; differ(old, price) {
+				s.Audit(AuditEntry{Event: "price", Beverage: c.Beverage.Key(), From: &old, To: &price})
+			}
+			s.Beverages[i] = c.Beverage
+			s.Audit(AuditEntry{Event: "catalog", Beverage: c.Beverage.Key(), Detail: c.String()})
+			changed++
+		}
+		return nil
+	})
+	return added, changed, err
+}
+
+func WriteCatalogChanges(out io.Writer, changes []CatalogChange) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, c := range changes {
+		switch {
+		case c.Problem != "":
+			fmt.Fprintf(w, "!\t%s\t(%s)\t\n", c.Beverage.Key(), c.Problem)
+		case c.New:
+			fmt.Fprintf(w, "+\t%s\t%s\t\n", c.Beverage.Key(), c)
+		case len(c.Fields) > 0:
+			fmt.Fprintf(w, "~\t%s\t%s\t\n", c.Beverage.Key(), c)
+		}
+	}
+	return w.Flush()
+}
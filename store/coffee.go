@@ -0,0 +1,35 @@
+package store
+
+import "time"
+
+// --- COFFEE MACHINE ---
+
+// CounterReading is a reading of the machine's shot counter together with
+// how many of the shots since the previous reading were billed.
+type CounterReading struct {
+	Time    time.Time `json:"time"`
+	Counter int       `json:"counter"`
+	Shots   int       `json:"shots"`
+	Billed  int       `json:"billed"`
+	Member  string    `json:"member,omitempty"`
+}
+
+// CoffeeShots returns how many shots were pulled since the last reading.
+// A counter lower than the last reading means the machine was reset.
+func (s *Store) CoffeeShots(counter int) int {
+	if len(s.CoffeeReadings) == 0 {
+		return 0
+	}
+	last := s.CoffeeReadings[len(s.CoffeeReadings)-1].Counter
+	if counter < last {
+		return counter
+	}
+	return counter - last
+}
+
+func (s *Store) LastCoffeeCounter() int {
+	if len(s.CoffeeReadings) == 0 {
+		return 0
+	}
+	return s.CoffeeReadings[len(s.CoffeeReadings)-1].Counter
+}
@@ -0,0 +1,219 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- CONSISTENCY CHECK ---
+
+// Most kiosks keep the store on an SD card, which can go bad without
+// anyone noticing. The consistency check replays the history the store
+// keeps against the state it keeps: the closed days against their sales,
+// the vouchers against the sales paid with them, and the members' balances
+// against their ledger and the sales charged to their tabs. Opening
+// balances aren't recorded, so those are taken from the snapshot of the
+// check before: whatever the history doesn't account for must not have
+// changed since.
+
+// ConsistencyConfig runs the check every night and keeps a snapshot of
+// each in Dir; without Dir, there are no checks. Divergences are mailed to
+// Notify.
+type ConsistencyConfig struct {
+	Dir string `json:"dir,omitempty"`
+	// At is the time of day the check runs, "03:00" without it.
+	At     string   `json:"at,omitempty"`
+	Notify []string `json:"notify,omitempty"`
+}
+
+// Snapshot is what a check found.
+type Snapshot struct {
+	Time  time.Time `json:"time"`
+	Sales int       `json:"sales"`
+	// Openings are the members' balances less what the history accounts
+	// for, by member ID.
+	Openings    map[string]float64 `json:"openings"`
+	Divergences []Divergence       `json:"divergences,omitempty"`
+}
+
+// Divergence is a part of the state that its history doesn't add up to.
+type Divergence struct {
+	What   string `json:"what"`
+	Detail string `json:"detail"`
+}
+
+func (d Divergence) String() string { return d.What + ": " + d.Detail }
+
+// atMinute is when the check runs, in minutes since midnight.
+func (c ConsistencyConfig) atMinute() (int, error) {
+	if c.At == "" {
+		return 3 * 60, nil
+	}
+	t, err := time.Parse("15:04", c.At)
+	if err != nil {
+		return 0, fmt.Errorf("consistency: at must look like \"03:00\", not %q", c.At)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (c ConsistencyConfig) Validate() error {
+	_, err := c.atMinute()
+	return err
+}
+
+// snapshotPath is the file of the snapshot taken on the day of t.
+func (c ConsistencyConfig) snapshotPath(t time.Time) string {
+	return filepath.Join(c.Dir, "snapshot-"+t.Format(time.DateOnly)+".json")
+}
+
+// Due reports whether tonight's check is still to run at now.
+func (c ConsistencyConfig) Due(now time.Time) bool {
+	at, err := c.atMinute()
+	if c.Dir == "" || err != nil || now.Hour()*60+now.Minute() < at {
+		return false
+	}
+	_, err = os.Stat(c.snapshotPath(now))
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// LatestSnapshot reads the newest snapshot in dir, or returns nil if there
+// is none yet.
+func LatestSnapshot(dir string) (*Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "snapshot-*.json"))
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	slices.Sort(paths)
+	data, err := os.ReadFile(paths[len(paths)-1])
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", paths[len(paths)-1], err)
+	}
+	return &snap, nil
+}
+
+// differ reports whether two amounts of money differ by a cent or more.
+func differ(a, b float64) bool { return math.Abs(a-b) >= 0.005 }
+
+// CheckConsistency replays the store's history against its state. The
+// members' balances are checked against prev, if there was a check before.
+func (s *Store) CheckConsistency(prev *Snapshot, now time.Time) Snapshot {
+	snap := Snapshot{Time: now, Sales: len(s.Sales), Openings: map[string]float64{}}
+	diverge := func(what, format string, args ...any) {
+		snap.Divergences = append(snap.Divergences, Divergence{What: what, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	for i := 1; i < len(s.Sales); i++ {
+		if s.Sales[i].ID <= s.Sales[i-1].ID {
+			diverge(fmt.Sprintf("sale #%d", s.Sales[i].ID), "comes after sale #%d", s.Sales[i-1].ID)
+		}
+	}
+
+	for _, c := range s.Closes {
+		day, err := time.ParseInLocation(time.DateOnly, c.Day, time.Local)
+		if err != nil {
+			diverge("day "+c.Day, "closed on an invalid day")
+			continue
+		}
+		sales, revenue := 0, 0.0
+		for _, sale := range s.SalesBetween(day, day.AddDate(0, 0, 1)) {
+			sales++
+			revenue += sale.Revenue()
+		}
+		if sales != c.Sales || differ(revenue, c.Revenue) {
+			diverge("day "+c.Day, "closed with %d sales for %.2f, the sales add up to %d for %.2f",
+				c.Sales, c.Revenue, sales, revenue)
+		}
+	}
+
+	spent := map[string]float64{}
+	for _, sale := range s.Sales {
+		if sale.Voucher != "" {
+			spent[normalizeVoucherCode(sale.Voucher)] += sale.VoucherAmount
+		}
+	}
+	for _, v := range s.Vouchers {
+		if left := v.Value - spent[normalizeVoucherCode(v.Code)]; differ(left, v.Balance) {
+			diverge("voucher "+v.Code, "%.2f left on it, the sales paid with it leave %.2f", v.Balance, left)
+		}
+	}
+
+	history := map[string]float64{}
+	for _, e := range s.Ledger {
+		history[e.Member] += e.Amount
+	}
+	for _, sale := range s.Sales {
+		if sale.Member != "" {
+			history[sale.Member] -= sale.Total()
+		}
+	}
+	for _, m := range s.Members {
+		opening := domain.RoundCents(m.Balance - history[m.ID])
+		snap.Openings[m.ID] = opening
+		if prev == nil {
+			continue
+		}
+		if was, ok := prev.Openings[m.ID]; ok && differ(was, opening) {
+			diverge("member "+m.ID, "balance %.2f, the history since the last check leaves %.2f",
+				m.Balance, domain.RoundCents(was+history[m.ID]))
+		}
+	}
+	if prev != nil {
+		var gone []string
+		for id := range prev.Openings {
+			if s.MemberIndex(id) < 0 {
+				gone = append(gone, id)
+			}
+		}
+		slices.Sort(gone)
+		for _, id := range gone {
+			diverge("member "+id, "gone since the last check")
+		}
+	}
+	return snap
+}
+
+// NightlyCheck checks the store against the latest snapshot in c.Dir,
+// keeps the result as tonight's snapshot and mails any divergences to
+// c.Notify.
+func (s *Store) NightlyCheck(c ConsistencyConfig, mail MailConfig, now time.Time) (Snapshot, error) {
+	prev, err := LatestSnapshot(c.Dir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snap := s.CheckConsistency(prev, now)
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return snap, err
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return snap, err
+	}
+	if err := os.WriteFile(c.snapshotPath(now), data, 0o644); err != nil {
+		return snap, err
+	}
+	if len(snap.Divergences) == 0 || len(c.Notify) == 0 {
+		return snap, nil
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "The consistency check of %s found the store diverging from its history:\n\n", s.Path)
+	for _, d := range snap.Divergences {
+		fmt.Fprintf(&body, "  %s\n", d)
+	}
+	fmt.Fprintf(&body, "\nThe snapshot is %s. Check the storage, and restore a backup if the store is corrupt.\n",
+		c.snapshotPath(now))
+	subject := fmt.Sprintf("BubbleTender: %s diverges from its history", filepath.Base(s.Path))
+	return snap, mail.Send(c.Notify, subject, body.String(), now)
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- DAY CLOSE ---
+
+// DayClose records that a day was closed. The sales of closed days are
+// final, as bookkeeping wants them: voiding one books a compensating sale
+// today instead of removing it.
+type DayClose struct {
+	Day      string    `json:"day"` // YYYY-MM-DD
+	ClosedAt time.Time `json:"closed_at"`
+	ClosedBy string    `json:"closed_by,omitempty"`
+	Sales    int       `json:"sales"`
+	Revenue  float64   `json:"revenue"`
+}
+
+var (
+	errDayClosed       = errors.New("the day is closed")
+	errApprovalMissing = errors.New("voiding a sale after the undo grace needs a second person's approval")
+	errSelfApproval    = errors.New("the approval has to come from someone other than the one voiding")
+	errActorUnknown    = errors.New("an approved void needs to know who is voiding")
+)
+
+// ClosedThrough returns the last closed day, or "" if none is. Closing a
+// day closes all days before it, too.
+func (s *Store) ClosedThrough() string {
+	last := ""
+	for _, c := range s.Closes {
+		last = max(last, c.Day)
+	}
+	return last
+}
+
+func (s *Store) isClosed(t time.Time) bool {
+	last := s.ClosedThrough()
+	return last != "" && t.Local().Format(time.DateOnly) <= last
+}
+
+func (s *Store) CloseDay(day time.Time) (DayClose, error) {
+	var c DayClose
+	err := s.Update(func() error {
+		name := day.Format(time.DateOnly)
+		if last := s.ClosedThrough(); name <= last {
+			return fmt.Errorf("%s is already closed (through %s)", name, last)
+		}
+		c = DayClose{Day: name, ClosedAt: time.Now(), ClosedBy: s.Actor}
+		for _, sale := range s.SalesBetween(day, day.AddDate(0, 0, 1)) {
+			c.Sales++
+			c.Revenue += sale.Revenue()
+		}
+		c.Revenue = domain.RoundCents(c.Revenue)
+		s.Closes = append(s.Closes, c)
+		s.Audit(AuditEntry{Time: c.ClosedAt, Event: "day closed", Amount: c.Revenue, Detail: name})
+		return nil
+	})
+	return c, err
+}
+
+// compensate books the reversal of a sale of a closed day: a sale dated
+// now with the quantities negated.
+func (s *Store) compensate(sale domain.Sale, approvedBy string) error {
+	now := time.Now()
+	if s.isClosed(now) {
+		return fmt.Errorf("can't book the void of sale #%d: %w", sale.ID, errDayClosed)
+	}
+	reversal := domain.Sale{ID: s.nextSaleID(), Time: now, Member: sale.Member, Voids: sale.ID,
+		Voucher: sale.Voucher, VoucherAmount: -sale.VoucherAmount, Rounding: -sale.Rounding}
+	for _, l := range sale.Lines {
+		l.Quantity = -l.Quantity
+		reversal.Lines = append(reversal.Lines, l)
+	}
+	s.Sales = append(s.Sales, reversal)
+	detail := fmt.Sprintf("sale #%d: %s", reversal.ID, sale.Items())
+	if approvedBy != "" {
+		detail += ", approved by " + approvedBy
+	}
+	s.Audit(AuditEntry{Time: now, Event: "void", Sale: sale.ID, Member: sale.Member, Amount: domain.RoundCents(sale.Total()), Detail: detail})
+	return nil
+}
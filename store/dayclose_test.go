@@ -0,0 +1,184 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// today is the start of the local day.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// sell books qty bottles of Club-Mate at at, paid from voucher if any.
+func sell(t *testing.T, s *Store, at time.Time, qty int, voucher string) domain.Sale {
+	t.Helper()
+	sale, err := s.RecordSale(domain.Sale{Time: at, Voucher: voucher, Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: qty, UnitPrice: 1.49}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sale
+}
+
+func TestCloseDay(t *testing.T) {
+	s := openTestStore(t, domain.Beverage{Name: "Club-Mate", Price: 1.49, Stock: 24})
+	yesterday := today().AddDate(0, 0, -1)
+	sell(t, s, yesterday.AddDate(0, 0, -1).Add(20*time.Hour), 1, "")
+	sell(t, s, yesterday.Add(20*time.Hour), 2, "")
+	sell(t, s, yesterday.Add(23*time.Hour), 1, "")
+	sell(t, s, today(), 5, "")
+
+	c, err := s.CloseDay(yesterday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only yesterday's sales count, not those before or since.
+	if c.Day != yesterday.Format(time.DateOnly) || c.Sales != 2 || c.Revenue != 4.47 {
+		t.Errorf("closed %+v, want %s with 2 sales for 4.47", c, yesterday.Format(time.DateOnly))
+	}
+	if s.ClosedThrough() != c.Day {
+		t.Errorf("closed through %q, want %q", s.ClosedThrough(), c.Day)
+	}
+	// Closing a day closes the days before it.
+	for _, tt := range []struct {
+		t      time.Time
+		closed bool
+	}{
+		{yesterday.AddDate(0, 0, -10), true},
+		{yesterday, true},
+		{today().Add(-time.Second), true},
+		{today(), false},
+		{time.Now(), false},
+	} {
+		if got := s.isClosed(tt.t); got != tt.closed {
+			t.Errorf("isClosed(%s) = %t, want %t", tt.t.Format(time.DateTime), got, tt.closed)
+		}
+	}
+	for _, day := range []time.Time{yesterday, yesterday.AddDate(0, 0, -1)} {
+		if _, err := s.CloseDay(day); err == nil {
+			t.Errorf("closing %s again: no error", day.Format(time.DateOnly))
+		}
+	}
+	if _, err := s.RecordSale(domain.Sale{Time: yesterday.Add(22 * time.Hour), Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: 1, UnitPrice: 1.49}}}); !errors.Is(err, errDayClosed) {
+		t.Errorf("a sale on a closed day: %v, want errDayClosed", err)
+	}
+	if len(s.Closes) != 1 {
+		t.Errorf("%d closes, want 1", len(s.Closes))
+	}
+}
+
+func TestVoidSaleOfClosedDay(t *testing.T) {
+	s := openTestStore(t, domain.Beverage{Name: "Club-Mate", Price: 1.49, Stock: 24})
+	s.Configure(Options{UndoGrace: time.Minute, CashRounding: 0.05})
+	s.Actor = "alice@till (void)"
+	if err := s.Update(func() error {
+		s.Vouchers = []domain.Voucher{{Code: "V1", Value: 1, Balance: 1}}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := today().AddDate(0, 0, -1)
+	// 4.47 less the voucher's 1.00 is paid as 3.45 in cash.
+	sale := sell(t, s, yesterday.Add(20*time.Hour), 3, "V1")
+	if sale.VoucherAmount != 1 || sale.Rounding != -0.02 {
+		t.Fatalf("sale paid %.2f from the voucher, rounded by %.2f; want 1.00 and -0.02", sale.VoucherAmount, sale.Rounding)
+	}
+	if _, err := s.CloseDay(yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.VoidSale(sale.ID, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	// The sale stays; another one today reverses it.
+	if len(s.Sales) != 2 || s.Sales[0].ID != sale.ID {
+		t.Fatalf("sales = %+v, want the sale and its reversal", s.Sales)
+	}
+	reversal := s.Sales[1]
+	if reversal.Voids != sale.ID || s.isClosed(reversal.Time) || reversal.ID == sale.ID {
+		t.Errorf("reversal #%d of sale #%d at %s, want one of sale #%d today", reversal.ID, reversal.Voids, reversal.Time, sale.ID)
+	}
+	if len(reversal.Lines) != 1 || reversal.Lines[0].Quantity != -3 || reversal.Total() != -4.47 {
+		t.Errorf("reversal lines %+v, want -3 Club-Mate for -4.47", reversal.Lines)
+	}
+	if reversal.Voucher != "V1" || reversal.VoucherAmount != -1 || reversal.Rounding != 0.02 || reversal.CashDue() != -3.45 {
+		t.Errorf("reversal voucher %s %.2f, rounding %.2f, cash %.2f; want V1 -1.00, 0.02 and -3.45",
+			reversal.Voucher, reversal.VoucherAmount, reversal.Rounding, reversal.CashDue())
+	}
+	if s.Vouchers[0].Balance != 1 || s.Beverages[0].Stock != 24 {
+		t.Errorf("voucher %.2f and %g in stock, want 1.00 and 24", s.Vouchers[0].Balance, s.Beverages[0].Stock)
+	}
+
+	if err := s.VoidSale(sale.ID, "bob"); err == nil {
+		t.Error("voiding the sale again: no error")
+	}
+	if err := s.VoidSale(reversal.ID, "bob"); err == nil {
+		t.Error("voiding the reversal: no error")
+	}
+
+	// Once today is closed as well, there is no day left to book it on.
+	other := sell(t, s, time.Now(), 1, "")
+	if _, err := s.CloseDay(today()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VoidSale(other.ID, "bob"); !errors.Is(err, errDayClosed) {
+		t.Errorf("voiding with today closed: %v, want errDayClosed", err)
+	}
+	if len(s.Sales) != 3 || s.Beverages[0].Stock != 23 {
+		t.Errorf("%d sales and %g in stock, want 3 and 23 as before", len(s.Sales), s.Beverages[0].Stock)
+	}
+}
+
+func TestVoidSaleApproval(t *testing.T) {
+	s := openTestStore(t, domain.Beverage{Name: "Club-Mate", Price: 1.49, Stock: 24})
+	s.Configure(Options{UndoGrace: time.Minute})
+
+	// Within the grace, anyone may void, even unknown.
+	recent := sell(t, s, time.Now(), 1, "")
+	if err := s.VoidSale(recent.ID, ""); err != nil {
+		t.Fatalf("voiding within the grace: %v", err)
+	}
+
+	late := sell(t, s, time.Now().Add(-time.Hour), 1, "")
+	tests := []struct {
+		actor, approvedBy string
+		err               error
+	}{
+		{"alice@till (void)", "", errApprovalMissing},
+		{"alice@till (void)", "alice", errSelfApproval},
+		{"alice@till (void)", "Alice", errSelfApproval},
+		{"alice@till (void)", "alice@till (void)", errSelfApproval},
+		{"alice@fridge (sell) via 10.0.0.2:50312", "ALICE", errSelfApproval},
+		{"", "bob", errActorUnknown},
+		{"unknown@till (void)", "bob", errActorUnknown},
+	}
+	for _, tt := range tests {
+		s.Actor = tt.actor
+		if err := s.VoidSale(late.ID, tt.approvedBy); !errors.Is(err, tt.err) {
+			t.Errorf("%q approved by %q: %v, want %v", tt.actor, tt.approvedBy, err, tt.err)
+		}
+	}
+	if len(s.Sales) != 1 || s.Beverages[0].Stock != 23 {
+		t.Fatalf("%d sales and %g in stock after refused voids, want 1 and 23", len(s.Sales), s.Beverages[0].Stock)
+	}
+
+	// An approval is checked within the grace, too.
+	s.Actor = "alice@till (void)"
+	recent = sell(t, s, time.Now(), 1, "")
+	if err := s.VoidSale(recent.ID, "alice"); !errors.Is(err, errSelfApproval) {
+		t.Errorf("self-approved within the grace: %v, want errSelfApproval", err)
+	}
+
+	for _, id := range []int{late.ID, recent.ID} {
+		if err := s.VoidSale(id, "bob"); err != nil {
+			t.Errorf("voiding sale #%d approved by bob: %v", id, err)
+		}
+	}
+	if len(s.Sales) != 0 || s.Beverages[0].Stock != 24 {
+		t.Errorf("%d sales and %g in stock, want none and 24", len(s.Sales), s.Beverages[0].Stock)
+	}
+}
@@ -0,0 +1,841 @@
+package store
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- MEMBER DIRECTORY ---
+
+// DirectoryConfig syncs the members from the space's member database, so
+// they aren't kept twice: an LDAP directory or an OIDC provider that lists
+// its users over SCIM. Syncing adds the members that are new, takes over
+// their names and card tokens, and deactivates those that left or were
+// deactivated there; their tabs stay as they are. Members added by hand
+// stay the till's own unless the directory has one of the same ID.
+type DirectoryConfig struct {
+	// Source is "ldap" or "oidc"; empty for no directory.
+	Source string     `json:"source,omitempty"`
+	LDAP   LDAPConfig `json:"ldap"`
+	OIDC   OIDCConfig `json:"oidc"`
+}
+
+const (
+	DirectoryLDAP = "ldap"
+	DirectoryOIDC = "oidc"
+)
+
+// LDAPConfig reads the members from an LDAP directory.
+type LDAPConfig struct {
+	// URL is ldaps://host[:port], or ldap://host[:port] for a directory
+	// that takes StartTLS; the till never binds in the clear.
+	URL      string `json:"url,omitempty"`
+	BindDN   string `json:"bind_dn,omitempty"`
+	Password string `json:"password,omitempty"`
+	BaseDN   string `json:"base_dn,omitempty"`
+	// Filter selects the members under BaseDN, by default
+	// (objectClass=person).
+	Filter string `json:"filter,omitempty"`
+	// ID and Name are the attributes of the member's ID and name, by
+	// default uid and cn; Token is the card token's, if the directory has
+	// them.
+	ID    string `json:"id_attribute,omitempty"`
+	Name  string `json:"name_attribute,omitempty"`
+	Token string `json:"token_attribute,omitempty"`
+	// Group is the DN of the group of active members, if not everyone the
+	// filter selects is one; it is looked for in memberOf.
+	Group string `json:"group,omitempty"`
+}
+
+// OIDCConfig reads the members from an OIDC provider, as a client with
+// credentials of its own that may list the users of the provider's SCIM
+// API.
+type OIDCConfig struct {
+	// Issuer is where the provider's openid-configuration is found.
+	Issuer       string `json:"issuer,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	// Users is the SCIM endpoint of the users, e.g.
+	// https://id.example.org/scim/v2/Users.
+	Users string `json:"users,omitempty"`
+	// ID and Name are the attributes of the member's ID and name, by
+	// default userName and displayName; Token is the card token's, if the
+	// users have them. Attributes of extensions are given by their path,
+	// like "name.formatted".
+	ID    string `json:"id_attribute,omitempty"`
+	Name  string `json:"name_attribute,omitempty"`
+	Token string `json:"token_attribute,omitempty"`
+	// Group is the name of the group of active members, if not every
+	// active user is one.
+	Group string `json:"group,omitempty"`
+}
+
+func (c DirectoryConfig) Enabled() bool { return c.Source != "" }
+
+func (c DirectoryConfig) Validate() error {
+	switch c.Source {
+	case "":
+		return nil
+	case DirectoryLDAP:
+		u, err := url.Parse(c.LDAP.URL)
+		if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+			return fmt.Errorf("directory: ldap.url must be ldap://host or ldaps://host, not %q", c.LDAP.URL)
+		}
+		if c.LDAP.BaseDN == "" {
+			return errors.New("directory: ldap.base_dn is needed")
+		}
+		if _, _, err := ldapFilter(cmp.Or(c.LDAP.Filter, ldapDefaultFilter)); err != nil {
+			return fmt.Errorf("directory: ldap.filter: %w", err)
+		}
+	case DirectoryOIDC:
+		if c.OIDC.Issuer == "" || c.OIDC.ClientID == "" || c.OIDC.Users == "" {
+			return errors.New("directory: oidc.issuer, oidc.client_id and oidc.users are needed")
+		}
+	default:
+		return fmt.Errorf("directory: source must be %q or %q, not %q", DirectoryLDAP, DirectoryOIDC, c.Source)
+	}
+	return nil
+}
+
+// DirectoryEntry is a member as the directory has them.
+type DirectoryEntry struct {
+	ID, Name string
+	// Token is empty if the directory has no card tokens.
+	Token  string
+	Active bool
+}
+
+// Entries reads every member from the directory.
+func (c DirectoryConfig) Entries() ([]DirectoryEntry, error) {
+	switch c.Source {
+	case DirectoryLDAP:
+		return c.LDAP.entries()
+	case DirectoryOIDC:
+		return c.OIDC.entries()
+	}
+	return nil, errors.New("no member directory configured")
+}
+
+// MemberChange is what syncing does to a member.
+type MemberChange struct {
+	// Member is the member as they will be.
+	Member domain.Member
+	New    bool
+	// Fields are the changes to a member that is already there.
+	Fields []FieldChange
+	// Note says what syncing left as it was, like a card token that is
+	// someone else's.
+	Note string
+}
+
+func (c MemberChange) String() string {
+	var fields []string
+	if c.New {
+		fields = append(fields, c.Member.Name)
+		if c.Member.Token != "" {
+			fields = append(fields, "token "+c.Member.Token)
+		}
+	}
+	for _, f := range c.Fields {
+		fields = append(fields, fmt.Sprintf("%s %s → %s", f.Field, cmp.Or(f.From, "-"), cmp.Or(f.To, "-")))
+	}
+	if c.Note != "" {
+		fields = append(fields, "("+c.Note+")")
+	}
+	return strings.Join(fields, ", ")
+}
+
+func memberStatus(inactive bool) string {
+	if inactive {
+		return "inactive"
+	}
+	return "active"
+}
+
+// PlanMemberSync works out what syncing the members with entries changes:
+// first the members in the directory, in its order, then the synced
+// members that aren't in it anymore.
+func (s *Store) PlanMemberSync(entries []DirectoryEntry) []MemberChange {
+	var changes []MemberChange
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e.ID == "" || seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		c := MemberChange{Member: domain.Member{ID: e.ID, Synced: true, Inactive: true}, New: true}
+		i := s.MemberIndex(e.ID)
+		switch {
+		case i >= 0 && s.Members[i].IsGuest():
+			continue
+		case i >= 0:
+			c.Member, c.New = s.Members[i], false
+		case !e.Active:
+			continue
+		}
+		m := &c.Member
+		change := func(field, from, to string) {
+			if from != to && !c.New {
+				c.Fields = append(c.Fields, FieldChange{Field: field, From: from, To: to})
+			}
+		}
+		if !m.Synced {
+			change("source", "till", "directory")
+			m.Synced = true
+		}
+		if name := cmp.Or(e.Name, m.Name, e.ID); name != m.Name {
+			change("name", m.Name, name)
+			m.Name = name
+		}
+		if e.Token != "" && e.Token != m.Token {
+			if j := slices.IndexFunc(s.Members, func(o domain.Member) bool { return o.Token == e.Token && o.ID != e.ID }); j >= 0 {
+				c.Note = "token is " + s.Members[j].ID + "'s"
+			} else {
+				change("token", m.Token, e.Token)
+				m.Token = e.Token
+			}
+		}
+		if m.Inactive == e.Active {
+			change("status", memberStatus(m.Inactive), memberStatus(!e.Active))
+			m.Inactive = !e.Active
+		}
+		if c.New || len(c.Fields) > 0 {
+			changes = append(changes, c)
+		}
+	}
+	for _, m := range s.Members {
+		if m.Synced && !m.Inactive && !seen[m.ID] {
+			c := MemberChange{Member: m, Note: "not in the directory"}
+			c.Fields = []FieldChange{{Field: "status", From: memberStatus(false), To: memberStatus(true)}}
+			c.Member.Inactive = true
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// SyncMembers syncs the members with entries, against the members as they
+// are by then, and returns what it changed.
+func (s *Store) SyncMembers(entries []DirectoryEntry) ([]MemberChange, error) {
+	var changes []MemberChange
+	err := s.Update(func() error {
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		changes = s.PlanMemberSync(entries)
+		for _, c := range changes {
+			if c.New {
+				s.Members = append(s.Members, c.Member)
+			} else {
+				s.Members[s.MemberIndex(c.Member.ID)] = c.Member
+			}
+			s.Audit(AuditEntry{Event: "member", Member: c.Member.ID, Detail: "directory: " + c.String()})
+		}
+		return nil
+	})
+	return changes, err
+}
+
+func WriteMemberChanges(out io.Writer, changes []MemberChange) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, c := range changes {
+		mark := "~"
+		if c.New {
+			mark = "+"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", mark, c.Member.ID, c)
+	}
+	return w.Flush()
+}
+
+var directoryTimeout = 30 * time.Second
+
+// --- LDAP ---
+
+// The till only binds and searches, so it speaks just that much LDAPv3
+// itself, in BER as RFC 4511 has it.
+
+const ldapDefaultFilter = "(objectClass=person)"
+
+// BER tags of the LDAP messages and filters the till uses.
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+	berSet         = 0x31
+
+	ldapBindRequest  = 0x60
+	ldapBindResponse = 0x61
+	ldapUnbind       = 0x42
+	ldapSearch       = 0x63
+	ldapSearchEntry  = 0x64
+	ldapSearchDone   = 0x65
+	ldapSearchRef    = 0x73
+	ldapExtended     = 0x77
+	ldapExtendedDone = 0x78
+	ldapSimpleAuth   = 0x80
+
+	ldapFilterAnd        = 0xa0
+	ldapFilterOr         = 0xa1
+	ldapFilterNot        = 0xa2
+	ldapFilterEquality   = 0xa3
+	ldapFilterSubstrings = 0xa4
+	ldapFilterPresent    = 0x87
+)
+
+// ldapStartTLS is the OID of the StartTLS extended operation.
+const ldapStartTLS = "1.3.6.1.4.1.1466.20037"
+
+// ber encodes an element of the given tag.
+func ber(tag byte, content ...[]byte) []byte {
+	n := 0
+	for _, c := range content {
+		n += len(c)
+	}
+	out := []byte{tag}
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	default:
+		var length []byte
+		for l := n; l > 0; l >>= 8 {
+			length = append([]byte{byte(l)}, length...)
+		}
+		out = append(append(out, 0x80|byte(len(length))), length...)
+	}
+	for _, c := range content {
+		out = append(out, c...)
+	}
+	return out
+}
+
+func berString(tag byte, s string) []byte { return ber(tag, []byte(s)) }
+
+func berInt(tag byte, v int) []byte {
+	b := []byte{byte(v)}
+	for v >>= 8; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return ber(tag, b)
+}
+
+// berNext splits the first element off b.
+func berNext(b []byte) (tag byte, content, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errors.New("ldap: truncated message")
+	}
+	tag, n, b := b[0], int(b[1]), b[2:]
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 4 || len(b) < size {
+			return 0, nil, nil, errors.New("ldap: bad length")
+		}
+		n = 0
+		for _, c := range b[:size] {
+			n = n<<8 | int(c)
+		}
+		b = b[size:]
+	}
+	if len(b) < n {
+		return 0, nil, nil, errors.New("ldap: truncated message")
+	}
+	return tag, b[:n], b[n:], nil
+}
+
+func berUint(b []byte) int {
+	v := 0
+	for _, c := range b {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+// readBER reads the next message off the connection.
+func readBER(r *bufio.Reader) ([]byte, error) {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if head[1]&0x80 != 0 {
+		size := int(head[1] & 0x7f)
+		if size == 0 || size > 4 {
+			return nil, errors.New("ldap: bad length")
+		}
+		length := make([]byte, size)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return nil, err
+		}
+		head = append(head, length...)
+	}
+	n := int(head[1])
+	if n&0x80 != 0 {
+		n = berUint(head[2:])
+	}
+	if n > 16<<20 {
+		return nil, errors.New("ldap: message too large")
+	}
+	msg := append(head, make([]byte, n)...)
+	if _, err := io.ReadFull(r, msg[len(head):]); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// ldapFilter encodes a filter in the string form of RFC 4515, as far as
+// it is and, or, not, equality, presence and substrings.
+func ldapFilter(s string) ([]byte, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, "", fmt.Errorf("%q doesn't start with (", s)
+	}
+	s = s[1:]
+	if s != "" && strings.ContainsRune("&|!", rune(s[0])) {
+		tag := map[byte]byte{'&': ldapFilterAnd, '|': ldapFilterOr, '!': ldapFilterNot}[s[0]]
+		var parts [][]byte
+		s = s[1:]
+		for strings.HasPrefix(s, "(") {
+			part, rest, err := ldapFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			parts, s = append(parts, part), rest
+		}
+		if !strings.HasPrefix(s, ")") || len(parts) == 0 || (tag == ldapFilterNot && len(parts) != 1) {
+			return nil, "", errors.New("unbalanced filter")
+		}
+		return ber(tag, parts...), s[1:], nil
+	}
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", errors.New("unbalanced filter")
+	}
+	item, rest := s[:end], s[end+1:]
+	attr, value, ok := strings.Cut(item, "=")
+	if !ok || attr == "" || strings.ContainsAny(attr, "<>~:") {
+		return nil, "", fmt.Errorf("only =, =* and substrings are supported, not %q", item)
+	}
+	if value == "*" {
+		return berString(ldapFilterPresent, attr), rest, nil
+	}
+	parts := strings.Split(value, "*")
+	for i, p := range parts {
+		v, err := ldapUnescape(p)
+		if err != nil {
+			return nil, "", err
+		}
+		parts[i] = v
+	}
+	if len(parts) == 1 {
+		return ber(ldapFilterEquality, berString(berOctetString, attr), berString(berOctetString, parts[0])), rest, nil
+	}
+	var subs [][]byte
+	for i, p := range parts {
+		switch {
+		case p == "":
+		case i == 0:
+			subs = append(subs, berString(0x80, p))
+		case i == len(parts)-1:
+			subs = append(subs, berString(0x82, p))
+		default:
+			subs = append(subs, berString(0x81, p))
+		}
+	}
+	return ber(ldapFilterSubstrings, berString(berOctetString, attr), ber(berSequence, subs...)), rest, nil
+}
+
+// ldapUnescape resolves the \XX escapes of a filter value.
+func ldapUnescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("bad escape in %q", s)
+		}
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("bad escape in %q", s)
+		}
+		b.Write(c)
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// ldapConn is a connection to the directory that sends one request at a
+// time.
+type ldapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	id   int
+}
+
+func dialLDAP(rawURL string) (*ldapConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: directoryTimeout}
+	var conn net.Conn
+	if u.Scheme == "ldaps" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", hostPort(u, "636"), &tls.Config{ServerName: u.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", hostPort(u, "389"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(directoryTimeout))
+	c := &ldapConn{conn: conn, r: bufio.NewReader(conn)}
+	if u.Scheme == "ldap" {
+		if err := c.startTLS(u.Hostname()); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// startTLS upgrades a plain connection to TLS, so that the bind's password
+// doesn't go over the wire as it is.
+func (c *ldapConn) startTLS(host string) error {
+	if err := c.send(ber(ldapExtended, berString(0x80, ldapStartTLS))); err != nil {
+		return err
+	}
+	tag, op, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if tag != ldapExtendedDone {
+		return fmt.Errorf("ldap: unexpected answer %#x to StartTLS", tag)
+	}
+	if err := ldapResult(op); err != nil {
+		return fmt.Errorf("StartTLS: %w; use ldaps:// if the directory only takes that", err)
+	}
+	conn := tls.Client(c.conn, &tls.Config{ServerName: host})
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	c.conn, c.r = conn, bufio.NewReader(conn)
+	return nil
+}
+
+func hostPort(u *url.URL, port string) string {
+	return net.JoinHostPort(u.Hostname(), cmp.Or(u.Port(), port))
+}
+
+// send sends op as the next message.
+func (c *ldapConn) send(op []byte) error {
+	c.id++
+	_, err := c.conn.Write(ber(berSequence, berInt(berInteger, c.id), op))
+	return err
+}
+
+// receive reads the next message and returns its operation.
+func (c *ldapConn) receive() (byte, []byte, error) {
+	msg, err := readBER(c.r)
+	if err != nil {
+		return 0, nil, err
+	}
+	_, content, _, err := berNext(msg)
+	if err != nil {
+		return 0, nil, err
+	}
+	_, _, content, err = berNext(content) // the message ID
+	if err != nil {
+		return 0, nil, err
+	}
+	tag, op, _, err := berNext(content)
+	return tag, op, err
+}
+
+// ldapResult is the error of an LDAPResult, if it reports one.
+func ldapResult(op []byte) error {
+	_, code, rest, err := berNext(op)
+	if err != nil {
+		return err
+	}
+	if berUint(code) == 0 {
+		return nil
+	}
+	_, _, rest, _ = berNext(rest) // the matched DN
+	_, msg, _, _ := berNext(rest)
+	return fmt.Errorf("ldap: result %d: %s", berUint(code), cmp.Or(string(msg), "failed"))
+}
+
+func (c *ldapConn) bind(dn, password string) error {
+	err := c.send(ber(ldapBindRequest, berInt(berInteger, 3), berString(berOctetString, dn), berString(ldapSimpleAuth, password)))
+	if err != nil {
+		return err
+	}
+	tag, op, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if tag != ldapBindResponse {
+		return fmt.Errorf("ldap: unexpected answer %#x to bind", tag)
+	}
+	return ldapResult(op)
+}
+
+// search returns the attributes of every entry the filter finds under
+// base, by lower-case name.
+func (c *ldapConn) search(base, filter string, attrs []string) ([]map[string][]string, error) {
+	f, _, err := ldapFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	var names [][]byte
+	for _, a := range attrs {
+		names = append(names, berString(berOctetString, a))
+	}
+	err = c.send(ber(ldapSearch,
+		berString(berOctetString, base),
+		berInt(berEnumerated, 2), // whole subtree
+		berInt(berEnumerated, 0), // never dereference aliases
+		berInt(berInteger, 0),    // no size limit
+		berInt(berInteger, 0),    // no time limit
+		ber(0x01, []byte{0}),     // types only: no
+		f,
+		ber(berSequence, names...),
+	))
+	if err != nil {
+		return nil, err
+	}
+	var entries []map[string][]string
+	for {
+		tag, op, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case ldapSearchDone:
+			return entries, ldapResult(op)
+		case ldapSearchRef:
+			continue
+		case ldapSearchEntry:
+		default:
+			return nil, fmt.Errorf("ldap: unexpected answer %#x to search", tag)
+		}
+		_, _, rest, err := berNext(op) // the DN
+		if err != nil {
+			return nil, err
+		}
+		_, list, _, err := berNext(rest)
+		if err != nil {
+			return nil, err
+		}
+		entry := map[string][]string{}
+		for len(list) > 0 {
+			var attr []byte
+			if _, attr, list, err = berNext(list); err != nil {
+				return nil, err
+			}
+			_, name, rest, err := berNext(attr)
+			if err != nil {
+				return nil, err
+			}
+			_, vals, _, err := berNext(rest)
+			if err != nil {
+				return nil, err
+			}
+			for len(vals) > 0 {
+				var v []byte
+				if _, v, vals, err = berNext(vals); err != nil {
+					return nil, err
+				}
+				key := strings.ToLower(string(name))
+				entry[key] = append(entry[key], string(v))
+			}
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (c *ldapConn) Close() error {
+	c.send(ldapUnbindRequest)
+	return c.conn.Close()
+}
+
+var ldapUnbindRequest = []byte{ldapUnbind, 0}
+
+func (c LDAPConfig) entries() ([]DirectoryEntry, error) {
+	conn, err := dialLDAP(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.bind(c.BindDN, c.Password); err != nil {
+		return nil, err
+	}
+	id, name := cmp.Or(c.ID, "uid"), cmp.Or(c.Name, "cn")
+	attrs := []string{id, name}
+	if c.Token != "" {
+		attrs = append(attrs, c.Token)
+	}
+	if c.Group != "" {
+		attrs = append(attrs, "memberOf")
+	}
+	found, err := conn.search(c.BaseDN, cmp.Or(c.Filter, ldapDefaultFilter), attrs)
+	if err != nil {
+		return nil, err
+	}
+	first := func(entry map[string][]string, attr string) string {
+		if v := entry[strings.ToLower(attr)]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	var entries []DirectoryEntry
+	for _, f := range found {
+		e := DirectoryEntry{ID: first(f, id), Name: first(f, name), Active: true}
+		if c.Token != "" {
+			e.Token = first(f, c.Token)
+		}
+		if c.Group != "" {
+			e.Active = slices.ContainsFunc(f["memberof"], func(dn string) bool { return strings.EqualFold(dn, c.Group) })
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// --- OIDC ---
+
+var directoryClient = &http.Client{Timeout: directoryTimeout}
+
+// getJSON fetches u into v, with the access token if there is one.
+func getJSON(u, token string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json, application/scim+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := directoryClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// token gets an access token for the till itself, by its client
+// credentials.
+func (c OIDCConfig) token() (string, error) {
+	var discovery struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := getJSON(strings.TrimSuffix(c.Issuer, "/")+"/.well-known/openid-configuration", "", &discovery); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	resp, err := directoryClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&token)
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("oidc: no access token: %s %s", resp.Status, token.Error)
+	}
+	return token.AccessToken, nil
+}
+
+// scimAttr is the attribute at path, like "name.formatted", as text.
+func scimAttr(user map[string]any, path string) string {
+	var v any = user
+	for _, p := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		v = m[p]
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// scimPage is how many users are asked for at once.
+const scimPage = 100
+
+func (c OIDCConfig) entries() ([]DirectoryEntry, error) {
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	id, name := cmp.Or(c.ID, "userName"), cmp.Or(c.Name, "displayName")
+	var entries []DirectoryEntry
+	for start := 1; ; {
+		var page struct {
+			TotalResults int              `json:"totalResults"`
+			Resources    []map[string]any `json:"Resources"`
+		}
+		sep := "?"
+		if strings.Contains(c.Users, "?") {
+			sep = "&"
+		}
+		if err := getJSON(fmt.Sprintf("%s%sstartIndex=%d&count=%d", c.Users, sep, start, scimPage), token, &page); err != nil {
+			return nil, err
+		}
+		for _, user := range page.Resources {
+			active, ok := user["active"].(bool)
+			e := DirectoryEntry{ID: scimAttr(user, id), Name: scimAttr(user, name), Active: active || !ok}
+			if c.Token != "" {
+				e.Token = scimAttr(user, c.Token)
+			}
+			if c.Group != "" && e.Active {
+				groups, _ := user["groups"].([]any)
+				e.Active = slices.ContainsFunc(groups, func(g any) bool {
+					m, _ := g.(map[string]any)
+					return m["display"] == c.Group || m["value"] == c.Group
+				})
+			}
+			entries = append(entries, e)
+		}
+		start += len(page.Resources)
+		if len(page.Resources) == 0 || start > page.TotalResults {
+			return entries, nil
+		}
+	}
+}
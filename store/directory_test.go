@@ -0,0 +1,214 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+func TestLDAPFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string // the BER, in hex
+		rest   string
+	}{
+		{"(objectClass=person)", "a315040b6f626a656374436c6173730406706572736f6e", ""},
+		{"(mail=*)", "87046d61696c", ""},
+		{"(cn=Al*ce*)", "a40e0402636e30088002416c81026365", ""},
+		{"(cn=*ice)", "a40b0402636e30058203696365", ""},
+		{"(&(objectClass=person)(!(uid=guest)))", "a027a315040b6f626a656374436c6173730406706572736f6ea20ea30c040375696404056775657374", ""},
+		{"(|(uid=a)(uid=b))", "a114a3080403756964040161a3080403756964040162", ""},
+		// An escaped * is part of the value.
+		{"(cn=Jo\\2a)", "a3090402636e04034a6f2a", ""},
+		{"(uid=a)(uid=b)", "a3080403756964040161", "(uid=b)"},
+	}
+	for _, tt := range tests {
+		got, rest, err := ldapFilter(tt.filter)
+		if err != nil {
+			t.Errorf("ldapFilter(%q): %v", tt.filter, err)
+			continue
+		}
+		if hex.EncodeToString(got) != tt.want || rest != tt.rest {
+			t.Errorf("ldapFilter(%q) = %x, %q, want %s, %q", tt.filter, got, rest, tt.want, tt.rest)
+		}
+	}
+
+	for _, filter := range []string{
+		"uid=a",
+		"(uid=a",
+		"(=a)",
+		"(&)",
+		"(&(uid=a)",
+		"(!(uid=a)(uid=b))",
+		"(uid>=3)",
+		"(cn:dn:=x)",
+		"(cn=\\zz)",
+		"(cn=\\2)",
+	} {
+		if got, _, err := ldapFilter(filter); err == nil {
+			t.Errorf("ldapFilter(%q) = %x, want an error", filter, got)
+		}
+	}
+}
+
+func TestBER(t *testing.T) {
+	// Lengths from 128 on take bytes of their own.
+	for _, n := range []int{0, 1, 127, 128, 200, 300, 70000} {
+		content := bytes.Repeat([]byte{'x'}, n)
+		msg := append(ber(berOctetString, content[:n/2], content[n/2:]), berInt(berInteger, n)...)
+		tag, got, rest, err := berNext(msg)
+		if err != nil || tag != berOctetString || !bytes.Equal(got, content) {
+			t.Errorf("%d bytes: berNext = %#x, %d bytes, %v", n, tag, len(got), err)
+			continue
+		}
+		tag, got, rest, err = berNext(rest)
+		if err != nil || tag != berInteger || berUint(got) != n || len(rest) != 0 {
+			t.Errorf("%d bytes: the integer after them is %#x %x, %v", n, tag, got, err)
+		}
+		// readBER takes the same messages off a connection, one at a time.
+		r := bufio.NewReader(bytes.NewReader(msg))
+		first, err := readBER(r)
+		if err != nil || !bytes.Equal(first, msg[:len(msg)-len(berInt(berInteger, n))]) {
+			t.Errorf("%d bytes: readBER = %d bytes, %v", n, len(first), err)
+		}
+	}
+
+	for v, want := range map[int]string{0: "020100", 3: "020103", 200: "020200c8", 0x1234: "02021234", 70000: "0203011170"} {
+		if got := hex.EncodeToString(berInt(berInteger, v)); got != want {
+			t.Errorf("berInt(%d) = %s, want %s", v, got, want)
+		}
+	}
+
+	for _, b := range []string{"", "04", "0405616263", "0480", "0485000000000000", "048201", "04820100"} {
+		msg, _ := hex.DecodeString(b)
+		if _, _, _, err := berNext(msg); err == nil {
+			t.Errorf("berNext(%s): no error", b)
+		}
+	}
+}
+
+func TestPlanMemberSync(t *testing.T) {
+	s := Memory(nil)
+	s.Members = []domain.Member{
+		{ID: "alice", Name: "Alice", Synced: true},
+		{ID: "bob", Name: "Bob", Synced: true},
+		{ID: "carol", Name: "Carol", Token: "T1"},
+		{ID: "dave", Name: "Dave"},
+		{ID: "gina", Name: "Gina", Synced: true},
+		{ID: "hank", Name: "Hank", Synced: true},
+		{ID: "ivan", Name: "Ivan", Synced: true, Inactive: true},
+		{ID: "guest1", Name: "Guest", Event: "camp"},
+	}
+	entries := []DirectoryEntry{
+		// Renamed, and given a token that is someone else's.
+		{ID: "alice", Name: "Alice Liddell", Token: "T1", Active: true},
+		// Added by hand, and now in the directory too.
+		{ID: "dave", Name: "Dave", Active: true},
+		// Guests are never the directory's.
+		{ID: "guest1", Name: "Someone", Active: true},
+		{ID: "erin", Name: "Erin", Token: "T5", Active: true},
+		// New, but inactive already.
+		{ID: "frank", Name: "Frank"},
+		{ID: "gina", Name: "Gina"},
+		{ID: "hank", Name: "Hank", Active: true},
+		{Name: "No ID", Active: true},
+		{ID: "erin", Name: "Erin again", Active: true},
+	}
+	var got []string
+	for _, c := range s.PlanMemberSync(entries) {
+		mark := "~"
+		if c.New {
+			mark = "+"
+		}
+		got = append(got, fmt.Sprintf("%s %s: %s", mark, c.Member.ID, c))
+	}
+	// Carol, added by hand, and Ivan, inactive already, stay as they are;
+	// so does Hank, who hasn't changed.
+	want := []string{
+		"~ alice: name Alice → Alice Liddell, (token is carol's)",
+		"~ dave: source till → directory",
+		"+ erin: Erin, token T5",
+		"~ gina: status active → inactive",
+		"~ bob: status active → inactive, (not in the directory)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	changes, err := s.SyncMembers(entries)
+	if err != nil || len(changes) != len(want) {
+		t.Fatalf("SyncMembers = %d changes, %v", len(changes), err)
+	}
+	for id, want := range map[string]domain.Member{
+		"alice": {ID: "alice", Name: "Alice Liddell", Synced: true},
+		"bob":   {ID: "bob", Name: "Bob", Synced: true, Inactive: true},
+		"carol": {ID: "carol", Name: "Carol", Token: "T1"},
+		"dave":  {ID: "dave", Name: "Dave", Synced: true},
+		"erin":  {ID: "erin", Name: "Erin", Token: "T5", Synced: true},
+	} {
+		i := s.MemberIndex(id)
+		if i < 0 {
+			t.Errorf("after syncing, %s is gone", id)
+		} else if s.Members[i] != want {
+			t.Errorf("after syncing, %s is %+v, want %+v", id, s.Members[i], want)
+		}
+	}
+	if s.MemberIndex("frank") >= 0 {
+		t.Error("frank, inactive in the directory, was added")
+	}
+	if again := s.PlanMemberSync(entries); len(again) != 0 {
+		t.Errorf("syncing again changes %v", again)
+	}
+}
+
+func TestLDAPRefusesCleartextBind(t *testing.T) {
+	// A directory that doesn't take StartTLS.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ops := make(chan []byte, 4)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		defer close(ops)
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := readBER(r)
+			if err != nil {
+				return
+			}
+			_, content, _, _ := berNext(msg)
+			_, id, op, _ := berNext(content)
+			ops <- op
+			if op[0] == ldapExtended {
+				conn.Write(ber(berSequence, berInt(berInteger, berUint(id)), ber(ldapExtendedDone,
+					berInt(berEnumerated, 2), berString(berOctetString, ""), berString(berOctetString, "unsupported"))))
+			}
+		}
+	}()
+
+	c := LDAPConfig{URL: "ldap://" + l.Addr().String(), BindDN: "cn=till", Password: "secret", BaseDN: "dc=example"}
+	if _, err := c.entries(); err == nil || !strings.Contains(err.Error(), "StartTLS") {
+		t.Errorf("entries() = %v, want StartTLS to fail", err)
+	}
+	for op := range ops {
+		if op[0] != ldapExtended && op[0] != ldapUnbind {
+			t.Errorf("the directory was sent %#x", op[0])
+		}
+		if bytes.Contains(op, []byte("secret")) {
+			t.Error("the password went over the wire in the clear")
+		}
+	}
+}
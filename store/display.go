@@ -0,0 +1,58 @@
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- CUSTOMER DISPLAY ---
+
+// TillSession is what a till shows the customer of the sale at hand, for a
+// display facing them. Tills that are clients of a server send it along
+// with every change; the server keeps only the latest of each till.
+type TillSession struct {
+	Till  string            `json:"till"`
+	Lines []domain.SaleLine `json:"lines"`
+	Total float64           `json:"total"`
+	// Paid is set once the sale is booked, while the till shows its
+	// receipt.
+	Paid bool `json:"paid,omitempty"`
+	// Payment is a link the customer can pay through, shown as a QR code.
+	Payment string    `json:"payment,omitempty"`
+	Updated time.Time `json:"updated"`
+	// Version changes with every update the server receives.
+	Version int `json:"version"`
+}
+
+// TillName is the name the till's session goes by, the host name unless
+// the config sets one.
+func (c ServerConfig) TillName() string {
+	host, _ := os.Hostname()
+	return cmp.Or(c.Till, host, "till")
+}
+
+// PublishSession sends the till's session to the server.
+func (r *RemoteStore) PublishSession(s TillSession) error {
+	_, err := r.Do(http.MethodPut, "/sessions/"+url.PathEscape(s.Till), s)
+	return err
+}
+
+// WatchSession waits for the session of till to differ from version and
+// returns it. Like /events, the server answers after EventsTimeout even
+// if nothing changed.
+func (r *RemoteStore) WatchSession(till string, version int) (TillSession, error) {
+	var s TillSession
+	data, err := r.Do(http.MethodGet, "/sessions/"+url.PathEscape(till)+"?since="+strconv.Itoa(version), nil)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- MQTT EVENTS ---
+
+// EventsConfig publishes what happens at the till to an MQTT broker, for
+// home automation and the like:
+//
+//	<topic>/sale               the SalePayload of every sale
+//	<topic>/stock/<beverage>   the beverage's stock, retained, on every change
+type EventsConfig struct {
+	MQTT  MQTTConfig `json:"mqtt"`
+	Topic string     `json:"topic,omitempty"`
+}
+
+type mqttMessage struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+// eventPublisher keeps one connection to the broker for the whole process
+// and sends the messages in order. A dropped connection is dialed again
+// for the next message.
+type eventPublisher struct {
+	cfg    EventsConfig
+	queue  chan mqttMessage
+	client *MQTTClient
+}
+
+var (
+	publishersMu sync.Mutex
+	publishers   = map[EventsConfig]*eventPublisher{}
+)
+
+// publisherFor returns the publisher for cfg, or nil if events are off.
+// Stores are created per request on the server, so publishers are shared.
+func publisherFor(cfg EventsConfig) *eventPublisher {
+	if cfg.MQTT.Broker == "" || cfg.Topic == "" {
+		return nil
+	}
+	publishersMu.Lock()
+	defer publishersMu.Unlock()
+	p, ok := publishers[cfg]
+	if !ok {
+		p = &eventPublisher{cfg: cfg, queue: make(chan mqttMessage, 64)}
+		go p.run()
+		publishers[cfg] = p
+	}
+	return p
+}
+
+func (p *eventPublisher) publish(topic string, payload []byte, retain bool) {
+	DeliveriesPending.Add(1)
+	p.queue <- mqttMessage{topic: p.cfg.Topic + "/" + topic, payload: payload, retain: retain}
+}
+
+func (p *eventPublisher) run() {
+	for msg := range p.queue {
+		if err := p.send(msg); err != nil {
+			fmt.Fprintf(os.Stderr, "mqtt events: %v\n", err)
+		}
+		DeliveriesPending.Done()
+	}
+}
+
+// send publishes msg, reconnecting once if the connection has gone away
+// since the last message.
+func (p *eventPublisher) send(msg mqttMessage) error {
+	var err error
+	for range 2 {
+		if p.client == nil {
+			if p.client, err = DialMQTT(p.cfg.MQTT); err != nil {
+				return err
+			}
+		}
+		if err = p.client.Publish(msg.topic, msg.payload, msg.retain); err == nil {
+			return nil
+		}
+		p.client.Close()
+		p.client = nil
+	}
+	return err
+}
+
+// stockLevels returns the stock of every stocked beverage.
+func (s *Store) stockLevels() map[string]float64 {
+	levels := map[string]float64{}
+	for _, b := range s.Beverages {
+		if !b.IsRecipe() {
+			levels[b.Key()] = b.Stock
+		}
+	}
+	return levels
+}
+
+// publishEvents publishes the sales booked by the last update and the
+// stock that changed since before.
+func (s *Store) publishEvents(before map[string]float64) {
+	if s.events == nil {
+		return
+	}
+	for _, sale := range s.booked {
+		if data, err := json.Marshal(s.salePayload(sale)); err == nil {
+			s.events.publish("sale", data, false)
+		}
+	}
+	for _, b := range s.Beverages {
+		if old, ok := before[b.Key()]; !b.IsRecipe() && (!ok || old != b.Stock) {
+			s.events.publish("stock/"+topicSegment(b.Key()), []byte(strconv.FormatFloat(b.Stock, 'f', -1, 64)), true)
+		}
+	}
+}
+
+// topicSegment keeps a beverage name from splitting the topic or acting
+// as a wildcard.
+func topicSegment(name string) string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(name)
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"slices"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- BEST-BEFORE DATES ---
+
+// Deliveries can be booked with the best-before date printed on them. As
+// with FIFO valuation, the oldest stock is taken to sell first, so the
+// stock on hand came from the latest deliveries; what is left of each is a
+// batch that keeps until the delivery's date.
+
+// Batch is what is left of a delivery that has a best-before date.
+type Batch struct {
+	Beverage   string
+	Unit       domain.Unit
+	Quantity   float64
+	BestBefore time.Time
+}
+
+// Expired reports whether the batch is past its best-before date at now.
+// It still keeps on the day of its date.
+func (b Batch) Expired(now time.Time) bool { return b.BestBefore.Before(startOfDay(now)) }
+
+// Expiring reports whether the batch is past its best-before date within
+// days of now, or already is.
+func (b Batch) Expiring(now time.Time, days int) bool {
+	return b.BestBefore.Before(startOfDay(now).AddDate(0, 0, days+1))
+}
+
+// Batches returns the batches of the stock on hand, those that keep the
+// shortest first.
+func (s *Store) Batches() []Batch {
+	deliveries := map[string][]Restock{}
+	for _, r := range s.Restocks {
+		if r.Quantity > 0 {
+			deliveries[r.Beverage] = append(deliveries[r.Beverage], r)
+		}
+	}
+	var batches []Batch
+	for _, b := range s.Beverages {
+		restocks := deliveries[b.Key()]
+		if b.IsRecipe() || len(restocks) == 0 {
+			continue
+		}
+		slices.SortStableFunc(restocks, func(x, y Restock) int { return x.Time.Compare(y.Time) })
+		left := b.Stock
+		for _, r := range slices.Backward(restocks) {
+			if left <= 0 {
+				break
+			}
+			qty := min(left, r.Quantity)
+			left -= qty
+			if !r.BestBefore.IsZero() {
+				batches = append(batches, Batch{Beverage: b.Key(), Unit: b.Unit, Quantity: qty, BestBefore: r.BestBefore})
+			}
+		}
+	}
+	slices.SortStableFunc(batches, func(x, y Batch) int { return x.BestBefore.Compare(y.BestBefore) })
+	return batches
+}
+
+// ExpiringBatches returns the batches that are past their best-before date
+// within days of now, so they can be sold off or drunk first.
+func (s *Store) ExpiringBatches(now time.Time, days int) []Batch {
+	var expiring []Batch
+	for _, b := range s.Batches() {
+		if b.Expiring(now, days) {
+			expiring = append(expiring, b)
+		}
+	}
+	return expiring
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
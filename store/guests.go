@@ -0,0 +1,120 @@
+package store
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- GUEST WRISTBANDS ---
+
+// Guests are temporary members for an event, each with a wristband or QR
+// code carrying their token. They pay in advance: their tab can't go below
+// zero, and once the event has expired it can't be charged at all. What's
+// left on the wristbands shows up in the guest report.
+
+var errGuestExpired = errors.New("guest wristband has expired")
+
+// CreateGuests adds count guests for event with credit on their tabs. IDs
+// are numbered on from the event's existing guests, e.g. "gpn-001".
+func (s *Store) CreateGuests(event string, count int, credit float64, expires time.Time) ([]domain.Member, error) {
+	if event == "" {
+		return nil, errors.New("event must not be empty")
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("can't create %d guests", count)
+	}
+	if credit < 0 {
+		return nil, errors.New("credit must not be negative")
+	}
+	var guests []domain.Member
+	err := s.Update(func() error {
+		guests = nil
+		n := 0
+		for _, m := range s.Members {
+			if m.Event == event {
+				n++
+			}
+		}
+		now := time.Now()
+		for len(guests) < count {
+			n++
+			id := fmt.Sprintf("%s-%03d", event, n)
+			if s.MemberIndex(id) >= 0 {
+				continue
+			}
+			g := domain.Member{ID: id, Name: fmt.Sprintf("Guest %d", n), Token: rand.Text(), Balance: credit, Event: event, Expires: expires}
+			s.Members = append(s.Members, g)
+			if credit != 0 {
+				s.Ledger = append(s.Ledger, domain.LedgerEntry{Time: now, Member: id, Kind: LedgerTopUp, Amount: credit, Note: "prepaid"})
+			}
+			guests = append(guests, g)
+		}
+		s.Audit(AuditEntry{Time: now, Event: "guests", Quantity: float64(count), Amount: credit, Detail: event})
+		return nil
+	})
+	return guests, err
+}
+
+// ExpireGuests ends the event for all of its guests at the given time.
+func (s *Store) ExpireGuests(event string, at time.Time) (int, error) {
+	n := 0
+	err := s.Update(func() error {
+		n = 0
+		for i := range s.Members {
+			if s.Members[i].Event == event {
+				s.Members[i].Expires = at
+				n++
+			}
+		}
+		if n == 0 {
+			return fmt.Errorf("no guests for event %q", event)
+		}
+		s.Audit(AuditEntry{Event: "guests expired", Quantity: float64(n), Detail: event})
+		return nil
+	})
+	return n, err
+}
+
+// WriteGuestReport lists what every guest of event loaded, spent and left
+// unused. Only top-ups count as loaded; credit moved by a transfer doesn't.
+func (s *Store) WriteGuestReport(out io.Writer, event string) error {
+	loaded := map[string]float64{}
+	for _, e := range s.Ledger {
+		if e.Kind == LedgerTopUp {
+			loaded[e.Member] += e.Amount
+		}
+	}
+	spent := map[string]float64{}
+	for _, sale := range s.Sales {
+		if sale.Member != "" {
+			spent[sale.Member] += sale.Total()
+		}
+	}
+
+	fmt.Fprintf(out, "Guests of %s\n\n", event)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Guest\tLoaded\tSpent\tUnused\t")
+	var guests int
+	var totalLoaded, totalSpent, unused float64
+	for _, m := range s.Members {
+		if m.Event != event {
+			continue
+		}
+		guests++
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.2f\t\n", m.ID, loaded[m.ID], spent[m.ID], m.Balance)
+		totalLoaded += loaded[m.ID]
+		totalSpent += spent[m.ID]
+		unused += m.Balance
+	}
+	if guests == 0 {
+		return fmt.Errorf("no guests for event %q", event)
+	}
+	fmt.Fprintf(w, "Total\t%.2f\t%.2f\t%.2f\t\n", totalLoaded, totalSpent, unused)
+	return w.Flush()
+}
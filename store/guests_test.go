@@ -0,0 +1,155 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+func TestCreateGuests(t *testing.T) {
+	s := openTestStore(t)
+	expires := time.Now().Add(24 * time.Hour)
+	ids := func(guests []domain.Member) string {
+		var ids []string
+		for _, g := range guests {
+			ids = append(ids, g.ID)
+		}
+		return strings.Join(ids, " ")
+	}
+
+	first, err := s.CreateGuests("camp", 2, 10, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(first) != "camp-001 camp-002" {
+		t.Errorf("first batch %s, want camp-001 camp-002", ids(first))
+	}
+	if first[0].Token == "" || first[0].Token == first[1].Token {
+		t.Errorf("tokens %q and %q, want two of their own", first[0].Token, first[1].Token)
+	}
+	// A later batch numbers on, past an ID that is taken.
+	if err := s.AddMember(domain.Member{ID: "camp-003", Name: "Not a guest"}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.CreateGuests("camp", 2, 0, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(second) != "camp-004 camp-005" {
+		t.Errorf("second batch %s, want camp-004 camp-005", ids(second))
+	}
+	other, err := s.CreateGuests("gpn", 1, 5, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(other) != "gpn-001" {
+		t.Errorf("another event's batch %s, want gpn-001", ids(other))
+	}
+	// Only the guests with credit have it in the ledger.
+	if len(s.Ledger) != 3 || s.Ledger[0].Kind != LedgerTopUp || s.Ledger[0].Amount != 10 || s.Ledger[2].Member != "gpn-001" {
+		t.Errorf("ledger %+v, want the 10.00 of camp-001 and camp-002 and the 5.00 of gpn-001", s.Ledger)
+	}
+
+	for _, tt := range []struct {
+		event  string
+		count  int
+		credit float64
+	}{
+		{"", 1, 10},
+		{"camp", 0, 10},
+		{"camp", -1, 10},
+		{"camp", 1, -10},
+	} {
+		if guests, err := s.CreateGuests(tt.event, tt.count, tt.credit, expires); err == nil {
+			t.Errorf("%d guests of %q with %.2f: created %s", tt.count, tt.event, tt.credit, ids(guests))
+		}
+	}
+	if len(s.Members) != 6 {
+		t.Errorf("%d members, want 6", len(s.Members))
+	}
+}
+
+func TestGuestExpiry(t *testing.T) {
+	s := openTestStore(t, domain.Beverage{Name: "Club-Mate", Price: 1.50, Stock: 24})
+	if _, err := s.CreateGuests("camp", 2, 5, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	sale := func(qty int) error {
+		_, err := s.RecordSale(domain.Sale{Member: "camp-001", Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: qty, UnitPrice: 1.50}}})
+		return err
+	}
+	// However high the tab limit, a guest only spends what they loaded.
+	s.Configure(Options{TabLimit: 100})
+	if err := sale(4); !errors.Is(err, errTabLimit) {
+		t.Errorf("past the credit: %v, want errTabLimit", err)
+	}
+	if err := sale(2); err != nil {
+		t.Fatalf("within the credit: %v", err)
+	}
+
+	if n, err := s.ExpireGuests("camp", time.Now()); err != nil || n != 2 {
+		t.Fatalf("ExpireGuests() = %d, %v", n, err)
+	}
+	if err := sale(1); !errors.Is(err, errGuestExpired) {
+		t.Errorf("after the event: %v, want errGuestExpired", err)
+	}
+	if err := s.Transfer("camp-001", "camp-002", 1, ""); !errors.Is(err, errGuestExpired) {
+		t.Errorf("transfer after the event: %v, want errGuestExpired", err)
+	}
+	if s.Members[0].Balance != 2 || len(s.Sales) != 1 {
+		t.Errorf("balance %.2f with %d sales, want 2.00 with 1", s.Members[0].Balance, len(s.Sales))
+	}
+	if _, err := s.ExpireGuests("gpn", time.Now()); err == nil {
+		t.Error("expiring an event without guests: no error")
+	}
+}
+
+func TestWriteGuestReport(t *testing.T) {
+	s := openTestStore(t, domain.Beverage{Name: "Club-Mate", Price: 1.50, Stock: 24})
+	if _, err := s.CreateGuests("camp", 2, 10, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddMember(domain.Member{ID: "alice", Name: "Alice", Balance: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RecordSale(domain.Sale{Member: "camp-001", Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: 2, UnitPrice: 1.50}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RecordSale(domain.Sale{Member: "alice", Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: 1, UnitPrice: 1.50}}}); err != nil {
+		t.Fatal(err)
+	}
+	// Moving credit between guests loads nothing more.
+	if err := s.Transfer("camp-002", "camp-001", 4, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.TopUp("camp-002", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := s.WriteGuestReport(&out, "camp"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Guest", "Loaded", "Spent", "Unused"},
+		{"camp-001", "10.00", "3.00", "11.00"},
+		{"camp-002", "15.00", "0.00", "11.00"},
+		{"Total", "25.00", "3.00", "22.00"},
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2+len(want) || lines[0] != "Guests of camp" {
+		t.Fatalf("report\n%s\nwant a title and %d lines", out.String(), len(want))
+	}
+	for i, w := range want {
+		if got := strings.Fields(lines[2+i]); strings.Join(got, " ") != strings.Join(w, " ") {
+			t.Errorf("line %q, want %q", lines[2+i], strings.Join(w, " "))
+		}
+	}
+
+	if err := s.WriteGuestReport(&out, "gpn"); err == nil {
+		t.Error("report of an event without guests: no error")
+	}
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- IDEMPOTENCY ---
+
+// Bots and client terminals on flaky WiFi retry a sale when they don't
+// hear back, not knowing whether it was booked. With an Idempotency-Key
+// header, POST /sales books a sale once per key; a retry gets the response
+// of the first request instead of charging again.
+//
+// IdempotencyHeader is the request header with the client's key, and
+// ReplayedHeader the response header marking a replayed response.
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	ReplayedHeader    = "Idempotent-Replayed"
+)
+
+// idempotencyWindow is how long keys are remembered. Retries come within
+// seconds; a day leaves room for clients that were offline for a while.
+const idempotencyWindow = 24 * time.Hour
+
+// IdempotentRequest remembers a request booked under a key, with a hash of
+// its body to tell retries from a reused key, and the response to replay.
+type IdempotentRequest struct {
+	Key      string          `json:"key"`
+	Hash     string          `json:"hash"`
+	Time     time.Time       `json:"time"`
+	Response json.RawMessage `json:"response"`
+}
+
+var ErrKeyReused = errors.New("the idempotency key was already used for a different request")
+
+// errReplay ends an update without saving, as a replay changes nothing.
+var errReplay = errors.New("replay")
+
+func RequestHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordSaleOnce is recordSale for a request with an idempotency key. It
+// returns the response to send: the booked sale or, if the key was seen
+// before, the response of that request, with replayed set.
+func (s *Store) RecordSaleOnce(key, hash string, sale domain.Sale, now time.Time) (response []byte, replayed bool, err error) {
+	err = s.Update(func() error {
+		cutoff := now.Add(-idempotencyWindow)
+		s.Requests = slices.DeleteFunc(s.Requests, func(r IdempotentRequest) bool { return r.Time.Before(cutoff) })
+		for _, r := range s.Requests {
+			if r.Key != key {
+				continue
+			}
+			if r.Hash != hash {
+				return ErrKeyReused
+			}
+			// The store file is indented, and so is the response in it.
+			var b bytes.Buffer
+			if err := json.Compact(&b, r.Response); err != nil {
+				return err
+			}
+			response = b.Bytes()
+			return errReplay
+		}
+		if err := s.BookSale(sale); err != nil {
+			return err
+		}
+		data, err := json.Marshal(s.Sales[len(s.Sales)-1])
+		if err != nil {
+			return err
+		}
+		response = data
+		s.Requests = append(s.Requests, IdempotentRequest{Key: key, Hash: hash, Time: now, Response: data})
+		return nil
+	})
+	if errors.Is(err, errReplay) {
+		return response, true, nil
+	}
+	return response, false, err
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+func TestRecordSaleOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(func() error {
+		s.Beverages = []domain.Beverage{{Name: "Club-Mate", Price: 1.50, Stock: 24}}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+	sale := func(qty int) domain.Sale {
+		return domain.Sale{Time: now, Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: qty, UnitPrice: 1.50}}}
+	}
+	check := func(what string, sales int, stock float64) {
+		t.Helper()
+		if len(s.Sales) != sales || s.Beverages[0].Stock != stock {
+			t.Errorf("%s: %d sales and %g in stock, want %d and %g", what, len(s.Sales), s.Beverages[0].Stock, sales, stock)
+		}
+	}
+
+	first, replayed, err := s.RecordSaleOnce("k1", RequestHash([]byte("two")), sale(2), now)
+	if err != nil || replayed {
+		t.Fatalf("first request: replayed %t, %v", replayed, err)
+	}
+	check("first request", 1, 22)
+
+	// A retry gets the same response without booking again, also from a
+	// process that reads it from the file.
+	again, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range []*Store{s, again} {
+		got, replayed, err := st.RecordSaleOnce("k1", RequestHash([]byte("two")), sale(2), now.Add(time.Minute))
+		if err != nil || !replayed || string(got) != string(first) {
+			t.Errorf("retry: %s, replayed %t, %v; want %s replayed", got, replayed, err, first)
+		}
+	}
+	if err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	check("retries", 1, 22)
+
+	// The same key for another request is refused, and so not booked.
+	if _, _, err := s.RecordSaleOnce("k1", RequestHash([]byte("three")), sale(3), now.Add(time.Minute)); !errors.Is(err, ErrKeyReused) {
+		t.Errorf("key reused for another body: %v, want ErrKeyReused", err)
+	}
+	check("key reused", 1, 22)
+
+	if _, replayed, err := s.RecordSaleOnce("k2", RequestHash([]byte("three")), sale(3), now.Add(time.Minute)); err != nil || replayed {
+		t.Errorf("another key: replayed %t, %v", replayed, err)
+	}
+	check("another key", 2, 19)
+
+	// Just within the window, the key is still remembered.
+	if _, replayed, err := s.RecordSaleOnce("k1", RequestHash([]byte("two")), sale(2), now.Add(idempotencyWindow)); err != nil || !replayed {
+		t.Errorf("retry within the window: replayed %t, %v", replayed, err)
+	}
+	check("retry within the window", 2, 19)
+
+	// Once the window is over, the key is forgotten and books anew.
+	later := now.Add(idempotencyWindow + time.Minute)
+	if _, replayed, err := s.RecordSaleOnce("k1", RequestHash([]byte("three")), sale(3), later); err != nil || replayed {
+		t.Errorf("key after the window: replayed %t, %v", replayed, err)
+	}
+	check("key after the window", 3, 16)
+	if len(s.Requests) != 2 || s.Requests[0].Key != "k2" || !s.Requests[1].Time.Equal(later) {
+		t.Errorf("requests = %+v, want k2 and the new k1", s.Requests)
+	}
+}
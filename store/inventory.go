@@ -0,0 +1,52 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- INVENTORY ---
+
+// InventoryItem is a beverage as the API reports it: the stored fields plus
+// what the till derives from them.
+type InventoryItem struct {
+	domain.Beverage
+	// Available is how many portions can be sold right now, which for
+	// recipes depends on their ingredients.
+	Available int  `json:"available"`
+	Low       bool `json:"low"`
+}
+
+// StockAdjustment corrects the stock of a beverage, e.g. after a count or
+// breakage. Delta is in the beverage's stock unit.
+type StockAdjustment struct {
+	Delta float64 `json:"delta"`
+}
+
+// AdjustStock adds delta, which may be negative, to the stock of a
+// beverage. Unlike restock it doesn't log a delivery.
+func (s *Store) AdjustStock(name string, delta float64) error {
+	return s.Update(func() error {
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		i := s.BeverageIndex(name)
+		if i < 0 {
+			return fmt.Errorf("unknown beverage %q", name)
+		}
+		b := &s.Beverages[i]
+		if b.IsRecipe() {
+			return fmt.Errorf("%s is made from a recipe; adjust its ingredients instead", b.Key())
+		}
+		if b.Stock+delta < 0 {
+			return fmt.Errorf("not enough %s in stock (%s)", b.Key(), b.StockLabel())
+		}
+		b.Stock += delta
+		s.Audit(AuditEntry{Event: "stock", Beverage: b.Key(), Quantity: delta})
+		return nil
+	})
+}
+
+// ActorHeader carries the actor of a client terminal.
+const ActorHeader = "X-BubbleTender-Actor"
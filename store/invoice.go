@@ -0,0 +1,391 @@
+package store
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- SUPPLIER INVOICES ---
+
+// SupplierConfig describes the invoices of the bar's wholesale supplier,
+// for keeping the cost prices up to date. Invoices come as CSV, with the
+// columns found by their header like those of bank statements, or as the
+// text of a PDF, whose table lines are told by the quantity, unit price and
+// total they end in.
+type SupplierConfig struct {
+	// Delimiter separates the fields of CSV invoices; it is guessed from
+	// the header if empty.
+	Delimiter         string `json:"delimiter,omitempty"`
+	ArticleColumn     string `json:"article_column,omitempty"`
+	DescriptionColumn string `json:"description_column,omitempty"`
+	QuantityColumn    string `json:"quantity_column,omitempty"`
+	PriceColumn       string `json:"price_column,omitempty"`
+	// Articles maps the supplier's article numbers or descriptions to the
+	// beverages they are. Articles it doesn't list are matched by the
+	// beverage's name.
+	Articles map[string]SupplierArticle `json:"articles,omitempty"`
+	// Margin is the share of the net retail price the bar keeps over the
+	// cost, in percent; retail prices are suggested from it. Without it,
+	// there are no suggestions.
+	Margin float64 `json:"margin,omitempty"`
+	// Round is what suggested prices are rounded up to a multiple of,
+	// 0.10 without it.
+	Round float64 `json:"round,omitempty"`
+}
+
+// SupplierArticle is what an article on the supplier's invoices is at the
+// bar. Items is how many items sold an invoiced one makes, e.g. 20 for a
+// crate of bottles; 1 if unset.
+type SupplierArticle struct {
+	Beverage string  `json:"beverage"`
+	Items    float64 `json:"items,omitempty"`
+}
+
+func (c SupplierConfig) Validate() error {
+	if c.Margin < 0 || c.Margin >= 100 {
+		return fmt.Errorf("supplier: margin must be at least 0 and below 100 percent, not %g", c.Margin)
+	}
+	if c.Round < 0 {
+		return fmt.Errorf("supplier: round must not be negative")
+	}
+	for name, a := range c.Articles {
+		if a.Beverage == "" || a.Items < 0 {
+			return fmt.Errorf("supplier: article %q needs a beverage and a positive number of items", name)
+		}
+	}
+	return nil
+}
+
+// invoiceColumns are the usual headers of each column, lower case.
+var invoiceColumns = map[string][]string{
+	"article":     {"artikelnummer", "artikelnr", "artikelnr.", "artikel-nr.", "art.-nr.", "art.nr.", "article", "article no", "article number", "item no", "sku"},
+	"description": {"bezeichnung", "artikelbezeichnung", "artikel", "beschreibung", "description", "product", "item"},
+	"quantity":    {"menge", "anzahl", "stück", "quantity", "qty"},
+	"price":       {"einzelpreis", "ek", "ek-preis", "ek netto", "preis", "stückpreis", "unit price", "price", "net price"},
+}
+
+// InvoiceLine is a line of an invoice and what it does to the beverage it
+// is.
+type InvoiceLine struct {
+	Article     string
+	Description string
+	Quantity    float64
+	// UnitPrice is the net price of one invoiced article.
+	UnitPrice float64
+	// Beverage is the beverage the article is, if one matched.
+	Beverage string
+	// Cost is the cost of one item sold, OldCost what was known before.
+	Cost    float64
+	OldCost float64
+	// Price is the beverage's retail price, Suggested the one the margin
+	// calls for, if there is a margin.
+	Price     float64
+	Suggested float64
+	// Problem says why the line doesn't update the beverage, if it doesn't.
+	Problem string
+}
+
+func (l InvoiceLine) Bookable() bool { return l.Beverage != "" && l.Problem == "" }
+
+// Reprices reports whether the suggested price differs from the current one.
+func (l InvoiceLine) Reprices() bool {
+	return l.Bookable() && l.Suggested > 0 && differ(l.Suggested, l.Price)
+}
+
+// ReadInvoice reads the lines of a supplier invoice, either CSV with a
+// header or the text of a PDF, e.g. from pdftotext -layout.
+func ReadInvoice(r io.Reader, cfg SupplierConfig) ([]InvoiceLine, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	lines := textLines(data)
+	configured := map[string]string{"article": cfg.ArticleColumn, "description": cfg.DescriptionColumn,
+		"quantity": cfg.QuantityColumn, "price": cfg.PriceColumn}
+	for i, line := range lines {
+		cols := headerColumns(line, cfg.Delimiter, invoiceColumns, configured)
+		_, article := cols["article"]
+		_, description := cols["description"]
+		if _, price := cols["price"]; price && (article || description) {
+			return readInvoiceCSV(lines[i:], cols, cfg)
+		}
+	}
+	invoice := readInvoiceText(lines)
+	if len(invoice) == 0 {
+		return nil, errors.New("neither a CSV header nor table lines found")
+	}
+	return invoice, nil
+}
+
+func readInvoiceCSV(lines []string, cols map[string]int, cfg SupplierConfig) ([]InvoiceLine, error) {
+	cr := csv.NewReader(strings.NewReader(strings.Join(lines[1:], "\n")))
+	cr.Comma, cr.FieldsPerRecord, cr.LazyQuotes = []rune(cmp.Or(cfg.Delimiter, guessDelimiter(lines[0])))[0], -1, true
+	var invoice []InvoiceLine
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		if field("price") == "" || field("article")+field("description") == "" {
+			// Trailers like the invoice total have no article.
+			continue
+		}
+		l := InvoiceLine{Article: field("article"), Description: field("description"), Quantity: 1}
+		if l.UnitPrice, err = parseBankAmount(field("price")); err != nil {
+			return nil, err
+		}
+		if field("quantity") != "" {
+			if l.Quantity, err = parseBankAmount(field("quantity")); err != nil {
+				return nil, err
+			}
+		}
+		invoice = append(invoice, l)
+	}
+	return invoice, nil
+}
+
+// invoiceNumber is an amount at the end of a text table line, like "3",
+// "18,00", "1.234,56€" or "7%".
+var invoiceNumber = regexp.MustCompile(`^[-+]?\d{1,3}([.,]?\d{3})*([.,]\d{1,3})?(€|EUR|%)?$`)
+
+// readInvoiceText picks the table lines from the text of an invoice: those
+// ending in a quantity, a unit price and their total, possibly followed by
+// a tax rate or the like. What comes before the quantity is the article:
+// its number, if it starts with one after the position, and its
+// description.
+func readInvoiceText(lines []string) []InvoiceLine {
+	var invoice []InvoiceLine
+	for _, line := range lines {
+		words := strings.Fields(line)
+		// nums are the numbers the line ends in, at where they are in words.
+		var nums []float64
+		var at []int
+		end := len(words)
+		for end > 0 {
+			if w := words[end-1]; w == "€" || w == "EUR" || w == "%" {
+				end--
+				continue
+			}
+			if !invoiceNumber.MatchString(words[end-1]) {
+				break
+			}
+			v, err := parseBankAmount(words[end-1])
+			if err != nil {
+				break
+			}
+			end--
+			nums, at = append([]float64{v}, nums...), append([]int{end}, at...)
+		}
+		for i := len(nums) - 3; i >= 0; i-- {
+			qty, price, total := nums[i], nums[i+1], nums[i+2]
+			if qty <= 0 || qty != math.Trunc(qty) || differ(domain.RoundCents(qty*price), total) {
+				continue
+			}
+			desc := words[:at[i]]
+			if len(desc) == 0 {
+				break
+			}
+			l := InvoiceLine{Quantity: qty, UnitPrice: price}
+			if len(desc) > 2 && strings.Trim(desc[0], "0123456789.") == "" && strings.ContainsAny(desc[1], "0123456789") {
+				desc = desc[1:] // the position on the invoice
+			}
+			if strings.ContainsAny(desc[0], "0123456789") && len(desc) > 1 {
+				l.Article, desc = desc[0], desc[1:]
+			}
+			l.Description = strings.Join(desc, " ")
+			invoice = append(invoice, l)
+			break
+		}
+	}
+	return invoice
+}
+
+// MatchInvoice finds the beverage of each invoice line, by the configured
+// articles or else by the beverage's name, and works out its cost and the
+// price the margin suggests. taxRate is the tax rate of a beverage in
+// percent, for the suggested prices to include.
+func (s *Store) MatchInvoice(invoice []InvoiceLine, cfg SupplierConfig, taxRate func(domain.Beverage) float64) {
+	for i := range invoice {
+		l := &invoice[i]
+		a, ok := cfg.article(l.Article, l.Description)
+		if !ok {
+			var names []string
+			for _, b := range s.Beverages {
+				if strings.EqualFold(b.Name, l.Description) || containsWord(l.Description, b.Name) {
+					names = append(names, b.Key())
+				}
+			}
+			switch len(names) {
+			case 0:
+				l.Problem = "unknown article"
+				continue
+			case 1:
+				a = SupplierArticle{Beverage: names[0]}
+			default:
+				l.Problem = "matches " + strings.Join(names, ", ")
+				continue
+			}
+		}
+		j := s.BeverageIndex(a.Beverage)
+		if j < 0 {
+			l.Problem = fmt.Sprintf("unknown beverage %q", a.Beverage)
+			continue
+		}
+		b := s.Beverages[j]
+		l.Beverage, l.OldCost, l.Price = b.Key(), b.Cost, b.Price
+		switch {
+		case b.IsRecipe():
+			l.Problem = "made from a recipe"
+			continue
+		case l.UnitPrice <= 0:
+			l.Problem = "no price"
+			continue
+		}
+		l.Cost = domain.RoundCents(l.UnitPrice / cmp.Or(a.Items, 1))
+		if cfg.Margin > 0 {
+			l.Suggested = SuggestPrice(l.Cost, cfg.Margin, taxRate(b), cmp.Or(cfg.Round, 0.10))
+		}
+	}
+}
+
+// article looks an invoice line up in the configured articles: by its
+// number or description, or else by the longest one the line mentions.
+func (c SupplierConfig) article(number, description string) (SupplierArticle, bool) {
+	for _, name := range []string{number, description} {
+		for k, a := range c.Articles {
+			if name != "" && strings.EqualFold(k, name) {
+				return a, true
+			}
+		}
+	}
+	best := ""
+	for k := range c.Articles {
+		if len(k) > len(best) && containsWord(number+" "+description, k) {
+			best = k
+		}
+	}
+	a, ok := c.Articles[best]
+	return a, ok && best != ""
+}
+
+// containsWord reports whether s has word in it, not as part of a longer
+// word.
+func containsWord(s, word string) bool {
+	s, word = strings.ToLower(s), strings.ToLower(word)
+	if word == "" {
+		return false
+	}
+	for i := strings.Index(s, word); i >= 0; {
+		before, after := i == 0 || !isWordByte(s[i-1]), i+len(word) == len(s) || !isWordByte(s[i+len(word)])
+		if before && after {
+			return true
+		}
+		next := strings.Index(s[i+1:], word)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c >= 0x80
+}
+
+// SuggestPrice is the retail price, with tax, at which the bar keeps margin
+// percent of the net price over cost, rounded up to a multiple of step.
+func SuggestPrice(cost, margin, taxRate, step float64) float64 {
+	gross := cost / (1 - margin/100) * (1 + taxRate/100)
+	return domain.RoundCents(math.Ceil(domain.RoundCents(gross)/step-1e-9) * step)
+}
+
+// UpdateCosts sets the cost of the beverages of the bookable invoice lines
+// and, for those in reprice, their price to the suggested one. It returns
+// how many costs and prices it changed.
+func (s *Store) UpdateCosts(invoice []InvoiceLine, reprice map[string]bool) (costs, prices int, err error) {
+	err = s.Update(func() error {
+		costs, prices = 0, 0
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		if len(reprice) > 0 && s.Lockdown != LockdownNone {
+			return errPriceFreeze
+		}
+		for _, l := range invoice {
+			if !l.Bookable() {
+				continue
+			}
+			i := s.BeverageIndex(l.Beverage)
+			if i < 0 {
+				return fmt.Errorf("unknown beverage %q", l.Beverage)
+			}
+			b := &s.Beverages[i]
+			if old := b.Cost; differ(old, l.Cost) {
+				b.Cost = l.Cost
+				s.Audit(AuditEntry{Event: "cost", Beverage: b.Key(), From: &old, To: &l.Cost, Detail: l.Article})
+				costs++
+			}
+			if reprice[l.Beverage] && l.Reprices() && differ(b.Price, l.Suggested) {
+				old := b.Price
+				b.Price = l.Suggested
+				s.Audit(AuditEntry{Event: "price", Beverage: b.Key(), From: &old, To: &b.Price})
+				prices++
+			}
+		}
+		return nil
+	})
+	return costs, prices, err
+}
+
+func WriteInvoice(out io.Writer, invoice []InvoiceLine) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Article\tBeverage\tCost\tPrice\tSuggested\t")
+	for _, l := range invoice {
+		if !l.Bookable() {
+			fmt.Fprintf(w, "%s\t(%s)\t\t\t\t\n", invoiceArticle(l), l.Problem)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%s\t\n", invoiceArticle(l), l.Beverage, CostChange(l), l.Price, suggestion(l))
+	}
+	return w.Flush()
+}
+
+// invoiceArticle names the article of an invoice line.
+func invoiceArticle(l InvoiceLine) string {
+	return strings.TrimSpace(l.Article + " " + l.Description)
+}
+
+// CostChange shows the cost of an invoice line, with the old one if it
+// changes.
+func CostChange(l InvoiceLine) string {
+	if l.OldCost > 0 && differ(l.OldCost, l.Cost) {
+		return fmt.Sprintf("%.2f → %.2f", l.OldCost, l.Cost)
+	}
+	return fmt.Sprintf("%.2f", l.Cost)
+}
+
+func suggestion(l InvoiceLine) string {
+	if !l.Reprices() {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", l.Suggested)
+}
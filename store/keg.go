@@ -0,0 +1,66 @@
+package store
+
+import (
+	"math"
+	"time"
+)
+
+// --- KEGS ---
+
+type PourSize struct {
+	Name   string  `json:"name"`
+	Liters float64 `json:"liters"`
+	Price  float64 `json:"price"`
+}
+
+// Keg is the keg currently connected to a tap.
+type Keg struct {
+	Tap       string  `json:"tap"`
+	Beverage  string  `json:"beverage"`
+	Capacity  float64 `json:"capacity"`
+	Remaining float64 `json:"remaining"`
+}
+
+// Pour is a single measured pour from a tap.
+type Pour struct {
+	Time   time.Time `json:"time"`
+	Tap    string    `json:"tap"`
+	Liters float64   `json:"liters"`
+	Size   string    `json:"size"`
+	Member string    `json:"member,omitempty"`
+}
+
+func (s *Store) KegIndex(tap string) int {
+	for i, k := range s.Kegs {
+		if k.Tap == tap {
+			return i
+		}
+	}
+	return -1
+}
+
+// LowKegs returns the kegs at or below the alert threshold.
+func (s *Store) LowKegs(alertLiters float64) []Keg {
+	var low []Keg
+	for _, k := range s.Kegs {
+		if k.Remaining <= alertLiters {
+			low = append(low, k)
+		}
+	}
+	return low
+}
+
+// ClosestPourSize returns the configured size nearest to the measured
+// volume.
+func ClosestPourSize(sizes []PourSize, liters float64) (PourSize, bool) {
+	if len(sizes) == 0 {
+		return PourSize{}, false
+	}
+	best := sizes[0]
+	for _, size := range sizes[1:] {
+		if math.Abs(size.Liters-liters) < math.Abs(best.Liters-liters) {
+			best = size
+		}
+	}
+	return best, true
+}
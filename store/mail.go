@@ -0,0 +1,46 @@
+package store
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// --- MAIL ---
+
+// MailConfig is the SMTP server mail is sent through. The connection is
+// upgraded with STARTTLS where the server offers it.
+type MailConfig struct {
+	// Host is the server with its port, e.g. "smtp.example.org:587".
+	Host     string `json:"host,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from,omitempty"`
+}
+
+func (c MailConfig) Enabled() bool { return c.Host != "" && c.From != "" }
+
+// Send mails a plain text message to the given addresses.
+func (c MailConfig) Send(to []string, subject, body string, now time.Time) error {
+	if !c.Enabled() {
+		return fmt.Errorf("no mail server configured")
+	}
+	var auth smtp.Auth
+	if c.Username != "" {
+		host, _, err := net.SplitHostPort(c.Host)
+		if err != nil {
+			return fmt.Errorf("mail host: %w", err)
+		}
+		auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", c.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(c.Host, auth, c.From, to, []byte(msg.String()))
+}
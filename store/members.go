@@ -0,0 +1,182 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- MEMBERS ---
+
+var (
+	errTabLimit       = errors.New("tab limit reached")
+	errMemberInactive = errors.New("member is no longer active")
+)
+
+// LedgerTopUp and LedgerTransfer are the kinds of ledger entries.
+const (
+	LedgerTopUp    = "topup"
+	LedgerTransfer = "transfer"
+)
+
+func (s *Store) MemberIndex(id string) int {
+	for i, m := range s.Members {
+		if m.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// MemberByToken looks up the member carrying the given card token. The
+// cards of inactive members don't work.
+func (s *Store) MemberByToken(token string) (domain.Member, bool) {
+	for _, m := range s.Members {
+		if m.Token != "" && m.Token == token && !m.Inactive {
+			return m, true
+		}
+	}
+	return domain.Member{}, false
+}
+
+// tokenIndex returns the index of the member carrying token, active or
+// not, or -1.
+func (s *Store) tokenIndex(token string) int {
+	for i, m := range s.Members {
+		if m.Token != "" && m.Token == token {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) AddMember(m domain.Member) error {
+	if m.ID == "" {
+		return errors.New("member id must not be empty")
+	}
+	return s.Update(func() error {
+		if s.MemberIndex(m.ID) >= 0 {
+			return fmt.Errorf("member %q already exists", m.ID)
+		}
+		s.Members = append(s.Members, m)
+		s.Audit(AuditEntry{Event: "member", Member: m.ID, Detail: m.Name})
+		return nil
+	})
+}
+
+// TopUp adds credit to a member's tab.
+func (s *Store) TopUp(id string, amount float64) error {
+	return s.Update(func() error {
+		i := s.MemberIndex(id)
+		if i < 0 {
+			return fmt.Errorf("unknown member %q", id)
+		}
+		s.Members[i].Balance = domain.RoundCents(s.Members[i].Balance + amount)
+		s.Ledger = append(s.Ledger, domain.LedgerEntry{Time: time.Now(), Member: id, Kind: LedgerTopUp, Amount: amount})
+		s.Audit(AuditEntry{Event: "topup", Member: id, Amount: amount})
+		return nil
+	})
+}
+
+// Transfer moves credit from one member's tab to another's. The sender may
+// go into debt down to the tab limit, like when buying something.
+func (s *Store) Transfer(from, to string, amount float64, note string) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if from == to {
+		return fmt.Errorf("can't transfer to the same member")
+	}
+	return s.Update(func() error {
+		if err := s.CanCharge(from, amount, s.TabLimit); err != nil {
+			return err
+		}
+		j := s.MemberIndex(to)
+		if j < 0 {
+			return fmt.Errorf("unknown member %q", to)
+		}
+		i := s.MemberIndex(from)
+		s.Members[i].Balance = domain.RoundCents(s.Members[i].Balance - amount)
+		s.Members[j].Balance = domain.RoundCents(s.Members[j].Balance + amount)
+
+		id := 1
+		for _, e := range s.Ledger {
+			id = max(id, e.Transfer+1)
+		}
+		now := time.Now()
+		s.Ledger = append(s.Ledger,
+			domain.LedgerEntry{Time: now, Member: from, Kind: LedgerTransfer, Amount: -amount, Transfer: id, Counterparty: to, Note: note},
+			domain.LedgerEntry{Time: now, Member: to, Kind: LedgerTransfer, Amount: amount, Transfer: id, Counterparty: from, Note: note},
+		)
+		s.Audit(AuditEntry{Time: now, Event: "transfer", Member: from, Amount: amount, Detail: "to " + to})
+		return nil
+	})
+}
+
+// historyEntry is a line of a member's history: a ledger entry or a sale
+// charged to the tab.
+type historyEntry struct {
+	Time        time.Time
+	Amount      float64
+	Description string
+}
+
+// MemberHistory returns everything that changed the member's balance, oldest
+// first.
+func (s *Store) MemberHistory(id string) []historyEntry {
+	var history []historyEntry
+	for _, sale := range s.Sales {
+		if sale.Member != id {
+			continue
+		}
+		history = append(history, historyEntry{
+			Time:        sale.Time,
+			Amount:      -domain.RoundCents(sale.Total()),
+			Description: fmt.Sprintf("sale #%d: %s", sale.ID, sale.Items()),
+		})
+	}
+	for _, e := range s.Ledger {
+		if e.Member != id {
+			continue
+		}
+		desc := "top-up"
+		if e.Kind == LedgerTransfer {
+			if e.Amount < 0 {
+				desc = "transfer to " + e.Counterparty
+			} else {
+				desc = "transfer from " + e.Counterparty
+			}
+		}
+		if e.Note != "" {
+			desc += ": " + e.Note
+		}
+		history = append(history, historyEntry{Time: e.Time, Amount: e.Amount, Description: desc})
+	}
+	slices.SortStableFunc(history, func(a, b historyEntry) int { return a.Time.Compare(b.Time) })
+	return history
+}
+
+// CanCharge reports whether the member's tab can cover amount without going
+// past the tab limit. Guests have no limit, they only spend what they paid.
+func (s *Store) CanCharge(id string, amount float64, limit float64) error {
+	i := s.MemberIndex(id)
+	if i < 0 {
+		return fmt.Errorf("unknown member %q", id)
+	}
+	if s.Members[i].Inactive {
+		return errMemberInactive
+	}
+	if s.Members[i].IsGuest() {
+		if s.Members[i].Expired(time.Now()) {
+			return errGuestExpired
+		}
+		limit = 0
+	}
+	if domain.RoundCents(s.Members[i].Balance-amount) < -limit {
+		return errTabLimit
+	}
+	return nil
+}
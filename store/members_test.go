@@ -0,0 +1,149 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// withMembers is a store of the given members, with a tab limit of 10.00.
+func withMembers(t *testing.T, members ...domain.Member) *Store {
+	t.Helper()
+	s := openTestStore(t)
+	s.Configure(Options{TabLimit: 10})
+	if err := s.Update(func() error {
+		s.Members = members
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestTransfer(t *testing.T) {
+	s := withMembers(t,
+		domain.Member{ID: "alice", Name: "Alice", Balance: 5},
+		domain.Member{ID: "bob", Name: "Bob"},
+	)
+	if err := s.Transfer("alice", "bob", 3, "pizza"); err != nil {
+		t.Fatal(err)
+	}
+	// Down to the tab limit, the sender goes into debt.
+	if err := s.Transfer("alice", "bob", 12, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Transfer("bob", "alice", 1.50, ""); err != nil {
+		t.Fatal(err)
+	}
+	if s.Members[0].Balance != -8.50 || s.Members[1].Balance != 13.50 {
+		t.Errorf("balances %.2f and %.2f, want -8.50 and 13.50", s.Members[0].Balance, s.Members[1].Balance)
+	}
+
+	// Each transfer is a pair of entries of its own ID.
+	if len(s.Ledger) != 6 {
+		t.Fatalf("%d ledger entries, want 6", len(s.Ledger))
+	}
+	for i := 0; i < len(s.Ledger); i += 2 {
+		out, in := s.Ledger[i], s.Ledger[i+1]
+		if out.Transfer != i/2+1 || in.Transfer != out.Transfer {
+			t.Errorf("transfer %d is entries of transfers %d and %d", i/2+1, out.Transfer, in.Transfer)
+		}
+		if out.Kind != LedgerTransfer || in.Kind != LedgerTransfer || out.Amount != -in.Amount || out.Amount >= 0 ||
+			out.Counterparty != in.Member || in.Counterparty != out.Member || out.Note != in.Note {
+			t.Errorf("transfer %d is %+v and %+v, want one out and one in", out.Transfer, out, in)
+		}
+	}
+
+	want := map[string][]historyEntry{
+		"alice": {{Amount: -3, Description: "transfer to bob: pizza"}, {Amount: -12, Description: "transfer to bob"}, {Amount: 1.50, Description: "transfer from bob"}},
+		"bob":   {{Amount: 3, Description: "transfer from alice: pizza"}, {Amount: 12, Description: "transfer from alice"}, {Amount: -1.50, Description: "transfer to alice"}},
+	}
+	for id, want := range want {
+		history := s.MemberHistory(id)
+		if len(history) != len(want) {
+			t.Errorf("%s: history %+v, want %d entries", id, history, len(want))
+			continue
+		}
+		for i, w := range want {
+			if history[i].Amount != w.Amount || history[i].Description != w.Description {
+				t.Errorf("%s, entry %d: %.2f %q, want %.2f %q", id, i, history[i].Amount, history[i].Description, w.Amount, w.Description)
+			}
+		}
+	}
+}
+
+func TestTransferRefused(t *testing.T) {
+	now := time.Now()
+	s := withMembers(t,
+		domain.Member{ID: "alice", Name: "Alice", Balance: 5},
+		domain.Member{ID: "bob", Name: "Bob"},
+		domain.Member{ID: "carol", Name: "Carol", Balance: 20, Inactive: true},
+		domain.Member{ID: "camp-1", Balance: 20, Event: "camp", Expires: now.Add(-time.Hour)},
+		domain.Member{ID: "camp-2", Balance: 20, Event: "camp", Expires: now.Add(time.Hour)},
+	)
+	tests := []struct {
+		name     string
+		from, to string
+		amount   float64
+		err      error // the error, if it is one of the store's
+	}{
+		{"past the tab limit", "alice", "bob", 15.01, errTabLimit},
+		{"inactive sender", "carol", "bob", 1, errMemberInactive},
+		{"expired guest", "camp-1", "bob", 1, errGuestExpired},
+		// Guests only spend what they paid.
+		{"guest past the balance", "camp-2", "bob", 20.01, errTabLimit},
+		{"unknown sender", "mallory", "bob", 1, nil},
+		{"unknown recipient", "alice", "mallory", 1, nil},
+		{"same member", "alice", "alice", 1, nil},
+		{"nothing", "alice", "bob", 0, nil},
+		{"negative", "alice", "bob", -1, nil},
+	}
+	for _, tt := range tests {
+		err := s.Transfer(tt.from, tt.to, tt.amount, "")
+		if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	if len(s.Ledger) != 0 || s.Members[0].Balance != 5 || s.Members[1].Balance != 0 {
+		t.Errorf("%d ledger entries, balances %.2f and %.2f; want none, 5.00 and 0.00",
+			len(s.Ledger), s.Members[0].Balance, s.Members[1].Balance)
+	}
+
+	if err := s.Transfer("camp-2", "bob", 20, ""); err != nil {
+		t.Errorf("guest within the balance: %v", err)
+	}
+}
+
+func TestMemberHistory(t *testing.T) {
+	s := withMembers(t, domain.Member{ID: "alice", Name: "Alice"}, domain.Member{ID: "bob", Name: "Bob"})
+	day := time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local)
+	if err := s.Update(func() error {
+		s.Sales = []domain.Sale{
+			{ID: 1, Time: day.Add(time.Hour), Member: "alice", Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: 2, UnitPrice: 1.50}}},
+			{ID: 2, Time: day.Add(2 * time.Hour), Member: "bob", Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: 1, UnitPrice: 1.50}}},
+		}
+		s.Ledger = []domain.LedgerEntry{
+			{Time: day.Add(3 * time.Hour), Member: "alice", Kind: LedgerTopUp, Amount: 20},
+			{Time: day, Member: "alice", Kind: LedgerTopUp, Amount: 5, Note: "cash"},
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	history := s.MemberHistory("alice")
+	want := []historyEntry{
+		{Time: day, Amount: 5, Description: "top-up: cash"},
+		{Time: day.Add(time.Hour), Amount: -3, Description: "sale #1: 2x Club-Mate"},
+		{Time: day.Add(3 * time.Hour), Amount: 20, Description: "top-up"},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history %+v, want %d entries", history, len(want))
+	}
+	for i, w := range want {
+		if !history[i].Time.Equal(w.Time) || history[i].Amount != w.Amount || history[i].Description != w.Description {
+			t.Errorf("entry %d: %+v, want %+v", i, history[i], w)
+		}
+	}
+}
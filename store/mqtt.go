@@ -0,0 +1,214 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// --- MQTT ---
+
+// MQTTConfig points at an MQTT broker.
+type MQTTConfig struct {
+	Broker   string `json:"broker,omitempty"` // host:port
+	ClientID string `json:"client_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// MQTTClient is a minimal MQTT 3.1.1 client: QoS 0 publish and subscribe,
+// which is all the integrations need.
+type MQTTClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex // serializes writes
+	done chan struct{}
+}
+
+const (
+	mqttConnect   = 1
+	mqttConnack   = 2
+	mqttPublish   = 3
+	mqttSubscribe = 8
+	mqttSuback    = 9
+	mqttPingreq   = 12
+	mqttPingresp  = 13
+
+	mqttKeepAlive = 60 * time.Second
+)
+
+func DialMQTT(cfg MQTTConfig) (*MQTTClient, error) {
+	conn, err := net.DialTimeout("tcp", cfg.Broker, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c := &MQTTClient{conn: conn, r: bufio.NewReader(conn), done: make(chan struct{})}
+
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("bubbletender-%d", time.Now().UnixNano()%1e6)
+	}
+	flags := byte(0x02) // clean session
+	payload := mqttString(clientID)
+	if cfg.Username != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(cfg.Username)...)
+		if cfg.Password != "" {
+			flags |= 0x40
+			payload = append(payload, mqttString(cfg.Password)...)
+		}
+	}
+	body := append(mqttString("MQTT"), 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(mqttKeepAlive/time.Second))
+	body = append(body, payload...)
+	if err := c.write(mqttConnect<<4, body); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	kind, ack, err := c.read()
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if kind != mqttConnack || len(ack) != 2 || ack[1] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt broker refused connection (code %v)", ack)
+	}
+
+	go c.keepAlive()
+	return c, nil
+}
+
+func (c *MQTTClient) keepAlive() {
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.write(mqttPingreq<<4, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *MQTTClient) Close() error {
+	close(c.done)
+	return c.conn.Close()
+}
+
+// Publish sends payload to topic with QoS 0.
+func (c *MQTTClient) Publish(topic string, payload []byte, retain bool) error {
+	header := byte(mqttPublish << 4)
+	if retain {
+		header |= 0x01
+	}
+	return c.write(header, append(mqttString(topic), payload...))
+}
+
+// Subscribe subscribes to the topic filter and calls handle for every
+// message until the connection drops. It blocks.
+func (c *MQTTClient) Subscribe(filter string, handle func(topic string, payload []byte)) error {
+	body := binary.BigEndian.AppendUint16(nil, 1) // packet id
+	body = append(body, mqttString(filter)...)
+	body = append(body, 0) // QoS 0
+	if err := c.write(mqttSubscribe<<4|0x02, body); err != nil {
+		return err
+	}
+	for {
+		kind, data, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case mqttSuback:
+			if len(data) >= 3 && data[2] == 0x80 {
+				return fmt.Errorf("mqtt broker rejected subscription to %q", filter)
+			}
+		case mqttPublish:
+			if len(data) < 2 {
+				continue
+			}
+			n := int(binary.BigEndian.Uint16(data))
+			if len(data) < 2+n {
+				continue
+			}
+			handle(string(data[2:2+n]), data[2+n:])
+		case mqttPingresp:
+		}
+	}
+}
+
+func (c *MQTTClient) write(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = appendRemainingLength(packet, len(body))
+	packet = append(packet, body...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// read returns the type and body of the next packet. For PUBLISH packets
+// with QoS > 0 the packet id is left in the body, but we only subscribe
+// with QoS 0 so brokers won't send those.
+func (c *MQTTClient) read() (byte, []byte, error) {
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, err := readRemainingLength(c.r)
+	if err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+func mqttString(s string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(s)))
+	return append(b, s...)
+}
+
+func appendRemainingLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+func readRemainingLength(r io.ByteReader) (int, error) {
+	n, mult := 0, 1
+	for i := 0; i < 4; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n += int(digit&0x7f) * mult
+		if digit&0x80 == 0 {
+			return n, nil
+		}
+		mult *= 128
+	}
+	return 0, errors.New("malformed mqtt remaining length")
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- PRETIX ---
+
+// PretixConfig imports drink vouchers sold with pretix tickets as prepaid
+// credit. Every ticket with vouchers becomes a guest of Event (see Member)
+// whose token is the ticket's QR code secret, so scanning the ticket at the
+// bar pays from its credit.
+type PretixConfig struct {
+	// URL is the pretix instance, e.g. https://pretix.eu.
+	URL       string `json:"url,omitempty"`
+	Token     string `json:"token,omitempty"` // API token
+	Organizer string `json:"organizer,omitempty"`
+	Event     string `json:"event,omitempty"`
+	// Vouchers maps the IDs of voucher products, usually add-ons to the
+	// ticket, to the credit each one is worth.
+	Vouchers map[string]float64 `json:"vouchers,omitempty"`
+}
+
+func (c PretixConfig) Enabled() bool { return c.URL != "" && c.Event != "" }
+
+type PretixOrder struct {
+	Code      string           `json:"code"`
+	Status    string           `json:"status"` // "p" once paid
+	Positions []pretixPosition `json:"positions"`
+}
+
+type pretixPosition struct {
+	ID           int    `json:"id"`
+	PositionID   int    `json:"positionid"`
+	Item         int    `json:"item"`
+	AddonTo      *int   `json:"addon_to"` // ID of the ticket position
+	Secret       string `json:"secret"`
+	AttendeeName string `json:"attendee_name"`
+	Canceled     bool   `json:"canceled"`
+}
+
+var pretixClient = &http.Client{Timeout: 30 * time.Second}
+
+func (c PretixConfig) get(path string, v any) error {
+	u := path
+	if !strings.HasPrefix(path, "http") {
+		u = fmt.Sprintf("%s/api/v1/organizers/%s/events/%s/%s", strings.TrimSuffix(c.URL, "/"),
+			url.PathEscape(c.Organizer), url.PathEscape(c.Event), path)
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+c.Token)
+	resp, err := pretixClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pretix: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// paidOrders fetches every paid order of the event.
+func (c PretixConfig) paidOrders() ([]PretixOrder, error) {
+	var orders []PretixOrder
+	next := "orders/?status=p"
+	for next != "" {
+		var page struct {
+			Next    *string       `json:"next"`
+			Results []PretixOrder `json:"results"`
+		}
+		if err := c.get(next, &page); err != nil {
+			return nil, err
+		}
+		orders = append(orders, page.Results...)
+		next = ""
+		if page.Next != nil {
+			next = *page.Next
+		}
+	}
+	return orders, nil
+}
+
+func (c PretixConfig) Order(code string) (PretixOrder, error) {
+	var o PretixOrder
+	err := c.get("orders/"+url.PathEscape(code)+"/", &o)
+	return o, err
+}
+
+// ImportPretixOrder credits the vouchers of a paid order to their tickets
+// and returns the credit added. Every voucher is credited only once, so
+// orders can be imported again and again.
+func (s *Store) ImportPretixOrder(cfg PretixConfig, o PretixOrder) (float64, error) {
+	if o.Status != "p" {
+		return 0, nil
+	}
+	var added float64
+	err := s.Update(func() error {
+		added = 0
+		positions := map[int]pretixPosition{}
+		for _, p := range o.Positions {
+			positions[p.ID] = p
+		}
+		now := time.Now()
+		for _, p := range o.Positions {
+			credit := cfg.Vouchers[strconv.Itoa(p.Item)]
+			if credit <= 0 || p.Canceled {
+				continue
+			}
+			note := fmt.Sprintf("pretix %s-%d", o.Code, p.PositionID)
+			if s.hasLedgerNote(note) {
+				continue
+			}
+			ticket := p
+			if p.AddonTo != nil {
+				if t, ok := positions[*p.AddonTo]; ok {
+					ticket = t
+				}
+			}
+			if ticket.Secret == "" {
+				continue
+			}
+			// A ticket that was deactivated keeps its guest rather than
+			// getting another one.
+			i := s.tokenIndex(ticket.Secret)
+			if i < 0 {
+				guest := domain.Member{
+					ID:    fmt.Sprintf("pretix-%s-%d", o.Code, ticket.PositionID),
+					Name:  cmp.Or(ticket.AttendeeName, "Ticket "+o.Code),
+					Token: ticket.Secret,
+					Event: cfg.Event,
+				}
+				s.Members = append(s.Members, guest)
+				s.Audit(AuditEntry{Time: now, Event: "member", Member: guest.ID, Detail: guest.Name})
+				i = len(s.Members) - 1
+			}
+			guest := &s.Members[i]
+			guest.Balance = domain.RoundCents(guest.Balance + credit)
+			s.Ledger = append(s.Ledger, domain.LedgerEntry{Time: now, Member: guest.ID, Kind: LedgerTopUp, Amount: credit, Note: note})
+			s.Audit(AuditEntry{Time: now, Event: "topup", Member: guest.ID, Amount: credit, Detail: note})
+			added += credit
+		}
+		return nil
+	})
+	return added, err
+}
+
+func (s *Store) hasLedgerNote(note string) bool {
+	for _, e := range s.Ledger {
+		if e.Note == note {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportPretix imports all paid orders and returns the credit added.
+func (s *Store) ImportPretix(cfg PretixConfig) (float64, error) {
+	orders, err := cfg.paidOrders()
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, o := range orders {
+		added, err := s.ImportPretixOrder(cfg, o)
+		if err != nil {
+			return total, fmt.Errorf("order %s: %w", o.Code, err)
+		}
+		total += added
+	}
+	return total, nil
+}
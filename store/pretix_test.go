@@ -0,0 +1,159 @@
+package store
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pretixServer serves the paid orders of event "camp" of organizer "ccc",
+// split over two pages, and each order on its own.
+func pretixServer(t *testing.T, orders func() []PretixOrder) PretixConfig {
+	t.Helper()
+	var srv *httptest.Server
+	write := func(w http.ResponseWriter, r *http.Request, v any) {
+		if r.Header.Get("Authorization") != "Token secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	}
+	base := "/api/v1/organizers/ccc/events/camp/orders/"
+	mux := http.NewServeMux()
+	mux.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("status") != "p" {
+			t.Errorf("orders requested with status %q, want p", r.URL.Query().Get("status"))
+		}
+		var paid []PretixOrder
+		for _, o := range orders() {
+			if o.Status == "p" {
+				paid = append(paid, o)
+			}
+		}
+		half := len(paid) / 2
+		if r.URL.Query().Get("page") == "2" {
+			write(w, r, map[string]any{"next": nil, "results": paid[half:]})
+			return
+		}
+		write(w, r, map[string]any{"next": srv.URL + base + "?status=p&page=2", "results": paid[:half]})
+	})
+	mux.HandleFunc(base+"{code}/", func(w http.ResponseWriter, r *http.Request) {
+		for _, o := range orders() {
+			if o.Code == r.PathValue("code") {
+				write(w, r, o)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return PretixConfig{URL: srv.URL + "/", Token: "secret", Organizer: "ccc", Event: "camp",
+		Vouchers: map[string]float64{"20": 5, "21": 10}}
+}
+
+func TestImportPretix(t *testing.T) {
+	ticket := func(id int) *int { return &id }
+	orders := []PretixOrder{
+		{Code: "AAAAA", Status: "p", Positions: []pretixPosition{
+			{ID: 1, PositionID: 1, Item: 10, Secret: "s-alice", AttendeeName: "Alice"},
+			// Add-on vouchers go onto the ticket they belong to.
+			{ID: 2, PositionID: 2, Item: 20, AddonTo: ticket(1)},
+			{ID: 3, PositionID: 3, Item: 20, AddonTo: ticket(1)},
+			{ID: 4, PositionID: 4, Item: 20, AddonTo: ticket(1), Canceled: true},
+		}},
+		// A voucher sold as a ticket of its own.
+		{Code: "BBBBB", Status: "p", Positions: []pretixPosition{
+			{ID: 5, PositionID: 1, Item: 21, Secret: "s-bob"},
+		}},
+		{Code: "CCCCC", Status: "n", Positions: []pretixPosition{
+			{ID: 6, PositionID: 1, Item: 21, Secret: "s-carol"},
+		}},
+		{Code: "DDDDD", Status: "p", Positions: []pretixPosition{
+			{ID: 7, PositionID: 1, Item: 10, Secret: "s-dave"},
+			{ID: 8, PositionID: 2, Item: 10, Secret: "s-erin", AttendeeName: "Erin"},
+			{ID: 9, PositionID: 3, Item: 20, AddonTo: ticket(8)},
+		}},
+	}
+	cfg := pretixServer(t, func() []PretixOrder { return orders })
+	s := openTestStore(t)
+
+	added, err := s.ImportPretix(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 25 {
+		t.Errorf("added %.2f, want 25.00", added)
+	}
+	want := []struct {
+		id, name, token string
+		balance         float64
+	}{
+		{"pretix-AAAAA-1", "Alice", "s-alice", 10},
+		{"pretix-BBBBB-1", "Ticket BBBBB", "s-bob", 10},
+		{"pretix-DDDDD-2", "Erin", "s-erin", 5},
+	}
+	check := func(what string) {
+		t.Helper()
+		if len(s.Members) != len(want) {
+			t.Fatalf("%s: members %+v, want %d", what, s.Members, len(want))
+		}
+		for i, w := range want {
+			m := s.Members[i]
+			if m.ID != w.id || m.Name != w.name || m.Token != w.token || m.Balance != w.balance || m.Event != "camp" {
+				t.Errorf("%s: member %+v, want %s (%s) of camp with %.2f", what, m, w.id, w.name, w.balance)
+			}
+		}
+	}
+	check("first import")
+	if len(s.Ledger) != 4 || s.Ledger[0].Note != "pretix AAAAA-2" || s.Ledger[1].Note != "pretix AAAAA-3" {
+		t.Errorf("ledger %+v, want an entry for every voucher", s.Ledger)
+	}
+
+	// An unpaid order adds nothing until it is paid.
+	if added, err := s.ImportPretixOrder(cfg, orders[2]); err != nil || added != 0 {
+		t.Errorf("unpaid order: added %.2f, %v", added, err)
+	}
+
+	// Importing again, all of it or a single order, credits nothing twice,
+	// also for a ticket whose guest has been deactivated since.
+	if err := s.Update(func() error {
+		s.Members[0].Inactive = true
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if added, err := s.ImportPretix(cfg); err != nil || added != 0 {
+		t.Errorf("second import: added %.2f, %v", added, err)
+	}
+	o, err := cfg.Order("AAAAA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, err := s.ImportPretixOrder(cfg, o); err != nil || added != 0 {
+		t.Errorf("importing order AAAAA again: added %.2f, %v", added, err)
+	}
+	check("imported again")
+
+	// A voucher bought later goes onto the same guest, active or not.
+	orders[0].Positions = append(orders[0].Positions, pretixPosition{ID: 10, PositionID: 5, Item: 20, AddonTo: ticket(1)})
+	orders[2].Status = "p"
+	if added, err := s.ImportPretix(cfg); err != nil || added != 15 {
+		t.Errorf("import after changes: added %.2f, %v; want 15.00", added, err)
+	}
+	want[0].balance = 15
+	want = append(want, struct {
+		id, name, token string
+		balance         float64
+	}{"pretix-CCCCC-1", "Ticket CCCCC", "s-carol", 10})
+	check("import after changes")
+	if len(s.Ledger) != 6 {
+		t.Errorf("%d ledger entries, want 6", len(s.Ledger))
+	}
+
+	cfg.Token = "wrong"
+	if _, err := s.ImportPretix(cfg); err == nil {
+		t.Error("import with a wrong token: no error")
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- ORDER QUEUE ---
+
+// QueueConfig queues what is sold for the bar to prepare, for events where
+// drinks are mixed to order. The queue terminal shows the open orders and
+// marks them in progress and done.
+type QueueConfig struct {
+	Enabled bool `json:"enabled"`
+	// Categories limits the queue to lines of these categories, e.g.
+	// "cocktails", leaving out what is handed over at the till. Without
+	// them, every line is queued.
+	Categories []string `json:"categories,omitempty"`
+}
+
+// OrderStatus is how far an order has got.
+type OrderStatus string
+
+const (
+	OrderQueued     OrderStatus = "queued"
+	OrderInProgress OrderStatus = "in_progress"
+	OrderDone       OrderStatus = "done"
+)
+
+// Next is the status an order moves on to, or "" once it is done.
+func (s OrderStatus) Next() OrderStatus {
+	switch s {
+	case OrderQueued:
+		return OrderInProgress
+	case OrderInProgress:
+		return OrderDone
+	}
+	return ""
+}
+
+// Previous is the status an order goes back to, or "" while it is queued.
+func (s OrderStatus) Previous() OrderStatus {
+	switch s {
+	case OrderInProgress:
+		return OrderQueued
+	case OrderDone:
+		return OrderInProgress
+	}
+	return ""
+}
+
+// Order is a sale waiting to be prepared. It goes by the number of its
+// sale, which the customer is called by.
+type Order struct {
+	Sale    int         `json:"sale"`
+	Time    time.Time   `json:"time"`
+	Items   []OrderItem `json:"items"`
+	Member  string      `json:"member,omitempty"`
+	Status  OrderStatus `json:"status"`
+	Updated time.Time   `json:"updated"`
+}
+
+type OrderItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// orderRetention is how long done orders stay in the store, for the queue
+// terminal to show what was just handed out.
+const orderRetention = 24 * time.Hour
+
+var errNoOrder = errors.New("no such order")
+
+// queueSale puts what sale needs prepared into the queue. Voids and lines
+// that aren't beverages, like vouchers, need nothing prepared. Done orders
+// older than orderRetention go meanwhile.
+func (s *Store) queueSale(sale domain.Sale) {
+	if !s.queue.Enabled || sale.Voids != 0 {
+		return
+	}
+	s.Orders = slices.DeleteFunc(s.Orders, func(o Order) bool {
+		return o.Status == OrderDone && sale.Time.Sub(o.Updated) > orderRetention
+	})
+	order := Order{Sale: sale.ID, Time: sale.Time, Member: sale.Member, Status: OrderQueued, Updated: sale.Time}
+	for _, l := range sale.Lines {
+		if l.Untracked {
+			continue
+		}
+		if len(s.queue.Categories) == 0 || slices.Contains(s.queue.Categories, l.Category) {
+			order.Items = append(order.Items, OrderItem{Name: l.Name, Quantity: l.Quantity})
+		}
+	}
+	if len(order.Items) > 0 {
+		s.Orders = append(s.Orders, order)
+	}
+}
+
+// OrderIndex returns the index of the order of sale, or -1.
+func (s *Store) OrderIndex(sale int) int {
+	return slices.IndexFunc(s.Orders, func(o Order) bool { return o.Sale == sale })
+}
+
+// SetOrderStatus moves the order of sale on to status.
+func (s *Store) SetOrderStatus(sale int, status OrderStatus, now time.Time) error {
+	switch status {
+	case OrderQueued, OrderInProgress, OrderDone:
+	default:
+		return fmt.Errorf("unknown order status %q", status)
+	}
+	if s.Remote != nil {
+		path := "/orders/" + strconv.Itoa(sale) + "?status=" + string(status)
+		if _, err := s.Remote.Do(http.MethodPost, path, nil); err != nil {
+			return err
+		}
+		return s.Reload()
+	}
+	return s.Update(func() error {
+		i := s.OrderIndex(sale)
+		if i < 0 {
+			return errNoOrder
+		}
+		s.Orders[i].Status, s.Orders[i].Updated = status, now
+		return nil
+	})
+}
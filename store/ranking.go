@@ -0,0 +1,50 @@
+package store
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- TOP SELLERS AND SLOW MOVERS ---
+
+// BeverageRank is how a beverage sold over a period.
+type BeverageRank struct {
+	Name    string
+	Units   int
+	Revenue float64
+}
+
+// RankBeverages ranks the beverages by units sold, then by revenue. Every
+// beverage of the inventory is ranked, even if it didn't sell at all, and
+// so are beverages that sold but have since been dropped.
+func RankBeverages(beverages []domain.Beverage, sales []domain.Sale) []BeverageRank {
+	var ranks []BeverageRank
+	byName := map[string]int{}
+	add := func(name string) int {
+		i, ok := byName[name]
+		if !ok {
+			i = len(ranks)
+			byName[name] = i
+			ranks = append(ranks, BeverageRank{Name: name})
+		}
+		return i
+	}
+	for _, b := range beverages {
+		add(b.Key())
+	}
+	for _, sale := range sales {
+		for _, l := range sale.Lines {
+			i := add(l.Name)
+			ranks[i].Units += l.Quantity
+			if !l.NonRevenue {
+				ranks[i].Revenue += l.Gross()
+			}
+		}
+	}
+	slices.SortStableFunc(ranks, func(a, b BeverageRank) int {
+		return cmp.Or(cmp.Compare(b.Units, a.Units), cmp.Compare(b.Revenue, a.Revenue), cmp.Compare(a.Name, b.Name))
+	})
+	return ranks
+}
@@ -0,0 +1,256 @@
+package store
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- RELEASES ---
+
+// DefaultReleaseURL is where the latest release is looked up, in the
+// format of GitHub's releases API.
+const DefaultReleaseURL = "https://api.github.com/repos/arunoruto/BubbleTender/releases/latest"
+
+// BuildVersion is the version of this build. Release builds set it with
+//
+//	-ldflags "-X github.com/arunoruto/BubbleTender/store.BuildVersion=v1.2.0"
+//
+// without that, it is the module version go install was given, if any.
+var BuildVersion string
+
+// CurrentVersion is the version of this build, "dev" if it has none.
+func CurrentVersion() string {
+	if BuildVersion != "" {
+		return BuildVersion
+	}
+	// Builds from a checkout have a pseudo-version, which isn't a release.
+	if info, ok := debug.ReadBuildInfo(); ok && !pseudoVersion.MatchString(info.Main.Version) &&
+		info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// pseudoVersion matches the versions Go makes up for commits, like
+// v0.0.0-20261014151305-bf4bf37bd162.
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}(\+dirty)?$`)
+
+// Release is a published version of BubbleTender.
+type Release struct {
+	Version string         `json:"tag_name"`
+	Notes   string         `json:"body"`
+	URL     string         `json:"html_url"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+var releaseClient = &http.Client{Timeout: 30 * time.Second}
+
+// LatestRelease looks up the latest release at url, DefaultReleaseURL if
+// empty.
+func LatestRelease(url string) (Release, error) {
+	if url == "" {
+		url = DefaultReleaseURL
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := releaseClient.Do(req)
+	if err != nil {
+		return Release{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("looking up the latest release: %s", resp.Status)
+	}
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return Release{}, fmt.Errorf("looking up the latest release: %w", err)
+	}
+	return r, nil
+}
+
+// Summary is the first line of the release notes, without its markdown.
+func (r Release) Summary() string {
+	for _, line := range strings.Split(r.Notes, "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#*-+ ")); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// NewerThan reports whether the release is newer than version. Builds
+// without a version, like ones from a checkout, are never out of date.
+func (r Release) NewerThan(version string) bool {
+	have, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+	got, ok := parseVersion(r.Version)
+	if !ok {
+		return false
+	}
+	for i := range got {
+		if got[i] != have[i] {
+			return got[i] > have[i]
+		}
+	}
+	return false
+}
+
+// parseVersion reads versions like "v1.2.3", ignoring anything after a
+// pre-release or build suffix.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	v, _, _ = strings.Cut(v, "+")
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// asset finds the build of the release for this system: a binary or a
+// .tar.gz named after the OS and architecture, like
+// bubbletender_linux_arm64.tar.gz.
+func (r Release) asset() (ReleaseAsset, bool) {
+	for _, a := range r.Assets {
+		name := strings.ToLower(a.Name)
+		if strings.Contains(name, runtime.GOOS) && strings.Contains(name, runtime.GOARCH) &&
+			!strings.HasSuffix(name, ".sha256") && !strings.HasSuffix(name, ".txt") && !strings.HasSuffix(name, ".zip") {
+			return a, true
+		}
+	}
+	return ReleaseAsset{}, false
+}
+
+// checksum is the SHA-256 the release's checksum file lists for name.
+func (r Release) checksum(name string) (string, error) {
+	for _, a := range r.Assets {
+		if n := strings.ToLower(a.Name); n != "checksums.txt" && n != "sha256sums" && !strings.HasSuffix(n, "_checksums.txt") {
+			continue
+		}
+		body, err := download(a.URL)
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		sc := bufio.NewScanner(body)
+		for sc.Scan() {
+			if sum, file, ok := strings.Cut(sc.Text(), " "); ok && strings.TrimLeft(file, " *") == name {
+				return sum, nil
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%s lists no checksum for %s", a.Name, name)
+	}
+	return "", fmt.Errorf("release %s has no checksum file to check %s against", r.Version, name)
+}
+
+func download(url string) (io.ReadCloser, error) {
+	resp, err := releaseClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Install replaces the executable at exe with the release's build for
+// this system, once it matches the SHA-256 in the release's checksum file;
+// a release without one isn't installed. The processes running the old one
+// keep doing so until they are restarted.
+func (r Release) Install(exe string) error {
+	a, ok := r.asset()
+	if !ok {
+		return fmt.Errorf("release %s has no build for %s/%s", r.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	want, err := r.checksum(a.Name)
+	if err != nil {
+		return err
+	}
+	body, err := download(a.URL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != strings.ToLower(want) {
+		return fmt.Errorf("%s doesn't match its checksum", a.Name)
+	}
+	if strings.HasSuffix(a.Name, ".tar.gz") || strings.HasSuffix(a.Name, ".tgz") {
+		if data, err = binaryFromTar(data); err != nil {
+			return fmt.Errorf("%s: %w", a.Name, err)
+		}
+	}
+	tmp := filepath.Join(filepath.Dir(exe), "."+filepath.Base(exe)+".new")
+	if err := os.WriteFile(tmp, data, 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// binaryFromTar picks the bubbletender executable from a gzipped tarball.
+func binaryFromTar(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("no bubbletender executable in it")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if name := strings.TrimSuffix(filepath.Base(h.Name), ".exe"); h.Typeflag == tar.TypeReg && strings.EqualFold(name, "bubbletender") {
+			return io.ReadAll(tr)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    [3]int
+		ok      bool
+	}{
+		{"v1.2.3", [3]int{1, 2, 3}, true},
+		{"1.2.3", [3]int{1, 2, 3}, true},
+		{"v1.2", [3]int{1, 2, 0}, true},
+		{"v2", [3]int{2, 0, 0}, true},
+		// Pre-release and build suffixes are ignored.
+		{"v1.3.0-rc.1", [3]int{1, 3, 0}, true},
+		{"v1.3.0+linux", [3]int{1, 3, 0}, true},
+		{"v0.0.0-20261014151305-bf4bf37bd162", [3]int{0, 0, 0}, true},
+		{"dev", [3]int{}, false},
+		{"", [3]int{}, false},
+		{"v1.2.3.4", [3]int{}, false},
+		{"v1.x", [3]int{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVersion(tt.version)
+		if ok != tt.ok || ok && got != tt.want {
+			t.Errorf("parseVersion(%q) = %v, %t, want %v, %t", tt.version, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReleaseNewerThan(t *testing.T) {
+	tests := []struct {
+		release, current string
+		want             bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0.0", "v1.99.99", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.3.0", false},
+		{"v1.2", "v1.2.0", false},
+		// A release candidate of the installed version isn't newer.
+		{"v1.2.0-rc.1", "v1.2.0", false},
+		// Builds without a version are never out of date.
+		{"v1.2.0", "dev", false},
+		{"v1.2.0", "", false},
+		{"nightly", "v1.2.0", false},
+	}
+	for _, tt := range tests {
+		if got := (Release{Version: tt.release}).NewerThan(tt.current); got != tt.want {
+			t.Errorf("%s.NewerThan(%q) = %t, want %t", tt.release, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestPseudoVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"v0.0.0-20261014151305-bf4bf37bd162", true},
+		{"v1.2.4-0.20261014151305-bf4bf37bd162", true},
+		{"v0.0.0-20261014151305-bf4bf37bd162+dirty", true},
+		{"v1.2.3", false},
+		{"v1.3.0-rc.1", false},
+		{"(devel)", false},
+	}
+	for _, tt := range tests {
+		if got := pseudoVersion.MatchString(tt.version); got != tt.want {
+			t.Errorf("pseudoVersion matches %q = %t, want %t", tt.version, got, tt.want)
+		}
+	}
+}
+
+// releaseServer serves a release whose build for this system is binary,
+// with a checksum file listing sum for it unless sum is empty.
+func releaseServer(t *testing.T, binary []byte, sum string) Release {
+	t.Helper()
+	name := "bubbletender_" + runtime.GOOS + "_" + runtime.GOARCH
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	mux.HandleFunc("/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789abcdef  bubbletender_plan9_mips\n" + sum + "  " + name + "\n"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	r := Release{Version: "v1.2.0", Assets: []ReleaseAsset{{Name: name, URL: srv.URL + "/" + name}}}
+	if sum != "" {
+		r.Assets = append(r.Assets, ReleaseAsset{Name: "checksums.txt", URL: srv.URL + "/checksums.txt"})
+	}
+	return r
+}
+
+func TestReleaseInstall(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho v1.2.0\n")
+	sum := sha256.Sum256(binary)
+	tests := []struct {
+		name string
+		sum  string
+		err  string // what the error says, "" if it installs
+	}{
+		{"matching checksum", hex.EncodeToString(sum[:]), ""},
+		{"upper-case checksum", strings.ToUpper(hex.EncodeToString(sum[:])), ""},
+		{"wrong checksum", strings.Repeat("0", 64), "doesn't match its checksum"},
+		{"no checksum file", "", "has no checksum file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exe := filepath.Join(t.TempDir(), "bubbletender")
+			if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			err := releaseServer(t, binary, tt.sum).Install(exe)
+			got, _ := os.ReadFile(exe)
+			if tt.err == "" {
+				if err != nil || string(got) != string(binary) {
+					t.Fatalf("Install() = %v, executable is %q", err, got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Install() = %v, want an error saying %q", err, tt.err)
+			}
+			if string(got) != "old" {
+				t.Errorf("the executable was replaced with %q", got)
+			}
+		})
+	}
+}
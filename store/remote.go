@@ -0,0 +1,135 @@
+package store
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- CLIENT/SERVER ---
+
+// ServerConfig connects several terminals to one store. The server owns
+// the store file; terminals with URL set book their sales through it and
+// see each other's changes right away.
+type ServerConfig struct {
+	// Listen is the address the serve command listens on.
+	Listen string `json:"listen,omitempty"`
+	// URL makes the TUI a client of the server at that address.
+	URL string `json:"url,omitempty"`
+	// Token, if set, has to be sent by clients as a bearer token.
+	Token string `json:"token,omitempty"`
+	// Till names the session of this terminal's cart for customer
+	// displays, see TillName.
+	Till string `json:"till,omitempty"`
+}
+
+var errRemoteStore = errors.New("this can only be done on the server")
+
+// EventsTimeout is how long the server holds a /events request open before
+// telling the client nothing changed.
+const EventsTimeout = 30 * time.Second
+
+// Version identifies a state of the store file, so that changes made
+// by any process sharing it, not just by the server, are noticed.
+func Version(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatInt(info.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36)
+}
+
+// RemoteStore is the connection of a client terminal to the server.
+type RemoteStore struct {
+	url    string
+	token  string
+	client *http.Client
+	// Actor is sent along for the server's audit log.
+	Actor string
+}
+
+// OpenRemote connects to the server and loads its store.
+func OpenRemote(cfg ServerConfig, tabLimit float64) (*Store, error) {
+	s := &Store{
+		TabLimit: tabLimit,
+		Remote: &RemoteStore{
+			url:   strings.TrimSuffix(cfg.URL, "/"),
+			token: cfg.Token,
+			// Long enough for a /events request the server holds open.
+			client: &http.Client{Timeout: EventsTimeout + 10*time.Second},
+		},
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Do sends a request and returns the response body. Errors the server
+// reports come back with its message.
+func (r *RemoteStore) Do(method, path string, body any) ([]byte, error) {
+	return r.request(method, path, body, nil)
+}
+
+// remoteAttempts is how often postOnce tries to reach the server.
+const remoteAttempts = 3
+
+// postOnce posts body under an idempotency key of its own, retrying while
+// the server can't be reached. The server books it once however many of
+// the attempts got through.
+func (r *RemoteStore) postOnce(path string, body any) ([]byte, error) {
+	header := http.Header{IdempotencyHeader: {rand.Text()}}
+	for attempt := 1; ; attempt++ {
+		data, err := r.request(http.MethodPost, path, body, header)
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) || attempt == remoteAttempts {
+			return data, err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (r *RemoteStore) request(method, path string, body any, header http.Header) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, r.url+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	if r.Actor != "" {
+		req.Header.Set(ActorHeader, r.Actor)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, errors.New(strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- RESTOCK ---
+
+// Restock is a delivery booked into the inventory. Quantity is in the
+// beverage's stock unit; Cost is the total purchase price, if known.
+// BestBefore is the date the delivery keeps until, if it has one, see
+// Batches.
+type Restock struct {
+	Time       time.Time   `json:"time"`
+	Beverage   string      `json:"beverage"`
+	Quantity   float64     `json:"quantity"`
+	Unit       domain.Unit `json:"unit,omitempty"`
+	Cost       float64     `json:"cost,omitempty"`
+	BestBefore time.Time   `json:"best_before,omitzero"`
+}
+
+// Restock adds a delivery to the stock and logs it.
+func (s *Store) Restock(r Restock) error {
+	if r.Quantity <= 0 {
+		return fmt.Errorf("quantity must be positive")
+	}
+	return s.Update(func() error {
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		i := s.BeverageIndex(r.Beverage)
+		if i < 0 {
+			return fmt.Errorf("unknown beverage %q", r.Beverage)
+		}
+		b := &s.Beverages[i]
+		if b.IsRecipe() {
+			return fmt.Errorf("%s is made from a recipe; restock its ingredients instead", b.Key())
+		}
+		b.Stock += r.Quantity
+		r.Unit = b.Unit
+		s.Restocks = append(s.Restocks, r)
+		s.Audit(AuditEntry{Time: r.Time, Event: "restock", Beverage: b.Key(), Quantity: r.Quantity, Amount: r.Cost})
+		return nil
+	})
+}
+
+// RestocksBetween returns the restocks booked in [from, to).
+func (s *Store) RestocksBetween(from, to time.Time) []Restock {
+	var restocks []Restock
+	for _, r := range s.Restocks {
+		if !r.Time.Before(from) && r.Time.Before(to) {
+			restocks = append(restocks, r)
+		}
+	}
+	return restocks
+}
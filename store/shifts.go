@@ -0,0 +1,164 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- SHIFTS ---
+
+// Shift is a stretch of bar duty with its own cash drawer count: it opens
+// with a float in the drawer and closes with the counted cash, which should
+// be the float plus the cash sales in between. Sales charged to a tab or
+// paid by card don't go through the drawer, nor what a voucher paid.
+type Shift struct {
+	ID       int       `json:"id"`
+	Opened   time.Time `json:"opened"`
+	OpenedBy string    `json:"opened_by,omitempty"`
+	Float    float64   `json:"float"`
+	Closed   time.Time `json:"closed,omitzero"`
+	ClosedBy string    `json:"closed_by,omitempty"`
+	// Expected is the float plus the cash sales, as of closing.
+	Expected float64 `json:"expected,omitempty"`
+	Counted  float64 `json:"counted,omitempty"`
+	Note     string  `json:"note,omitempty"`
+	// Checklist is the end-of-night checklist as it was ticked off when
+	// the shift closed.
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+}
+
+// ChecklistItem is a task of the end-of-night checklist, like counting
+// the cash or locking the tap.
+type ChecklistItem struct {
+	Task string `json:"task"`
+	Done bool   `json:"done"`
+}
+
+// ChecklistDone counts the ticked-off tasks of a checklist.
+func ChecklistDone(items []ChecklistItem) int {
+	n := 0
+	for _, it := range items {
+		if it.Done {
+			n++
+		}
+	}
+	return n
+}
+
+var errNoShift = errors.New("no shift is open")
+
+func (sh Shift) Open() bool { return sh.Closed.IsZero() }
+
+// Variance is how much more cash was counted than expected.
+func (sh Shift) Variance() float64 { return domain.RoundCents(sh.Counted - sh.Expected) }
+
+// CurrentShift returns the index of the open shift, or -1.
+func (s *Store) CurrentShift() int {
+	for i := len(s.Shifts) - 1; i >= 0; i-- {
+		if s.Shifts[i].Open() {
+			return i
+		}
+	}
+	return -1
+}
+
+// cashSales adds up the cash paid for the sales not charged to a tab or
+// paid by card or link from since until before until; a zero until means
+// up to now.
+func (s *Store) cashSales(since, until time.Time) (count int, total float64) {
+	for _, sale := range s.Sales {
+		if sale.Member != "" || sale.Card != nil || sale.Link != nil || sale.Time.Before(since) || (!until.IsZero() && !sale.Time.Before(until)) {
+			continue
+		}
+		count++
+		total += sale.CashDue()
+	}
+	return count, domain.RoundCents(total)
+}
+
+func (s *Store) OpenShift(float float64) (Shift, error) {
+	var sh Shift
+	err := s.Update(func() error {
+		if i := s.CurrentShift(); i >= 0 {
+			return fmt.Errorf("shift #%d is still open since %s", s.Shifts[i].ID, s.Shifts[i].Opened.Format("15:04"))
+		}
+		sh = Shift{ID: 1, Opened: time.Now(), OpenedBy: s.Actor, Float: float}
+		if n := len(s.Shifts); n > 0 {
+			sh.ID = s.Shifts[n-1].ID + 1
+		}
+		s.Shifts = append(s.Shifts, sh)
+		s.Audit(AuditEntry{Time: sh.Opened, Event: "shift opened", Amount: float, Detail: fmt.Sprintf("#%d", sh.ID)})
+		return nil
+	})
+	return sh, err
+}
+
+// CloseShift closes the open shift with the counted cash and the checklist
+// as it was ticked off, if there is one.
+func (s *Store) CloseShift(counted float64, note string, checklist []ChecklistItem) (Shift, error) {
+	var sh Shift
+	err := s.Update(func() error {
+		i := s.CurrentShift()
+		if i < 0 {
+			return errNoShift
+		}
+		sh = s.Shifts[i]
+		sh.Closed, sh.ClosedBy = time.Now(), s.Actor
+		_, cash := s.cashSales(sh.Opened, sh.Closed)
+		sh.Expected = domain.RoundCents(sh.Float + cash)
+		sh.Counted, sh.Note, sh.Checklist = counted, note, checklist
+		s.Shifts[i] = sh
+		detail := fmt.Sprintf("#%d, variance %+.2f", sh.ID, sh.Variance())
+		if len(checklist) > 0 {
+			detail += fmt.Sprintf(", checklist %d/%d", ChecklistDone(checklist), len(checklist))
+		}
+		s.Audit(AuditEntry{Time: sh.Closed, Event: "shift closed", Amount: counted, Detail: detail})
+		return nil
+	})
+	return sh, err
+}
+
+// WriteShiftReport writes the cash reconciliation of a shift; an open
+// shift is reconciled up to now, without a count.
+func (s *Store) WriteShiftReport(out io.Writer, sh Shift) error {
+	sales, cash := s.cashSales(sh.Opened, sh.Closed)
+	until := "now"
+	if !sh.Open() {
+		until = sh.Closed.Format("15:04")
+	}
+	fmt.Fprintf(out, "Shift #%d, %s – %s", sh.ID, sh.Opened.Format("2006-01-02 15:04"), until)
+	if sh.OpenedBy != "" {
+		fmt.Fprintf(out, " (%s)", sh.OpenedBy)
+	}
+	fmt.Fprint(out, "\n\n")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "Float\t%.2f\t\n", sh.Float)
+	fmt.Fprintf(w, "Cash sales (%d)\t%.2f\t\n", sales, cash)
+	fmt.Fprintf(w, "Expected\t%.2f\t\n", domain.RoundCents(sh.Float+cash))
+	if !sh.Open() {
+		fmt.Fprintf(w, "Counted\t%.2f\t\n", sh.Counted)
+		fmt.Fprintf(w, "Variance\t%+.2f\t\n", sh.Variance())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if sh.Note != "" {
+		fmt.Fprintf(out, "\nNote: %s\n", sh.Note)
+	}
+	if len(sh.Checklist) > 0 {
+		fmt.Fprintf(out, "\nChecklist (%d of %d done):\n", ChecklistDone(sh.Checklist), len(sh.Checklist))
+		for _, it := range sh.Checklist {
+			box := "[ ]"
+			if it.Done {
+				box = "[x]"
+			}
+			fmt.Fprintf(out, "  %s %s\n", box, it.Task)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+func TestCloseShift(t *testing.T) {
+	s := openTestStore(t)
+	if _, err := s.CloseShift(0, "", nil); !errors.Is(err, errNoShift) {
+		t.Errorf("closing without a shift: %v, want errNoShift", err)
+	}
+	sh, err := s.OpenShift(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.OpenShift(50); err == nil || !strings.Contains(err.Error(), "still open") {
+		t.Errorf("opening a second shift: %v, want shift #1 still open", err)
+	}
+
+	mate := func(qty int) []domain.SaleLine {
+		return []domain.SaleLine{{Name: "Club-Mate", Quantity: qty, UnitPrice: 1.49}}
+	}
+	now := time.Now()
+	if err := s.Update(func() error {
+		s.Sales = []domain.Sale{
+			// Before the shift.
+			{ID: 1, Time: sh.Opened.Add(-time.Minute), Lines: mate(10)},
+			// Paid in cash, 4.47 and 1.49 rounded to 4.45 and 1.50.
+			{ID: 2, Time: now, Lines: mate(3), Rounding: -0.02},
+			{ID: 3, Time: now, Lines: mate(1), Rounding: 0.01},
+			// Not through the drawer.
+			{ID: 4, Time: now, Lines: mate(2), Member: "alice"},
+			{ID: 5, Time: now, Lines: mate(2), Card: &domain.CardPayment{Provider: "sumup", Reference: "r1", Status: "paid"}},
+			{ID: 6, Time: now, Lines: mate(2), Link: &domain.LinkPayment{Method: "PayPal", Reference: "p1"}},
+			// Only what the voucher didn't pay, 1.98 as 2.00.
+			{ID: 7, Time: now, Lines: mate(2), Voucher: "V1", VoucherAmount: 1, Rounding: 0.02},
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n, cash := s.cashSales(sh.Opened, time.Time{}); n != 3 || cash != 7.95 {
+		t.Errorf("cash sales %d for %.2f, want 3 for 7.95", n, cash)
+	}
+
+	closed, err := s.CloseShift(57, "one coin short", []ChecklistItem{{Task: "count", Done: true}, {Task: "lock"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed.Open() || closed.Expected != 57.95 || closed.Variance() != -0.95 {
+		t.Errorf("closed shift expects %.2f, variance %.2f; want 57.95 and -0.95", closed.Expected, closed.Variance())
+	}
+	if s.CurrentShift() >= 0 || s.Shifts[0].Counted != 57 || len(s.Shifts[0].Checklist) != 2 {
+		t.Errorf("shifts = %+v, want #1 closed with the count and checklist", s.Shifts)
+	}
+	if _, err := s.CloseShift(57, "", nil); !errors.Is(err, errNoShift) {
+		t.Errorf("closing again: %v, want errNoShift", err)
+	}
+
+	// Later sales don't go into the closed shift's report.
+	if err := s.Update(func() error {
+		s.Sales = append(s.Sales, domain.Sale{ID: 8, Time: closed.Closed.Add(time.Minute), Lines: mate(1)})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := s.WriteShiftReport(&out, closed); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Cash sales (3)  7.95", "Expected  57.95", "Variance  -0.95", "Note: one coin short", "(1 of 2 done)"} {
+		if !strings.Contains(strings.Join(strings.Fields(out.String()), " "), strings.Join(strings.Fields(want), " ")) {
+			t.Errorf("report\n%s\nwant %q", out.String(), want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import "time"
+
+// --- STATS ---
+
+// SalesStats is what the stats tab shows, counted from the sales history.
+type SalesStats struct {
+	TodayRevenue, WeekRevenue, MonthRevenue float64
+	TodaySales, WeekSales                   int
+	// Hourly is today's revenue per hour of the day.
+	Hourly [24]float64
+	Sold   map[string]int // units sold today per beverage
+}
+
+func (s *Store) SalesStats(now time.Time) SalesStats {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// Weeks start on Monday.
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	month := today.AddDate(0, 0, 1-today.Day())
+	st := SalesStats{Sold: map[string]int{}}
+	for _, sale := range s.Sales {
+		if (sale.Time.Before(week) && sale.Time.Before(month)) || sale.Time.After(now) {
+			continue
+		}
+		revenue := sale.Revenue()
+		if !sale.Time.Before(month) {
+			st.MonthRevenue += revenue
+		}
+		if sale.Time.Before(week) {
+			continue
+		}
+		st.WeekRevenue += revenue
+		st.WeekSales++
+		if sale.Time.Before(today) {
+			continue
+		}
+		st.TodayRevenue += revenue
+		st.TodaySales++
+		st.Hourly[sale.Time.Hour()] += revenue
+		for _, l := range sale.Lines {
+			st.Sold[l.Name] += l.Quantity
+		}
+	}
+	return st
+}
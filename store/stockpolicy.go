@@ -0,0 +1,31 @@
+package store
+
+import "fmt"
+
+// --- STOCK POLICY ---
+
+// StockPolicy decides what happens when a sale needs more than the recorded
+// stock. Honor-system fridges often sell faster than deliveries are booked,
+// so the stock on record can't always be trusted.
+type StockPolicy string
+
+const (
+	// StockBlock refuses the sale.
+	StockBlock StockPolicy = "block"
+	// StockWarn books it, taking the stock below zero, and warns at the till.
+	StockWarn StockPolicy = "warn"
+	// StockAllow books it without a word.
+	StockAllow StockPolicy = "allow"
+)
+
+// Blocks reports whether sales are refused beyond the stock. The zero value
+// blocks, like the default config.
+func (p StockPolicy) Blocks() bool { return p != StockWarn && p != StockAllow }
+
+func (p StockPolicy) Validate() error {
+	switch p {
+	case StockBlock, StockWarn, StockAllow:
+		return nil
+	}
+	return fmt.Errorf("unknown stock_policy %q (use block, warn or allow)", p)
+}
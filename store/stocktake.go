@@ -0,0 +1,66 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- STOCKTAKING ---
+
+// A stocktake counts what is actually there and corrects the stock to it.
+// Each count is taken against the book stock at the time, so what sells
+// while the fridge is being counted still comes off: the stock changes by
+// the difference between the count and the book stock it was taken
+// against.
+
+// StockCount is the counted stock of a beverage next to the book stock at
+// the time of the count, both in the beverage's unit.
+type StockCount struct {
+	Beverage string  `json:"beverage"`
+	Book     float64 `json:"book"`
+	Counted  float64 `json:"counted"`
+}
+
+// Difference is what the count found more than the books said, or less if
+// negative.
+func (c StockCount) Difference() float64 { return c.Counted - c.Book }
+
+// ApplyStocktake corrects the stock by the counts that differ from the book
+// stock, auditing each correction as a stocktake. What is missing is
+// written off as such. It returns how many beverages it corrected.
+func (s *Store) ApplyStocktake(counts []StockCount) (corrected int, err error) {
+	err = s.Update(func() error {
+		corrected = 0
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		for _, c := range counts {
+			i := s.BeverageIndex(c.Beverage)
+			if i < 0 {
+				return fmt.Errorf("unknown beverage %q", c.Beverage)
+			}
+			b := &s.Beverages[i]
+			if b.IsRecipe() {
+				return fmt.Errorf("%s is made from a recipe; count its ingredients instead", b.Key())
+			}
+			if c.Counted < 0 {
+				return fmt.Errorf("invalid count %g of %s", c.Counted, b.Key())
+			}
+			delta := c.Difference()
+			if delta == 0 {
+				continue
+			}
+			b.Stock += delta
+			if delta < 0 {
+				s.addWriteOff(WriteOff{Time: time.Now(), Beverage: b.Key(), Quantity: -delta, Reason: WriteOffMissing, Note: "stocktake"}, *b)
+			}
+			s.Audit(AuditEntry{
+				Event: "stocktake", Beverage: b.Key(), Quantity: delta,
+				Detail: fmt.Sprintf("counted %s, book %s", b.Unit.Format(c.Counted), b.Unit.Format(c.Book)),
+			})
+			corrected++
+		}
+		return nil
+	})
+	return corrected, err
+}
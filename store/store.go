@@ -0,0 +1,501 @@
+// Package store keeps the till's state in a JSON file, or on a server for
+// client terminals, and books sales, top-ups and stock changes to it under
+// a file lock shared by every process using the file.
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- STORE ---
+
+// Store is the persistent state of the till: the inventory and every sale
+// booked so far. It is kept as a single JSON document on disk.
+type Store struct {
+	Path string `json:"-"`
+	// TabLimit is how far below zero a member's balance may go.
+	TabLimit float64 `json:"-"`
+	// Remote is set on client terminals, whose store lives on the server.
+	Remote *RemoteStore `json:"-"`
+	// webhooks are told about every sale once it is saved; booked holds
+	// the sales of the running update until then.
+	webhooks []Webhook
+	booked   []domain.Sale
+	// StockPolicy says whether sales may take the stock below zero.
+	StockPolicy StockPolicy `json:"-"`
+	// CashRounding is the step what is paid in cash is rounded to, see
+	// domain.CashRounding.
+	CashRounding float64 `json:"-"`
+	// undoGrace is how long a sale may be voided without approval.
+	undoGrace time.Duration
+	// events publishes sales and stock changes, if configured.
+	events *eventPublisher
+	// queue decides which sales go into the order queue.
+	queue QueueConfig
+	// AuditLog records every change made by actor; audited holds the
+	// entries of the running update until it is saved.
+	AuditLog *AuditLog `json:"-"`
+	Actor    string    `json:"-"`
+	audited  []AuditEntry
+
+	Beverages []domain.Beverage    `json:"beverages"`
+	Members   []domain.Member      `json:"members,omitempty"`
+	Ledger    []domain.LedgerEntry `json:"ledger,omitempty"`
+	Vouchers  []domain.Voucher     `json:"vouchers,omitempty"`
+	Sales     []domain.Sale        `json:"sales"`
+	Banner    *Banner              `json:"banner,omitempty"`
+	Lockdown  Lockdown             `json:"lockdown,omitempty"`
+
+	CoffeeReadings []CounterReading `json:"coffee_readings,omitempty"`
+	Kegs           []Keg            `json:"kegs,omitempty"`
+	Pours          []Pour           `json:"pours,omitempty"`
+	Restocks       []Restock        `json:"restocks,omitempty"`
+	WriteOffs      []WriteOff       `json:"write_offs,omitempty"`
+	Shifts         []Shift          `json:"shifts,omitempty"`
+	Closes         []DayClose       `json:"closes,omitempty"`
+	// Requests are the API requests booked under an idempotency key.
+	Requests []IdempotentRequest `json:"requests,omitempty"`
+	// Orders are the sales in the order queue, see QueueConfig.
+	Orders []Order `json:"orders,omitempty"`
+}
+
+// Lockdown is an emergency switch that restricts what every kiosk may do.
+type Lockdown string
+
+const (
+	LockdownNone        Lockdown = ""
+	LockdownPriceFreeze Lockdown = "price-freeze"
+	LockdownReadOnly    Lockdown = "read-only"
+)
+
+var errReadOnly = errors.New("the till is in read-only mode")
+var errPriceFreeze = errors.New("prices are frozen")
+
+func ParseLockdown(s string) (Lockdown, error) {
+	switch l := Lockdown(s); l {
+	case LockdownPriceFreeze, LockdownReadOnly:
+		return l, nil
+	case "off", "none":
+		return LockdownNone, nil
+	}
+	return LockdownNone, fmt.Errorf("unknown lockdown mode %q (use read-only, price-freeze or off)", s)
+}
+
+// Banner is an admin message shown on every kiosk until it expires.
+type Banner struct {
+	Message string    `json:"message"`
+	Expires time.Time `json:"expires"`
+}
+
+// ActiveBanner returns the banner message, or "" if none is set or it has
+// expired.
+func (s *Store) ActiveBanner(now time.Time) string {
+	if s.Banner == nil || !now.Before(s.Banner.Expires) {
+		return ""
+	}
+	return s.Banner.Message
+}
+
+// SetBanner shows b on all kiosks, or clears the banner if b is nil.
+func (s *Store) SetBanner(b *Banner) error {
+	return s.Update(func() error {
+		s.Banner = b
+		e := AuditEntry{Event: "banner"}
+		if b != nil {
+			e.Detail = b.Message
+		}
+		s.Audit(e)
+		return nil
+	})
+}
+
+func (s *Store) SetLockdown(mode Lockdown) error {
+	return s.Update(func() error {
+		s.Lockdown = mode
+		s.Audit(AuditEntry{Event: "lockdown", Detail: string(cmp.Or(mode, "off"))})
+		return nil
+	})
+}
+
+// Open loads the store from path. If the file doesn't exist yet, the
+// store starts out with the default inventory.
+func Open(path string) (*Store, error) {
+	s := &Store{Path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.Beverages = append([]domain.Beverage(nil), DefaultBeverages...)
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if key := domain.DuplicateKey(s.Beverages); key != "" {
+		return nil, fmt.Errorf("%s: more than one beverage is %q", path, key)
+	}
+	return s, nil
+}
+
+// Memory returns a store that lives in memory only, starting out with
+// beverages. Nothing is saved or shared with other processes; it is for
+// tests and for frontends that keep their state elsewhere.
+func Memory(beverages []domain.Beverage) *Store {
+	return &Store{Beverages: slices.Clone(beverages)}
+}
+
+// inMemory reports whether the store has neither a file nor a server.
+func (s *Store) inMemory() bool { return s.Path == "" && s.Remote == nil }
+
+// Options are the settings from the config that the store enforces.
+type Options struct {
+	TabLimit     float64
+	Webhooks     []Webhook
+	StockPolicy  StockPolicy
+	CashRounding float64
+	UndoGrace    time.Duration
+	Events       EventsConfig
+	AuditLog     string
+	Queue        QueueConfig
+}
+
+// Configure applies the options.
+func (s *Store) Configure(o Options) {
+	s.TabLimit, s.webhooks, s.StockPolicy, s.CashRounding = o.TabLimit, o.Webhooks, o.StockPolicy, o.CashRounding
+	s.undoGrace = o.UndoGrace
+	s.events = publisherFor(o.Events)
+	s.AuditLog = openAuditLog(o.AuditLog)
+	s.queue = o.Queue
+}
+
+// Settings returns an empty store with the same file and configuration.
+func (s *Store) Settings() *Store {
+	return &Store{Path: s.Path, TabLimit: s.TabLimit, Remote: s.Remote, webhooks: s.webhooks, StockPolicy: s.StockPolicy, events: s.events,
+		CashRounding: s.CashRounding, AuditLog: s.AuditLog, Actor: s.Actor, undoGrace: s.undoGrace, queue: s.queue}
+}
+
+// Reload replaces the in-memory state with what is on disk, picking up
+// changes made by other processes sharing the store. If the file doesn't
+// exist yet, the in-memory state is kept.
+func (s *Store) Reload() error {
+	if s.inMemory() {
+		return nil
+	}
+	var data []byte
+	var err error
+	if s.Remote != nil {
+		data, err = s.Remote.Do(http.MethodGet, "/store", nil)
+	} else {
+		data, err = os.ReadFile(s.Path)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fresh := *s.Settings()
+	if err := json.Unmarshal(data, &fresh); err != nil {
+		return fmt.Errorf("parsing %s: %w", s.Path, err)
+	}
+	if key := domain.DuplicateKey(fresh.Beverages); key != "" {
+		return fmt.Errorf("%s: more than one beverage is %q", s.Path, key)
+	}
+	*s = fresh
+	return nil
+}
+
+// updating keeps the updates of a process to one at a time, also where
+// lockFile can't lock the file against the other processes.
+var updating sync.Mutex
+
+// Update applies fn to the latest state on disk and saves the result. The
+// store is locked meanwhile, so the kiosks, bridges and admin commands that
+// share it don't overwrite each other's changes. If fn fails, nothing is
+// saved and the store is as it was; fn must not call update itself.
+func (s *Store) Update(fn func() error) error {
+	if s.Remote != nil {
+		return errRemoteStore
+	}
+	if s.inMemory() {
+		return s.updateInMemory(fn)
+	}
+	updating.Lock()
+	defer updating.Unlock()
+	unlock, err := lockFile(s.Path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := s.Reload(); err != nil {
+		return err
+	}
+	before := s.stockLevels()
+	if err := fn(); err != nil {
+		// What fn changed before it failed goes, as on disk it never was.
+		if rerr := s.Reload(); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		return err
+	}
+	if err := s.save(); err != nil {
+		return err
+	}
+	s.committed(before)
+	return nil
+}
+
+// updateInMemory is Update for a store in memory, which has no file to
+// go back to: a copy taken before fn is restored if it fails.
+func (s *Store) updateInMemory(fn func() error) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	before := s.stockLevels()
+	if err := fn(); err != nil {
+		fresh := *s.Settings()
+		if uerr := json.Unmarshal(data, &fresh); uerr != nil {
+			return uerr
+		}
+		*s = fresh
+		return err
+	}
+	s.committed(before)
+	return nil
+}
+
+// committed passes on what an update did once it is saved: the audit log
+// entries, the events and the webhooks of the sales booked.
+func (s *Store) committed(before map[string]float64) {
+	s.AuditLog.Write(s.audited...)
+	s.audited = nil
+	s.publishEvents(before)
+	for _, sale := range s.booked {
+		s.notify(sale)
+	}
+	s.booked = nil
+}
+
+// save writes the store back to disk, going through a temporary file so a
+// crash mid-write doesn't leave a truncated store behind.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return err
+	}
+	s.writeCache()
+	return nil
+}
+
+// RecordSale books a sale: stock is taken out of the inventory, the total is
+// charged to the member's tab if the sale names one, and the sale is appended
+// to the history. Nothing is changed if any line exceeds stock or the tab
+// can't cover the total. It returns the sale as booked.
+func (s *Store) RecordSale(sale domain.Sale) (domain.Sale, error) {
+	if s.Remote != nil {
+		data, err := s.Remote.postOnce("/sales", sale)
+		if err != nil {
+			return domain.Sale{}, err
+		}
+		if err := json.Unmarshal(data, &sale); err != nil {
+			return domain.Sale{}, err
+		}
+		return sale, s.Reload()
+	}
+	err := s.Update(func() error { return s.BookSale(sale) })
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	return s.Sales[len(s.Sales)-1], nil
+}
+
+// BookSale is recordSale for callers already inside update.
+func (s *Store) BookSale(sale domain.Sale) error {
+	if s.Lockdown == LockdownReadOnly {
+		return errReadOnly
+	}
+	check := domain.CheckStock
+	if !s.StockPolicy.Blocks() {
+		check = domain.StockNeeded
+	}
+	if !sale.Time.IsZero() && s.isClosed(sale.Time) {
+		return errDayClosed
+	}
+	if !sale.Time.IsZero() {
+		if err := domain.CheckAvailable(s.Beverages, sale.Lines, sale.Time); err != nil {
+			return err
+		}
+	}
+	needed, err := check(s.Beverages, sale.Lines)
+	if err != nil {
+		return err
+	}
+	if sale.Member != "" {
+		if err := s.CanCharge(sale.Member, sale.Total(), s.TabLimit); err != nil {
+			return err
+		}
+		i := s.MemberIndex(sale.Member)
+		s.Members[i].Balance = domain.RoundCents(s.Members[i].Balance - sale.Total())
+	}
+	if sale.Voucher != "" {
+		if err := s.redeemVoucher(&sale); err != nil {
+			return err
+		}
+	}
+	// What is left due of a sale not paid otherwise is paid in cash.
+	if sale.Member == "" && sale.Card == nil && sale.Link == nil && sale.Voids == 0 {
+		sale.Rounding = domain.CashRounding(sale.Due(), s.CashRounding)
+	}
+	for name, amount := range needed {
+		s.Beverages[s.BeverageIndex(name)].Stock -= amount
+	}
+	lines := make([]domain.SaleLine, len(sale.Lines))
+	for i, line := range sale.Lines {
+		if b := s.BeverageIndex(line.Name); !line.Untracked && !s.Beverages[b].IsRecipe() {
+			line.Unit, line.Portion = s.Beverages[b].Unit, s.Beverages[b].PortionSize()
+		}
+		lines[i] = line
+	}
+	sale.Lines = lines
+	sale.ID = s.nextSaleID()
+	s.Sales = append(s.Sales, sale)
+	s.booked = append(s.booked, sale)
+	s.queueSale(sale)
+	s.Audit(AuditEntry{Event: "sale", Sale: sale.ID, Member: sale.Member, Amount: domain.RoundCents(sale.Total()), Detail: sale.Items()})
+	return nil
+}
+
+func (s *Store) nextSaleID() int {
+	// IDs aren't reused when a sale is voided.
+	if n := len(s.Sales); n > 0 {
+		return s.Sales[n-1].ID + 1
+	}
+	return 1
+}
+
+// SetPrice changes the price of a beverage unless prices are frozen.
+func (s *Store) SetPrice(name string, price float64) error {
+	return s.Update(func() error {
+		if s.Lockdown != LockdownNone {
+			return errPriceFreeze
+		}
+		i := s.BeverageIndex(name)
+		if i < 0 {
+			return fmt.Errorf("unknown beverage %q", name)
+		}
+		old := s.Beverages[i].Price
+		s.Beverages[i].Price = price
+		s.Audit(AuditEntry{Event: "price", Beverage: name, From: &old, To: &price})
+		return nil
+	})
+}
+
+// SetTierPrice changes the price of a beverage for a price tier, like
+// SetPrice.
+func (s *Store) SetTierPrice(name, tier string, price float64) error {
+	return s.Update(func() error {
+		if s.Lockdown != LockdownNone {
+			return errPriceFreeze
+		}
+		i := s.BeverageIndex(name)
+		if i < 0 {
+			return fmt.Errorf("unknown beverage %q", name)
+		}
+		b := &s.Beverages[i]
+		old := b.TierPrice(tier)
+		if b.Tiers == nil {
+			b.Tiers = map[string]float64{}
+		}
+		b.Tiers[tier] = price
+		s.Audit(AuditEntry{Event: "price", Beverage: name, From: &old, To: &price, Detail: "tier " + tier})
+		return nil
+	})
+}
+
+// SetBundle sells quantity of a beverage for price from now on, or stops
+// selling the bundle of that size with a negative price. Like SetPrice, it
+// fails while prices are frozen.
+func (s *Store) SetBundle(name string, quantity int, price float64) error {
+	if quantity < 2 {
+		return fmt.Errorf("a bundle needs at least 2 items, not %d", quantity)
+	}
+	return s.Update(func() error {
+		if s.Lockdown != LockdownNone {
+			return errPriceFreeze
+		}
+		i := s.BeverageIndex(name)
+		if i < 0 {
+			return fmt.Errorf("unknown beverage %q", name)
+		}
+		b := &s.Beverages[i]
+		entry := AuditEntry{Event: "price", Beverage: name, Detail: fmt.Sprintf("bundle of %d", quantity)}
+		j := slices.IndexFunc(b.Bundles, func(bundle domain.Bundle) bool { return bundle.Quantity == quantity })
+		if j >= 0 {
+			old := b.Bundles[j].Price
+			entry.From = &old
+		}
+		switch {
+		case price < 0 && j < 0:
+			return fmt.Errorf("%s has no bundle of %d", name, quantity)
+		case price < 0:
+			b.Bundles = slices.Delete(b.Bundles, j, j+1)
+		case j >= 0:
+			b.Bundles[j].Price = price
+			entry.To = &price
+		default:
+			b.Bundles = append(b.Bundles, domain.Bundle{Quantity: quantity, Price: price})
+			entry.To = &price
+		}
+		s.Audit(entry)
+		return nil
+	})
+}
+
+func (s *Store) BeverageIndex(name string) int { return domain.IndexOf(s.Beverages, name) }
+
+// SalesBetween returns the sales booked in [from, to).
+func (s *Store) SalesBetween(from, to time.Time) []domain.Sale {
+	var sales []domain.Sale
+	for _, sale := range s.Sales {
+		if !sale.Time.Before(from) && sale.Time.Before(to) {
+			sales = append(sales, sale)
+		}
+	}
+	return sales
+}
+
+// DefaultBeverages is the inventory a new store starts with.
+var DefaultBeverages = []domain.Beverage{
+	{Name: "Club-Mate", Price: 1.50, Stock: 24},
+	{Name: "Espresso", Price: 1.00, Stock: 50, TaxClass: "reduced"},
+	{Name: "Fritz-Kola", Price: 2.00, Stock: 12},
+	{Name: "Water", Price: 0.50, Stock: 100},
+	{Name: "Beer", Price: 2.50, Stock: 6},
+	{Name: "Coffee beans", Price: 5.00, Stock: 2000, Unit: domain.UnitGram, Portion: 250, TaxClass: "reduced"},
+	{Name: "Rum", Price: 3.00, Stock: 700, Unit: domain.UnitMilliliter, Portion: 40},
+	{Name: "Lime", Price: 0.50, Stock: 10},
+	{Name: "Tschunk", Price: 5.00, Recipe: []domain.Ingredient{
+		{Name: "Club-Mate", Amount: 1},
+		{Name: "Rum", Amount: 40},
+		{Name: "Lime", Amount: 1},
+	}},
+}
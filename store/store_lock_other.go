@@ -0,0 +1,10 @@
+//go:build !unix
+
+package store
+
+// lockFile is a no-op where flock isn't available; there, only the updates
+// of one process take turns, by updating, and processes sharing a store
+// have to take turns themselves.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// openTestStore is a store in a file of its own, holding beverages.
+func openTestStore(t *testing.T, beverages ...domain.Beverage) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(func() error {
+		s.Beverages = beverages
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestUpdateRestoresOnError(t *testing.T) {
+	s := openTestStore(t, domain.Beverage{Name: "Club-Mate", Price: 1.50, Stock: 24})
+	if err := s.Update(func() error {
+		s.Members = []domain.Member{{ID: "alice", Name: "Alice", Balance: 10}}
+		s.Vouchers = []domain.Voucher{{Code: "V1", Value: 5, Balance: 5}}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// The tab is charged before the voucher is refused.
+	sale := domain.Sale{Time: time.Now(), Member: "alice", Voucher: "V1", Lines: []domain.SaleLine{{Name: "Club-Mate", Quantity: 2, UnitPrice: 1.50}}}
+	if _, err := s.RecordSale(sale); err == nil {
+		t.Fatal("a sale paid from a tab and a voucher: no error")
+	}
+	if s.Members[0].Balance != 10 || s.Vouchers[0].Balance != 5 || s.Beverages[0].Stock != 24 || len(s.Sales) != 0 {
+		t.Errorf("after the failed sale: balance %.2f, voucher %.2f, stock %.0f, %d sales; want it all as it was",
+			s.Members[0].Balance, s.Vouchers[0].Balance, s.Beverages[0].Stock, len(s.Sales))
+	}
+	// Nor does the next update save it.
+	if err := s.Update(func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Open(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Members[0].Balance != 10 {
+		t.Errorf("balance on disk %.2f, want 10.00", again.Members[0].Balance)
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- UNDO ---
+
+var errNoSale = errors.New("no such sale")
+
+// VoidSale removes a sale from the history, puts its stock back and
+// refunds the member's tab. Sales of closed days stay and get a
+// compensating sale instead. Within the undo grace anyone at the till may
+// void a sale; later, approvedBy has to name a second person, someone
+// other than the store's Actor.
+func (s *Store) VoidSale(id int, approvedBy string) error {
+	if s.Remote != nil {
+		path := "/sales/" + strconv.Itoa(id)
+		if approvedBy != "" {
+			path += "?approved_by=" + url.QueryEscape(approvedBy)
+		}
+		if _, err := s.Remote.Do(http.MethodDelete, path, nil); err != nil {
+			return err
+		}
+		return s.Reload()
+	}
+	return s.Update(func() error {
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		i := -1
+		for j, sale := range s.Sales {
+			if sale.ID == id {
+				i = j
+			}
+		}
+		if i < 0 {
+			return errNoSale
+		}
+		sale := s.Sales[i]
+		if sale.Voids != 0 {
+			return fmt.Errorf("sale #%d is the void of sale #%d", id, sale.Voids)
+		}
+		for _, other := range s.Sales {
+			if other.Voids == id {
+				return fmt.Errorf("sale #%d was already voided by sale #%d", id, other.ID)
+			}
+		}
+		if approvedBy != "" || time.Since(sale.Time) > s.undoGrace {
+			if err := s.checkApproval(approvedBy); err != nil {
+				return err
+			}
+		}
+		needed, err := domain.StockNeeded(s.Beverages, sale.Lines)
+		if err != nil {
+			return err
+		}
+		if err := s.refundVoucher(sale); err != nil {
+			return err
+		}
+		for name, amount := range needed {
+			s.Beverages[s.BeverageIndex(name)].Stock += amount
+		}
+		if sale.Member != "" {
+			if j := s.MemberIndex(sale.Member); j >= 0 {
+				s.Members[j].Balance = domain.RoundCents(s.Members[j].Balance + sale.Total())
+			}
+		}
+		if j := s.OrderIndex(id); j >= 0 {
+			s.Orders = slices.Delete(s.Orders, j, j+1)
+		}
+		if s.isClosed(sale.Time) {
+			return s.compensate(sale, approvedBy)
+		}
+		s.Sales = append(s.Sales[:i], s.Sales[i+1:]...)
+		detail := sale.Items()
+		if approvedBy != "" {
+			detail += ", approved by " + approvedBy
+		}
+		s.Audit(AuditEntry{Event: "void", Sale: sale.ID, Member: sale.Member, Amount: domain.RoundCents(sale.Total()), Detail: detail})
+		return nil
+	})
+}
+
+// checkApproval refuses an approval that doesn't come from a second
+// person: none at all, one by the actor voiding, or one where it isn't
+// known who that is.
+func (s *Store) checkApproval(approvedBy string) error {
+	if approvedBy == "" {
+		return errApprovalMissing
+	}
+	actor := actorName(s.Actor)
+	if actor == "" || actor == "unknown" {
+		return errActorUnknown
+	}
+	if strings.EqualFold(approvedBy, actor) || strings.EqualFold(approvedBy, s.Actor) {
+		return errSelfApproval
+	}
+	return nil
+}
+
+// actorName is the person of an actor, without where they work from:
+// the user of LocalActor, or what a terminal sent before " via ".
+func actorName(actor string) string {
+	name, _, _ := strings.Cut(actor, " via ")
+	name, _, _ = strings.Cut(name, "@")
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- INVENTORY VALUATION ---
+
+// ValuationMethod is how the stock on hand is valued at the cost of the
+// deliveries it came from.
+type ValuationMethod string
+
+const (
+	// ValuationFIFO assumes the oldest stock sells first, so what is left
+	// came with the latest deliveries and is valued at their costs.
+	ValuationFIFO ValuationMethod = "fifo"
+	// ValuationAverage values all stock at the average cost per unit of
+	// every delivery.
+	ValuationAverage ValuationMethod = "average"
+)
+
+func (v ValuationMethod) Validate() error {
+	switch v {
+	case ValuationFIFO, ValuationAverage:
+		return nil
+	}
+	return fmt.Errorf("unknown valuation %q (use fifo or average)", v)
+}
+
+// StockValue is what the stock of a beverage is worth at cost. Uncosted is
+// the part of the stock no delivery with a cost accounts for, e.g. stock
+// from before restocks were booked. It is valued at the oldest delivery's
+// unit cost under FIFO and at the average under ValuationAverage; with no
+// costed delivery at all, it is worth nothing.
+type StockValue struct {
+	Beverage string
+	Unit     domain.Unit
+	Stock    float64
+	Uncosted float64
+	UnitCost float64 // Value per unit of stock
+	Value    float64
+}
+
+// ValueStock values the stock on hand of every beverage that isn't made
+// from a recipe, by the restocks booked for it. Stock below zero is worth
+// nothing.
+func ValueStock(beverages []domain.Beverage, restocks []Restock, method ValuationMethod) []StockValue {
+	costed := map[string][]Restock{}
+	for _, r := range restocks {
+		if r.Cost > 0 && r.Quantity > 0 {
+			costed[r.Beverage] = append(costed[r.Beverage], r)
+		}
+	}
+	// Deliveries can be booked after the fact.
+	for _, deliveries := range costed {
+		slices.SortStableFunc(deliveries, func(a, b Restock) int { return a.Time.Compare(b.Time) })
+	}
+	var values []StockValue
+	for _, b := range beverages {
+		if b.IsRecipe() {
+			continue
+		}
+		v := StockValue{Beverage: b.Key(), Unit: b.Unit, Stock: b.Stock}
+		if method == ValuationAverage {
+			v.Value, v.Uncosted = valueAverage(max(b.Stock, 0), costed[b.Key()])
+		} else {
+			v.Value, v.Uncosted = valueFIFO(max(b.Stock, 0), costed[b.Key()])
+		}
+		v.Value = domain.RoundCents(v.Value)
+		if b.Stock > 0 {
+			v.UnitCost = v.Value / b.Stock
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// valueFIFO takes stock from the latest deliveries back, each at its own
+// unit cost.
+func valueFIFO(stock float64, deliveries []Restock) (value, uncosted float64) {
+	left := stock
+	for _, r := range slices.Backward(deliveries) {
+		if left <= 0 {
+			break
+		}
+		qty := min(left, r.Quantity)
+		value += qty * r.Cost / r.Quantity
+		left -= qty
+	}
+	if left > 0 && len(deliveries) > 0 {
+		value += left * deliveries[0].Cost / deliveries[0].Quantity
+	}
+	return value, max(left, 0)
+}
+
+// valueAverage values stock at the cost of all deliveries over their
+// quantity.
+func valueAverage(stock float64, deliveries []Restock) (value, uncosted float64) {
+	var cost, qty float64
+	for _, r := range deliveries {
+		cost += r.Cost
+		qty += r.Quantity
+	}
+	if qty > 0 {
+		value = stock * cost / qty
+	}
+	return value, max(stock-qty, 0)
+}
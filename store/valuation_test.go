@@ -0,0 +1,84 @@
+package store
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+func TestValueStock(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 18, 0, 0, 0, time.UTC) }
+	// Club-Mate came at 0.50, then 0.75, then 1.00 a bottle.
+	deliveries := []Restock{
+		{Time: day(1), Beverage: "Club-Mate", Quantity: 24, Cost: 12},
+		{Time: day(10), Beverage: "Club-Mate", Quantity: 24, Cost: 18},
+		{Time: day(20), Beverage: "Club-Mate", Quantity: 12, Cost: 12},
+		// Neither a delivery without a cost nor another beverage's counts.
+		{Time: day(25), Beverage: "Club-Mate", Quantity: 100},
+		{Time: day(25), Beverage: "Water", Quantity: 10, Cost: 100},
+	}
+	tests := []struct {
+		name     string
+		stock    float64
+		restocks []Restock
+		method   ValuationMethod
+		value    float64
+		uncosted float64
+	}{
+		{"fifo, within the latest delivery", 12, deliveries, ValuationFIFO, 12, 0},
+		{"fifo, into the one before", 18, deliveries, ValuationFIFO, 16.50, 0},
+		{"fifo, over several deliveries", 50, deliveries, ValuationFIFO, 37, 0},
+		{"fifo, every delivery", 60, deliveries, ValuationFIFO, 42, 0},
+		// The stock no delivery accounts for costs what the oldest did.
+		{"fifo, uncosted", 70, deliveries, ValuationFIFO, 47, 10},
+		{"fifo, booked out of order", 18, []Restock{deliveries[2], deliveries[0], deliveries[1]}, ValuationFIFO, 16.50, 0},
+		{"fifo, uncosted and out of order", 70, []Restock{deliveries[1], deliveries[2], deliveries[0]}, ValuationFIFO, 47, 10},
+		{"fifo, no stock", 0, deliveries, ValuationFIFO, 0, 0},
+		{"fifo, below zero", -3, deliveries, ValuationFIFO, 0, 0},
+		{"fifo, no delivery", 20, nil, ValuationFIFO, 0, 20},
+		{"fifo, in cents", 1, []Restock{{Time: day(1), Beverage: "Club-Mate", Quantity: 3, Cost: 1}}, ValuationFIFO, 0.33, 0},
+		// 42.00 over 60 bottles is 0.70 each, whatever the order.
+		{"average", 18, deliveries, ValuationAverage, 12.60, 0},
+		{"average, out of order", 18, []Restock{deliveries[2], deliveries[1], deliveries[0]}, ValuationAverage, 12.60, 0},
+		{"average, uncosted", 70, deliveries, ValuationAverage, 49, 10},
+		{"average, below zero", -3, deliveries, ValuationAverage, 0, 0},
+		{"average, no delivery", 20, nil, ValuationAverage, 0, 20},
+	}
+	for _, tt := range tests {
+		beverages := []domain.Beverage{{Name: "Club-Mate", Price: 1.50, Stock: tt.stock}}
+		values := ValueStock(beverages, tt.restocks, tt.method)
+		if len(values) != 1 {
+			t.Errorf("%s: %d values, want 1", tt.name, len(values))
+			continue
+		}
+		v := values[0]
+		if math.Abs(v.Value-tt.value) > 1e-9 || math.Abs(v.Uncosted-tt.uncosted) > 1e-9 || v.Stock != tt.stock {
+			t.Errorf("%s: %+v, want value %.2f with %g uncosted", tt.name, v, tt.value, tt.uncosted)
+		}
+		if tt.stock > 0 && math.Abs(v.UnitCost-tt.value/tt.stock) > 1e-9 {
+			t.Errorf("%s: unit cost %.4f, want %.4f", tt.name, v.UnitCost, tt.value/tt.stock)
+		}
+	}
+}
+
+func TestValueStockPerBeverage(t *testing.T) {
+	beverages := []domain.Beverage{
+		{Name: "Club-Mate", Price: 1.50, Stock: 24},
+		{Name: "Tschunk", Price: 5.00, Recipe: []domain.Ingredient{{Name: "Club-Mate", Amount: 1}}},
+		{Name: "Club-Mate", Size: "0.33 l", Price: 1.20, Stock: 10},
+	}
+	restocks := []Restock{
+		{Beverage: "Club-Mate", Quantity: 24, Cost: 12},
+		{Beverage: "Club-Mate 0.33 l", Quantity: 10, Cost: 4},
+	}
+	values := ValueStock(beverages, restocks, ValuationFIFO)
+	if len(values) != 2 || values[0].Beverage != "Club-Mate" || values[1].Beverage != "Club-Mate 0.33 l" {
+		t.Fatalf("values = %+v, want both sizes of Club-Mate and no recipe", values)
+	}
+	// Each size is valued by its own deliveries.
+	if values[0].Value != 12 || values[1].Value != 4 {
+		t.Errorf("values = %.2f and %.2f, want 12.00 and 4.00", values[0].Value, values[1].Value)
+	}
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- VOUCHERS ---
+
+// Vouchers are sold like anything else, as a line that isn't revenue: the
+// tax is due when the voucher is spent on drinks. A sale paid with a
+// voucher takes what it can from the balance and leaves the rest due, and
+// what the voucher doesn't spend stays on it for the next time.
+
+var (
+	errUnknownVoucher = errors.New("unknown voucher")
+	errVoucherEmpty   = errors.New("the voucher is used up")
+)
+
+// voucherCode makes a code that is easy to read out and type: three groups
+// of four letters and digits, e.g. "K7QD-M2XA-P4TB".
+func voucherCode() string {
+	text := rand.Text()
+	return text[0:4] + "-" + text[4:8] + "-" + text[8:12]
+}
+
+// normalizeVoucherCode ignores case, spaces and dashes, so that codes can
+// be typed however they are read.
+func normalizeVoucherCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(code))
+}
+
+// VoucherIndex returns the index of the voucher with code, or -1.
+func (s *Store) VoucherIndex(code string) int {
+	code = normalizeVoucherCode(code)
+	for i, v := range s.Vouchers {
+		if normalizeVoucherCode(v.Code) == code {
+			return i
+		}
+	}
+	return -1
+}
+
+// VoucherByCode returns the voucher with code.
+func (s *Store) VoucherByCode(code string) (domain.Voucher, bool) {
+	if i := s.VoucherIndex(code); i >= 0 {
+		return s.Vouchers[i], true
+	}
+	return domain.Voucher{}, false
+}
+
+// IssueVoucher sells a voucher worth value and returns it with its new
+// code.
+func (s *Store) IssueVoucher(value float64, note string, now time.Time) (domain.Voucher, error) {
+	if value <= 0 {
+		return domain.Voucher{}, fmt.Errorf("value must be positive")
+	}
+	var v domain.Voucher
+	err := s.Update(func() error {
+		v = domain.Voucher{Code: voucherCode(), Value: domain.RoundCents(value), Issued: now, Note: note}
+		for s.VoucherIndex(v.Code) >= 0 {
+			v.Code = voucherCode()
+		}
+		v.Balance = v.Value
+		sale := domain.Sale{Time: now, Lines: []domain.SaleLine{{
+			Name: "Voucher " + v.Code, Quantity: 1, UnitPrice: v.Value, Untracked: true, NonRevenue: true,
+		}}}
+		if err := s.BookSale(sale); err != nil {
+			return err
+		}
+		v.Sale = s.Sales[len(s.Sales)-1].ID
+		s.Vouchers = append(s.Vouchers, v)
+		s.Audit(AuditEntry{Time: now, Event: "voucher", Sale: v.Sale, Amount: v.Value, Detail: v.Code})
+		return nil
+	})
+	return v, err
+}
+
+// redeemVoucher pays what it can of sale from its voucher.
+func (s *Store) redeemVoucher(sale *domain.Sale) error {
+	if sale.Member != "" {
+		return fmt.Errorf("a sale can't be paid from a tab and a voucher")
+	}
+	i := s.VoucherIndex(sale.Voucher)
+	if i < 0 {
+		return errUnknownVoucher
+	}
+	v := &s.Vouchers[i]
+	if v.Balance <= 0 {
+		return errVoucherEmpty
+	}
+	sale.Voucher = v.Code
+	sale.VoucherAmount = domain.RoundCents(min(v.Balance, max(sale.Total(), 0)))
+	v.Balance = domain.RoundCents(v.Balance - sale.VoucherAmount)
+	return nil
+}
+
+// refundVoucher undoes what sale did to vouchers: what it paid with one
+// goes back on it, and one it sold goes away, unless it has been spent
+// from since.
+func (s *Store) refundVoucher(sale domain.Sale) error {
+	for i, v := range s.Vouchers {
+		if v.Sale != sale.ID {
+			continue
+		}
+		if v.Balance != v.Value {
+			return fmt.Errorf("voucher %s has already been spent from", v.Code)
+		}
+		s.Vouchers = append(s.Vouchers[:i], s.Vouchers[i+1:]...)
+		break
+	}
+	if sale.Voucher != "" {
+		if i := s.VoucherIndex(sale.Voucher); i >= 0 {
+			s.Vouchers[i].Balance = domain.RoundCents(s.Vouchers[i].Balance + sale.VoucherAmount)
+		}
+	}
+	return nil
+}
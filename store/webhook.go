@@ -0,0 +1,126 @@
+package store
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- WEBHOOKS ---
+
+// Webhook is called with a POST for every sale, e.g. to tell a chat room
+// or a bot tracking the revenue.
+type Webhook struct {
+	URL string `json:"url"`
+	// Format is "json" for the full SalePayload, or "text" for a
+	// {"text": "2x Club-Mate sold, €3.00"} message as Slack, Mattermost
+	// and most Matrix bridges take it.
+	Format string `json:"format,omitempty"`
+	// Headers are sent along, e.g. for authorization.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// SalePayload is what a "json" webhook gets.
+type SalePayload struct {
+	Event string        `json:"event"`
+	ID    int           `json:"id"`
+	Time  time.Time     `json:"time"`
+	Items []PayloadItem `json:"items"`
+	Total float64       `json:"total"`
+	// Payer is the member whose tab was charged, if any.
+	Payer *PayloadPayer `json:"payer,omitempty"`
+	Text  string        `json:"text"`
+}
+
+type PayloadItem struct {
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Total     float64 `json:"total"`
+}
+
+type PayloadPayer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+const webhookTimeout = 5 * time.Second
+
+// DeliveriesPending tracks webhook and event deliveries still running, so
+// that short-lived commands like sell can wait for them before exiting.
+var DeliveriesPending sync.WaitGroup
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// notify delivers a saved sale to the webhooks in the background. Failed
+// deliveries are logged and not retried; the sale stands either way.
+func (s *Store) notify(sale domain.Sale) {
+	if len(s.webhooks) == 0 {
+		return
+	}
+	payload := s.salePayload(sale)
+	for _, hook := range s.webhooks {
+		DeliveriesPending.Add(1)
+		go func() {
+			defer DeliveriesPending.Done()
+			if err := hook.deliver(payload); err != nil {
+				fmt.Fprintf(os.Stderr, "webhook %s: %v\n", hook.URL, err)
+			}
+		}()
+	}
+}
+
+func (s *Store) salePayload(sale domain.Sale) SalePayload {
+	p := SalePayload{Event: "sale", ID: sale.ID, Time: sale.Time, Total: domain.RoundCents(sale.Total())}
+	for _, l := range sale.Lines {
+		p.Items = append(p.Items, PayloadItem{Name: l.Name, Quantity: l.Quantity, UnitPrice: l.UnitPrice, Total: domain.RoundCents(l.Gross())})
+	}
+	p.Text = fmt.Sprintf("%s sold, €%.2f", sale.Items(), p.Total)
+	if sale.Member != "" {
+		p.Payer = &PayloadPayer{ID: sale.Member}
+		if i := s.MemberIndex(sale.Member); i >= 0 {
+			p.Payer.Name = s.Members[i].Name
+		}
+		p.Text += " on the tab of " + cmp.Or(p.Payer.Name, p.Payer.ID)
+	}
+	return p
+}
+
+func (h Webhook) deliver(p SalePayload) error {
+	var body any = p
+	switch h.Format {
+	case "", "json":
+	case "text":
+		body = map[string]string{"text": p.Text}
+	default:
+		return fmt.Errorf("unknown format %q (use json or text)", h.Format)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- WRITE-OFFS ---
+
+// Stock that goes without being sold, like a dropped bottle or goods past
+// their date, is written off with the reason. It leaves the stock but
+// isn't a sale, so it is neither revenue nor tax; the shrinkage report sums
+// it up at cost.
+
+// WriteOffReason says why stock was written off.
+type WriteOffReason string
+
+const (
+	WriteOffBreakage WriteOffReason = "breakage"
+	WriteOffExpired  WriteOffReason = "expired"
+	WriteOffSpoiled  WriteOffReason = "spoiled"
+	WriteOffStaff    WriteOffReason = "staff" // drunk by whoever works the bar
+	// WriteOffMissing is what a stocktake found missing, see
+	// ApplyStocktake.
+	WriteOffMissing WriteOffReason = "missing"
+	WriteOffOther   WriteOffReason = "other"
+)
+
+// WriteOffReasons are the reasons stock can be written off for.
+var WriteOffReasons = []WriteOffReason{WriteOffBreakage, WriteOffExpired, WriteOffSpoiled, WriteOffStaff, WriteOffMissing, WriteOffOther}
+
+func ParseWriteOffReason(s string) (WriteOffReason, error) {
+	if r := WriteOffReason(s); slices.Contains(WriteOffReasons, r) {
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown reason %q (use one of %v)", s, WriteOffReasons)
+}
+
+// WriteOff is stock taken out of the inventory without being sold.
+// Quantity is in the beverage's stock unit; Cost is what it cost the bar,
+// at the beverage's cost at the time, see unitCost.
+type WriteOff struct {
+	Time     time.Time      `json:"time"`
+	Beverage string         `json:"beverage"`
+	Quantity float64        `json:"quantity"`
+	Unit     domain.Unit    `json:"unit,omitempty"`
+	Reason   WriteOffReason `json:"reason"`
+	Note     string         `json:"note,omitempty"`
+	Cost     float64        `json:"cost,omitempty"`
+}
+
+// WriteOff takes stock out of the inventory and logs it.
+func (s *Store) WriteOff(w WriteOff) error {
+	if w.Quantity <= 0 {
+		return fmt.Errorf("quantity must be positive")
+	}
+	if _, err := ParseWriteOffReason(string(w.Reason)); err != nil {
+		return err
+	}
+	return s.Update(func() error {
+		if s.Lockdown == LockdownReadOnly {
+			return errReadOnly
+		}
+		i := s.BeverageIndex(w.Beverage)
+		if i < 0 {
+			return fmt.Errorf("unknown beverage %q", w.Beverage)
+		}
+		b := &s.Beverages[i]
+		if b.IsRecipe() {
+			return fmt.Errorf("%s is made from a recipe; write off its ingredients instead", b.Key())
+		}
+		if w.Quantity > b.Stock {
+			return fmt.Errorf("not enough %s in stock (%s)", b.Key(), b.StockLabel())
+		}
+		b.Stock -= w.Quantity
+		w = s.addWriteOff(w, *b)
+		s.Audit(AuditEntry{
+			Time: w.Time, Event: "writeoff", Beverage: b.Key(), Quantity: -w.Quantity,
+			Amount: w.Cost, Detail: string(w.Reason) + cmp.Or(": "+w.Note, ""),
+		})
+		return nil
+	})
+}
+
+// addWriteOff books w, of stock of b that already left the inventory, at
+// b's cost.
+func (s *Store) addWriteOff(w WriteOff, b domain.Beverage) WriteOff {
+	w.Unit = b.Unit
+	w.Cost = domain.RoundCents(w.Quantity * s.unitCost(b))
+	s.WriteOffs = append(s.WriteOffs, w)
+	return w
+}
+
+// unitCost is what a unit of b's stock costs the bar: its cost, or without
+// one that of its last delivery with a cost.
+func (s *Store) unitCost(b domain.Beverage) float64 {
+	if b.Cost > 0 {
+		return b.Cost / b.PortionSize()
+	}
+	for _, r := range slices.Backward(s.Restocks) {
+		if r.Beverage == b.Key() && r.Cost > 0 && r.Quantity > 0 {
+			return r.Cost / r.Quantity
+		}
+	}
+	return 0
+}
+
+// WriteOffsBetween returns the write-offs booked in [from, to).
+func (s *Store) WriteOffsBetween(from, to time.Time) []WriteOff {
+	var writeOffs []WriteOff
+	for _, w := range s.WriteOffs {
+		if !w.Time.Before(from) && w.Time.Before(to) {
+			writeOffs = append(writeOffs, w)
+		}
+	}
+	return writeOffs
+}
+
+// Shrinkage is what was written off of a beverage for a reason.
+type Shrinkage struct {
+	Beverage string
+	Reason   WriteOffReason
+	Unit     domain.Unit
+	Quantity float64
+	Cost     float64
+	Count    int // write-offs
+}
+
+// SumShrinkage sums up write-offs by beverage and reason, the costliest
+// first.
+func SumShrinkage(writeOffs []WriteOff) []Shrinkage {
+	type key struct {
+		beverage string
+		reason   WriteOffReason
+	}
+	byKey := map[key]*Shrinkage{}
+	var sums []*Shrinkage
+	for _, w := range writeOffs {
+		k := key{w.Beverage, w.Reason}
+		sum, ok := byKey[k]
+		if !ok {
+			sum = &Shrinkage{Beverage: w.Beverage, Reason: w.Reason, Unit: w.Unit}
+			byKey[k] = sum
+			sums = append(sums, sum)
+		}
+		sum.Quantity += w.Quantity
+		sum.Cost += w.Cost
+		sum.Count++
+	}
+	shrinkage := make([]Shrinkage, len(sums))
+	for i, sum := range sums {
+		shrinkage[i] = *sum
+	}
+	slices.SortStableFunc(shrinkage, func(a, b Shrinkage) int {
+		return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(b.Quantity, a.Quantity))
+	})
+	return shrinkage
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+// --- ACCESSIBILITY ---
+
+// Accessibility selects a display mode for those who can't make out the
+// regular theme. The high-contrast and colorblind modes bring their own
+// theme, replacing the configured one, and mark the selected and low-stock
+// rows with a symbol as well as a color.
+type Accessibility string
+
+const (
+	AccessibilityOff Accessibility = ""
+	// AccessibilityHighContrast draws in black, white and yellow.
+	AccessibilityHighContrast Accessibility = "high-contrast"
+	// AccessibilityColorblind uses blue and orange, which stay apart with
+	// every common color vision deficiency.
+	AccessibilityColorblind Accessibility = "colorblind"
+	// AccessibilityPlain draws the kiosk as plain labeled lines for
+	// braille displays and screen readers, without colors or borders.
+	AccessibilityPlain Accessibility = "plain"
+)
+
+func (a Accessibility) validate() error {
+	switch a {
+	case AccessibilityOff, AccessibilityHighContrast, AccessibilityColorblind, AccessibilityPlain:
+		return nil
+	}
+	return fmt.Errorf("unknown accessibility mode %q (use high-contrast, colorblind or plain)", a)
+}
+
+// Adapt fits the terminal profile to the mode: plain text has neither
+// colors, Unicode glyphs, images nor the mouse.
+func (a Accessibility) Adapt(p TerminalProfile) TerminalProfile {
+	if a == AccessibilityPlain {
+		p.colors, p.unicode, p.mouse, p.graphics = termenv.Ascii, false, false, graphicsNone
+	}
+	return p
+}
+
+// ThemeConfig is the theme to draw with: the mode's own, if one is on.
+func (c Config) ThemeConfig() ThemeConfig {
+	if c.Accessibility == AccessibilityHighContrast || c.Accessibility == AccessibilityColorblind {
+		return ThemeConfig{Preset: string(c.Accessibility)}
+	}
+	return c.Theme
+}
+
+// markRow puts a symbol into the padding in front of a table row.
+func markRow(symbol string) func(string) string {
+	return func(row string) string {
+		if rest, ok := strings.CutPrefix(row, " "); ok {
+			return symbol + rest
+		}
+		return row
+	}
+}
+
+// TimeLimits lengthens or lifts every time limit a customer has to beat,
+// for those who need longer to read the screen or to find the keys.
+type TimeLimits struct {
+	// Scale multiplies the limits, e.g. 3 for three times as long.
+	Scale float64 `json:"scale,omitempty"`
+	// Off lifts them altogether.
+	Off bool `json:"off,omitempty"`
+}
+
+// noTimeLimit stands in for a limit that is lifted; it is far enough away
+// for anyone and still safe to add to a time.
+const noTimeLimit = 100 * 365 * 24 * time.Hour
+
+func (t TimeLimits) validate() error {
+	if t.Scale != 0 && t.Scale < 1 {
+		return fmt.Errorf("time_limits scale must be at least 1, not %g", t.Scale)
+	}
+	return nil
+}
+
+// timeLimits are the settings that put a customer under time pressure:
+// the undo window after checkout and the card sessions at the espresso
+// machine and the taps. Every new one has to be listed here, so that
+// RelaxTimeLimits covers it.
+func (c *Config) timeLimits() []*Duration {
+	return []*Duration{&c.UndoGrace, &c.Coffee.SessionTimeout, &c.Kegs.SessionTimeout}
+}
+
+// RelaxTimeLimits applies TimeLimits to all of timeLimits. It runs once
+// the config is loaded, so no view or device has to care.
+func (c *Config) RelaxTimeLimits() {
+	for _, d := range c.timeLimits() {
+		switch {
+		case c.TimeLimits.Off:
+			d.Duration = noTimeLimit
+		case c.TimeLimits.Scale > 1:
+			d.Duration = time.Duration(float64(d.Duration) * c.TimeLimits.Scale)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"strconv"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- ACCOUNTING ---
+
+// Category groups beverages for bookkeeping. Every sale line records the
+// category and account of its beverage at the time of the sale, so that
+// the accounting export stays right when categories change later.
+type Category struct {
+	// Account is the bookkeeping account the lines are booked to; it
+	// defaults to the category name.
+	Account string `json:"account,omitempty"`
+	// NonRevenue lines, like deposits or transfers between members, aren't
+	// sales: they're tax exempt and left out of the tax report.
+	NonRevenue bool `json:"non_revenue,omitempty"`
+}
+
+// exemptTaxClass is the tax class of non-revenue lines.
+const exemptTaxClass = "exempt"
+
+// category returns the named category, falling back to the default
+// category for unknown or empty names.
+func (c Config) category(name string) (string, Category) {
+	if _, ok := c.Categories[name]; !ok {
+		name = c.DefaultCategory
+	}
+	cat := c.Categories[name]
+	if cat.Account == "" {
+		cat.Account = name
+	}
+	return name, cat
+}
+
+// Classify sets the tax and bookkeeping fields of a line for beverage b.
+// Lines without an inventory item pass the zero Beverage and get the
+// defaults.
+func (c Config) Classify(line *domain.SaleLine, b domain.Beverage) {
+	var cat Category
+	line.Category, cat = c.category(b.Category)
+	line.Account, line.NonRevenue = cat.Account, cat.NonRevenue
+	if cat.NonRevenue {
+		line.TaxClass, line.TaxRate = exemptTaxClass, 0
+		return
+	}
+	line.TaxClass, line.TaxRate = c.taxRate(b.TaxClass)
+}
+
+// LineAccount returns the account of a line. Lines booked before there
+// were categories go to the default category's account.
+func (c Config) LineAccount(l domain.SaleLine) (category, account string) {
+	if l.Account != "" {
+		return l.Category, l.Account
+	}
+	name, cat := c.category("")
+	return name, cat.Account
+}
+
+// Money writes an amount for the CSV, which doesn't follow the locale.
+func Money(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
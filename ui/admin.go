@@ -0,0 +1,562 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- ADMIN TUI ---
+
+// The admin TUI manages the till from a terminal of its own, typically
+// over SSH on the machine that holds the store:
+//
+//	ssh fridge bubbletender admin
+//
+// It only has the management views, so it can run next to the daemon and
+// the kiosks without getting in their way; changes go through the same
+// locked updates as the commands.
+
+type adminView int
+
+const (
+	adminCatalog adminView = iota
+	adminMembers
+	adminReports
+	adminStatus
+	adminCount
+)
+
+var adminViewNames = []string{"Catalog", "Members", "Reports", "Status", "Count"}
+
+// adminPrompt is the value being typed in, if any.
+type adminPrompt int
+
+const (
+	promptNone adminPrompt = iota
+	promptPrice
+	promptRestock
+	promptWriteOff
+	promptTopUp
+	promptBanner
+	promptCount
+)
+
+type adminKeyMap struct {
+	Up, Down         key.Binding
+	NextView         key.Binding
+	PrevView         key.Binding
+	Price, Restock   key.Binding
+	WriteOff         key.Binding
+	TopUp            key.Binding
+	Lockdown, Banner key.Binding
+	ClearBanner      key.Binding
+	TaxReport        key.Binding
+	PrevRange        key.Binding
+	NextRange        key.Binding
+	Count            key.Binding
+	Accept           key.Binding
+	ClearCounts      key.Binding
+	Apply, Back      key.Binding
+	Quit             key.Binding
+}
+
+var adminKeys = adminKeyMap{
+	Up:          key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:        key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	NextView:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next view")),
+	PrevView:    key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "previous view")),
+	Price:       key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "price")),
+	Restock:     key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "restock")),
+	WriteOff:    key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "write off")),
+	TopUp:       key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "top up")),
+	Lockdown:    key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "lockdown")),
+	Banner:      key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "banner")),
+	ClearBanner: key.NewBinding(key.WithKeys("B"), key.WithHelp("B", "clear banner")),
+	TaxReport:   key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "sales/tax")),
+	PrevRange:   key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←", "shorter")),
+	NextRange:   key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→", "longer")),
+	Count:       key.NewBinding(key.WithKeys("enter", "c"), key.WithHelp("enter", "count")),
+	Accept:      key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "accept counts")),
+	ClearCounts: key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "clear counts")),
+	Apply:       key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "apply")),
+	Back:        key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	Quit:        key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+func (k *adminKeyMap) bindings() []*key.Binding {
+	return []*key.Binding{&k.Up, &k.Down, &k.NextView, &k.PrevView, &k.Price, &k.Restock, &k.WriteOff, &k.TopUp,
+		&k.Lockdown, &k.Banner, &k.ClearBanner, &k.TaxReport, &k.PrevRange, &k.NextRange,
+		&k.Count, &k.Accept, &k.ClearCounts, &k.Apply, &k.Back, &k.Quit}
+}
+
+// lockdownCycle is the order the lockdown key steps through.
+var lockdownCycle = []store.Lockdown{store.LockdownNone, store.LockdownPriceFreeze, store.LockdownReadOnly}
+
+// adminChrome is everything around the tables: the view line, the notice,
+// the help and the padding.
+const adminChrome = 9
+
+// AdminModel is the admin console for members, stock and reports.
+type AdminModel struct {
+	store       *store.Store
+	config      Config
+	view        adminView
+	catalog     table.Model
+	members     table.Model
+	count       table.Model
+	counts      map[string]store.StockCount // by beverage, see countView
+	input       textinput.Model
+	prompt      adminPrompt
+	target      string // the beverage or member the prompt is for
+	reportRange int    // index into rankingRanges
+	taxReport   bool
+	notice      string
+	err         error
+	help        help.Model
+	now         time.Time
+	release     *store.Release // newer than this build, see checkRelease
+}
+
+// NewAdminModel returns the admin console for the store.
+func NewAdminModel(cfg Config, st *store.Store) AdminModel {
+	newTable := func(cols []table.Column) table.Model {
+		t := table.New(table.WithColumns(cols), table.WithHeight(10))
+		s := table.DefaultStyles()
+		s.Header = s.Header.BorderStyle(glyphs.border).BorderBottom(true)
+		s.Selected = s.Selected.Foreground(theme.SelectedForeground).Background(theme.SelectedBackground).Bold(false)
+		t.SetStyles(s)
+		return t
+	}
+	m := AdminModel{
+		store:  st,
+		config: cfg,
+		catalog: newTable([]table.Column{
+			{Title: "Beverage", Width: 24}, {Title: "Price", Width: 14},
+			{Title: "Stock", Width: 12}, {Title: "Available", Width: 10},
+			{Title: "Best before", Width: 12},
+		}),
+		members: newTable([]table.Column{
+			{Title: "ID", Width: 16}, {Title: "Name", Width: 24}, {Title: "Balance", Width: 10},
+		}),
+		count: newTable([]table.Column{
+			{Title: "Beverage", Width: 24}, {Title: "Book", Width: 12},
+			{Title: "Counted", Width: 12}, {Title: "Difference", Width: 12},
+		}),
+		counts: map[string]store.StockCount{},
+		input:  textinput.New(),
+		help:   newHelp(),
+		now:    time.Now(),
+	}
+	m.catalog.Focus()
+	m.updateTables()
+	return m
+}
+
+// updateTables fills the tables from the store, keeping the cursors. The
+// catalog has the date of the batch of each beverage that keeps the
+// shortest, marked if it is about to expire.
+func (m *AdminModel) updateTables() {
+	bestBefore := map[string]string{}
+	for _, batch := range m.store.Batches() {
+		if _, ok := bestBefore[batch.Beverage]; !ok {
+			bestBefore[batch.Beverage] = batch.BestBefore.Format(time.DateOnly)
+			if batch.Expiring(m.now, m.config.ExpiryDays) {
+				bestBefore[batch.Beverage] += " !"
+			}
+		}
+	}
+	var rows []table.Row
+	for _, b := range m.store.Beverages {
+		available := strconv.Itoa(b.AvailableFrom(m.store.Beverages))
+		if b.IsLowStock(m.config.LowStock) {
+			available += " !"
+		}
+		stock := b.StockLabel()
+		if b.IsRecipe() {
+			stock = "recipe"
+		}
+		rows = append(rows, table.Row{b.Key(), priceLabel(b), stock, available, bestBefore[b.Key()]})
+	}
+	m.catalog.SetRows(rows)
+	rows = nil
+	for _, mem := range m.store.Members {
+		if mem.IsGuest() {
+			continue
+		}
+		rows = append(rows, table.Row{mem.ID, mem.Name, uiLocale.Number(mem.Balance)})
+	}
+	m.members.SetRows(rows)
+	m.count.SetRows(m.countRows())
+}
+
+func (m AdminModel) Init() tea.Cmd {
+	return tea.Batch(pollStore(), checkRelease(m.config.Updates, 0))
+}
+
+func (m AdminModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+		m.catalog.SetHeight(max(3, msg.Height-adminChrome))
+		m.members.SetHeight(max(3, msg.Height-adminChrome))
+		m.count.SetHeight(max(3, msg.Height-adminChrome))
+		return m, nil
+	case storeTickMsg:
+		// A failed reload keeps the state we already have.
+		_ = m.store.Reload()
+		m.now = time.Time(msg)
+		m.updateTables()
+		return m, pollStore()
+	case releaseMsg:
+		m.release = msg.release
+		return m, checkRelease(m.config.Updates, m.config.Updates.Check.Duration)
+	case tea.KeyMsg:
+		if m.prompt != promptNone {
+			return m.updatePrompt(msg)
+		}
+		m.notice, m.err = "", nil
+		switch {
+		case key.Matches(msg, adminKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, adminKeys.NextView):
+			m.setView((m.view + 1) % adminView(len(adminViewNames)))
+			return m, nil
+		case key.Matches(msg, adminKeys.PrevView):
+			m.setView((m.view + adminView(len(adminViewNames)) - 1) % adminView(len(adminViewNames)))
+			return m, nil
+		}
+		if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 && n <= len(adminViewNames) {
+			m.setView(adminView(n - 1))
+			return m, nil
+		}
+		return m.updateView(msg)
+	}
+	return m, nil
+}
+
+func (m *AdminModel) setView(v adminView) {
+	m.view = v
+	m.catalog.Blur()
+	m.members.Blur()
+	m.count.Blur()
+	switch v {
+	case adminCatalog:
+		m.catalog.Focus()
+	case adminMembers:
+		m.members.Focus()
+	case adminCount:
+		m.count.Focus()
+	}
+}
+
+// updateView handles the keys of the current view.
+func (m AdminModel) updateView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch m.view {
+	case adminCatalog:
+		switch {
+		case key.Matches(msg, adminKeys.Price):
+			return m.startPrompt(promptPrice, m.selected(m.catalog), "New price: ")
+		case key.Matches(msg, adminKeys.Restock):
+			return m.startPrompt(promptRestock, m.selected(m.catalog), "Quantity delivered [best before]: ")
+		case key.Matches(msg, adminKeys.WriteOff):
+			return m.startPrompt(promptWriteOff, m.selected(m.catalog), "Write off quantity and reason: ")
+		}
+		m.catalog, cmd = m.catalog.Update(msg)
+	case adminMembers:
+		if key.Matches(msg, adminKeys.TopUp) {
+			return m.startPrompt(promptTopUp, m.selected(m.members), "Top up by: ")
+		}
+		m.members, cmd = m.members.Update(msg)
+	case adminReports:
+		switch {
+		case key.Matches(msg, adminKeys.TaxReport):
+			m.taxReport = !m.taxReport
+		case key.Matches(msg, adminKeys.PrevRange):
+			m.reportRange = max(0, m.reportRange-1)
+		case key.Matches(msg, adminKeys.NextRange):
+			m.reportRange = min(len(rankingRanges)-1, m.reportRange+1)
+		}
+	case adminStatus:
+		switch {
+		case key.Matches(msg, adminKeys.Lockdown):
+			next := lockdownCycle[0]
+			for i, mode := range lockdownCycle {
+				if mode == m.store.Lockdown {
+					next = lockdownCycle[(i+1)%len(lockdownCycle)]
+				}
+			}
+			if m.err = m.store.SetLockdown(next); m.err == nil {
+				m.notice = "Lockdown: " + string(cmp.Or(next, "off"))
+			}
+		case key.Matches(msg, adminKeys.Banner):
+			return m.startPrompt(promptBanner, "", "Banner for 24h: ")
+		case key.Matches(msg, adminKeys.ClearBanner):
+			if m.err = m.store.SetBanner(nil); m.err == nil {
+				m.notice = "Banner cleared."
+			}
+		}
+	case adminCount:
+		return m.updateCount(msg)
+	}
+	return m, cmd
+}
+
+// selected returns the first column of the table's selected row.
+func (m AdminModel) selected(t table.Model) string {
+	if row := t.SelectedRow(); row != nil {
+		return row[0]
+	}
+	return ""
+}
+
+func (m AdminModel) startPrompt(p adminPrompt, target, label string) (tea.Model, tea.Cmd) {
+	if target == "" && p != promptBanner {
+		return m, nil
+	}
+	m.prompt, m.target = p, target
+	m.input.Reset()
+	m.input.Prompt = label
+	return m, m.input.Focus()
+}
+
+func (m AdminModel) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, adminKeys.Back):
+		m.prompt = promptNone
+		m.input.Blur()
+		return m, nil
+	case key.Matches(msg, adminKeys.Apply):
+		m.notice, m.err = m.apply(strings.TrimSpace(m.input.Value()))
+		if m.prompt == promptCount && m.err == nil {
+			m.count.MoveDown(1) // on to the next beverage to count
+		}
+		m.prompt = promptNone
+		m.input.Blur()
+		m.updateTables()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// apply carries out the prompt with the value typed in.
+func (m AdminModel) apply(value string) (string, error) {
+	if m.prompt == promptBanner {
+		if value == "" {
+			return "", nil
+		}
+		return "Banner set.", m.store.SetBanner(&store.Banner{Message: value, Expires: time.Now().Add(24 * time.Hour)})
+	}
+	switch m.prompt {
+	case promptRestock:
+		return m.restock(value)
+	case promptWriteOff:
+		return m.writeOff(value)
+	case promptCount:
+		return m.countStock(value)
+	}
+	amount, err := ParseDecimal(value)
+	if err != nil || amount < 0 {
+		return "", fmt.Errorf("invalid amount %q", value)
+	}
+	switch m.prompt {
+	case promptPrice:
+		return fmt.Sprintf("%s now costs %s.", m.target, uiLocale.money(amount)), m.store.SetPrice(m.target, amount)
+	case promptTopUp:
+		return fmt.Sprintf("Topped up %s by %s.", m.target, uiLocale.money(amount)), m.store.TopUp(m.target, amount)
+	}
+	return "", nil
+}
+
+// restock books the delivery typed in: its quantity, and the best-before
+// date printed on it if it has one, e.g. "24 2026-12-31".
+func (m AdminModel) restock(value string) (string, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", fmt.Errorf("invalid delivery %q; type the quantity and maybe the best-before date", value)
+	}
+	qty, err := ParseDecimal(fields[0])
+	if err != nil || qty <= 0 {
+		return "", fmt.Errorf("invalid quantity %q", fields[0])
+	}
+	r := store.Restock{Time: time.Now(), Beverage: m.target, Quantity: qty}
+	if len(fields) == 2 {
+		if r.BestBefore, err = time.ParseInLocation(time.DateOnly, fields[1], time.Local); err != nil {
+			return "", fmt.Errorf("invalid best-before date %q (YYYY-MM-DD)", fields[1])
+		}
+	}
+	return fmt.Sprintf("Restocked %g of %s.", qty, m.target), m.store.Restock(r)
+}
+
+// writeOff writes off the stock typed in: its quantity and the reason,
+// then what happened if there is more to say, e.g. "1 breakage dropped".
+func (m AdminModel) writeOff(value string) (string, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("type the quantity and one of the reasons %v", store.WriteOffReasons)
+	}
+	qty, err := ParseDecimal(fields[0])
+	if err != nil || qty <= 0 {
+		return "", fmt.Errorf("invalid quantity %q", fields[0])
+	}
+	reason, err := store.ParseWriteOffReason(fields[1])
+	if err != nil {
+		return "", err
+	}
+	w := store.WriteOff{Time: time.Now(), Beverage: m.target, Quantity: qty, Reason: reason, Note: strings.Join(fields[2:], " ")}
+	return fmt.Sprintf("Wrote off %g of %s (%s).", qty, m.target, reason), m.store.WriteOff(w)
+}
+
+// --- ADMIN VIEWS ---
+
+func (m AdminModel) View() string {
+	if m.config.Accessibility == AccessibilityPlain {
+		return m.plainView()
+	}
+	var views []string
+	for i, name := range adminViewNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if adminView(i) == m.view {
+			views = append(views, activeButtonStyle.Render(label))
+		} else {
+			views = append(views, buttonStyle.Render(label))
+		}
+	}
+
+	var content string
+	switch m.view {
+	case adminCatalog:
+		content = m.catalog.View()
+	case adminMembers:
+		content = m.members.View()
+		if len(m.members.Rows()) == 0 {
+			content = "No members yet; add them with the member command."
+		}
+	case adminReports:
+		content = m.reportView()
+	case adminStatus:
+		content = m.statusView()
+	case adminCount:
+		content = m.count.View()
+	}
+
+	var footer string
+	switch {
+	case m.prompt != promptNone:
+		footer = m.input.View()
+	case m.err != nil:
+		footer = warningStyle.Render(m.err.Error())
+	default:
+		footer = m.notice
+	}
+	return docStyle.Render(lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, views...), "", content, "", footer, m.help.View(m.helpKeys())))
+}
+
+func (m AdminModel) helpKeys() contextKeys {
+	general := []key.Binding{adminKeys.NextView, adminKeys.Quit}
+	if m.prompt != promptNone {
+		return contextKeys{short: []key.Binding{adminKeys.Apply, adminKeys.Back}}
+	}
+	var view []key.Binding
+	switch m.view {
+	case adminCatalog:
+		view = []key.Binding{adminKeys.Up, adminKeys.Down, adminKeys.Price, adminKeys.Restock, adminKeys.WriteOff}
+	case adminMembers:
+		view = []key.Binding{adminKeys.Up, adminKeys.Down, adminKeys.TopUp}
+	case adminReports:
+		view = []key.Binding{adminKeys.PrevRange, adminKeys.NextRange, adminKeys.TaxReport}
+	case adminStatus:
+		view = []key.Binding{adminKeys.Lockdown, adminKeys.Banner, adminKeys.ClearBanner}
+	case adminCount:
+		view = []key.Binding{adminKeys.Up, adminKeys.Down, adminKeys.Count, adminKeys.Accept, adminKeys.ClearCounts}
+	}
+	return contextKeys{short: append(view, general...)}
+}
+
+// reportView shows the sales or tax report over the chosen range, the
+// same as the report and tax-report commands print it.
+func (m AdminModel) reportView() string {
+	days := rankingRanges[m.reportRange]
+	y, mo, d := m.now.Date()
+	to := time.Date(y, mo, d+1, 0, 0, 0, 0, time.Local)
+	from := to.AddDate(0, 0, -days)
+	period := from.Format(time.DateOnly) + " – " + to.AddDate(0, 0, -1).Format(time.DateOnly)
+	sales := m.store.SalesBetween(from, to)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\n\n", glyphs.prev, rankingRangeLabel(days), glyphs.next)
+	var err error
+	if m.taxReport {
+		err = WriteTaxReport(&b, period, sales)
+	} else {
+		err = WriteSalesReport(&b, period, sales)
+	}
+	if err != nil {
+		return warningStyle.Render(err.Error())
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// statusView sums up what the kiosks are showing and doing right now.
+func (m AdminModel) statusView() string {
+	s := m.store
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Store\t%s\n", s.Path)
+	if m.release != nil {
+		fmt.Fprintf(w, "Version\t%s, %s; install it with bubbletender update\n", store.CurrentVersion(), releaseNotice(m.release))
+	} else {
+		fmt.Fprintf(w, "Version\t%s\n", store.CurrentVersion())
+	}
+	fmt.Fprintf(w, "Lockdown\t%s\n", cmp.Or(string(s.Lockdown), "off"))
+	if msg := s.ActiveBanner(m.now); msg != "" {
+		fmt.Fprintf(w, "Banner\t%s (until %s)\n", msg, s.Banner.Expires.Format("2006-01-02 15:04"))
+	} else {
+		fmt.Fprintf(w, "Banner\tnone\n")
+	}
+	if i := s.CurrentShift(); i >= 0 {
+		sh := s.Shifts[i]
+		fmt.Fprintf(w, "Shift\t#%d, open since %s by %s\n", sh.ID, sh.Opened.Format("2006-01-02 15:04"), sh.OpenedBy)
+	} else {
+		fmt.Fprintf(w, "Shift\tnone open\n")
+	}
+	fmt.Fprintf(w, "Closed through\t%s\n", cmp.Or(s.ClosedThrough(), "no day closed"))
+	st := s.SalesStats(m.now)
+	fmt.Fprintf(w, "Today\t%s in %d sales\n", uiLocale.money(st.TodayRevenue), st.TodaySales)
+	if n := len(s.Sales); n > 0 {
+		fmt.Fprintf(w, "Last sale\t#%d at %s\n", s.Sales[n-1].ID, s.Sales[n-1].Time.Format("2006-01-02 15:04"))
+	}
+	var low []string
+	for _, bev := range s.Beverages {
+		if bev.IsLowStock(m.config.LowStock) {
+			low = append(low, fmt.Sprintf("%s (%s)", bev.Key(), bev.StockLabel()))
+		}
+	}
+	fmt.Fprintf(w, "Low stock\t%s\n", cmp.Or(strings.Join(low, ", "), "none"))
+	var expiring []string
+	for _, batch := range s.ExpiringBatches(m.now, m.config.ExpiryDays) {
+		due := "by"
+		if batch.Expired(m.now) {
+			due = "expired"
+		}
+		expiring = append(expiring, fmt.Sprintf("%s (%s %s %s)", batch.Beverage, batch.Unit.Format(batch.Quantity), due, batch.BestBefore.Format(time.DateOnly)))
+	}
+	fmt.Fprintf(w, "Expiring\t%s\n", cmp.Or(strings.Join(expiring, ", "), "none"))
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
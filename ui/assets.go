@@ -0,0 +1,285 @@
+package ui
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- ASSETS ---
+
+// The default themes, templates and locales are built into the binary.
+// Files of the same name in the configured assets directory replace them,
+// and new ones there add to them:
+//
+//	themes/<preset>.json
+//	templates/receipt.tmpl   (text/template, gets the Sale)
+//	templates/board.html     (html/template, gets the menu board)
+//	locales/<locale>.json
+//
+//go:embed assets
+var embeddedAssets embed.FS
+
+// overlayFS serves files from dir where they exist and from base otherwise.
+type overlayFS struct {
+	dir  fs.FS
+	base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if o.dir != nil {
+		if f, err := o.dir.Open(name); err == nil {
+			return f, nil
+		}
+	}
+	return o.base.Open(name)
+}
+
+// ReadDir merges both directories, so that a theme in the assets directory
+// shows up next to the built-in ones.
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(o.base, name)
+	if o.dir == nil {
+		return entries, err
+	}
+	extra, derr := fs.ReadDir(o.dir, name)
+	if err != nil && derr != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		seen[e.Name()] = true
+	}
+	for _, e := range extra {
+		if !seen[e.Name()] {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// AssetFS returns the built-in assets overlaid with those in dir, if set.
+func AssetFS(dir string) fs.FS {
+	base, _ := fs.Sub(embeddedAssets, "assets")
+	if dir == "" {
+		return overlayFS{base: base}
+	}
+	return overlayFS{dir: os.DirFS(dir), base: base}
+}
+
+var (
+	receiptTemplate *template.Template
+	boardHTML       *htmltemplate.Template
+	// uiLocale is the language of the kiosk and the menu board,
+	// PrintLocale that of receipts and reports.
+	uiLocale, PrintLocale locale
+)
+
+func init() {
+	if err := ApplyAssets(AssetFS(""), "en", "en"); err != nil {
+		panic(err)
+	}
+}
+
+// tr looks up a message of the UI locale.
+func tr(key string) string { return uiLocale.T(key) }
+
+// trf formats a message of the UI locale.
+func trf(key string, args ...any) string { return fmt.Sprintf(tr(key), args...) }
+
+// EnvLocale picks the locale from the environment the way gettext does,
+// e.g. "de" for LANG=de_DE.UTF-8. Languages without a translation get
+// English.
+func EnvLocale(assets fs.FS) string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		lang, _, _ := strings.Cut(value, ".")
+		lang, _, _ = strings.Cut(lang, "_")
+		if _, err := fs.Stat(assets, "locales/"+lang+".json"); err == nil {
+			return lang
+		}
+		break
+	}
+	return "en"
+}
+
+// ApplyAssets loads the theme presets, templates and locales. Like
+// ApplyTheme, it has to run before the model is created.
+func ApplyAssets(assets fs.FS, ui, print string) error {
+	presets, err := loadThemePresets(assets)
+	if err != nil {
+		return err
+	}
+	uiMsgs, err := loadLocale(assets, ui)
+	if err != nil {
+		return err
+	}
+	printMsgs, err := loadLocale(assets, print)
+	if err != nil {
+		return err
+	}
+	receipt, err := template.New("receipt.tmpl").Funcs(template.FuncMap{
+		"t":          printMsgs.T,
+		"money":      printMsgs.money,
+		"percent":    printMsgs.percent,
+		"lineLabel":  printMsgs.lineLabel,
+		"taxSummary": func(s domain.Sale) []domain.TaxSummary { return domain.SummarizeTax([]domain.Sale{s}) },
+	}).ParseFS(assets, "templates/receipt.tmpl")
+	if err != nil {
+		return err
+	}
+	board, err := htmltemplate.New("board.html").Funcs(htmltemplate.FuncMap{
+		"t": uiMsgs.T,
+	}).ParseFS(assets, "templates/board.html")
+	if err != nil {
+		return err
+	}
+	themePresets, uiLocale, PrintLocale, receiptTemplate, boardHTML = presets, uiMsgs, printMsgs, receipt, board
+	keys.localize()
+	return nil
+}
+
+// locale holds the messages of a language. Besides the messages, locale
+// files set how numbers are written: decimal_separator,
+// thousands_separator, and currency_format with %s where the amount goes,
+// e.g. "%s €".
+type locale map[string]string
+
+// T looks up a message, falling back to its key.
+func (l locale) T(key string) string {
+	if msg, ok := l[key]; ok {
+		return msg
+	}
+	return key
+}
+
+// Number formats v with two decimals and the thousands grouped.
+func (l locale) Number(v float64) string {
+	digits := strconv.FormatFloat(math.Abs(v), 'f', 2, 64)
+	whole, frac, _ := strings.Cut(digits, ".")
+	var b strings.Builder
+	if v < 0 && digits != "0.00" {
+		b.WriteByte('-')
+	}
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.T("thousands_separator"))
+		}
+		b.WriteRune(d)
+	}
+	return b.String() + l.T("decimal_separator") + frac
+}
+
+func (l locale) money(v float64) string {
+	return strings.Replace(l.T("currency_format"), "%s", l.Number(v), 1)
+}
+
+func (l locale) percent(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', 1, 64), ".", l.T("decimal_separator"), 1) + "%"
+}
+
+// lineLabel is the name a sale line is listed under, with the bundle it
+// was sold in.
+func (l locale) lineLabel(line domain.SaleLine) string {
+	if line.Bundle > 0 {
+		return fmt.Sprintf(l.T("bundle_line"), line.Label(), line.Bundle)
+	}
+	return line.Label()
+}
+
+// loadLocale reads the messages of a locale on top of the English ones, so
+// that a partial translation still shows every message.
+func loadLocale(assets fs.FS, name string) (locale, error) {
+	msgs := locale{}
+	for _, name := range []string{"en", name} {
+		data, err := fs.ReadFile(assets, "locales/"+name+".json")
+		if err != nil {
+			if name != "en" && errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("unknown locale %q", name)
+			}
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, fmt.Errorf("locales/%s.json: %w", name, err)
+		}
+	}
+	return msgs, nil
+}
+
+// themeColor is a color in a theme file: either a single color or
+// {"light": ..., "dark": ...} for one per terminal background.
+type themeColor struct{ lipgloss.TerminalColor }
+
+func (c *themeColor) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		c.TerminalColor = lipgloss.Color(s)
+		return nil
+	}
+	var adaptive lipgloss.AdaptiveColor
+	if err := json.Unmarshal(b, &adaptive); err != nil {
+		return errors.New(`color must be a string or {"light": ..., "dark": ...}`)
+	}
+	c.TerminalColor = adaptive
+	return nil
+}
+
+// orNone leaves colors a theme file doesn't set to the terminal.
+func (c themeColor) orNone() lipgloss.TerminalColor {
+	if c.TerminalColor == nil {
+		return lipgloss.NoColor{}
+	}
+	return c.TerminalColor
+}
+
+type themeFile struct {
+	Tabs               themeColor `json:"tabs"`
+	Border             themeColor `json:"border"`
+	SelectedForeground themeColor `json:"selected_foreground"`
+	SelectedBackground themeColor `json:"selected_background"`
+	Warning            themeColor `json:"warning"`
+}
+
+func loadThemePresets(assets fs.FS) (map[string]Theme, error) {
+	entries, err := fs.ReadDir(assets, "themes")
+	if err != nil {
+		return nil, err
+	}
+	presets := map[string]Theme{}
+	for _, e := range entries {
+		if path.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := fs.ReadFile(assets, "themes/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		var f themeFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("themes/%s: %w", e.Name(), err)
+		}
+		presets[strings.TrimSuffix(e.Name(), ".json")] = Theme{
+			Tabs:               f.Tabs.orNone(),
+			Border:             f.Border.orNone(),
+			SelectedForeground: f.SelectedForeground.orNone(),
+			SelectedBackground: f.SelectedBackground.orNone(),
+			Warning:            f.Warning.orNone(),
+		}
+	}
+	return presets, nil
+}
@@ -0,0 +1,14 @@
+package ui
+
+import "github.com/arunoruto/BubbleTender/store"
+
+// --- AUDIT LOG ---
+
+// auditCart logs a change of the cart right away; the cart isn't saved.
+func (m Model) auditCart(name string, from, to int, detail string) {
+	f, t := float64(from), float64(to)
+	m.store.AuditLog.Write(store.AuditEntry{
+		Time: m.now(), Actor: m.store.Actor, Event: "cart",
+		Beverage: name, From: &f, To: &t, Detail: detail,
+	})
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- BANK IMPORT REVIEW ---
+
+// BankReview lists the transfers of a statement for review before they
+// are booked; bookable ones can be left out.
+type BankReview struct {
+	transfers []store.BankTransfer
+	skip      map[int]bool
+	cursor    int
+	Confirmed bool
+}
+
+// NewBankReview lists transfers for review, with every bookable one
+// selected.
+func NewBankReview(transfers []store.BankTransfer) BankReview {
+	return BankReview{transfers: transfers, skip: map[int]bool{}}
+}
+
+func (m BankReview) Init() tea.Cmd { return nil }
+
+func (m BankReview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(km, keys.Up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(km, keys.Down):
+		m.cursor = min(len(m.transfers)-1, m.cursor+1)
+	case key.Matches(km, bankToggle):
+		if m.transfers[m.cursor].Bookable() {
+			m.skip[m.cursor] = !m.skip[m.cursor]
+		}
+	case key.Matches(km, bankBook):
+		m.Confirmed = true
+		return m, tea.Quit
+	case key.Matches(km, abortKey):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m BankReview) SelectedTransfers() []store.BankTransfer {
+	var selected []store.BankTransfer
+	for i, t := range m.transfers {
+		if t.Bookable() && !m.skip[i] {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
+
+func (m BankReview) View() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for i, t := range m.transfers {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		box, member := "[x]", t.Member
+		switch {
+		case t.Problem != "":
+			box, member = "   ", t.Problem
+		case m.skip[i]:
+			box = "[ ]"
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t\n", cursor, box, t.Date.Format(time.DateOnly), uiLocale.money(t.Amount), member, t.Reference)
+	}
+	w.Flush()
+
+	n, total := 0, 0.0
+	for _, t := range m.SelectedTransfers() {
+		n++
+		total += t.Amount
+	}
+	rows := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	for i, t := range m.transfers {
+		if t.Problem != "" {
+			rows[i] = lipgloss.NewStyle().Faint(true).Render(rows[i])
+		}
+	}
+	summary := fmt.Sprintf("%d top-ups, %s in total", n, uiLocale.money(total))
+	return strings.Join(rows, "\n") + "\n\n" + summary + "\n" + plainKeys([]key.Binding{keys.Up, keys.Down, bankToggle, bankBook, abortKey}) + "\n"
+}
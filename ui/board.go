@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- MENU BOARD ---
+
+// BoardConfig configures the read-only menu board for the wall display.
+type BoardConfig struct {
+	Title    string    `json:"title"`
+	Specials []Special `json:"specials"`
+}
+
+// Special is a highlighted item on the menu board. Name is usually a
+// beverage, whose price and availability are then shown, but can be any
+// text. Days limits it to weekdays ("mon", "tue", ...); empty means every
+// day.
+type Special struct {
+	Name string   `json:"name"`
+	Note string   `json:"note,omitempty"`
+	Days []string `json:"days,omitempty"`
+}
+
+func (s Special) activeOn(t time.Time) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	today := strings.ToLower(t.Weekday().String()[:3])
+	return slices.ContainsFunc(s.Days, func(d string) bool { return strings.EqualFold(d, today) })
+}
+
+// menuItem is one line of the menu board.
+type menuItem struct {
+	Name      string
+	Price     string
+	Note      string
+	Available bool
+}
+
+type boardMenu struct {
+	Title    string
+	Banner   string
+	Specials []menuItem
+	Items    []menuItem
+	Updated  string
+}
+
+// BuildMenu collects what the board shows at the given time.
+func BuildMenu(cfg Config, st *store.Store, now time.Time) boardMenu {
+	item := func(b domain.Beverage) menuItem {
+		return menuItem{Name: b.Key(), Price: priceLabel(b), Available: b.AvailableFrom(st.Beverages) > 0}
+	}
+	m := boardMenu{
+		Title:   cfg.Board.Title,
+		Banner:  st.ActiveBanner(now),
+		Updated: now.Format("15:04"),
+	}
+	for _, s := range cfg.Board.Specials {
+		if !s.activeOn(now) {
+			continue
+		}
+		special := menuItem{Name: s.Name, Available: true}
+		if i := st.BeverageIndex(s.Name); i >= 0 {
+			special = item(st.Beverages[i])
+		}
+		special.Note = s.Note
+		m.Specials = append(m.Specials, special)
+	}
+	for _, b := range st.Beverages {
+		if !b.HiddenAt(now) {
+			m.Items = append(m.Items, item(b))
+		}
+	}
+	return m
+}
+
+var (
+	boardTitleStyle   = lipgloss.NewStyle().Bold(true).Padding(0, 2)
+	boardSectionStyle = lipgloss.NewStyle().Bold(true).Underline(true).MarginTop(1)
+	boardSoldOutStyle = lipgloss.NewStyle().Faint(true).Strikethrough(true)
+	boardNoteStyle    = lipgloss.NewStyle().Italic(true)
+)
+
+// BoardModel shows the menu full screen and reloads it with the store.
+// It takes no input apart from quitting.
+type BoardModel struct {
+	config Config
+	store  *store.Store
+	now    time.Time
+	width  int
+	height int
+}
+
+// NewBoardModel returns the menu board as of now.
+func NewBoardModel(cfg Config, s *store.Store, now time.Time) BoardModel {
+	return BoardModel{config: cfg, store: s, now: now}
+}
+
+func (m BoardModel) Init() tea.Cmd { return pollStore() }
+
+func (m BoardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case storeTickMsg:
+		_ = m.store.Reload()
+		m.now = time.Time(msg)
+		return m, pollStore()
+	case tea.KeyMsg:
+		if msg.String() == "q" || msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m BoardModel) View() string {
+	menu := BuildMenu(m.config, m.store, m.now)
+	nameWidth := 0
+	for _, it := range slices.Concat(menu.Specials, menu.Items) {
+		nameWidth = max(nameWidth, lipgloss.Width(it.Name))
+	}
+	line := func(it menuItem) string {
+		price := it.Price
+		if !it.Available {
+			price = tr("sold_out")
+		}
+		s := fmt.Sprintf("%-*s  %14s", nameWidth, it.Name, price)
+		if !it.Available {
+			s = boardSoldOutStyle.Render(s)
+		}
+		if it.Note != "" {
+			s += "\n" + boardNoteStyle.Render("  "+it.Note)
+		}
+		return s
+	}
+
+	section := func(title string, items []menuItem) string {
+		lines := []string{boardSectionStyle.Foreground(theme.Tabs).Render(title)}
+		for _, it := range items {
+			lines = append(lines, line(it))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	var parts []string
+	if menu.Banner != "" {
+		parts = append(parts, bannerStyle.Render(menu.Banner))
+	}
+	if len(menu.Specials) > 0 {
+		parts = append(parts, section(tr("todays_specials"), menu.Specials))
+	}
+	parts = append(parts, section(tr("drinks"), menu.Items))
+	body := lipgloss.JoinVertical(lipgloss.Left, parts...)
+	title := boardTitleStyle.Background(theme.Tabs).Foreground(lipgloss.Color("230")).Render(menu.Title)
+	board := lipgloss.JoinVertical(lipgloss.Center, title, body)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		windowStyle.Border(glyphs.rounded).Padding(1, 4).Render(board))
+}
+
+// WriteBoardHTML renders the menu as a self-refreshing page for the
+// signage player. The file is replaced atomically so the player never
+// loads half a page.
+func WriteBoardHTML(path string, m boardMenu, refresh time.Duration) error {
+	accent := "#7D56F4"
+	if c, ok := theme.Tabs.(lipgloss.AdaptiveColor); ok {
+		accent = c.Dark
+	} else if c, ok := theme.Tabs.(lipgloss.Color); ok && strings.HasPrefix(string(c), "#") {
+		accent = string(c)
+	}
+	var b bytes.Buffer
+	err := boardHTML.Execute(&b, struct {
+		boardMenu
+		Refresh int
+		Accent  string
+	}{m, int(refresh.Seconds()), accent})
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- STARTUP CACHE ---
+
+type storeLoadedMsg struct {
+	store *store.Store
+	err   error
+}
+
+// loadStore reads the whole store in the background into a copy of the
+// store's settings, which the model then takes over.
+func loadStore(s *store.Store) tea.Cmd {
+	full := s.Settings()
+	return func() tea.Msg {
+		err := full.Reload()
+		return storeLoadedMsg{store: full, err: err}
+	}
+}
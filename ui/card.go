@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- CARD PAYMENT ---
+
+// Paying by card puts the amount on the card terminal, see
+// store.CardConfig, and waits for the customer to pay there; the cart
+// can't change meanwhile. The sale is booked once the terminal reports the
+// payment as successful, with its reference. A payment that fails leaves
+// the cart as it was, to try again or pay some other way; the audit log
+// has why it failed.
+
+// cardPollInterval is how often the terminal is asked whether the payment
+// went through.
+const cardPollInterval = time.Second
+
+// cardTimeout is how long the customer has to pay before the payment is
+// taken off the terminal.
+const cardTimeout = 2 * time.Minute
+
+// cardCharge is a payment on the terminal that the kiosk waits for.
+type cardCharge struct {
+	ref     string
+	lines   []domain.SaleLine // what is paid for, as it was charged
+	amount  float64
+	started time.Time
+	// cancel is set once the payment is being taken off the terminal.
+	cancel bool
+	// failed is why the payment didn't go through, once it didn't.
+	failed string
+}
+
+type cardChargedMsg struct {
+	ref string
+	err error
+}
+
+type cardStatusMsg struct {
+	status store.CardStatus
+	reason string
+	err    error
+}
+
+type cardCancelMsg struct{ err error }
+
+// startCard puts what the cart costs on the terminal.
+func (m *Model) startCard() tea.Cmd {
+	terminal, err := m.config.Card.Terminal()
+	if err != nil {
+		return nil
+	}
+	c := &cardCharge{lines: m.checkoutLines(), amount: domain.RoundCents(m.checkoutTotal()), started: m.now()}
+	m.classify(c.lines)
+	m.card = c
+	description := m.config.Board.Title
+	return func() tea.Msg {
+		ref, err := terminal.Charge(c.amount, description)
+		return cardChargedMsg{ref, err}
+	}
+}
+
+// pollCard asks the terminal how the payment is going after a while.
+func (m Model) pollCard() tea.Cmd {
+	terminal, _ := m.config.Card.Terminal()
+	ref := m.card.ref
+	return tea.Tick(cardPollInterval, func(time.Time) tea.Msg {
+		status, reason, err := terminal.Status(ref)
+		return cardStatusMsg{status, reason, err}
+	})
+}
+
+// cancelCard takes the payment off the terminal. The kiosk goes on asking
+// about it until the terminal says it failed, as the customer may just
+// have paid.
+func (m *Model) cancelCard() tea.Cmd {
+	if m.card.cancel || m.card.ref == "" {
+		return nil
+	}
+	m.card.cancel = true
+	terminal, _ := m.config.Card.Terminal()
+	ref := m.card.ref
+	return func() tea.Msg { return cardCancelMsg{terminal.Cancel(ref)} }
+}
+
+// updateCard follows the payment on the terminal.
+func (m Model) updateCard(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case cardChargedMsg:
+		if msg.err != nil {
+			m.cardFailed(msg.err.Error())
+			return m, nil
+		}
+		m.card.ref = msg.ref
+		return m, m.pollCard()
+	case cardCancelMsg:
+		// Esc tries again.
+		m.card.cancel = msg.err == nil
+		return m, nil
+	case cardStatusMsg:
+		if m.card.failed != "" {
+			return m, nil
+		}
+		switch {
+		case msg.err != nil:
+			// The terminal may still take the payment; ask again.
+			return m, m.pollCard()
+		case msg.status == store.CardSuccessful:
+			c := m.card
+			m.card = nil
+			return m, m.book(domain.Sale{Lines: c.lines, Card: &domain.CardPayment{
+				Provider: m.config.Card.Provider, Reference: c.ref, Status: string(msg.status),
+			}})
+		case msg.status == store.CardFailed:
+			m.cardFailed(msg.reason)
+			return m, nil
+		}
+		if m.now().Sub(m.card.started) > cardTimeout {
+			return m, tea.Batch(m.cancelCard(), m.pollCard())
+		}
+		return m, m.pollCard()
+	case tea.KeyMsg:
+		switch {
+		case m.card.failed != "":
+			// Any key goes back to the cart.
+			m.card = nil
+		case key.Matches(msg, keys.Back):
+			return m, m.cancelCard()
+		}
+	}
+	return m, nil
+}
+
+// cardFailed records why the payment didn't go through.
+func (m *Model) cardFailed(reason string) {
+	m.card.failed = reason
+	amount := m.card.amount
+	m.store.AuditLog.Write(store.AuditEntry{
+		Time: m.now(), Actor: m.store.Actor, Event: "card_failed", Amount: amount,
+		Detail: fmt.Sprintf("%s %s: %s", m.config.Card.Provider, m.card.ref, reason),
+	})
+}
+
+func (m Model) cardView() string {
+	c := m.card
+	switch {
+	case c.failed != "":
+		return "\n\n" + warningStyle.Render(trf("card_failed", c.failed)) + "\n" + tr("press_any_key")
+	case c.cancel:
+		return "\n\n" + tr("card_cancelling")
+	}
+	return "\n\n" + trf("card_waiting", uiLocale.money(c.amount))
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// --- CART TABLE ---
+
+// cartColumns returns the cart table's columns. They line up with the
+// shop's, the subtotal taking the place of the stock.
+func cartColumns(nameWidth int) []table.Column {
+	return []table.Column{
+		{Title: tr("col_name"), Width: nameWidth},
+		{Title: tr("col_price"), Width: 14},
+		{Title: tr("col_qty"), Width: qtyWidth},
+		{Title: tr("col_subtotal"), Width: 12},
+	}
+}
+
+// updateCartRows rebuilds the cart table from the cart, in the order of
+// the inventory, with a row of its own for the bundles of a beverage. The
+// cursor stays on the line it was on; if that line left the cart, it moves
+// to the one that took its place.
+func (m *Model) updateCartRows() {
+	selected, _ := m.selectedCartLine()
+	m.cartNames = m.cartNames[:0]
+	var rows []table.Row
+	for _, b := range m.beverages {
+		lines := m.beverageLines(b)
+		_, overridden := m.prices[b.Key()]
+		for i, l := range lines {
+			m.cartNames = append(m.cartNames, b.Key())
+			name := b.Key()
+			if m.marked[b.Key()] {
+				name = glyphs.marked + " " + name
+			}
+			price, qty := priceLabel(b), fmt.Sprintf("%d", l.Quantity)
+			switch {
+			case l.Bundle > 0:
+				name = trf("bundle_line", name, l.Bundle)
+				price, qty = uiLocale.money(l.BundlePrice()), fmt.Sprintf("%d", l.Quantity/l.Bundle)
+			case overridden:
+				price = overriddenLabel(l.UnitPrice)
+			}
+			// The cart's quantity keys change the quantity of the
+			// beverage, its bundles taken together.
+			if i == len(lines)-1 {
+				qty = fmt.Sprintf("%s %s %s", glyphs.minus, qty, glyphs.plus)
+			}
+			rows = append(rows, table.Row{name, price, qty, uiLocale.money(l.Gross())})
+		}
+	}
+	cursor := min(m.cartTable.Cursor(), len(rows)-1)
+	for row, name := range m.cartNames {
+		if name == selected.Key() {
+			cursor = row
+		}
+	}
+	m.cartTable.SetRows(rows)
+	m.cartTable.SetCursor(max(0, cursor))
+}
+
+// selectedCartLine returns the beverage under the cart table's cursor.
+func (m Model) selectedCartLine() (domain.Beverage, bool) {
+	row := m.cartTable.Cursor()
+	if row < 0 || row >= len(m.cartNames) {
+		return domain.Beverage{}, false
+	}
+	if i := domain.IndexOf(m.beverages, m.cartNames[row]); i >= 0 {
+		return m.beverages[i], true
+	}
+	return domain.Beverage{}, false
+}
+
+// cursorBeverage returns the beverage the keys for the quantity act on:
+// the selected cart line on the cart tab, the selected shop row elsewhere.
+func (m Model) cursorBeverage() (domain.Beverage, bool) {
+	if m.activeTab == 1 {
+		return m.selectedCartLine()
+	}
+	return m.selectedBeverage()
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- CATALOG IMPORT REVIEW ---
+
+// CatalogReview lists what merging a catalog adds and changes, for review
+// before it is merged; each change can be left out.
+type CatalogReview struct {
+	changes   []store.CatalogChange
+	skip      map[int]bool
+	cursor    int
+	Confirmed bool
+}
+
+// NewCatalogReview lists the changes that do something, or would but for
+// a problem, with every mergeable one selected.
+func NewCatalogReview(changes []store.CatalogChange) CatalogReview {
+	m := CatalogReview{skip: map[int]bool{}}
+	for _, c := range changes {
+		if c.Mergeable() || c.Problem != "" {
+			m.changes = append(m.changes, c)
+		}
+	}
+	return m
+}
+
+func (m CatalogReview) Init() tea.Cmd { return nil }
+
+func (m CatalogReview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(km, keys.Up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(km, keys.Down):
+		m.cursor = min(len(m.changes)-1, m.cursor+1)
+	case key.Matches(km, catalogToggle):
+		if len(m.changes) > 0 && m.changes[m.cursor].Mergeable() {
+			m.skip[m.cursor] = !m.skip[m.cursor]
+		}
+	case key.Matches(km, catalogMerge):
+		m.Confirmed = true
+		return m, tea.Quit
+	case key.Matches(km, abortKey):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m CatalogReview) SelectedChanges() []store.CatalogChange {
+	var selected []store.CatalogChange
+	for i, c := range m.changes {
+		if c.Mergeable() && !m.skip[i] {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+func (m CatalogReview) View() string {
+	if len(m.changes) == 0 {
+		return "The inventory already is as the catalog says.\n" + plainKeys([]key.Binding{abortKey}) + "\n"
+	}
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for i, c := range m.changes {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		box, mark, what := "[x]", "~", c.String()
+		switch {
+		case c.Problem != "":
+			box, mark, what = "   ", "!", c.Problem
+		case m.skip[i]:
+			box = "[ ]"
+		}
+		if c.New && c.Problem == "" {
+			mark = "+"
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t\n", cursor, box, mark, c.Beverage.Key(), what)
+	}
+	w.Flush()
+
+	added, changed := 0, 0
+	for _, c := range m.SelectedChanges() {
+		if c.New {
+			added++
+		} else {
+			changed++
+		}
+	}
+	rows := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	for i, c := range m.changes {
+		if c.Problem != "" || m.skip[i] {
+			rows[i] = lipgloss.NewStyle().Faint(true).Render(rows[i])
+		}
+	}
+	summary := fmt.Sprintf("%d to add, %d to change", added, changed)
+	return strings.Join(rows, "\n") + "\n\n" + summary + "\n" + plainKeys([]key.Binding{keys.Up, keys.Down, catalogToggle, catalogMerge, abortKey}) + "\n"
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- BAR-CLOSE CHECKLIST ---
+
+// Checklist is the end-of-night checklist, ticked off task by task before
+// the shift closes. Tasks left open don't keep it from closing; the shift
+// records which were done.
+type Checklist struct {
+	items     []store.ChecklistItem
+	cursor    int
+	Confirmed bool
+}
+
+// NewChecklist lists the tasks with none done yet.
+func NewChecklist(tasks []string) Checklist {
+	var m Checklist
+	for _, task := range tasks {
+		m.items = append(m.items, store.ChecklistItem{Task: task})
+	}
+	return m
+}
+
+func (m Checklist) Init() tea.Cmd { return nil }
+
+func (m Checklist) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(km, keys.Up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(km, keys.Down):
+		m.cursor = min(len(m.items)-1, m.cursor+1)
+	case key.Matches(km, checklistToggle):
+		m.items = append([]store.ChecklistItem(nil), m.items...)
+		m.items[m.cursor].Done = !m.items[m.cursor].Done
+		// On to the next task, as they are mostly done in order.
+		if m.items[m.cursor].Done {
+			m.cursor = min(len(m.items)-1, m.cursor+1)
+		}
+	case key.Matches(km, checklistClose):
+		m.Confirmed = true
+		return m, tea.Quit
+	case key.Matches(km, abortKey):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// Items is the checklist as it was ticked off.
+func (m Checklist) Items() []store.ChecklistItem { return m.items }
+
+func (m Checklist) View() string {
+	var rows []string
+	for i, it := range m.items {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		row := cursor + "[ ] " + it.Task
+		if it.Done {
+			row = lipgloss.NewStyle().Faint(true).Render(cursor + "[x] " + it.Task)
+		}
+		rows = append(rows, row)
+	}
+	done := store.ChecklistDone(m.items)
+	summary := fmt.Sprintf("%d of %d done", done, len(m.items))
+	if done < len(m.items) {
+		summary = warningStyle.Render(summary)
+	}
+	return "Before closing the shift:\n\n" + strings.Join(rows, "\n") + "\n\n" + summary + "\n" +
+		plainKeys([]key.Binding{keys.Up, keys.Down, checklistToggle, checklistClose, abortKey}) + "\n"
+}
@@ -0,0 +1,22 @@
+package ui
+
+// --- COFFEE MACHINE ---
+
+// CoffeeConfig configures billing from the espresso machine's shot counter.
+// The counter is either polled from CounterURL or pushed to the webhook on
+// Listen; both may be used at the same time.
+type CoffeeConfig struct {
+	// CounterURL returns the machine's total shot count, either as a bare
+	// number or as JSON of the form {"shots": N}.
+	CounterURL   string   `json:"counter_url,omitempty"`
+	PollInterval Duration `json:"poll_interval"`
+	// Listen is the address of the webhook. POST /shot with an optional
+	// {"shots": N} body reports the current counter; without a body it
+	// counts a single shot.
+	Listen string `json:"listen,omitempty"`
+	// Beverage is the inventory item billed per shot.
+	Beverage string `json:"beverage"`
+	// SessionTimeout is how long after a card tap shots are billed to that
+	// member.
+	SessionTimeout Duration `json:"session_timeout"`
+}
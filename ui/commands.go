@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- COMMANDS ---
+
+// WriteTaxReport writes the tax report of sales in the print locale; period
+// says what they cover.
+func WriteTaxReport(out io.Writer, period string, sales []domain.Sale) error {
+	p := PrintLocale
+	fmt.Fprintf(out, "%s %s\n\n", p.T("tax_report"), period)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", p.T("tax_class"), p.T("rate"), p.T("net"), p.T("tax"), p.T("gross"))
+	var total domain.TaxSummary
+	for _, sum := range domain.SummarizeTax(sales) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", sum.Class, p.percent(sum.Rate), p.Number(sum.Net), p.Number(sum.Tax), p.Number(sum.Gross))
+		total.Net += sum.Net
+		total.Tax += sum.Tax
+		total.Gross += sum.Gross
+	}
+	fmt.Fprintf(w, "%s\t\t%s\t%s\t%s\t\n", p.T("total"), p.Number(total.Net), p.Number(total.Tax), p.Number(total.Gross))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	nonRevenue := 0.0
+	for _, sale := range sales {
+		for _, l := range sale.Lines {
+			if l.NonRevenue {
+				nonRevenue += l.Gross()
+			}
+		}
+	}
+	if nonRevenue != 0 {
+		fmt.Fprintf(out, "\n%s: %s\n", p.T("not_revenue"), p.money(nonRevenue))
+	}
+	return nil
+}
+
+// WriteSalesReport writes the sales report of sales in the print locale;
+// period says what they cover.
+func WriteSalesReport(out io.Writer, period string, sales []domain.Sale) error {
+	type row struct {
+		name  string
+		qty   int
+		gross float64
+	}
+	var rows []*row
+	byName := map[string]*row{}
+	total := 0.0
+	for _, sale := range sales {
+		for _, line := range sale.Lines {
+			r := byName[line.Name]
+			if r == nil {
+				r = &row{name: line.Name}
+				byName[line.Name] = r
+				rows = append(rows, r)
+			}
+			r.qty += line.Quantity
+			r.gross += line.Gross()
+		}
+		total += sale.Total()
+	}
+	slices.SortStableFunc(rows, func(a, b *row) int { return cmp.Compare(b.gross, a.gross) })
+
+	p := PrintLocale
+	fmt.Fprintf(out, "%s %s: %d %s\n\n", p.T("sales_report"), period, len(sales), p.T("sales"))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s\t%s\t%s\t\n", p.T("beverage"), p.T("sold"), p.T("gross"))
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%d\t%s\t\n", r.name, r.qty, p.Number(r.gross))
+	}
+	fmt.Fprintf(w, "%s\t\t%s\t\n", p.T("total"), p.Number(total))
+	return w.Flush()
+}
@@ -0,0 +1,421 @@
+package ui
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- CONFIG ---
+
+// Config holds the installation-wide settings. It is read from a JSON file;
+// every key is optional and falls back to the value in defaultConfig. Keys
+// can be overridden from the environment and the command line, see
+// ApplyOverrides.
+type Config struct {
+	// TaxClasses maps a tax class name to its rate in percent.
+	TaxClasses map[string]float64 `json:"tax_classes"`
+	// DefaultTaxClass is used for beverages that don't name a class.
+	DefaultTaxClass string `json:"default_tax_class"`
+	// Categories map beverage categories to bookkeeping accounts.
+	Categories map[string]Category `json:"categories"`
+	// DefaultCategory is used for beverages that don't name a category.
+	DefaultCategory string `json:"default_category"`
+	// Theme selects the UI colors.
+	Theme ThemeConfig `json:"theme"`
+	// AssetsDir holds themes, templates and locales that replace or add to
+	// the built-in ones.
+	AssetsDir string `json:"assets_dir,omitempty"`
+	// Locale selects the language of the kiosk and the menu board. If it
+	// is empty, LANG decides.
+	Locale string `json:"locale"`
+	// ReceiptLocale selects the language and number format of receipts
+	// and reports. It defaults to Locale.
+	ReceiptLocale string `json:"receipt_locale,omitempty"`
+	// Accessibility is "high-contrast" or "colorblind" for a display mode
+	// that doesn't rely on telling colors apart, or "plain" for plain text
+	// on braille displays and screen readers.
+	Accessibility Accessibility `json:"accessibility,omitempty"`
+	// TimeLimits lengthens or lifts the undo window and card sessions.
+	TimeLimits TimeLimits `json:"time_limits"`
+	// Terminal overrides the detected terminal capabilities.
+	Terminal TerminalConfig `json:"terminal"`
+	// MonthlyGoal is the revenue the bar should make each month, e.g. to
+	// cover electricity and restocking; the stats tab tracks it.
+	MonthlyGoal float64 `json:"monthly_goal,omitempty"`
+	// LowStock is the default low-stock threshold, in items, for beverages
+	// that don't set their own.
+	LowStock float64 `json:"low_stock"`
+	// ExpiryDays is how many days ahead of their best-before date the
+	// admin view lists deliveries as about to expire.
+	ExpiryDays int `json:"expiry_days"`
+	// PriceProfiles are alternative price lists, e.g. for happy hour, that
+	// the key switch selects. Each maps beverage names to their price;
+	// beverages a profile doesn't list keep their regular price.
+	PriceProfiles map[string]map[string]float64 `json:"price_profiles,omitempty"`
+	// PriceTiers are the tiers beverages may have prices of their own for,
+	// like ["member", "staff"], in the order the tier key goes through
+	// them; see Beverage.Tiers. Price profiles take precedence.
+	PriceTiers []string `json:"price_tiers,omitempty"`
+	// MemberTier is the tier of members whose account names none.
+	MemberTier string `json:"member_tier,omitempty"`
+	// Keyswitch reads the key switch at the till.
+	Keyswitch KeyswitchConfig `json:"keyswitch"`
+	// StockPolicy decides whether sales may exceed the recorded stock.
+	StockPolicy store.StockPolicy `json:"stock_policy"`
+	// Valuation is how the stock is valued at cost, "fifo" or "average".
+	Valuation store.ValuationMethod `json:"valuation"`
+	// TabLimit is how far below zero a member's balance may go.
+	TabLimit float64 `json:"tab_limit"`
+	// RecentMembers is how many of the members who paid from their tab last
+	// the cashier can pick from; 0 turns the picker off.
+	RecentMembers int `json:"recent_members"`
+	// CashRounding rounds what is paid in cash to a multiple of it, like
+	// 0.05 where there are no one and two cent coins; 0 doesn't round.
+	CashRounding float64 `json:"cash_rounding"`
+	// MailReceipts offers mailing the receipt after checkout, through Mail.
+	MailReceipts bool `json:"mail_receipts"`
+	// QuickAdd numbers the first nine rows the shop shows, and the digit keys
+	// 1 to 9 add one of them to the cart rather than enter a quantity.
+	QuickAdd bool `json:"quick_add"`
+	// Checklist is what has to be done at the end of the night, like
+	// counting the cash or locking the tap; it is ticked off when the
+	// shift closes.
+	Checklist []string `json:"checklist,omitempty"`
+	// UndoGrace is how long after checkout a sale can still be undone.
+	UndoGrace Duration `json:"undo_grace"`
+	// Vending configures the MDB bridge to the vending machine.
+	Vending VendingConfig `json:"vending"`
+	// Coffee configures billing from the espresso machine's shot counter.
+	Coffee CoffeeConfig `json:"coffee"`
+	// Kegs configures volume tracking of kegs through flow meters.
+	Kegs KegConfig `json:"kegs"`
+	// ShopOrder pins beverages to the top of the shop and sets the order
+	// it shows them in.
+	ShopOrder ShopOrderConfig `json:"shop_order"`
+	// Favorites are the beverages on the bar above the shop, with their
+	// keys.
+	Favorites FavoritesConfig `json:"favorites"`
+	// Queue sends what is sold to the queue terminal for preparing.
+	Queue store.QueueConfig `json:"queue"`
+	// Board configures the menu board for the wall display.
+	Board BoardConfig `json:"board"`
+	// Drawer opens the cash drawer for cash sales.
+	Drawer DrawerConfig `json:"drawer"`
+	// PaymentLink offers paying through a link, like PayPal.me, at checkout.
+	PaymentLink PaymentLinkConfig `json:"payment_link"`
+	// USB configures the export to a USB drive from the kiosk.
+	USB USBConfig `json:"usb"`
+	// LowPower schedules the hours the kiosk sleeps.
+	LowPower LowPowerConfig `json:"low_power"`
+	// Screensaver blanks and locks the kiosk when it is left alone.
+	Screensaver ScreensaverConfig `json:"screensaver"`
+	// Events publishes sales and stock changes over MQTT.
+	Events store.EventsConfig `json:"events"`
+	// AuditLog is the file every change is logged to, as JSON lines.
+	AuditLog string `json:"audit_log,omitempty"`
+	// AdminPIN authorizes what only an admin may do at the kiosk, like
+	// overriding the price of a cart line. Without it, that is off.
+	AdminPIN string `json:"admin_pin,omitempty"`
+	// LockPIN unlocks the till once it was locked, by hand or by the
+	// screensaver. Without it, the admin PIN does.
+	LockPIN string `json:"lock_pin,omitempty"`
+	// SigningKey is the PEM file with the Ed25519 private key the year-end
+	// export is signed with.
+	SigningKey string `json:"signing_key,omitempty"`
+	// Footer is shown below receipts and on the checkout screen.
+	Footer FooterConfig `json:"footer"`
+	// Webhooks are called for every sale.
+	Webhooks []store.Webhook `json:"webhooks,omitempty"`
+	// Bank describes the bank statements top-ups are imported from.
+	Bank store.BankConfig `json:"bank"`
+	// Supplier describes the invoices cost prices are imported from.
+	Supplier store.SupplierConfig `json:"supplier"`
+	// Card takes payments on the card terminal.
+	Card store.CardConfig `json:"card"`
+	// Pretix imports drink vouchers sold with event tickets.
+	Pretix store.PretixConfig `json:"pretix"`
+	// Directory is the member database the members are synced from.
+	Directory store.DirectoryConfig `json:"directory"`
+	// Server shares one store between several terminals.
+	Server store.ServerConfig `json:"server"`
+	// Updates looks for newer releases.
+	Updates UpdateConfig `json:"updates"`
+	// Mail is the SMTP server the admin is mailed through.
+	Mail store.MailConfig `json:"mail"`
+	// Consistency checks the store against its history every night.
+	Consistency store.ConsistencyConfig `json:"consistency"`
+}
+
+// Duration is a time.Duration written as a string like "30s" in the config.
+type Duration struct{ time.Duration }
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) { return json.Marshal(d.String()) }
+
+// DefaultConfig returns the settings used when there is no config file.
+func DefaultConfig() Config {
+	return Config{
+		TaxClasses: map[string]float64{
+			"standard": 19,
+			"reduced":  7,
+		},
+		DefaultTaxClass: "standard",
+		Categories:      map[string]Category{"drinks": {}},
+		DefaultCategory: "drinks",
+		Theme:           ThemeConfig{Preset: defaultThemePreset},
+		LowStock:        6,
+		ExpiryDays:      7,
+		StockPolicy:     store.StockBlock,
+		Valuation:       store.ValuationFIFO,
+		TabLimit:        20,
+		RecentMembers:   10,
+		UndoGrace:       Duration{time.Minute},
+		Vending:         VendingConfig{Device: "/dev/ttyACM0"},
+		Coffee: CoffeeConfig{
+			Beverage:       "Espresso",
+			PollInterval:   Duration{10 * time.Second},
+			SessionTimeout: Duration{2 * time.Minute},
+		},
+		Kegs: KegConfig{
+			Source:         "serial",
+			Device:         "/dev/ttyUSB0",
+			Topic:          "bubbletender/flow/+",
+			PulsesPerLiter: 450,
+			PourIdle:       Duration{2 * time.Second},
+			AlertLiters:    3,
+			PourSizes: []store.PourSize{
+				{Name: "0.3l", Liters: 0.3, Price: 2.00},
+				{Name: "0.5l", Liters: 0.5, Price: 3.00},
+			},
+			SessionTimeout: Duration{2 * time.Minute},
+		},
+		Board: BoardConfig{Title: "BubbleTender"},
+		USB:   USBConfig{MountRoots: []string{"/media", "/run/media"}},
+		LowPower: LowPowerConfig{
+			Idle: Duration{2 * time.Minute},
+			Poll: Duration{5 * time.Minute},
+		},
+		Server: store.ServerConfig{Listen: ":7878"},
+	}
+}
+
+// LoadConfig reads the config file at path. A missing file is not an error,
+// the defaults are used instead.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Validate checks settings that can't be told apart from typos otherwise.
+func (c Config) Validate() error {
+	if err := c.StockPolicy.Validate(); err != nil {
+		return err
+	}
+	if err := c.Valuation.Validate(); err != nil {
+		return err
+	}
+	if err := c.Accessibility.validate(); err != nil {
+		return err
+	}
+	if err := c.TimeLimits.validate(); err != nil {
+		return err
+	}
+	if err := c.Terminal.validate(); err != nil {
+		return err
+	}
+	if err := c.Footer.validate(); err != nil {
+		return err
+	}
+	if err := c.LowPower.validate(); err != nil {
+		return err
+	}
+	if err := c.ShopOrder.validate(); err != nil {
+		return err
+	}
+	if err := c.Favorites.validate(); err != nil {
+		return err
+	}
+	if err := c.Screensaver.validate(c.unlockPIN()); err != nil {
+		return err
+	}
+	if err := c.Drawer.validate(); err != nil {
+		return err
+	}
+	if err := c.PaymentLink.validate(); err != nil {
+		return err
+	}
+	if err := c.Updates.validate(); err != nil {
+		return err
+	}
+	if err := c.Directory.Validate(); err != nil {
+		return err
+	}
+	if err := c.Card.Validate(); err != nil {
+		return err
+	}
+	if err := c.Supplier.Validate(); err != nil {
+		return err
+	}
+	if err := c.Consistency.Validate(); err != nil {
+		return err
+	}
+	if len(c.Consistency.Notify) > 0 && !c.Mail.Enabled() {
+		return fmt.Errorf("consistency: notify needs mail.host and mail.from")
+	}
+	if err := c.validateTiers(); err != nil {
+		return err
+	}
+	if c.ExpiryDays < 0 {
+		return fmt.Errorf("expiry_days must not be negative")
+	}
+	if c.CashRounding < 0 || c.CashRounding > 1 {
+		return fmt.Errorf("cash_rounding must be between 0 and 1, like 0.05")
+	}
+	if c.MailReceipts && !c.Mail.Enabled() {
+		return fmt.Errorf("mail_receipts needs mail.host and mail.from")
+	}
+	if strings.Trim(c.AdminPIN, "0123456789") != "" || (c.AdminPIN != "" && len(c.AdminPIN) < 4) {
+		return fmt.Errorf("admin_pin must be at least 4 digits")
+	}
+	if strings.Trim(c.LockPIN, "0123456789") != "" || (c.LockPIN != "" && len(c.LockPIN) < 4) {
+		return fmt.Errorf("lock_pin must be at least 4 digits")
+	}
+	return c.Keyswitch.validate(c.PriceProfiles)
+}
+
+// unlockPIN is the PIN that unlocks a locked till.
+func (c Config) unlockPIN() string { return cmp.Or(c.LockPIN, c.AdminPIN) }
+
+// StoreOptions picks the settings the store enforces.
+func (c Config) StoreOptions() store.Options {
+	return store.Options{TabLimit: c.TabLimit, Webhooks: c.Webhooks, StockPolicy: c.StockPolicy,
+		CashRounding: c.CashRounding, UndoGrace: c.UndoGrace.Duration, Events: c.Events, AuditLog: c.AuditLog, Queue: c.Queue}
+}
+
+// taxRate returns the rate in percent for the given class, falling back to
+// the default class for unknown or empty names.
+func (c Config) taxRate(class string) (string, float64) {
+	if rate, ok := c.TaxClasses[class]; ok {
+		return class, rate
+	}
+	return c.DefaultTaxClass, c.TaxClasses[c.DefaultTaxClass]
+}
+
+// envPrefix starts the environment variables that override config keys.
+// Nested keys are joined with a double underscore, as single ones appear in
+// key names: BUBBLETENDER_KEGS__PULSES_PER_LITER=500.
+const envPrefix = "BUBBLETENDER_"
+
+// EnvFlags are the environment variables that set command line flags
+// rather than config keys.
+var EnvFlags = map[string]string{
+	envPrefix + "CONFIG": "config",
+	envPrefix + "DATA":   "data",
+	envPrefix + "LISTEN": "listen",
+}
+
+// ConfigOverrides collects repeated -set key=value flags.
+type ConfigOverrides []string
+
+func (o *ConfigOverrides) String() string { return strings.Join(*o, ", ") }
+
+func (o *ConfigOverrides) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("%q is not key=value", s)
+	}
+	*o = append(*o, s)
+	return nil
+}
+
+// ApplyOverrides sets the config keys named by the environment and then by
+// the -set flags, which win. Keys are the JSON names, dotted for nested
+// ones: kegs.mqtt.broker or tax_classes.reduced.
+func (c *Config) ApplyOverrides(environ []string, sets []string) error {
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, envPrefix) || EnvFlags[name] != "" {
+			continue
+		}
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, envPrefix)), "__")
+		if err := setConfigKey(reflect.ValueOf(c).Elem(), path, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, kv := range sets {
+		key, value, _ := strings.Cut(kv, "=")
+		if err := setConfigKey(reflect.ValueOf(c).Elem(), strings.Split(key, "."), value); err != nil {
+			return fmt.Errorf("-set %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// setConfigKey follows the path of JSON names from v and decodes value into
+// the field it ends at. Values are JSON, but strings may be given bare.
+func setConfigKey(v reflect.Value, path []string, value string) error {
+	if len(path) == 0 {
+		ptr := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+			quoted, _ := json.Marshal(value)
+			if json.Unmarshal(quoted, ptr.Interface()) != nil {
+				return err
+			}
+		}
+		v.Set(ptr.Elem())
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := range v.NumField() {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			if name == path[0] && v.Type().Field(i).IsExported() {
+				return setConfigKey(v.Field(i), path[1:], value)
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.ValueOf(path[0]).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if old := v.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		if err := setConfigKey(elem, path[1:], value); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+	return fmt.Errorf("unknown config key %q", path[0])
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- CONFIRMATION DIALOG ---
+
+// confirmAction is what a confirmation dialog asks about; the model carries
+// it out once the dialog is confirmed.
+type confirmAction int
+
+const (
+	confirmCheckout confirmAction = iota + 1
+	confirmClearCart
+	confirmRemoveItem
+	confirmQuit
+)
+
+// confirmDialog is a modal yes/no question. While it is open it takes all
+// keys: y and n answer directly, the arrow keys move the focus between the
+// buttons and enter picks the focused one.
+type confirmDialog struct {
+	action  confirmAction
+	message string
+	yes, no string
+	// item is the beverage a confirmRemoveItem dialog is about.
+	item     string
+	focusYes bool
+}
+
+// newConfirm opens a dialog. Destructive questions should start with the
+// focus on no, so that a stray enter doesn't throw anything away.
+func newConfirm(action confirmAction, message, yes, no string, focusYes bool) *confirmDialog {
+	return &confirmDialog{action: action, message: message, yes: yes, no: no, focusYes: focusYes}
+}
+
+// confirmResult is how a key press left the dialog.
+type confirmResult int
+
+const (
+	confirmPending confirmResult = iota
+	confirmYes
+	confirmNo
+)
+
+func (d *confirmDialog) update(msg tea.KeyMsg) confirmResult {
+	switch {
+	case key.Matches(msg, keys.Confirm):
+		return confirmYes
+	case key.Matches(msg, keys.Cancel):
+		return confirmNo
+	case key.Matches(msg, keys.Apply):
+		if d.focusYes {
+			return confirmYes
+		}
+		return confirmNo
+	case key.Matches(msg, keys.SwitchFocus):
+		d.focusYes = !d.focusYes
+	}
+	return confirmPending
+}
+
+func (d confirmDialog) View() string {
+	yes, no := buttonStyle, activeButtonStyle
+	if d.focusYes {
+		yes, no = activeButtonStyle, buttonStyle
+	}
+	buttons := lipgloss.JoinHorizontal(lipgloss.Top, yes.Render(d.yes), "  ", no.Render(d.no))
+	return dialogStyle.Render(lipgloss.JoinVertical(lipgloss.Center, d.message, "", buttons))
+}
+
+// answerConfirm carries out or drops the open dialog's action.
+func (m Model) answerConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.confirm
+	result := d.update(msg)
+	if result == confirmPending {
+		return m, nil
+	}
+	m.confirm = nil
+	if result == confirmNo {
+		return m, nil
+	}
+	switch d.action {
+	case confirmCheckout:
+		cmd := m.checkout("", "")
+		return m, cmd
+	case confirmClearCart:
+		m.clearCart()
+	case confirmRemoveItem:
+		m.setQty(d.item, 0)
+	case confirmQuit:
+		return m, tea.Quit
+	}
+	return m, nil
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- CONSISTENCY CHECK ---
+
+// A kiosk with a store file of its own runs the nightly consistency check,
+// see store.ConsistencyConfig, on a copy read from disk. What it finds is
+// warned about until the next night's check.
+
+type checkedMsg struct {
+	snap store.Snapshot
+	err  error
+}
+
+// checkIfDue starts the nightly check once it is due.
+func (m *Model) checkIfDue(now time.Time) tea.Cmd {
+	if m.store.Remote != nil || m.loading || m.checking || !m.config.Consistency.Due(now) {
+		return nil
+	}
+	m.checking = true
+	path, c, mail := m.store.Path, m.config.Consistency, m.config.Mail
+	return func() tea.Msg {
+		s, err := store.Open(path)
+		if err != nil {
+			return checkedMsg{err: err}
+		}
+		snap, err := s.NightlyCheck(c, mail, now)
+		return checkedMsg{snap, err}
+	}
+}
+
+func (m Model) checkNotice() string {
+	switch {
+	case m.checkErr != nil:
+		return trf("check_failed", m.checkErr)
+	case m.diverged > 0:
+		return trf("check_diverged", m.diverged, m.config.Consistency.Dir)
+	}
+	return ""
+}
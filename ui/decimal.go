@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- DECIMAL INPUT ---
+
+// Amounts, prices and quantities typed in by hand take either decimal
+// mark, "1,50" as well as "1.50". A mark with three digits after it that
+// is the locale's thousands separator groups, though: "1.500" is fifteen
+// hundred in German and one and a half in English.
+
+var (
+	plainDecimal = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)$`)
+	// groupedWhole is a whole number with its thousands grouped by one of
+	// the marks, like "1.234.567".
+	groupedWhole = regexp.MustCompile(`^[+-]?[0-9]{1,3}((\.[0-9]{3})+|(,[0-9]{3})+)$`)
+)
+
+// ParseDecimal reads a number typed in by hand, in the UI locale.
+func ParseDecimal(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	number := s
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		mark, whole, frac := s[i:i+1], s[:i], s[i+1:]
+		switch {
+		case groupedWhole.MatchString(s) && (strings.Contains(whole, mark) || mark == uiLocale.T("thousands_separator")):
+			number = strings.ReplaceAll(s, mark, "")
+		case !strings.ContainsAny(whole, ".,"):
+			number = whole + "." + frac
+		case groupedWhole.MatchString(whole) && !strings.Contains(whole, mark):
+			number = strings.NewReplacer(".", "", ",", "").Replace(whole) + "." + frac
+		default:
+			number = ""
+		}
+	}
+	if !plainDecimal.MatchString(number) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return strconv.ParseFloat(number, 64)
+}
+
+// formatDecimal writes v with two decimals for an input to start from, the
+// way the UI locale does, without grouping the thousands.
+func formatDecimal(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', 2, 64), ".", uiLocale.T("decimal_separator"), 1)
+}
+
+// newDecimalInput returns an input for an amount; only digits and decimal
+// marks reach it, see decimalKey.
+func newDecimalInput(prompt string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = prompt
+	ti.Placeholder = formatDecimal(0)
+	ti.CharLimit = 8
+	ti.Width = 10
+	return ti
+}
+
+// decimalKey reports whether msg belongs in a decimal input: a digit, a
+// decimal mark or a key that edits rather than types.
+func decimalKey(msg tea.KeyMsg) bool {
+	if msg.Type != tea.KeyRunes {
+		return true
+	}
+	for _, r := range msg.Runes {
+		if (r < '0' || r > '9') && r != '.' && r != ',' {
+			return false
+		}
+	}
+	return true
+}
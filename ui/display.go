@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- CUSTOMER DISPLAY ---
+
+// A till that is a client of a server sends its session, the cart or the
+// receipt on screen, to the server with every change. The customer display
+// on a second screen shows the session of its till as it comes in.
+
+// sessionPublisher sends a till's sessions to the server one at a time. A
+// session that is replaced before it was sent is dropped, so the server
+// always ends up with the latest and never with an older one.
+type sessionPublisher struct {
+	latest chan store.TillSession
+}
+
+func newSessionPublisher(r *store.RemoteStore) *sessionPublisher {
+	p := &sessionPublisher{latest: make(chan store.TillSession, 1)}
+	go func() {
+		for s := range p.latest {
+			_ = r.PublishSession(s)
+		}
+	}()
+	return p
+}
+
+func (p *sessionPublisher) publish(s store.TillSession) {
+	select {
+	case <-p.latest:
+	default:
+	}
+	p.latest <- s
+}
+
+// session is what the customer sees of the sale at hand, just the marked
+// lines of a split checkout.
+func (m Model) session() store.TillSession {
+	s := store.TillSession{Till: m.config.Server.TillName()}
+	if m.receipt != nil {
+		s.Lines, s.Total, s.Paid = m.receipt.Lines, domain.RoundCents(m.receipt.Total()), true
+	} else {
+		s.Lines, s.Total = m.checkoutLines(), domain.RoundCents(m.checkoutTotal())
+	}
+	if m.link != nil {
+		s.Payment = m.link.url
+	}
+	return s
+}
+
+// publishSession sends the session to the server if it changed since it
+// was last sent.
+func (m *Model) publishSession() {
+	if m.publisher == nil {
+		return
+	}
+	s := m.session()
+	key, _ := json.Marshal(s)
+	if string(key) == m.published {
+		return
+	}
+	m.published = string(key)
+	s.Updated = m.now()
+	m.publisher.publish(s)
+}
+
+// DisplayModel is the customer display. It takes no input apart from
+// quitting.
+type DisplayModel struct {
+	config  Config
+	remote  *store.RemoteStore
+	till    string
+	session store.TillSession
+	err     error
+	width   int
+	height  int
+}
+
+// NewDisplayModel returns the customer display of the till named by
+// server.till. It follows the till through the server only.
+func NewDisplayModel(cfg Config, s *store.Store) (DisplayModel, error) {
+	if s.Remote == nil {
+		return DisplayModel{}, errors.New("the customer display needs server.url set to the server the till uses")
+	}
+	return DisplayModel{config: cfg, remote: s.Remote, till: cfg.Server.TillName(), session: store.TillSession{Version: -1}}, nil
+}
+
+type sessionMsg struct {
+	session store.TillSession
+	err     error
+}
+
+// watchSession waits for the till's session to change, backing off on
+// errors like watchStore.
+func (m DisplayModel) watchSession() tea.Cmd {
+	remote, till, version := m.remote, m.till, m.session.Version
+	return func() tea.Msg {
+		s, err := remote.WatchSession(till, version)
+		if err != nil {
+			time.Sleep(time.Second)
+		}
+		return sessionMsg{s, err}
+	}
+}
+
+func (m DisplayModel) Init() tea.Cmd { return m.watchSession() }
+
+func (m DisplayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case sessionMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.session = msg.session
+		}
+		return m, m.watchSession()
+	case tea.KeyMsg:
+		if msg.String() == "q" || msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+var (
+	displayTotalStyle  = lipgloss.NewStyle().Bold(true).MarginTop(1)
+	displayThanksStyle = lipgloss.NewStyle().Bold(true).MarginTop(1)
+)
+
+func (m DisplayModel) View() string {
+	s := m.session
+	var view string
+	switch {
+	case m.err != nil:
+		view = warningStyle.Render(trf("display_offline", m.err))
+	case len(s.Lines) == 0:
+		view = boardTitleStyle.Foreground(theme.Tabs).Render(m.config.Board.Title) + "\n\n" + tr("display_welcome")
+	default:
+		nameWidth := 0
+		for _, l := range s.Lines {
+			nameWidth = max(nameWidth, lipgloss.Width(uiLocale.lineLabel(l)))
+		}
+		var lines []string
+		for _, l := range s.Lines {
+			lines = append(lines, fmt.Sprintf("%3d × %-*s  %10s", l.Quantity, nameWidth, uiLocale.lineLabel(l), uiLocale.money(l.Gross())))
+		}
+		total := fmt.Sprintf("%s %s", tr("total"), uiLocale.money(s.Total))
+		lines = append(lines, displayTotalStyle.Render(total))
+		if s.Paid {
+			lines = append(lines, displayThanksStyle.Foreground(theme.Tabs).Render(tr("display_thanks")))
+		}
+		view = strings.Join(lines, "\n")
+		if s.Payment != "" {
+			if q, err := newQRCode(s.Payment); err == nil {
+				view = lipgloss.JoinHorizontal(lipgloss.Center, view, "    ", q.View())
+			}
+		}
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, view)
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- CASH DRAWER ---
+
+// DrawerConfig opens the cash drawer, which most drawers do on a pulse:
+// either from the receipt printer they are plugged into or from a relay on
+// a serial or USB port. The drawer opens by itself for every sale paid in
+// cash, and by hand with the admin PIN. Without Device or Command, there
+// is no drawer.
+type DrawerConfig struct {
+	// Device is written the pulse to, like /dev/usb/lp0 for a receipt
+	// printer or /dev/ttyUSB0 for a relay.
+	Device string `json:"device,omitempty"`
+	// Pulse is what is written to Device, in hex. It defaults to the
+	// ESC/POS drawer kick on pin 2.
+	Pulse string `json:"pulse,omitempty"`
+	// Command is run instead, for relays that come with a tool of their
+	// own, e.g. ["usbrelay", "DRAWER_1=1"].
+	Command []string `json:"command,omitempty"`
+}
+
+// escposKick is ESC p 0 with a pulse of 50 ms on and 500 ms off.
+const escposKick = "1b700019fa"
+
+// drawerTimeout is how long opening the drawer may take before it is taken
+// to have failed.
+const drawerTimeout = 5 * time.Second
+
+func (c DrawerConfig) validate() error {
+	if c.Device != "" && len(c.Command) > 0 {
+		return errors.New("drawer: set device or command, not both")
+	}
+	if c.Pulse != "" && c.Device == "" {
+		return errors.New("drawer: pulse needs a device")
+	}
+	if _, err := hex.DecodeString(c.Pulse); err != nil {
+		return fmt.Errorf("drawer: pulse must be hex, like %q: %w", escposKick, err)
+	}
+	return nil
+}
+
+func (c DrawerConfig) enabled() bool { return c.Device != "" || len(c.Command) > 0 }
+
+// open sends the pulse or runs the command.
+func (c DrawerConfig) open() error {
+	ctx, cancel := context.WithTimeout(context.Background(), drawerTimeout)
+	defer cancel()
+	if len(c.Command) > 0 {
+		out, err := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...).CombinedOutput()
+		if err != nil && len(out) > 0 {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	pulse, _ := hex.DecodeString(c.Pulse)
+	if len(pulse) == 0 {
+		pulse, _ = hex.DecodeString(escposKick)
+	}
+	f, err := os.OpenFile(c.Device, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.Write(pulse)
+		done <- errors.Join(err, f.Close())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// A printer that is off or out of paper blocks the write.
+		f.Close()
+		return fmt.Errorf("%s didn't take the pulse: %w", c.Device, ctx.Err())
+	}
+}
+
+// drawerMsg reports whether the drawer opened.
+type drawerMsg struct{ err error }
+
+// openDrawer opens the drawer, for reason in the audit log.
+func (m *Model) openDrawer(reason string) tea.Cmd {
+	if !m.config.Drawer.enabled() {
+		return nil
+	}
+	m.drawerErr = nil
+	m.store.AuditLog.Write(store.AuditEntry{Time: m.now(), Actor: m.store.Actor, Event: "drawer", Detail: reason})
+	drawer := m.config.Drawer
+	return func() tea.Msg { return drawerMsg{drawer.open()} }
+}
+
+// cashDrawer opens the drawer for a sale that is paid in cash, at least in
+// part.
+func (m *Model) cashDrawer(sale domain.Sale) tea.Cmd {
+	if sale.Member != "" || sale.Card != nil || sale.Link != nil || sale.CashDue() <= 0 {
+		return nil
+	}
+	return m.openDrawer(fmt.Sprintf("sale #%d", sale.ID))
+}
+
+// startDrawer asks for the admin PIN to open the drawer by hand. Without
+// an admin PIN or a drawer, it can't be.
+func (m *Model) startDrawer() tea.Cmd {
+	if m.config.AdminPIN == "" || !m.config.Drawer.enabled() {
+		return nil
+	}
+	m.drawerPIN = true
+	m.drawerErr = nil
+	m.pinInput.SetValue("")
+	return m.pinInput.Focus()
+}
+
+// updateDrawer handles keys while the PIN to open the drawer is entered.
+func (m Model) updateDrawer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.stopDrawer()
+		return m, nil
+	case key.Matches(msg, keys.Apply):
+		if subtle.ConstantTimeCompare([]byte(m.pinInput.Value()), []byte(m.config.AdminPIN)) != 1 {
+			m.drawerErr = errors.New(tr("wrong_pin"))
+			m.pinInput.SetValue("")
+			return m, nil
+		}
+		m.stopDrawer()
+		return m, m.openDrawer("by hand")
+	}
+	var cmd tea.Cmd
+	m.pinInput, cmd = m.pinInput.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) stopDrawer() {
+	m.drawerPIN = false
+	m.drawerErr = nil
+	m.pinInput.Blur()
+}
+
+func (m Model) drawerView() string {
+	view := "\n\n" + tr("drawer_open") + "\n" + m.pinInput.View()
+	if m.drawerErr != nil {
+		view += "\n" + warningStyle.Render(m.drawerErr.Error())
+	}
+	return view
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- FAVORITES ---
+
+// FavoritesConfig puts the few drinks most of the evening is made of on a
+// bar above the shop table, each with a key of its own that adds one to
+// the cart from anywhere in the shop.
+type FavoritesConfig struct {
+	// Beverages are the favorites in the order of the bar, by name, with
+	// the size for a drink sold in several; at most maxFavorites.
+	Beverages []string `json:"beverages,omitempty"`
+	// Keys are the keys of the favorites, one each, like "f1" or "alt+1".
+	// Left empty, the favorites get F1, F2 and so on.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// maxFavorites is how many favorites there can be, one per function key.
+const maxFavorites = 12
+
+func (c FavoritesConfig) validate() error {
+	if len(c.Beverages) > maxFavorites {
+		return fmt.Errorf("favorites: at most %d beverages, not %d", maxFavorites, len(c.Beverages))
+	}
+	if len(c.Keys) > 0 && len(c.Keys) != len(c.Beverages) {
+		return fmt.Errorf("favorites: %d keys for %d beverages", len(c.Keys), len(c.Beverages))
+	}
+	for i, k := range c.keys() {
+		if slices.Contains(c.keys()[:i], k) {
+			return fmt.Errorf("favorites: key %q is given twice", k)
+		}
+		for name, b := range keys.bindings() {
+			if slices.Contains(b.Keys(), k) {
+				return fmt.Errorf("favorites: key %q is the kiosk's key to %s", k, strings.ReplaceAll(name, "_", " "))
+			}
+		}
+	}
+	return nil
+}
+
+// keys are the keys of the favorites, in the order of Beverages.
+func (c FavoritesConfig) keys() []string {
+	if len(c.Keys) > 0 {
+		return c.Keys
+	}
+	keys := make([]string, len(c.Beverages))
+	for i := range keys {
+		keys[i] = fmt.Sprintf("f%d", i+1)
+	}
+	return keys
+}
+
+// favorite is a favorite the shop has, with its key.
+type favorite struct {
+	key      string
+	beverage domain.Beverage
+}
+
+// favorites are the favorites in the shop at the moment; those dropped from
+// the inventory or hidden for now are left out.
+func (m Model) favorites() []favorite {
+	var favs []favorite
+	now := m.now()
+	for i, k := range m.config.Favorites.keys() {
+		j := domain.IndexOf(m.beverages, m.config.Favorites.Beverages[i])
+		if j < 0 || m.beverages[j].HiddenAt(now) {
+			continue
+		}
+		favs = append(favs, favorite{k, m.beverages[j]})
+	}
+	return favs
+}
+
+// addFavorite adds one of the favorite whose key msg is, and reports
+// whether it was one.
+func (m *Model) addFavorite(msg tea.KeyMsg) bool {
+	for _, f := range m.favorites() {
+		if f.key == msg.String() {
+			m.add(f.beverage)
+			return true
+		}
+	}
+	return false
+}
+
+// favoritesKey stands for the favorites' keys in the help.
+func (m Model) favoritesKey() key.Binding {
+	favs := m.config.Favorites.keys()
+	return key.NewBinding(key.WithKeys(favs...), key.WithHelp(favs[0]+"…", tr("favorites_help")))
+}
+
+var favoriteKeyStyle = lipgloss.NewStyle().Bold(true)
+
+// favoritesView is the bar of favorites, wrapped to width; empty without
+// any.
+func (m Model) favoritesView(width int) string {
+	var lines []string
+	line := ""
+	for _, f := range m.favorites() {
+		item := favoriteKeyStyle.Render(strings.ToUpper(f.key)) + " " + f.beverage.Key() + " " + uiLocale.money(f.beverage.Price)
+		if !m.canAdd(f.beverage) {
+			item = lipgloss.NewStyle().Faint(true).Render(item)
+		}
+		switch {
+		case line == "":
+			line = item
+		case lipgloss.Width(line)+2+lipgloss.Width(item) > width:
+			lines = append(lines, line)
+			line = item
+		default:
+			line += "  " + item
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
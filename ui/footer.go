@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// --- FOOTER ---
+
+// FooterConfig adds a footer below the receipt and the order on the
+// checkout screen. Text is a text/template that gets a footerData, e.g.
+//
+//	{{with .Balance}}Left on your tab: {{.}}
+//	{{end}}{{with .NextEvent}}See you at {{.Name}} on {{.Date.Format "Jan 2"}}!
+//	{{end}}{{.Thanks}}
+type FooterConfig struct {
+	Text string `json:"text,omitempty"`
+	// Events are the upcoming events .NextEvent picks from.
+	Events []FooterEvent `json:"events,omitempty"`
+	// Thanks are the thank-you messages .Thanks picks one of at random.
+	Thanks []string `json:"thanks,omitempty"`
+}
+
+// FooterEvent is an event on a date written like "2026-12-27".
+type FooterEvent struct {
+	Name string `json:"name"`
+	Date string `json:"date"`
+}
+
+// footerData is what the footer template gets. It is filled in from the
+// model each time the footer renders, so the balance is the one after the
+// sale.
+type footerData struct {
+	// Member and Balance are who paid the receipt's sale and what is left
+	// on their tab; both are empty on the checkout screen and for cash
+	// sales.
+	Member  string
+	Balance string
+	// NextEvent is the first event from today on, or nil.
+	NextEvent *footerEventDate
+	Thanks    string
+}
+
+type footerEventDate struct {
+	Name string
+	Date time.Time
+	Days int // from today
+}
+
+func (c FooterConfig) validate() error {
+	if _, err := c.template(); err != nil {
+		return fmt.Errorf("footer: %w", err)
+	}
+	for _, e := range c.Events {
+		if _, err := time.Parse(time.DateOnly, e.Date); err != nil {
+			return fmt.Errorf("footer: event %q: date must look like 2026-12-27", e.Name)
+		}
+	}
+	return nil
+}
+
+// template parses Text, or returns nil if there is no footer.
+func (c FooterConfig) template() (*template.Template, error) {
+	if strings.TrimSpace(c.Text) == "" {
+		return nil, nil
+	}
+	return template.New("footer").Parse(c.Text)
+}
+
+// nextEvent returns the first event on or after the day of now.
+func (c FooterConfig) nextEvent(now time.Time) *footerEventDate {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var next *footerEventDate
+	for _, e := range c.Events {
+		date, err := time.ParseInLocation(time.DateOnly, e.Date, now.Location())
+		if err != nil || date.Before(today) {
+			continue
+		}
+		if next == nil || date.Before(next.Date) {
+			next = &footerEventDate{Name: e.Name, Date: date, Days: int(date.Sub(today).Hours()+12) / 24}
+		}
+	}
+	return next
+}
+
+// pickThanks picks the thank-you message for the next footer, so that it
+// stays the same while the receipt is on screen.
+func (m *Model) pickThanks() {
+	if n := len(m.config.Footer.Thanks); n > 0 {
+		m.thanks = m.config.Footer.Thanks[rand.IntN(n)]
+	}
+}
+
+// footerView renders the footer for the receipt on screen, or for the
+// checkout screen without one, with the amounts in loc.
+func (m Model) footerView(loc locale, now time.Time) string {
+	tmpl, err := m.config.Footer.template()
+	if tmpl == nil || err != nil {
+		return ""
+	}
+	data := footerData{NextEvent: m.config.Footer.nextEvent(now), Thanks: m.thanks}
+	if m.receipt != nil && m.receipt.Member != "" {
+		if i := m.store.MemberIndex(m.receipt.Member); i >= 0 {
+			payer := m.store.Members[i]
+			data.Member = cmp.Or(payer.Name, payer.ID)
+			data.Balance = loc.money(payer.Balance)
+		}
+	}
+	var s strings.Builder
+	if err := tmpl.Execute(&s, data); err != nil {
+		return fmt.Sprintf("footer template: %v", err)
+	}
+	return strings.TrimRight(s.String(), "\n")
+}
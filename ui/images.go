@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// --- IMAGES ---
+
+// graphicsProtocol is how the terminal draws images, if it can.
+type graphicsProtocol string
+
+const (
+	graphicsNone  graphicsProtocol = ""
+	graphicsKitty graphicsProtocol = "kitty"
+	graphicsSixel graphicsProtocol = "sixel"
+)
+
+// graphics is the protocol of this terminal, set by ApplyTerminal.
+var graphics graphicsProtocol
+
+// sixelTerminals are the TERM values of terminals known to draw sixels.
+var sixelTerminals = map[string]bool{"foot": true, "foot-extra": true, "mlterm": true, "yaft-256color": true, "xterm-sixel": true}
+
+// detectGraphics guesses the protocol from the environment; terminals
+// can't be asked without waiting for their answer on startup.
+func detectGraphics(term string) graphicsProtocol {
+	switch {
+	case term == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "":
+		return graphicsKitty
+	case os.Getenv("TERM_PROGRAM") == "ghostty" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return graphicsKitty
+	case sixelTerminals[term]:
+		return graphicsSixel
+	}
+	return graphicsNone
+}
+
+const (
+	// imageCols and imageRows are the cells the image panel takes.
+	imageCols = 16
+	imageRows = 8
+	// imagePanelWidth is the panel with the gap to the table.
+	imagePanelWidth = imageCols + 2
+	// cellWidth and cellHeight are the pixels of a cell on the usual
+	// fonts; sixels are drawn in pixels, not cells.
+	cellWidth  = 10
+	cellHeight = 20
+	// kittyImageID is the image the panel shows. Sending another one under
+	// the same ID replaces it.
+	kittyImageID = 1
+)
+
+// imageCache holds the escape sequences drawing each image.
+var imageCache = map[string]string{}
+
+// showsImages reports whether the shop has room for the image panel and
+// any beverage to show in it.
+func (m Model) showsImages() bool {
+	if graphics == graphicsNone || m.width < minWidth+imagePanelWidth {
+		return false
+	}
+	for _, b := range m.beverages {
+		if b.Image != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// imagePanel is the image of the selected beverage, drawn over a block of
+// blank cells that keep the layout in place. Beverages without an image,
+// and images that fail to load, get the blank block.
+func (m Model) imagePanel() string {
+	var path string
+	if b, ok := m.selectedBeverage(); ok {
+		path = b.Image
+	}
+	lines := make([]string, imageRows)
+	for i := range lines {
+		lines[i] = strings.Repeat(" ", imageCols)
+	}
+	lines[0] = drawImage(path) + lines[0]
+	return strings.Join(lines, "\n")
+}
+
+// drawImage returns the escape sequence drawing the image at path from the
+// cursor on, leaving the cursor where it was.
+func drawImage(path string) string {
+	if seq, ok := imageCache[path]; ok {
+		return seq
+	}
+	var img image.Image
+	if path != "" {
+		// Images that can't be read are left blank, like those of
+		// beverages without one.
+		img, _ = loadImage(path)
+	}
+	var seq string
+	switch graphics {
+	case graphicsKitty:
+		seq = kittyDelete
+		if img != nil {
+			seq = kittyImage(fitImage(img))
+		}
+	case graphicsSixel:
+		seq = sixelImage(fitImage(img))
+	}
+	imageCache[path] = seq
+	return seq
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// fitImage scales img into the panel, keeping its aspect ratio and leaving
+// the rest transparent. A nil img gives an empty panel.
+func fitImage(img image.Image) *image.NRGBA {
+	w, h := imageCols*cellWidth, imageRows*cellHeight
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if img == nil {
+		return dst
+	}
+	src := img.Bounds()
+	scale := min(float64(w)/float64(src.Dx()), float64(h)/float64(src.Dy()))
+	sw, sh := max(1, int(float64(src.Dx())*scale)), max(1, int(float64(src.Dy())*scale))
+	x0, y0 := (w-sw)/2, (h-sh)/2
+	// Nearest neighbor is plenty for a thumbnail.
+	for y := range sh {
+		for x := range sw {
+			sx := src.Min.X + x*src.Dx()/sw
+			sy := src.Min.Y + y*src.Dy()/sh
+			dst.Set(x0+x, y0+y, img.At(sx, sy))
+		}
+	}
+	return dst
+}
+
+// kittyDelete removes the panel's image, for beverages without one.
+var kittyDelete = fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+
+// kittyImage sends img as PNG, in chunks as the protocol wants them, and
+// places it over the panel's cells without moving the cursor.
+func kittyImage(img image.Image) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return kittyDelete
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+	const chunk = 4096
+	var s strings.Builder
+	for i := 0; i < len(data); i += chunk {
+		end := min(i+chunk, len(data))
+		more := 0
+		if end < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&s, "\x1b_Ga=T,f=100,i=%d,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\", kittyImageID, imageCols, imageRows, more, data[i:end])
+		} else {
+			fmt.Fprintf(&s, "\x1b_Gm=%d;%s\x1b\\", more, data[i:end])
+		}
+	}
+	return s.String()
+}
+
+// sixelImage encodes img as sixels in a palette of 6×6×6 colors.
+// Transparent pixels get the background, so that a new image paints over
+// all of the old one. The cursor is saved and restored around it, as
+// drawing sixels moves it below the image.
+func sixelImage(img *image.NRGBA) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	const transparent = -1
+	colors := make([]int, w*h)
+	for y := range h {
+		for x := range w {
+			c := img.NRGBAAt(x, y)
+			if c.A < 128 {
+				colors[y*w+x] = transparent
+				continue
+			}
+			colors[y*w+x] = int(c.R)*5/255*36 + int(c.G)*5/255*6 + int(c.B)*5/255
+		}
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "\x1b7\x1bP0;0;0q\"1;1;%d;%d", w, h)
+	for i := range 216 {
+		fmt.Fprintf(&s, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+	}
+	for y0 := 0; y0 < h; y0 += 6 {
+		band := make(map[int][]byte)
+		var order []int
+		for x := range w {
+			for k := range 6 {
+				if y0+k >= h {
+					break
+				}
+				c := colors[(y0+k)*w+x]
+				if c == transparent {
+					continue
+				}
+				if band[c] == nil {
+					band[c] = bytes.Repeat([]byte{0}, w)
+					order = append(order, c)
+				}
+				band[c][x] |= 1 << k
+			}
+		}
+		for i, c := range order {
+			if i > 0 {
+				s.WriteByte('$')
+			}
+			fmt.Fprintf(&s, "#%d", c)
+			writeSixels(&s, band[c])
+		}
+		s.WriteByte('-')
+	}
+	s.WriteString("\x1b\\\x1b8")
+	return s.String()
+}
+
+// writeSixels writes a band's column bits, run-length encoded.
+func writeSixels(s *strings.Builder, bits []byte) {
+	for i := 0; i < len(bits); {
+		j := i
+		for j < len(bits) && bits[j] == bits[i] {
+			j++
+		}
+		ch := byte(63 + bits[i])
+		if n := j - i; n > 3 {
+			fmt.Fprintf(s, "!%d%c", n, ch)
+		} else {
+			s.WriteString(strings.Repeat(string(ch), n))
+		}
+		i = j
+	}
+}
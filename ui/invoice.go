@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- INVOICE IMPORT REVIEW ---
+
+// InvoiceReview lists the lines of a supplier invoice for review before the
+// costs are updated; the suggested prices are taken line by line.
+type InvoiceReview struct {
+	invoice   []store.InvoiceLine
+	reprice   map[string]bool
+	cursor    int
+	Confirmed bool
+}
+
+// NewInvoiceReview lists an invoice for review, with no suggested price
+// taken yet.
+func NewInvoiceReview(invoice []store.InvoiceLine) InvoiceReview {
+	return InvoiceReview{invoice: invoice, reprice: map[string]bool{}}
+}
+
+func (m InvoiceReview) Init() tea.Cmd { return nil }
+
+func (m InvoiceReview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(km, keys.Up):
+		m.cursor = max(0, m.cursor-1)
+	case key.Matches(km, keys.Down):
+		m.cursor = min(len(m.invoice)-1, m.cursor+1)
+	case key.Matches(km, invoiceReprice):
+		if l := m.invoice[m.cursor]; l.Reprices() {
+			m.reprice[l.Beverage] = !m.reprice[l.Beverage]
+		}
+	case key.Matches(km, invoiceUpdate):
+		m.Confirmed = true
+		return m, tea.Quit
+	case key.Matches(km, abortKey):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// Reprice is the beverages whose suggested price was taken.
+func (m InvoiceReview) Reprice() map[string]bool {
+	reprice := map[string]bool{}
+	for name, on := range m.reprice {
+		if on {
+			reprice[name] = true
+		}
+	}
+	return reprice
+}
+
+func (m InvoiceReview) View() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	costs := 0
+	for i, l := range m.invoice {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		article := strings.TrimSpace(l.Article + " " + l.Description)
+		if !l.Bookable() {
+			fmt.Fprintf(w, "%s   \t%s\t%s\t\t\t\n", cursor, article, l.Problem)
+			continue
+		}
+		costs++
+		box, suggested := "   ", ""
+		if l.Reprices() {
+			box, suggested = "[ ]", uiLocale.money(l.Suggested)
+			if m.reprice[l.Beverage] {
+				box = "[x]"
+			}
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t\n", cursor, box, article, l.Beverage, store.CostChange(l), uiLocale.money(l.Price), suggested)
+	}
+	w.Flush()
+
+	rows := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	for i, l := range m.invoice {
+		if !l.Bookable() {
+			rows[i] = lipgloss.NewStyle().Faint(true).Render(rows[i])
+		}
+	}
+	summary := fmt.Sprintf("%d costs, %d new prices", costs, len(m.Reprice()))
+	return strings.Join(rows, "\n") + "\n\n" + summary + "\n" + plainKeys([]key.Binding{keys.Up, keys.Down, invoiceReprice, invoiceUpdate, abortKey}) + "\n"
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- JUMP TO ITEM ---
+
+func newJumpInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("jump_prompt")
+	ti.CharLimit = 30
+	ti.Width = 20
+	return ti
+}
+
+// startJump opens the jump-to input. The cursor follows the best match
+// while typing; esc takes it back to where it was.
+func (m *Model) startJump() tea.Cmd {
+	m.jumping = true
+	m.jumpFrom = m.table.Cursor()
+	m.jumpInput.SetValue("")
+	return m.jumpInput.Focus()
+}
+
+// updateJump handles keys while the jump-to input is open.
+func (m Model) updateJump(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Apply):
+		m.stopJump()
+		return m, nil
+	case key.Matches(msg, keys.Back):
+		m.table.SetCursor(m.jumpFrom)
+		m.stopJump()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.jumpInput, cmd = m.jumpInput.Update(msg)
+	if row, ok := m.bestMatch(m.jumpInput.Value()); ok {
+		m.table.SetCursor(row)
+	}
+	return m, cmd
+}
+
+func (m *Model) stopJump() {
+	m.jumping = false
+	m.jumpInput.Blur()
+}
+
+func (m Model) jumpView() string {
+	view := "\n\n" + m.jumpInput.View()
+	if query := m.jumpInput.Value(); query != "" {
+		if _, ok := m.bestMatch(query); !ok {
+			view += "\n" + warningStyle.Render(trf("no_match", query))
+		}
+	}
+	return view
+}
+
+// bestMatch returns the table row whose beverage matches query best. Ties
+// go to the shorter name, then to the row further up.
+func (m Model) bestMatch(query string) (int, bool) {
+	best, bestScore, bestLen := -1, 0, 0
+	for row, i := range m.order {
+		name := m.beverages[i].Key()
+		score, ok := fuzzyScore(query, name)
+		if !ok {
+			continue
+		}
+		if best < 0 || score > bestScore || score == bestScore && len(name) < bestLen {
+			best, bestScore, bestLen = row, score, len(name)
+		}
+	}
+	return best, best >= 0
+}
+
+// fuzzyScore matches the letters of query in order anywhere in name,
+// ignoring case and spaces in the query, so "cm" finds Club-Mate. Letters
+// at the start of a word and runs of letters score higher; letters skipped
+// in between cost a little.
+func fuzzyScore(query, name string) (int, bool) {
+	q := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	if len(q) == 0 {
+		return 0, false
+	}
+	n := []rune(strings.ToLower(name))
+	score, qi, last := 0, 0, -1
+	for ni := 0; ni < len(n) && qi < len(q); ni++ {
+		if n[ni] != q[qi] {
+			continue
+		}
+		switch {
+		case ni == 0 || !unicode.IsLetter(n[ni-1]) && !unicode.IsDigit(n[ni-1]):
+			score += 10
+		case last == ni-1:
+			score += 5
+		default:
+			score++
+		}
+		if last >= 0 {
+			score -= min(ni-last-1, 3)
+		}
+		last = ni
+		qi++
+	}
+	return score, qi == len(q)
+}
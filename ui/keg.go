@@ -0,0 +1,26 @@
+package ui
+
+import "github.com/arunoruto/BubbleTender/store"
+
+// --- KEGS ---
+
+// KegConfig configures volume tracking for kegs on tap. Flow meters report
+// pulses either as lines of "<tap> <pulses>" on a serial device, or as MQTT
+// messages on Topic whose last segment is the tap and whose payload is the
+// pulse count.
+type KegConfig struct {
+	Source string           `json:"source,omitempty"` // "serial" or "mqtt"
+	Device string           `json:"device,omitempty"`
+	MQTT   store.MQTTConfig `json:"mqtt"`
+	Topic  string           `json:"topic,omitempty"`
+	// PulsesPerLiter calibrates the flow meters.
+	PulsesPerLiter float64 `json:"pulses_per_liter"`
+	// PourIdle is how long the meter has to be quiet for a pour to end.
+	PourIdle Duration `json:"pour_idle"`
+	// AlertLiters triggers the "keg nearly empty" warning.
+	AlertLiters float64 `json:"alert_liters"`
+	// PourSizes are what gets billed; a pour is billed as the size closest
+	// to the measured volume.
+	PourSizes      []store.PourSize `json:"pour_sizes"`
+	SessionTimeout Duration         `json:"session_timeout"`
+}
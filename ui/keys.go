@@ -0,0 +1,396 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// --- KEYS ---
+
+type keyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	Increase     key.Binding
+	Decrease     key.Binding
+	SortName     key.Binding
+	SortPrice    key.Binding
+	SortStock    key.Binding
+	EditQty      key.Binding
+	QuickAdd     key.Binding
+	Jump         key.Binding
+	Apply        key.Binding
+	Back         key.Binding
+	ShopTab      key.Binding
+	CartTab      key.Binding
+	StatsTab     key.Binding
+	Ranking      key.Binding
+	PrevRange    key.Binding
+	NextRange    key.Binding
+	Checkout     key.Binding
+	PayByTab     key.Binding
+	PayByVoucher key.Binding
+	PayByCard    key.Binding
+	PayByLink    key.Binding
+	MailReceipt  key.Binding
+	PickMember   key.Binding
+	NextMember   key.Binding
+	PrevMember   key.Binding
+	Confirm      key.Binding
+	Cancel       key.Binding
+	Undo         key.Binding
+	ClearCart    key.Binding
+	Note         key.Binding
+	Park         key.Binding
+	Parked       key.Binding
+	RemoveItem   key.Binding
+	MarkLine     key.Binding
+	Override     key.Binding
+	SwitchFocus  key.Binding
+	Export       key.Binding
+	Lock         key.Binding
+	Drawer       key.Binding
+	PriceTier    key.Binding
+	Help         key.Binding
+	Quit         key.Binding
+}
+
+var keys = keyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+	Increase: key.NewBinding(
+		key.WithKeys("+", "=", "right"),
+		key.WithHelp("→/+", "add"),
+	),
+	Decrease: key.NewBinding(
+		key.WithKeys("-", "left"),
+		key.WithHelp("←/-", "remove"),
+	),
+	SortName: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "sort by name"),
+	),
+	SortPrice: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "sort by price"),
+	),
+	SortStock: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "sort by stock"),
+	),
+	EditQty: key.NewBinding(
+		key.WithKeys("e", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("e/0-9", "quantity"),
+	),
+	QuickAdd: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "add numbered"),
+	),
+	Jump: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "jump to"),
+	),
+	Apply: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "apply"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	ShopTab: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "shop"),
+	),
+	CartTab: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "cart"),
+	),
+	StatsTab: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "stats"),
+	),
+	Ranking: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "ranking"),
+	),
+	PrevRange: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←", "shorter"),
+	),
+	NextRange: key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("→", "longer"),
+	),
+	Checkout: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "checkout"),
+	),
+	PayByTab: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "pay by ticket/card"),
+	),
+	PayByVoucher: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "pay with voucher"),
+	),
+	PayByCard: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pay by card"),
+	),
+	PayByLink: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "pay by link"),
+	),
+	MailReceipt: key.NewBinding(
+		key.WithKeys("@"),
+		key.WithHelp("@", "email receipt"),
+	),
+	PickMember: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "pay by recent member"),
+	),
+	NextMember: key.NewBinding(
+		key.WithKeys("tab", "down"),
+		key.WithHelp("tab/↓", "next member"),
+	),
+	PrevMember: key.NewBinding(
+		key.WithKeys("shift+tab", "up"),
+		key.WithHelp("shift+tab/↑", "previous member"),
+	),
+	Confirm: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "confirm"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n/esc", "cancel"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	ClearCart: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "clear"),
+	),
+	Note: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "note"),
+	),
+	Park: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "park order"),
+	),
+	Parked: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "parked orders"),
+	),
+	RemoveItem: key.NewBinding(
+		key.WithKeys("d", "delete"),
+		key.WithHelp("d", "remove from cart"),
+	),
+	MarkLine: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "check out on its own"),
+	),
+	Override: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "override price"),
+	),
+	SwitchFocus: key.NewBinding(
+		key.WithKeys("left", "right", "tab", "h", "l"),
+		key.WithHelp("←/→", "choose"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "export to USB"),
+	),
+	Lock: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "lock"),
+	),
+	Drawer: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "open drawer"),
+	),
+	PriceTier: key.NewBinding(
+		key.WithKeys("$"),
+		key.WithHelp("$", "price tier"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// localize translates the descriptions of the keys for the help into the
+// UI locale; the messages are named after the bindings, e.g. key_sort_name.
+func (k *keyMap) localize() {
+	for name, b := range k.bindings() {
+		b.SetHelp(b.Help().Key, tr("key_"+name))
+	}
+}
+
+// bindings returns the key map's bindings by name.
+func (k *keyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up": &k.Up, "down": &k.Down, "increase": &k.Increase, "decrease": &k.Decrease,
+		"sort_name": &k.SortName, "sort_price": &k.SortPrice, "sort_stock": &k.SortStock,
+		"edit_qty": &k.EditQty, "quick_add": &k.QuickAdd, "jump": &k.Jump, "apply": &k.Apply, "back": &k.Back,
+		"shop_tab": &k.ShopTab, "cart_tab": &k.CartTab, "stats_tab": &k.StatsTab,
+		"ranking": &k.Ranking, "prev_range": &k.PrevRange, "next_range": &k.NextRange,
+		"checkout": &k.Checkout, "pay_by_tab": &k.PayByTab, "pay_by_voucher": &k.PayByVoucher, "pay_by_card": &k.PayByCard, "pay_by_link": &k.PayByLink, "mail_receipt": &k.MailReceipt,
+		"pick_member": &k.PickMember, "next_member": &k.NextMember, "prev_member": &k.PrevMember, "confirm": &k.Confirm, "cancel": &k.Cancel,
+		"undo": &k.Undo, "clear_cart": &k.ClearCart, "note": &k.Note, "park": &k.Park, "parked": &k.Parked, "remove_item": &k.RemoveItem, "mark_line": &k.MarkLine, "override": &k.Override,
+		"switch_focus": &k.SwitchFocus, "export": &k.Export, "lock": &k.Lock, "drawer": &k.Drawer, "price_tier": &k.PriceTier, "help": &k.Help, "quit": &k.Quit,
+	}
+}
+
+// contextKeys is the subset of the key map that applies to the current
+// screen. It implements help.KeyMap so the help bubble only lists keys that
+// actually do something right now.
+type contextKeys struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+func (c contextKeys) ShortHelp() []key.Binding  { return c.short }
+func (c contextKeys) FullHelp() [][]key.Binding { return c.full }
+
+var _ help.KeyMap = contextKeys{}
+
+// helpKeys returns the key hints for the model's current state.
+func (m Model) helpKeys() contextKeys {
+	general := []key.Binding{keys.ShopTab, keys.CartTab, keys.StatsTab, keys.Help, keys.Quit}
+	if m.usbDrive != "" {
+		general = append(general, keys.Export)
+	}
+	if m.config.unlockPIN() != "" {
+		general = append(general, keys.Lock)
+	}
+	if m.config.AdminPIN != "" && m.config.Drawer.enabled() {
+		general = append(general, keys.Drawer)
+	}
+	if len(m.config.PriceTiers) > 0 {
+		general = append(general, keys.PriceTier)
+	}
+	switch {
+	case m.card != nil:
+		return contextKeys{
+			short: []key.Binding{keys.Back},
+			full:  [][]key.Binding{{keys.Back}},
+		}
+	case m.link != nil:
+		return contextKeys{
+			short: []key.Binding{keys.Apply, keys.Back},
+			full:  [][]key.Binding{{keys.Apply, keys.Back}},
+		}
+	case m.editingQty || m.scanning || m.jumping || m.overriding || m.redeeming || m.drawerPIN || m.mailing || m.noting:
+		return contextKeys{
+			short: []key.Binding{keys.Apply, keys.Back},
+			full:  [][]key.Binding{{keys.Apply, keys.Back}},
+		}
+	case m.picking:
+		return contextKeys{
+			short: []key.Binding{keys.Apply, keys.NextMember, keys.Back},
+			full:  [][]key.Binding{{keys.NextMember, keys.PrevMember}, {keys.Apply, keys.Back}},
+		}
+	case m.resuming:
+		return contextKeys{
+			short: []key.Binding{keys.Apply, keys.Down, keys.Back},
+			full:  [][]key.Binding{{keys.Up, keys.Down}, {keys.Apply, keys.Back}},
+		}
+	case m.confirm != nil:
+		return contextKeys{
+			short: []key.Binding{keys.Confirm, keys.Cancel, keys.SwitchFocus},
+			full:  [][]key.Binding{{keys.Confirm, keys.Cancel}, {keys.SwitchFocus, keys.Apply}},
+		}
+	case m.activeTab == 2 && m.showRanking:
+		return contextKeys{
+			short: []key.Binding{keys.PrevRange, keys.NextRange, keys.Ranking, keys.Help, keys.Quit},
+			full:  [][]key.Binding{{keys.PrevRange, keys.NextRange, keys.Ranking}, general},
+		}
+	case m.activeTab == 2:
+		return contextKeys{
+			short: []key.Binding{keys.Ranking, keys.ShopTab, keys.CartTab, keys.Help, keys.Quit},
+			full:  [][]key.Binding{{keys.Ranking}, general},
+		}
+	case m.activeTab == 1 && m.receipt != nil && m.config.MailReceipts:
+		return contextKeys{
+			short: []key.Binding{keys.MailReceipt, keys.ShopTab, keys.Help, keys.Quit},
+			full:  [][]key.Binding{{keys.MailReceipt, keys.Undo}, general},
+		}
+	case m.activeTab == 1 && !m.cart.HasItems() && len(m.parked) > 0:
+		return contextKeys{
+			short: []key.Binding{keys.Parked, keys.ShopTab, keys.Help, keys.Quit},
+			full:  [][]key.Binding{{keys.Parked}, general},
+		}
+	case m.activeTab == 1 && !m.cart.HasItems():
+		return contextKeys{
+			short: []key.Binding{keys.ShopTab, keys.Help, keys.Quit},
+			full:  [][]key.Binding{general},
+		}
+	case m.activeTab == 1:
+		edit := []key.Binding{keys.Increase, keys.Decrease, keys.RemoveItem, keys.MarkLine, keys.Undo}
+		if m.config.AdminPIN != "" {
+			edit = append(edit, keys.Override)
+		}
+		pay := []key.Binding{keys.Checkout, keys.PayByTab, keys.PayByVoucher}
+		if m.config.Card.Enabled() {
+			pay = append(pay, keys.PayByCard)
+		}
+		if m.config.PaymentLink.enabled() {
+			pay = append(pay, keys.PayByLink)
+		}
+		if m.config.RecentMembers > 0 {
+			pay = append(pay, keys.PickMember)
+		}
+		pay = append(pay, keys.Note, keys.Park)
+		if len(m.parked) > 0 {
+			pay = append(pay, keys.Parked)
+		}
+		pay = append(pay, keys.ClearCart)
+		return contextKeys{
+			short: []key.Binding{keys.Checkout, keys.PayByTab, keys.Increase, keys.Decrease, keys.ShopTab, keys.Help, keys.Quit},
+			full:  [][]key.Binding{{keys.Up, keys.Down}, edit, pay, general},
+		}
+	default:
+		edit := []key.Binding{keys.Increase, keys.Decrease, m.editQtyKey(), keys.RemoveItem, keys.Undo}
+		if m.config.QuickAdd {
+			edit = append(edit, keys.QuickAdd)
+		}
+		if len(m.config.Favorites.Beverages) > 0 {
+			edit = append(edit, m.favoritesKey())
+		}
+		return contextKeys{
+			short: []key.Binding{keys.Increase, keys.Decrease, m.editQtyKey(), keys.CartTab, keys.Help, keys.Quit},
+			full:  [][]key.Binding{{keys.Up, keys.Down, keys.Jump}, edit, {keys.SortName, keys.SortPrice, keys.SortStock}, general},
+		}
+	}
+}
+
+// The keys of the screens the commands show before they book anything: the
+// bank import, the catalog merge, the supplier invoice and the bar-close
+// checklist. Each of them is left with abortKey.
+var (
+	abortKey = key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "abort"))
+
+	bankToggle      = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "book/skip"))
+	bankBook        = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "book"))
+	catalogToggle   = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "merge/skip"))
+	catalogMerge    = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "merge"))
+	invoiceReprice  = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "take/keep price"))
+	invoiceUpdate   = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "update"))
+	checklistToggle = key.NewBinding(key.WithKeys(" ", "x"), key.WithHelp("space", "done/not done"))
+	checklistClose  = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "close shift"))
+)
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- KEYSWITCH ---
+
+// KeyswitchConfig reads a physical key switch at the till. Its positions
+// select the price profile the till sells at and whether the admin
+// features, like undoing a sale, are unlocked. The switch is read either
+// from a GPIO value file such as /sys/class/gpio/gpio17/value, which is
+// polled, or from a serial device that sends a line with the position
+// whenever the key is turned.
+type KeyswitchConfig struct {
+	Source string `json:"source,omitempty"` // "gpio" or "serial"; empty for no switch
+	Device string `json:"device,omitempty"`
+	// Positions maps what the device reports, e.g. "0" and "1" for a GPIO,
+	// to what the position does. Positions not listed are locked and sell
+	// at the regular prices.
+	Positions map[string]KeyPosition `json:"positions,omitempty"`
+}
+
+type KeyPosition struct {
+	// Profile names an entry of price_profiles.
+	Profile string `json:"profile,omitempty"`
+	Admin   bool   `json:"admin,omitempty"`
+}
+
+// gpioPollInterval is how often a GPIO key switch is read.
+const gpioPollInterval = 100 * time.Millisecond
+
+func (c KeyswitchConfig) validate(profiles map[string]map[string]float64) error {
+	for name, pos := range c.Positions {
+		if _, ok := profiles[pos.Profile]; pos.Profile != "" && !ok {
+			return fmt.Errorf("keyswitch position %q: unknown price profile %q", name, pos.Profile)
+		}
+	}
+	return nil
+}
+
+// keyswitchMsg reports the position the key was turned to.
+type keyswitchMsg string
+
+// keyswitch delivers the positions of the switch as they change.
+type keyswitch struct {
+	positions chan string
+}
+
+func openKeyswitch(cfg KeyswitchConfig) (*keyswitch, error) {
+	k := &keyswitch{positions: make(chan string)}
+	switch cfg.Source {
+	case "gpio":
+		// Read once, so that a wrong path fails at startup.
+		if _, err := os.ReadFile(cfg.Device); err != nil {
+			return nil, err
+		}
+		go k.pollGPIO(cfg.Device)
+	case "serial":
+		port, err := os.Open(cfg.Device)
+		if err != nil {
+			return nil, err
+		}
+		lines := make(chan string)
+		go ScanLines(port, lines)
+		go func() {
+			for line := range lines {
+				if pos := strings.TrimSpace(line); pos != "" {
+					k.positions <- pos
+				}
+			}
+			port.Close()
+			close(k.positions)
+		}()
+	default:
+		return nil, fmt.Errorf("unknown keyswitch.source %q (use gpio or serial)", cfg.Source)
+	}
+	return k, nil
+}
+
+// pollGPIO reports the GPIO's value at startup and whenever it changes.
+// Read errors, e.g. while the pin is being re-exported, are skipped.
+func (k *keyswitch) pollGPIO(path string) {
+	last := ""
+	for ; ; time.Sleep(gpioPollInterval) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if pos := strings.TrimSpace(string(data)); pos != last {
+			last = pos
+			k.positions <- pos
+		}
+	}
+}
+
+// next waits for the key to be turned. Once a serial switch is gone it
+// reports nothing more, and the till stays in the last position.
+func (k *keyswitch) next() tea.Cmd {
+	return func() tea.Msg {
+		pos, ok := <-k.positions
+		if !ok {
+			return nil
+		}
+		return keyswitchMsg(pos)
+	}
+}
+
+// priced returns the inventory at the prices of the key's price profile.
+// Beverages the profile doesn't list keep the price of the kiosk's price
+// tier.
+func (m Model) priced(inventory []domain.Beverage) []domain.Beverage {
+	prices := m.config.PriceProfiles[m.keyPos.Profile]
+	if len(prices) == 0 && m.tier == "" {
+		return inventory
+	}
+	beverages := make([]domain.Beverage, len(inventory))
+	copy(beverages, inventory)
+	for i, b := range beverages {
+		beverages[i].Price = b.TierPrice(m.tier)
+		if price, ok := prices[b.Key()]; ok {
+			beverages[i].Price = price
+		}
+	}
+	return beverages
+}
+
+// adminLocked reports whether the key switch keeps the admin features
+// locked. Without a switch they are always available.
+func (m Model) adminLocked() bool {
+	return m.config.Keyswitch.Source != "" && !m.keyPos.Admin
+}
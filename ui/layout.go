@@ -0,0 +1,118 @@
+package ui
+
+import "github.com/charmbracelet/lipgloss"
+
+// --- LAYOUT ---
+
+const (
+	// minWidth and minHeight are the smallest terminal the UI still fits.
+	minWidth  = 49
+	minHeight = 16
+
+	minNameWidth     = 10
+	defaultNameWidth = 20
+	maxNameWidth     = 40
+	// fixedColumnsWidth is the width of all shop columns except the name,
+	// including the padding of every cell and the window border.
+	fixedColumnsWidth = 14 + 12 + qtyWidth + 4*2 + 2
+	// qtyWidth fits the quantity between its [−] and [+] click zones.
+	qtyWidth = 10
+	// quickAddWidth is the width of the index column of quick add.
+	quickAddWidth = 1
+	// tableChrome is everything around the table rows: the table header
+	// and its border, the window's padding and bottom border, the tab row
+	// and the blank lines before the help.
+	tableChrome = 2 + 4 + 1 + 3 + 2
+)
+
+// fixedColumnsWidth is fixedColumnsWidth with the index column of quick add,
+// if it is on, and its padding.
+func (m Model) fixedColumnsWidth() int {
+	if m.config.QuickAdd {
+		return fixedColumnsWidth + quickAddWidth + 2
+	}
+	return fixedColumnsWidth
+}
+
+func (m Model) tooSmall() bool {
+	// Before the first WindowSizeMsg we don't know the size yet.
+	if m.width == 0 && m.height == 0 {
+		return false
+	}
+	return m.width < minWidth || m.height < minHeight
+}
+
+// layout sizes the shop table to the terminal: the name column takes the
+// spare width and the table as many rows as fit, but no more than there are
+// beverages. The cart table gets the same columns and the rows it needs.
+func (m *Model) layout() {
+	if m.width == 0 || m.height == 0 || m.tooSmall() {
+		return
+	}
+
+	nameWidth := m.width - m.fixedColumnsWidth()
+	if m.showsImages() {
+		nameWidth -= imagePanelWidth
+	}
+	nameWidth = max(minNameWidth, min(nameWidth, maxNameWidth))
+	if nameWidth != m.nameWidth {
+		m.nameWidth = nameWidth
+		m.table.SetColumns(shopColumns(m.sortBy, m.sortDesc, m.nameWidth, m.config.QuickAdd))
+		m.cartTable.SetColumns(cartColumns(m.nameWidth))
+	}
+	// Keep the help inside the window rather than letting it widen it.
+	m.help.Width = m.nameWidth + m.fixedColumnsWidth() - 2
+
+	used := tableChrome + len(m.notices()) + lipgloss.Height(m.help.View(m.helpKeys()))
+	if m.editingQty {
+		used += lipgloss.Height(m.qtyEntryView())
+	}
+	if m.jumping {
+		used += lipgloss.Height(m.jumpView())
+	}
+	if bar := m.favoritesView(m.help.Width); bar != "" {
+		used += 1 + lipgloss.Height(bar)
+	}
+	if m.confirm != nil {
+		used += 1 + lipgloss.Height(m.confirm.View())
+	}
+	rows := max(1, min(m.height-used, len(m.order)))
+	// The table's height includes its two header lines.
+	if height := rows + 2; height != m.table.Height() {
+		m.table.SetHeight(height)
+	}
+	m.layoutCart()
+}
+
+// layoutCart sizes the cart table to the lines in the cart, as many as fit
+// between the order's heading and the total.
+func (m *Model) layoutCart() {
+	used := tableChrome + len(m.notices()) + lipgloss.Height(m.help.View(m.helpKeys()))
+	// The heading with its blank line, and the one before the total.
+	used += 3 + lipgloss.Height(m.cartSummary())
+	if m.scanning {
+		used += lipgloss.Height(m.scanView())
+	}
+	if m.overriding {
+		used += lipgloss.Height(m.overrideView())
+	}
+	if m.redeeming {
+		used += lipgloss.Height(m.voucherView())
+	}
+	if m.picking {
+		used += lipgloss.Height(m.pickMemberView())
+	}
+	if m.resuming {
+		used += lipgloss.Height(m.resumeView())
+	}
+	if m.noting {
+		used += lipgloss.Height(m.noteView())
+	}
+	if m.confirm != nil {
+		used += 1 + lipgloss.Height(m.confirm.View())
+	}
+	rows := max(1, min(m.height-used, len(m.cartNames)))
+	if height := rows + 2; height != m.cartTable.Height() {
+		m.cartTable.SetHeight(height)
+	}
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- LOW POWER ---
+
+// LowPowerConfig puts the kiosk to sleep at night, for tills that run off
+// a battery. Between From and Until, e.g. "22:00" and "07:00", a kiosk
+// nobody has touched for Idle blanks the screen, stops looking for USB
+// drives and checks the store only every Poll. Any key or click wakes it
+// at once. Without From, the kiosk never sleeps.
+type LowPowerConfig struct {
+	From  string   `json:"from,omitempty"`
+	Until string   `json:"until,omitempty"`
+	Idle  Duration `json:"idle"`
+	Poll  Duration `json:"poll"`
+	// Backlight is the display's bl_power file, e.g.
+	// /sys/class/backlight/rpi_backlight/bl_power, to switch its backlight
+	// off while the kiosk sleeps.
+	Backlight string `json:"backlight,omitempty"`
+}
+
+func (c LowPowerConfig) validate() error {
+	if c.From == "" && c.Until == "" {
+		return nil
+	}
+	for _, clock := range []string{c.From, c.Until} {
+		if _, err := minuteOfDay(clock); err != nil {
+			return fmt.Errorf("low_power: %w", err)
+		}
+	}
+	return nil
+}
+
+// minuteOfDay parses a time of day like "22:30" into minutes since
+// midnight.
+func minuteOfDay(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("time of day must look like \"22:30\", not %q", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// during reports whether t falls between From and Until, which may span
+// midnight.
+func (c LowPowerConfig) during(t time.Time) bool {
+	from, err := minuteOfDay(c.From)
+	if err != nil {
+		return false
+	}
+	until, err := minuteOfDay(c.Until)
+	if err != nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if from <= until {
+		return from <= minute && minute < until
+	}
+	return minute >= from || minute < until
+}
+
+// sleepIfIdle puts the kiosk to sleep once it is in the low-power hours
+// and has sat idle long enough, and wakes it when they end.
+func (m *Model) sleepIfIdle(now time.Time) {
+	lp := m.config.LowPower
+	if !lp.during(now) {
+		if m.asleep {
+			m.wake(now)
+		}
+		return
+	}
+	// What is on screen stays as it was for whoever wakes the kiosk, only
+	// an export has to finish first.
+	if m.asleep || m.exporting || now.Sub(m.lastInput) < lp.Idle.Duration {
+		return
+	}
+	m.asleep = true
+	setBacklight(lp.Backlight, false)
+}
+
+// wake brings the kiosk back from sleep, catching up on what it missed.
+func (m *Model) wake(now time.Time) {
+	m.asleep = false
+	setBacklight(m.config.LowPower.Backlight, true)
+	m.refresh(now)
+}
+
+// setBacklight switches the display's backlight through its bl_power
+// file, where 0 is on. A display without one just stays lit.
+func setBacklight(path string, on bool) {
+	if path == "" {
+		return
+	}
+	power := "1"
+	if on {
+		power = "0"
+	}
+	_ = os.WriteFile(path, []byte(power), 0o644)
+}
+
+// sleepView is the blank screen of the sleeping kiosk.
+func (m Model) sleepView() string {
+	return lipgloss.Place(m.width, m.height, lipgloss.Left, lipgloss.Top, "")
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// --- LOW STOCK ---
+
+func (m Model) lowStock() []domain.Beverage {
+	var low []domain.Beverage
+	for _, b := range m.beverages {
+		if b.IsLowStock(m.config.LowStock) {
+			low = append(low, b)
+		}
+	}
+	return low
+}
+
+func (m Model) lowStockNotice() string {
+	low := m.lowStock()
+	if len(low) == 0 {
+		return ""
+	}
+	names := make([]string, len(low))
+	for i, b := range low {
+		names[i] = fmt.Sprintf("%s (%s)", b.Key(), b.StockLabel())
+	}
+	return trf("low_stock", strings.Join(names, ", "))
+}
+
+// shopTableView renders the shop table with low-stock rows in the warning
+// color. The table can't style single rows, so we find their lines in the
+// rendered table and recolor them; the selected row keeps its highlight.
+func (m Model) shopTableView() string {
+	t := m.table
+	if m.config.QuickAdd {
+		t = m.quickAddTable()
+	}
+	view := t.View()
+	low := map[string]bool{}
+	from, to := m.visibleRows()
+	for row := from; row < to; row++ {
+		if m.beverages[m.order[row]].IsLowStock(m.config.LowStock) && row != t.Cursor() {
+			low[m.plainRow(t.Rows()[row])] = true
+		}
+	}
+	if len(low) == 0 {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		if low[line] {
+			if m.config.Accessibility != AccessibilityOff {
+				line = markRow(glyphs.lowStock)(line)
+			}
+			lines[i] = lowStockRowStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainRow renders an unselected row the way the table does.
+func (m Model) plainRow(row table.Row) string {
+	cols := m.table.Columns()
+	cells := make([]string, 0, len(cols))
+	for i, value := range row {
+		if cols[i].Width <= 0 {
+			continue
+		}
+		style := lipgloss.NewStyle().Width(cols[i].Width).MaxWidth(cols[i].Width).Inline(true)
+		cells = append(cells, m.cellStyle.Render(style.Render(runewidth.Truncate(value, cols[i].Width, glyphs.ellipsis))))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, cells...)
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- EMAIL RECEIPTS ---
+
+// With mail_receipts set, the receipt on screen can be mailed through the
+// config's mail server: the address is typed in, or taken from the
+// member's profile for a sale charged to a tab. The mail goes out in the
+// background; the kiosk says how it went for a few seconds.
+
+// mailNoticeFor is how long the kiosk says whether the receipt was mailed.
+const mailNoticeFor = 5 * time.Second
+
+type receiptMailedMsg struct{ err error }
+
+func newMailInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("mail_prompt")
+	ti.Placeholder = "name@example.org"
+	ti.CharLimit = 254
+	ti.Width = 30
+	return ti
+}
+
+// startMail asks for the address to mail the receipt to, the member's if
+// they have one.
+func (m *Model) startMail() tea.Cmd {
+	if !m.config.MailReceipts || m.receipt == nil || m.mailSending {
+		return nil
+	}
+	m.mailing = true
+	m.mailErr = nil
+	m.mailInput.SetValue("")
+	if i := m.store.MemberIndex(m.receipt.Member); i >= 0 {
+		m.mailInput.SetValue(m.store.Members[i].Email)
+	}
+	m.mailInput.CursorEnd()
+	return m.mailInput.Focus()
+}
+
+// updateMail handles keys while the address is entered.
+func (m Model) updateMail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Back):
+		m.stopMail()
+		return m, nil
+	case key.Matches(msg, keys.Apply):
+		addr, err := mail.ParseAddress(m.mailInput.Value())
+		if err != nil {
+			m.mailErr = errors.New(tr("mail_invalid"))
+			return m, nil
+		}
+		m.stopMail()
+		m.mailSending, m.mailTo = true, addr.Address
+		return m, tea.Batch(m.mailSpinner.Tick, m.sendReceipt(*m.receipt, addr.Address))
+	}
+	var cmd tea.Cmd
+	m.mailInput, cmd = m.mailInput.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) stopMail() {
+	m.mailing = false
+	m.mailErr = nil
+	m.mailInput.Blur()
+}
+
+// sendReceipt mails the receipt of sale to addr.
+func (m Model) sendReceipt(sale domain.Sale, addr string) tea.Cmd {
+	subject := fmt.Sprintf(PrintLocale.T("mail_subject"), cmp.Or(m.config.Board.Title, "BubbleTender"), sale.ID)
+	body := receiptView(sale)
+	if footer := m.footerView(PrintLocale, m.now()); footer != "" {
+		body += "\n" + footer + "\n"
+	}
+	mailer, now := m.config.Mail, m.now()
+	m.store.AuditLog.Write(store.AuditEntry{Time: now, Actor: m.store.Actor, Event: "receipt_mail", Member: sale.Member, Detail: fmt.Sprintf("sale #%d", sale.ID)})
+	return func() tea.Msg {
+		return receiptMailedMsg{mailer.Send([]string{addr}, subject, body, now)}
+	}
+}
+
+func (m Model) mailView() string {
+	view := "\n\n" + m.mailInput.View()
+	if m.mailErr != nil {
+		view += "\n" + warningStyle.Render(m.mailErr.Error())
+	}
+	return view
+}
+
+// mailNotice says, for a few seconds, whether the receipt was mailed.
+func (m Model) mailNotice() (string, bool) {
+	switch {
+	case m.mailing || m.mailSending || m.mailed.IsZero() || m.now().Sub(m.mailed) >= mailNoticeFor:
+		return "", false
+	case m.mailErr != nil:
+		return trf("mail_failed", m.mailErr), true
+	}
+	return trf("mail_sent", m.mailTo), false
+}
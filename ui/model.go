@@ -0,0 +1,1233 @@
+// Package ui is the till's terminal interface: the shop kiosk, the admin
+// console and the menu board, as Bubble Tea models, along with the config
+// they are built from. A frontend of its own can run New on a store, or
+// embed the Model in a program of its own.
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- DATA ---
+
+func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
+	border := glyphs.rounded
+	border.BottomLeft = left
+	border.Bottom = middle
+	border.BottomRight = right
+	return border
+}
+
+var (
+	inactiveTabBorder = tabBorderWithBottom("┴", "─", "┴")
+	activeTabBorder   = tabBorderWithBottom("┘", " ", "└")
+	docStyle          = lipgloss.NewStyle().Padding(1, 2, 1, 2)
+	theme             = themePresets[defaultThemePreset]
+	inactiveTabStyle  = lipgloss.NewStyle().Border(inactiveTabBorder, true).BorderForeground(theme.Tabs).Padding(0, 1)
+	activeTabStyle    = inactiveTabStyle.Border(activeTabBorder, true)
+	windowStyle       = lipgloss.NewStyle().BorderForeground(theme.Border).Padding(2, 0).Align(lipgloss.Center).Border(lipgloss.NormalBorder()).UnsetBorderTop()
+	bannerStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("230")).Background(theme.Tabs).Bold(true).Padding(0, 1)
+	warningStyle      = lipgloss.NewStyle().Foreground(theme.Warning).Bold(true)
+	lowStockRowStyle  = lipgloss.NewStyle().Foreground(theme.Warning)
+	lockdownStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("231")).Background(theme.Warning).Bold(true).Align(lipgloss.Center)
+	dialogStyle       = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Tabs).Padding(0, 2)
+	buttonStyle       = lipgloss.NewStyle().Padding(0, 2).Faint(true)
+	activeButtonStyle = lipgloss.NewStyle().Padding(0, 2).Foreground(theme.SelectedForeground).Background(theme.SelectedBackground)
+)
+
+// StorePollInterval is how often a kiosk reloads the store to pick up
+// changes from other processes, like a new banner, a lockdown or stock
+// levels.
+const StorePollInterval = 10 * time.Second
+
+// --- MODEL ---
+
+// Model is the shop kiosk: the inventory with the cart, the checkout and
+// the stats, on their tabs.
+type Model struct {
+	config     Config
+	store      *store.Store
+	beverages  []domain.Beverage
+	table      table.Model
+	cartTable  table.Model
+	cartNames  []string // beverage name of each cart table row
+	cellStyle  lipgloss.Style
+	help       help.Model
+	qtyInput   textinput.Model
+	editingQty bool
+	qtyErr     string
+	scanInput  textinput.Model
+	jumping    bool
+	jumpFrom   int // cursor row when the jump started
+	jumpInput  textinput.Model
+	scanning   bool
+	scanErr    string
+	// overriding is while an admin sets the price of a cart line, see
+	// startOverride.
+	overriding  bool
+	overrideErr string
+	priceInput  textinput.Model
+	pinInput    textinput.Model
+	prices      map[string]float64 // overridden unit prices by beverage name
+	marked      map[string]bool    // cart lines to check out on their own, see toggleMarked
+	// redeeming is while the code of a voucher to pay with is entered.
+	redeeming    bool
+	voucherErr   string
+	voucherInput textinput.Model
+	// card is the payment on the card terminal that is waited for.
+	card *cardCharge
+	// link is the payment by link that is waited for.
+	link *linkPayment
+	// picking is while the member to charge is picked from those who paid
+	// recently, see startPickMember.
+	picking      bool
+	memberRow    int
+	memberFilter textinput.Model
+	cart         domain.Cart  // quantity per beverage name
+	history      []cartChange // undo stack of cart changes
+	order        []int        // beverage index of each table row
+	sortBy       sortColumn
+	tier         string // the price tier shown, see PriceTiers
+	sortDesc     bool
+	pins         []string          // beverages at the top of the shop, see ShopOrderConfig
+	publisher    *sessionPublisher // to the customer display, for clients of a server
+	published    string            // the session last sent, as JSON
+	nameWidth    int
+	confirm      *confirmDialog // the open confirmation dialog, if any
+	stats        *statsTab      // while the stats tab is open
+	receipt      *domain.Sale
+	thanks       string    // the footer's thank-you message, see pickThanks
+	undoUntil    time.Time // end of the grace period to undo the receipt's sale
+	undoErr      string
+	err          error
+	banner       string
+	lockdown     store.Lockdown
+	keyswitch    *keyswitch
+	keyPos       KeyPosition // where the key switch is turned to
+	usbDrive     string      // mount point of the USB drive, if one is plugged in
+	exporting    bool
+	exportedTo   string
+	exportErr    error
+	drawerErr    error
+	drawerPIN    bool // the admin PIN to open the drawer is entered
+	loading      bool // the store holds just the cache until storeLoadedMsg
+	asleep       bool // blanked for the low-power hours, see sleepIfIdle
+	saver        bool // the screensaver is on, see saveScreenIfIdle
+	locked       bool // locked by hand, see lock
+	unlockInput  textinput.Model
+	unlockErr    string
+	lastInput    time.Time
+	refreshed    time.Time // when the store was last checked for changes
+	checking     bool      // the nightly consistency check is running
+	diverged     int       // divergences the last consistency check found
+	checkErr     error
+	release      *store.Release // newer than this build, see checkRelease
+	reloader     *configReloader
+	reloaded     time.Time // when the config was last reloaded
+	reloadErr    error
+	mailing      bool // the address to mail the receipt to is entered
+	mailInput    textinput.Model
+	mailSpinner  spinner.Model
+	mailSending  bool
+	mailTo       string    // the address the receipt was last mailed to
+	mailed       time.Time // when mailing the receipt last finished
+	mailErr      error
+	activeTab    int
+	width        int
+	height       int
+	now          func() time.Time
+
+	// showRanking switches the stats tab to the ranking over the period
+	// rankingRanges[rankingRange].
+	showRanking  bool
+	rankingRange int
+
+	// parked are the orders put aside, see park; resuming is while their
+	// list is open, at parkedRow.
+	parked    []parkedCart
+	parkSeq   int // number of the order last parked
+	resuming  bool
+	parkedRow int
+
+	// note is the note the order in the cart is booked with; noting is
+	// while it is edited.
+	note      string
+	noting    bool
+	noteInput textinput.Model
+}
+
+// New returns the kiosk for the store. ApplyAssets, ApplyTerminal and
+// ApplyTheme set up what it looks like before it is run.
+func New(cfg Config, st *store.Store, opts ...Option) Model {
+	t := table.New(
+		table.WithColumns(shopColumns(sortByInventory, false, defaultNameWidth, cfg.QuickAdd)),
+		table.WithFocused(true),
+		table.WithHeight(7),
+	)
+	s := tableStyles(cfg)
+	t.SetStyles(s)
+	// d and u are ours (remove item, undo); the table keeps ctrl+d/ctrl+u
+	// for paging.
+	t.KeyMap.HalfPageDown.SetKeys("ctrl+d")
+	t.KeyMap.HalfPageUp.SetKeys("ctrl+u")
+	cart := table.New(
+		table.WithColumns(cartColumns(defaultNameWidth)),
+		table.WithFocused(true),
+		table.WithHeight(7),
+	)
+	cart.SetStyles(s)
+	cart.KeyMap = t.KeyMap
+
+	m := Model{
+		config:       cfg,
+		store:        st,
+		beverages:    st.Beverages,
+		table:        t,
+		cartTable:    cart,
+		cellStyle:    s.Cell,
+		help:         newHelp(),
+		qtyInput:     newQtyInput(),
+		scanInput:    newScanInput(),
+		jumpInput:    newJumpInput(),
+		priceInput:   newPriceInput(),
+		pinInput:     newPINInput(),
+		voucherInput: newVoucherInput(),
+		memberFilter: newMemberInput(),
+		unlockInput:  newUnlockInput(),
+		mailInput:    newMailInput(),
+		noteInput:    newNoteInput(),
+		mailSpinner:  spinner.New(spinner.WithSpinner(spinner.MiniDot)),
+		cart:         domain.Cart{},
+		prices:       map[string]float64{},
+		marked:       map[string]bool{},
+		activeTab:    0,
+		lockdown:     st.Lockdown,
+		nameWidth:    defaultNameWidth,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	m.banner = st.ActiveBanner(m.now())
+	m.lastInput = m.now()
+	m.pickThanks()
+	m.pins = slices.Concat(cfg.ShopOrder.Pinned, m.topSellers(m.now()))
+	if st.Remote != nil {
+		m.publisher = newSessionPublisher(st.Remote)
+	}
+	m.updateRows()
+	return m
+}
+
+// tableStyles are the styles of the shop and cart tables in the current
+// theme.
+func tableStyles(cfg Config) table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.BorderStyle(glyphs.border).BorderBottom(true)
+	s.Selected = s.Selected.Foreground(theme.SelectedForeground).Background(theme.SelectedBackground).Bold(false)
+	if cfg.Accessibility != AccessibilityOff {
+		s.Selected = s.Selected.Transform(markRow(glyphs.selected))
+	}
+	return s
+}
+
+type storeTickMsg time.Time
+
+func pollStore() tea.Cmd {
+	return tea.Tick(StorePollInterval, func(t time.Time) tea.Msg { return storeTickMsg(t) })
+}
+
+func (m Model) Init() tea.Cmd {
+	cmds := []tea.Cmd{pollStore()}
+	if m.loading {
+		cmds = append(cmds, loadStore(m.store))
+	}
+	if m.store.Remote != nil {
+		cmds = append(cmds, watchStore(m.store.Remote, ""))
+	}
+	if m.keyswitch != nil {
+		cmds = append(cmds, m.keyswitch.next())
+	}
+	cmds = append(cmds, m.watchIdle(), checkRelease(m.config.Updates, 0), m.reloader.next())
+	return tea.Batch(cmds...)
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	// Whatever changed may have changed how much room the table has.
+	if nm, ok := next.(Model); ok {
+		nm.layout()
+		nm.publishSession()
+		next = nm
+	}
+	return next, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg:
+		m.lastInput = m.now()
+		// The key that wakes the kiosk does nothing else.
+		if m.asleep {
+			m.wake(m.now())
+			return m, nil
+		}
+		if m.saver {
+			return m.updateScreensaver(msg)
+		}
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Serial lines don't know their size; the configured one stays.
+		if msg.Width > 0 && msg.Height > 0 {
+			m.width = msg.Width
+			m.height = msg.Height
+		}
+		return m, nil
+	case storeLoadedMsg:
+		m.loading = false
+		// If loading failed, the next refresh tries again.
+		if msg.err == nil {
+			*m.store = *msg.store
+		}
+		m.refresh(m.now())
+		return m, nil
+	case storeTickMsg:
+		now := time.Time(msg)
+		// Asleep, the kiosk only checks the store every LowPower.Poll.
+		if !m.asleep || now.Sub(m.refreshed) >= m.config.LowPower.Poll.Duration {
+			m.refresh(now)
+			m.reloadIfChanged()
+		}
+		m.sleepIfIdle(now)
+		return m, tea.Batch(pollStore(), m.checkIfDue(now))
+	case receiptMailedMsg:
+		m.mailSending, m.mailErr, m.mailed = false, msg.err, m.now()
+		return m, nil
+	case spinner.TickMsg:
+		if !m.mailSending {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.mailSpinner, cmd = m.mailSpinner.Update(msg)
+		return m, cmd
+	case checkedMsg:
+		m.checking = false
+		m.diverged, m.checkErr = len(msg.snap.Divergences), msg.err
+		return m, nil
+	case storeChangedMsg:
+		// Waking up catches up on the changes.
+		if !m.asleep {
+			m.refresh(m.now())
+		}
+		return m, watchStore(m.store.Remote, string(msg))
+	case configHupMsg:
+		m.reloadConfig()
+		return m, m.reloader.next()
+	case releaseMsg:
+		m.release = msg.release
+		return m, checkRelease(m.config.Updates, m.config.Updates.Check.Duration)
+	case screensaverTickMsg:
+		m.saveScreenIfIdle(m.now())
+		return m, m.watchIdle()
+	case keyswitchMsg:
+		m.keyPos = m.config.Keyswitch.Positions[string(msg)]
+		m.beverages = m.priced(m.store.Beverages)
+		m.updateRows()
+		return m, m.keyswitch.next()
+	case exportDoneMsg:
+		m.exporting = false
+		m.exportedTo, m.exportErr = msg.dir, msg.err
+		return m, nil
+	case drawerMsg:
+		m.drawerErr = msg.err
+		return m, nil
+	case cardChargedMsg, cardStatusMsg, cardCancelMsg:
+		if m.card == nil {
+			return m, nil
+		}
+		return m.updateCard(msg)
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.editingQty {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateQtyEntry(msg)
+		}
+		if m.scanning {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateScan(msg)
+		}
+		if m.jumping {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateJump(msg)
+		}
+		if m.overriding {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateOverride(msg)
+		}
+		if m.redeeming {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateVoucher(msg)
+		}
+		if m.picking {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updatePickMember(msg)
+		}
+		if m.noting {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateNote(msg)
+		}
+		if m.resuming {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateResume(msg)
+		}
+		if m.drawerPIN {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateDrawer(msg)
+		}
+		if m.card != nil {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateCard(msg)
+		}
+		if m.link != nil {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateLink(msg)
+		}
+		if m.mailing {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.updateMail(msg)
+		}
+		if m.confirm != nil {
+			if msg.Type == tea.KeyCtrlC {
+				return m, tea.Quit
+			}
+			return m.answerConfirm(msg)
+		}
+
+		switch {
+		case key.Matches(msg, keys.Quit):
+			if m.cart.HasItems() && msg.Type != tea.KeyCtrlC {
+				m.confirm = newConfirm(confirmQuit, tr("confirm_quit"), tr("quit"), tr("stay"), false)
+				return m, nil
+			}
+			return m, tea.Quit
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			return m, nil
+		case key.Matches(msg, keys.Lock):
+			m.lock()
+			return m, nil
+		case key.Matches(msg, keys.Drawer):
+			return m, m.startDrawer()
+		case key.Matches(msg, keys.PriceTier) && len(m.config.PriceTiers) > 0:
+			m.nextTier()
+			return m, nil
+		case key.Matches(msg, keys.Export) && m.usbDrive != "" && !m.exporting:
+			if m.adminLocked() {
+				m.exportErr = errors.New(tr("turn_key_export"))
+				return m, nil
+			}
+			m.exporting, m.exportedTo, m.exportErr = true, "", nil
+			return m, exportToUSB(m.store, m.usbDrive, m.now())
+		}
+
+		if key.Matches(msg, keys.Undo) && m.receipt == nil && m.err == nil {
+			m.undo()
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, keys.ShopTab):
+			m.showTab(0) // Shop
+		case key.Matches(msg, keys.CartTab):
+			m.showTab(1) // Cart
+		case key.Matches(msg, keys.StatsTab):
+			m.showTab(2) // Stats
+		}
+
+		switch m.activeTab {
+		case 0: // Shop Tab
+			if m.addFavorite(msg) {
+				return m, nil
+			}
+			switch {
+			case key.Matches(msg, keys.Increase):
+				m.addOne()
+			case key.Matches(msg, keys.Decrease):
+				m.removeOne()
+			case key.Matches(msg, keys.SortName):
+				m.toggleSort(sortByName)
+			case key.Matches(msg, keys.SortPrice):
+				m.toggleSort(sortByPrice)
+			case key.Matches(msg, keys.SortStock):
+				m.toggleSort(sortByStock)
+			case key.Matches(msg, keys.RemoveItem):
+				m.confirmRemove()
+			case m.config.QuickAdd && key.Matches(msg, keys.QuickAdd):
+				m.quickAdd(msg)
+			case key.Matches(msg, keys.EditQty):
+				return m, m.startQtyEntry(msg)
+			case key.Matches(msg, keys.Jump):
+				return m, m.startJump()
+			}
+			m.table, cmd = m.table.Update(msg)
+
+		case 2: // Stats Tab
+			m.updateStats(msg)
+
+		case 1: // Cart Tab
+			if m.receipt != nil && key.Matches(msg, keys.Undo) {
+				m.undoSale()
+			} else if m.receipt != nil && m.config.MailReceipts && key.Matches(msg, keys.MailReceipt) {
+				return m, m.startMail()
+			} else if m.receipt != nil || m.err != nil {
+				// Any other key dismisses the receipt or error of the last
+				// checkout.
+				m.receipt = nil
+				m.undoErr = ""
+				m.err = nil
+			} else if key.Matches(msg, keys.Parked) {
+				m.startResume()
+			} else if m.cart.HasItems() {
+				switch {
+				case key.Matches(msg, keys.Increase):
+					m.addOne()
+				case key.Matches(msg, keys.Decrease):
+					m.removeOne()
+				case key.Matches(msg, keys.RemoveItem):
+					m.confirmRemove()
+				case key.Matches(msg, keys.MarkLine):
+					m.toggleMarked()
+				case key.Matches(msg, keys.Override):
+					return m, m.startOverride()
+				case key.Matches(msg, keys.Checkout):
+					if m.lockdown != store.LockdownReadOnly {
+						m.confirm = newConfirm(confirmCheckout, tr("confirm_checkout"), tr("buy"), tr("cancel"), true)
+					}
+				case key.Matches(msg, keys.PayByTab):
+					if m.lockdown != store.LockdownReadOnly {
+						return m, m.startScan()
+					}
+				case key.Matches(msg, keys.PayByVoucher):
+					if m.lockdown != store.LockdownReadOnly {
+						return m, m.startVoucher()
+					}
+				case key.Matches(msg, keys.PayByCard):
+					if m.lockdown != store.LockdownReadOnly {
+						return m, m.startCard()
+					}
+				case key.Matches(msg, keys.PayByLink):
+					if m.lockdown != store.LockdownReadOnly {
+						m.startLink()
+					}
+				case key.Matches(msg, keys.PickMember):
+					if m.lockdown != store.LockdownReadOnly {
+						return m, m.startPickMember()
+					}
+				case key.Matches(msg, keys.Note):
+					return m, m.startNote()
+				case key.Matches(msg, keys.Park):
+					m.park()
+				case key.Matches(msg, keys.ClearCart):
+					m.confirm = newConfirm(confirmClearCart, tr("confirm_clear"), tr("clear"), tr("keep"), false)
+				default:
+					m.cartTable, cmd = m.cartTable.Update(msg)
+				}
+			}
+		}
+	}
+
+	return m, cmd
+}
+
+// refresh picks up changes to the store made elsewhere.
+func (m *Model) refresh(now time.Time) {
+	// A failed reload keeps the state we already have. While the store is
+	// still loading in the background, the cached state has to do.
+	if !m.loading {
+		_ = m.store.Reload()
+	}
+	m.refreshed = now
+	m.beverages = m.priced(m.store.Beverages)
+	if !m.loading {
+		m.pins = slices.Concat(m.config.ShopOrder.Pinned, m.topSellers(now))
+	}
+	m.updateRows()
+	if m.stats != nil && !m.loading {
+		m.stats = newStatsTab(m.store, now)
+	}
+	m.banner = m.store.ActiveBanner(now)
+	m.lockdown = m.store.Lockdown
+	if drive := findUSBDrive(m.config.USB.MountRoots); drive != m.usbDrive {
+		m.usbDrive, m.exportedTo, m.exportErr = drive, "", nil
+	}
+	if m.lockdown == store.LockdownReadOnly && m.confirm != nil && m.confirm.action == confirmCheckout {
+		m.confirm = nil
+	}
+	if m.lockdown == store.LockdownReadOnly && m.scanning {
+		m.stopScan()
+	}
+}
+
+// clearCart empties the cart. Every line goes on the undo stack, so the
+// cart can be brought back with undo.
+func (m *Model) clearCart() {
+	for _, b := range m.beverages {
+		m.setQty(b.Key(), 0)
+	}
+	m.note = ""
+}
+
+// checkout books the cart, or its marked lines, as a sale and leaves its
+// receipt to be shown. With a member, the sale is charged to their tab, at
+// their price tier; with a voucher, it is paid from that as far as it goes.
+func (m *Model) checkout(member, voucher string) tea.Cmd {
+	return m.book(domain.Sale{Member: member, Voucher: voucher, Lines: m.memberLines(member)})
+}
+
+// book books sale with the cart's lines, unless it comes with lines of its
+// own, like a card payment that holds on to what it charged for.
+func (m *Model) book(sale domain.Sale) tea.Cmd {
+	sale.Time = m.now()
+	sale.Note = m.note
+	if sale.Lines == nil {
+		sale.Lines = m.checkoutLines()
+	}
+	m.classify(sale.Lines)
+	sale, err := m.store.RecordSale(sale)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.receipt = &sale
+	m.pickThanks()
+	m.undoUntil = sale.Time.Add(m.config.UndoGrace.Duration)
+	m.beverages = m.priced(m.store.Beverages)
+	m.keepUnmarked()
+	if !m.cart.HasItems() {
+		m.note = ""
+	}
+	m.history = nil
+	m.updateRows()
+	return m.cashDrawer(sale)
+}
+
+// classify sets the tax and account of lines from their beverages. A card
+// or link payment holds on to its lines, classified as it started, while
+// the store may change; a line whose beverage has gone since keeps that
+// and is booked outside the stock, as it was paid for.
+func (m Model) classify(lines []domain.SaleLine) {
+	for i, line := range lines {
+		j := domain.IndexOf(m.beverages, line.Name)
+		if j < 0 {
+			lines[i].Untracked = true
+			continue
+		}
+		m.config.Classify(&lines[i], m.beverages[j])
+	}
+}
+
+// addOne puts one more of the selected beverage into the cart.
+func (m *Model) addOne() {
+	if b, ok := m.cursorBeverage(); ok {
+		m.add(b)
+	}
+}
+
+// add puts one more of b into the cart, if it can take it.
+func (m *Model) add(b domain.Beverage) {
+	if m.lockdown != store.LockdownReadOnly && m.canAdd(b) {
+		m.setQty(b.Key(), m.cart[b.Key()]+1)
+	}
+}
+
+// removeOne takes one of the selected beverage out of the cart.
+func (m *Model) removeOne() {
+	if b, ok := m.cursorBeverage(); ok && m.cart[b.Key()] > 0 {
+		m.setQty(b.Key(), m.cart[b.Key()]-1)
+	}
+}
+
+// confirmRemove asks whether to take all of the selected beverage out of
+// the cart.
+func (m *Model) confirmRemove() {
+	if b, ok := m.cursorBeverage(); ok && m.cart[b.Key()] > 0 {
+		m.confirm = newConfirm(confirmRemoveItem,
+			trf("confirm_remove", m.cart[b.Key()], b.Key()), tr("remove"), tr("keep"), false)
+		m.confirm.item = b.Key()
+	}
+}
+
+type sortColumn int
+
+// The zero value keeps the order of the inventory, or the one configured in
+// ShopOrderConfig.Order.
+const (
+	sortByInventory sortColumn = iota
+	sortByName
+	sortByPrice
+	sortByStock
+)
+
+// shopColumns returns the shop table's columns with the sort indicator on
+// the sorted one, after the index column of quick add if it is on.
+func shopColumns(by sortColumn, desc bool, nameWidth int, quickAdd bool) []table.Column {
+	columns := []table.Column{
+		{Title: tr("col_name"), Width: nameWidth},
+		{Title: tr("col_price"), Width: 14},
+		{Title: tr("col_stock"), Width: 12},
+		{Title: tr("col_qty"), Width: qtyWidth},
+	}
+	if by != sortByInventory {
+		arrow := " " + glyphs.sortUp
+		if desc {
+			arrow = " " + glyphs.sortDown
+		}
+		columns[by-1].Title += arrow
+	}
+	if quickAdd {
+		columns = append([]table.Column{{Title: "", Width: quickAddWidth}}, columns...)
+	}
+	return columns
+}
+
+// toggleSort sorts by the given column, flipping the direction if the table
+// is already sorted by it. The cursor stays on the selected beverage.
+func (m *Model) toggleSort(by sortColumn) {
+	if m.sortBy == by {
+		m.sortDesc = !m.sortDesc
+	} else {
+		m.sortBy, m.sortDesc = by, false
+	}
+	selected, ok := m.selectedBeverage()
+	m.table.SetColumns(shopColumns(m.sortBy, m.sortDesc, m.nameWidth, m.config.QuickAdd))
+	m.updateRows()
+	if !ok {
+		return
+	}
+	for row, i := range m.order {
+		if m.beverages[i].Key() == selected.Key() {
+			m.table.SetCursor(row)
+			break
+		}
+	}
+}
+
+// available is how many of b the cart can hold, given what the rest of
+// the cart already takes from shared stock.
+func (m Model) available(b domain.Beverage) int {
+	var others []domain.SaleLine
+	for name, qty := range m.cart {
+		if name != b.Key() && qty > 0 {
+			others = append(others, domain.SaleLine{Name: name, Quantity: qty})
+		}
+	}
+	needed, err := domain.StockNeeded(m.beverages, others)
+	if err != nil || len(needed) == 0 {
+		return b.AvailableFrom(m.beverages)
+	}
+	left := slices.Clone(m.beverages)
+	for i := range left {
+		left[i].Stock -= needed[left[i].Key()]
+	}
+	if i := domain.IndexOf(left, b.Key()); i >= 0 {
+		b = left[i]
+	}
+	return max(0, b.AvailableFrom(left))
+}
+
+// StockLabel renders the stock column; recipes show how many can be made
+// from the ingredients.
+func (m Model) stockLabel(b domain.Beverage) string {
+	if !b.AvailableAt(m.now()) {
+		return tr("not_now")
+	}
+	if !b.IsRecipe() {
+		return b.StockLabel()
+	}
+	if n := m.available(b); n > 0 {
+		return fmt.Sprintf("%d", n)
+	}
+	return tr("unavailable")
+}
+
+// stockSortKey sorts recipes by how many can be made and everything else
+// by its stock.
+func stockSortKey(b domain.Beverage, inventory []domain.Beverage) float64 {
+	if b.IsRecipe() {
+		return float64(b.AvailableFrom(inventory))
+	}
+	return b.Stock
+}
+
+// selectedBeverage returns the beverage under the table cursor.
+func (m Model) selectedBeverage() (domain.Beverage, bool) {
+	row := m.table.Cursor()
+	if row < 0 || row >= len(m.order) {
+		return domain.Beverage{}, false
+	}
+	return m.beverages[m.order[row]], true
+}
+
+// updateRows rebuilds the shop table from the inventory and the cart, in
+// the current sort order. Changes to the cart alone only need updateRow.
+func (m *Model) updateRows() {
+	// Beverages hidden outside their hours are left out, unless they are
+	// still in the cart from before.
+	now := m.now()
+	m.order = make([]int, 0, len(m.beverages))
+	for i, b := range m.beverages {
+		if !b.HiddenAt(now) || m.cart[b.Key()] > 0 {
+			m.order = append(m.order, i)
+		}
+	}
+	sort.SliceStable(m.order, func(a, b int) bool {
+		x, y := m.beverages[m.order[a]], m.beverages[m.order[b]]
+		if px, py := m.pinRank(x), m.pinRank(y); px != py {
+			return px < py
+		}
+		if m.sortDesc {
+			x, y = y, x
+		}
+		switch m.sortBy {
+		case sortByName:
+			return strings.ToLower(x.Name) < strings.ToLower(y.Name)
+		case sortByPrice:
+			return x.Price < y.Price
+		case sortByStock:
+			return stockSortKey(x, m.beverages) < stockSortKey(y, m.beverages)
+		}
+		return m.orderRank(x) < m.orderRank(y)
+	})
+
+	rows := make([]table.Row, 0, len(m.order))
+	for _, i := range m.order {
+		rows = append(rows, m.shopRow(m.beverages[i]))
+	}
+	m.table.SetRows(rows)
+	if m.table.Cursor() >= len(rows) {
+		m.table.SetCursor(max(0, len(rows)-1))
+	}
+	m.updateCartRows()
+}
+
+// updateRow renders the row of a beverage whose quantity changed, rather
+// than all of them. Recipes come along, as what can still be made of them
+// depends on the cart.
+func (m *Model) updateRow(name string) {
+	rows := m.table.Rows()
+	if len(rows) != len(m.order) {
+		m.updateRows()
+		return
+	}
+	for row, i := range m.order {
+		if b := m.beverages[i]; b.Key() == name || b.IsRecipe() {
+			rows[row] = m.shopRow(b)
+		}
+	}
+	m.table.SetRows(rows)
+	m.updateCartRows()
+}
+
+// shopRow is the row of b in the shop table.
+func (m Model) shopRow(b domain.Beverage) table.Row {
+	cells := table.Row{
+		b.Key(),
+		priceLabel(b),
+		m.stockLabel(b),
+		fmt.Sprintf("%s %d %s", glyphs.minus, m.cart[b.Key()], glyphs.plus),
+	}
+	if m.config.QuickAdd {
+		// Numbered as it is drawn, see quickAddTable.
+		cells = append(table.Row{""}, cells...)
+	}
+	return cells
+}
+
+// visibleRows returns the range of rows that can be on screen. The table
+// only renders those around the cursor, scrolling to keep it in view, so
+// none further from it than the table's height show.
+func (m Model) visibleRows() (from, to int) {
+	cursor, height := m.table.Cursor(), m.table.Height()
+	return max(0, cursor-height), min(len(m.order), cursor+height+1)
+}
+
+// --- VIEWS ---
+
+func (m Model) View() string {
+	if m.asleep {
+		return m.sleepView()
+	}
+	if m.saver {
+		return m.screensaverView()
+	}
+	if m.config.Accessibility == AccessibilityPlain {
+		return m.plainView()
+	}
+	if m.tooSmall() {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+			warningStyle.Render(tr("window_too_small"))+"\n"+trf("window_size", m.width, m.height, minWidth, minHeight))
+	}
+
+	var mainContent string
+
+	// --- 1. Generate the Main Content String ---
+	switch m.activeTab {
+	case 2: // Stats
+		if m.loading {
+			mainContent = tr("loading_history")
+		} else if m.showRanking {
+			mainContent = m.rankingView()
+		} else {
+			mainContent = m.statsView()
+		}
+	case 1: // Cart
+		mainContent = m.cartView()
+		if m.scanning {
+			mainContent += m.scanView()
+		}
+		if m.overriding {
+			mainContent += m.overrideView()
+		}
+		if m.redeeming {
+			mainContent += m.voucherView()
+		}
+		if m.picking {
+			mainContent += m.pickMemberView()
+		}
+		if m.resuming {
+			mainContent += m.resumeView()
+		}
+		if m.noting {
+			mainContent += m.noteView()
+		}
+		if m.card != nil {
+			mainContent += m.cardView()
+		}
+		if m.link != nil {
+			mainContent += m.linkView()
+		}
+		if m.confirm != nil {
+			mainContent += "\n\n" + m.confirm.View()
+		}
+	default: // Shop
+		mainContent = m.shopTableView()
+		if m.showsImages() {
+			mainContent = lipgloss.JoinHorizontal(lipgloss.Top, mainContent, "  ", m.imagePanel())
+		}
+		if bar := m.favoritesView(m.help.Width); bar != "" {
+			mainContent = bar + "\n\n" + mainContent
+		}
+		if m.editingQty {
+			mainContent += m.qtyEntryView()
+		}
+		if m.jumping {
+			mainContent += m.jumpView()
+		}
+		if m.confirm != nil {
+			mainContent += "\n\n" + m.confirm.View()
+		}
+	}
+	if m.drawerPIN {
+		mainContent += m.drawerView()
+	}
+	// Kitty keeps images on screen until they are deleted.
+	if m.activeTab != 0 && graphics == graphicsKitty && m.showsImages() {
+		mainContent = kittyDelete + mainContent
+	}
+	helpText := "\n\n" + m.help.View(m.helpKeys())
+
+	// Render the content inside its styled window
+	renderedContent := windowStyle.Render(mainContent + helpText)
+
+	// --- 2. Measure the Content's Width ---
+	contentWidth := lipgloss.Width(renderedContent)
+
+	// --- 3. Render the Tabs to Match the Width ---
+	// The cart sits on the right, the other tabs on the left.
+	tabs := kioskTabs()
+	renderedTabs := []string{}
+
+	// Create styled tab strings
+	for i, t := range tabs {
+		var style lipgloss.Style
+		isFirst, isLast, isActive := i == 0, i == len(tabs)-1, t.index == m.activeTab
+		if isActive {
+			style = activeTabStyle
+		} else {
+			style = inactiveTabStyle
+		}
+		border, _, _, _, _ := style.GetBorder()
+		if isFirst && isActive {
+			border.BottomLeft = glyphs.vertical
+		} else if isFirst && !isActive {
+			border.BottomLeft = glyphs.teeLeft
+		} else if isLast && isActive {
+			border.BottomRight = glyphs.vertical
+		} else if isLast && !isActive {
+			border.BottomRight = glyphs.teeRight
+		}
+		style = style.Border(border)
+		renderedTabs = append(renderedTabs, style.Render(t.label))
+	}
+
+	// Calculate the width of the tabs and create a filler
+	tabsWidth := 0
+	for _, t := range renderedTabs {
+		tabsWidth += lipgloss.Width(t)
+	}
+	// Narrow content still has to make room for all tabs; the window's
+	// width doesn't count its side borders.
+	if contentWidth < tabsWidth {
+		renderedContent = windowStyle.Width(tabsWidth - 2).Render(mainContent + helpText)
+		contentWidth = tabsWidth
+	}
+	fillerWidth := contentWidth - tabsWidth
+
+	// Create a style for the filler that only has a bottom border
+	fillerStyle := lipgloss.NewStyle().
+		BorderStyle(inactiveTabBorder).
+		BorderBottom(true).
+		BorderForeground(theme.Border).
+		Width(fillerWidth)
+
+	// Join the tabs and filler
+	last := len(renderedTabs) - 1
+	tabsRow := lipgloss.JoinHorizontal(lipgloss.Bottom, append(renderedTabs[:last:last], fillerStyle.Render(""), renderedTabs[last])...)
+
+	// --- 4. Combine and Center ---
+	finalView := lipgloss.JoinVertical(lipgloss.Left, tabsRow, renderedContent)
+	notices := m.notices()
+	for i := len(notices) - 1; i >= 0; i-- {
+		finalView = lipgloss.JoinVertical(lipgloss.Left, notices[i].Width(contentWidth).Render(), finalView)
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		finalView,
+	)
+}
+
+// notices returns the status lines shown above the tabs, each already
+// styled and carrying its text.
+func (m Model) notices() []lipgloss.Style {
+	var notices []lipgloss.Style
+	if notice := m.lockdownNotice(); notice != "" {
+		notices = append(notices, lockdownStyle.SetString(notice))
+	}
+	for _, keg := range m.store.LowKegs(m.config.Kegs.AlertLiters) {
+		notice := trf("keg_low", keg.Tap, keg.Beverage, keg.Remaining)
+		notices = append(notices, warningStyle.SetString(notice))
+	}
+	if notice := m.lowStockNotice(); notice != "" {
+		notices = append(notices, warningStyle.SetString(notice))
+	}
+	if notice := m.oversoldNotice(); notice != "" {
+		notices = append(notices, warningStyle.SetString(notice))
+	}
+	if notice := m.availabilityNotice(); notice != "" {
+		notices = append(notices, warningStyle.SetString(notice))
+	}
+	if notice := m.checkNotice(); notice != "" {
+		notices = append(notices, warningStyle.SetString(notice))
+	}
+	if notice, failed := m.reloadNotice(); notice != "" {
+		style := bannerStyle
+		if failed {
+			style = warningStyle
+		}
+		notices = append(notices, style.SetString(notice))
+	}
+	if notice := releaseNotice(m.release); notice != "" {
+		notices = append(notices, bannerStyle.SetString(notice))
+	}
+	if m.drawerErr != nil && !m.drawerPIN {
+		notices = append(notices, warningStyle.SetString(trf("drawer_failed", m.drawerErr)))
+	}
+	if notice, failed := m.mailNotice(); notice != "" {
+		style := bannerStyle
+		if failed {
+			style = warningStyle
+		}
+		notices = append(notices, style.SetString(notice))
+	}
+	if notice := m.usbNotice(); notice != "" {
+		style := bannerStyle
+		if m.exportErr != nil {
+			style = warningStyle
+		}
+		notices = append(notices, style.SetString(notice))
+	}
+	if m.keyPos.Profile != "" {
+		notices = append(notices, bannerStyle.SetString(trf("price_profile", m.keyPos.Profile)))
+	}
+	if m.tier != "" {
+		notices = append(notices, bannerStyle.SetString(trf("price_tier", m.tier)))
+	}
+	if m.banner != "" && m.activeTab == 0 {
+		notices = append(notices, bannerStyle.SetString(m.banner))
+	}
+	return notices
+}
+
+func (m Model) lockdownNotice() string {
+	switch m.lockdown {
+	case store.LockdownReadOnly:
+		return tr("read_only_notice")
+	case store.LockdownPriceFreeze:
+		return tr("price_freeze_notice")
+	}
+	return ""
+}
+
+func (m Model) cartView() string {
+	if m.err != nil {
+		return warningStyle.Render(trf("checkout_failed", m.err)) + "\n\n" + tr("press_any_key")
+	}
+	if m.receipt != nil {
+		view := receiptView(*m.receipt)
+		if m.receipt.Member != "" {
+			view += "\n" + m.payerNotice()
+		}
+		if m.receipt.Voucher != "" {
+			view += "\n" + m.voucherNotice()
+		}
+		if m.receipt.Card != nil {
+			view += "\n" + tr("paid_by_card")
+		}
+		if m.receipt.Link != nil {
+			view += "\n" + trf("paid_by_link", m.receipt.Link.Method, m.receipt.Link.Reference)
+		}
+		if m.store.OrderIndex(m.receipt.ID) >= 0 {
+			view += "\n" + trf("order_number", m.receipt.ID)
+		}
+		if footer := m.footerView(PrintLocale, m.now()); footer != "" {
+			view += "\n\n" + footer
+		}
+		if m.mailing {
+			return view + m.mailView()
+		}
+		if m.mailSending {
+			view += "\n\n" + m.mailSpinner.View() + " " + trf("mail_sending", m.mailTo)
+		}
+		if m.undoErr != "" {
+			view += "\n\n" + warningStyle.Render(m.undoErr)
+		}
+		if m.canUndoSale(m.now()) && !m.adminLocked() {
+			return view + "\n\n" + tr("undo_or_continue")
+		}
+		return view + "\n\n" + tr("press_any_key")
+	}
+
+	var s strings.Builder
+	s.WriteString(tr("your_order") + "\n\n")
+	if !m.cart.HasItems() {
+		s.WriteString("  " + tr("cart_empty") + "\n\n\n" + tr("go_to_shop"))
+		if note := m.parkedNote(); note != "" {
+			s.WriteString("\n" + note)
+		}
+		return s.String()
+	}
+	s.WriteString(m.cartTable.View() + "\n\n")
+	s.WriteString(m.cartSummary())
+	return s.String()
+}
+
+// cartSummary is what the cart tab shows below the lines of a full cart:
+// the total, the footer and why checkout is off, if it is.
+func (m Model) cartSummary() string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("  %s: %s\n", tr("total"), uiLocale.money(m.cartTotal())))
+	if len(m.prices) > 0 {
+		s.WriteString("  " + tr("price_overridden") + "\n")
+	}
+	if m.note != "" {
+		s.WriteString(fmt.Sprintf("  %s: %s\n", tr("note"), m.note))
+	}
+	if note := m.parkedNote(); note != "" {
+		s.WriteString("  " + note + "\n")
+	}
+	if len(m.marked) > 0 {
+		s.WriteString(fmt.Sprintf("  %s: %s\n", tr("marked_total"), uiLocale.money(m.checkoutTotal())))
+	}
+	if rounding := domain.CashRounding(m.checkoutTotal(), m.config.CashRounding); rounding != 0 {
+		s.WriteString(fmt.Sprintf("  %s: %s\n", tr("rounding"), uiLocale.money(rounding)))
+		s.WriteString(fmt.Sprintf("  %s: %s\n", tr("cash_due"), uiLocale.money(domain.RoundCents(m.checkoutTotal()+rounding))))
+	}
+	if footer := m.footerView(uiLocale, m.now()); footer != "" {
+		s.WriteString("\n" + footer + "\n")
+	}
+	if m.lockdown == store.LockdownReadOnly {
+		s.WriteString("\n\n" + warningStyle.Render(tr("checkout_disabled")))
+	}
+	return s.String()
+}
+
+// Run runs the shop until it quits. With cached, the store is still
+// loading in the background.
+func Run(cfg Config, s *store.Store, terminal TerminalProfile, cached bool, options ...Option) error {
+	m := New(cfg, s, append([]Option{WithSize(terminal.width, terminal.height)}, options...)...)
+	m.loading = cached
+	if cfg.Keyswitch.Source != "" {
+		ks, err := openKeyswitch(cfg.Keyswitch)
+		if err != nil {
+			return fmt.Errorf("opening the key switch: %w", err)
+		}
+		m.keyswitch = ks
+	}
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if terminal.mouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	_, err := tea.NewProgram(m, opts...).Run()
+	return err
+}
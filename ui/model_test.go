@@ -0,0 +1,1282 @@
+package ui_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/muesli/termenv"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// clock is the fake time the kiosk runs at, a Friday evening.
+var clock = time.Date(2024, time.March, 8, 21, 30, 0, 0, time.UTC)
+
+func TestMain(m *testing.M) {
+	// Without colours the output can be matched as plain text.
+	lipgloss.SetColorProfile(termenv.Ascii)
+	if err := ui.ApplyAssets(ui.AssetFS(""), "en", "en"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func inventory() []domain.Beverage {
+	return []domain.Beverage{
+		{Name: "Club-Mate", Price: 1.50, Stock: 24},
+		{Name: "Water", Price: 0.50, Stock: 100},
+	}
+}
+
+// kiosk starts the kiosk on an in-memory store with the given inventory.
+func kiosk(t *testing.T, beverages []domain.Beverage) (*teatest.TestModel, *store.Store) {
+	t.Helper()
+	s := store.Memory(beverages)
+	return kioskWith(t, ui.DefaultConfig(), s), s
+}
+
+// kioskWith starts the kiosk with cfg on s.
+func kioskWith(t *testing.T, cfg ui.Config, s *store.Store) *teatest.TestModel {
+	t.Helper()
+	m := ui.New(cfg, s, ui.WithClock(func() time.Time { return clock }), ui.WithSize(100, 40))
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 40))
+	t.Cleanup(func() { _ = tm.Quit() })
+	return tm
+}
+
+// waitFor waits until the kiosk has shown all of texts.
+func waitFor(t *testing.T, tm *teatest.TestModel, texts ...string) {
+	t.Helper()
+	var seen bytes.Buffer
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		seen.Write(out)
+		for _, text := range texts {
+			if !strings.Contains(seen.String(), text) {
+				return false
+			}
+		}
+		return true
+	}, teatest.WithDuration(3*time.Second), teatest.WithCheckInterval(10*time.Millisecond))
+}
+
+func press(tm *teatest.TestModel, keys ...string) {
+	for _, k := range keys {
+		switch k {
+		case "enter":
+			tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+		case "down":
+			tm.Send(tea.KeyMsg{Type: tea.KeyDown})
+		case "tab":
+			tm.Send(tea.KeyMsg{Type: tea.KeyTab})
+		case "esc":
+			tm.Send(tea.KeyMsg{Type: tea.KeyEsc})
+		case "ctrl+u":
+			tm.Send(tea.KeyMsg{Type: tea.KeyCtrlU})
+		default:
+			tm.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+}
+
+func TestTabNavigation(t *testing.T) {
+	tm, _ := kiosk(t, inventory())
+	waitFor(t, tm, "Club-Mate", "Water")
+
+	press(tm, "c")
+	waitFor(t, tm, "Your Current Order:")
+
+	press(tm, "a")
+	waitFor(t, tm, "Nothing sold today yet.")
+
+	press(tm, "s")
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+}
+
+func TestCartTotal(t *testing.T) {
+	tm, _ := kiosk(t, inventory())
+	waitFor(t, tm, "Club-Mate")
+
+	// Two Club-Mate and three water.
+	press(tm, "+", "+", "down", "+", "+", "+", "c")
+	waitFor(t, tm, "Your Current Order:", "4.50")
+
+	// Quitting with a full cart asks first.
+	press(tm, "q")
+	waitFor(t, tm, "Quit")
+	press(tm, "y")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+}
+
+func TestCheckout(t *testing.T) {
+	tm, s := kiosk(t, inventory())
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "+", "c", "enter")
+	waitFor(t, tm, "Your Current Order:")
+	press(tm, "y")
+	waitFor(t, tm, "Receipt", "3.00")
+	// Dismissing the receipt leaves an empty cart, so quitting needs no
+	// confirmation.
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	sale := s.Sales[0]
+	if !sale.Time.Equal(clock) {
+		t.Errorf("sale booked at %v, want the fake clock's %v", sale.Time, clock)
+	}
+	if len(sale.Lines) != 1 || sale.Lines[0].Name != "Club-Mate" || sale.Lines[0].Quantity != 2 {
+		t.Errorf("sale lines = %+v, want 2 Club-Mate", sale.Lines)
+	}
+	if stock := s.Beverages[0].Stock; stock != 22 {
+		t.Errorf("Club-Mate stock = %v, want 22", stock)
+	}
+}
+
+func TestCashRounding(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.CashRounding = 0.05
+	beverages := inventory()
+	beverages[1].Price = 0.52
+	s := store.Memory(beverages)
+	s.Configure(cfg.StoreOptions())
+	s.Members = []domain.Member{{ID: "alice", Name: "Alice", Balance: 10, Token: "A1"}}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "j", "+", "c")
+	waitFor(t, tm, "Total: €2.02", "Rounding (cash): €-0.02", "In cash: €2.00")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt", "Rounding (cash): €-0.02", "In cash: €2.00")
+	// Paid from the tab, nothing is rounded.
+	press(tm, "x", "s", "+", "c", "t", "A1", "enter")
+	waitFor(t, tm, "Paid by Alice")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 2 || s.Sales[0].Rounding != -0.02 || s.Sales[0].CashDue() != 2.00 || s.Sales[1].Rounding != 0 {
+		t.Fatalf("sales = %+v, want the first rounded by -0.02 and the second not", s.Sales)
+	}
+}
+
+func TestPriceTiers(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.PriceTiers, cfg.MemberTier = []string{"member", "staff"}, "member"
+	beverages := inventory()
+	beverages[0].Tiers = map[string]float64{"member": 1.20, "staff": 1.00}
+	s := store.Memory(beverages)
+	s.Members = []domain.Member{{ID: "alice", Name: "Alice", Balance: 10, Token: "A1"}}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate", "€1.50")
+
+	press(tm, "$")
+	waitFor(t, tm, "Showing member prices", "€1.20")
+	press(tm, "$")
+	waitFor(t, tm, "Showing staff prices", "€1.00")
+	// Back at the regular prices, Alice still pays hers from the tab.
+	press(tm, "$", "+", "c")
+	waitFor(t, tm, "Total: €1.50")
+	press(tm, "t", "A1", "enter")
+	waitFor(t, tm, "Receipt", "€1.20", "Paid by Alice")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 || s.Sales[0].Total() != 1.20 || s.Members[0].Balance != 8.80 {
+		t.Fatalf("sales = %+v, balance %.2f; want one of 1.20 at the member price", s.Sales, s.Members[0].Balance)
+	}
+}
+
+func TestBundles(t *testing.T) {
+	beverages := inventory()
+	beverages[0].Bundles = []domain.Bundle{{Quantity: 3, Price: 4}}
+	tm, s := kiosk(t, beverages)
+	waitFor(t, tm, "Club-Mate")
+
+	// Four Club-Mate are a bundle of three and one at the regular price.
+	press(tm, "+", "+", "+", "+", "c")
+	waitFor(t, tm, "Club-Mate (bundle of 3)", "€4.00", "Total: €5.50")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	lines := s.Sales[0].Lines
+	if len(lines) != 2 || lines[0].Bundle != 3 || lines[0].Quantity != 3 || lines[1].Bundle != 0 || lines[1].Quantity != 1 {
+		t.Errorf("sale lines = %+v, want a bundle of 3 and 1 more", lines)
+	}
+	if total := domain.RoundCents(s.Sales[0].Total()); total != 5.50 || s.Beverages[0].Stock != 20 {
+		t.Errorf("total %.2f, stock %.0f; want 5.50 and 20", total, s.Beverages[0].Stock)
+	}
+}
+
+func TestSizesOfTheSameDrink(t *testing.T) {
+	beverages := []domain.Beverage{
+		{Name: "Club-Mate", Price: 1.50, Stock: 24, Size: "0.5 l", Volume: 0.5},
+		{Name: "Club-Mate", Price: 1.20, Stock: 24, Size: "0.33 l"},
+	}
+	tm, s := kiosk(t, beverages)
+	waitFor(t, tm, "Club-Mate 0.5 l", "Club-Mate 0.33 l")
+
+	press(tm, "+", "down", "+", "+", "c")
+	waitFor(t, tm, "Total: €3.90")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt", "Club-Mate 0.33 l")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	if lines := s.Sales[0].Lines; len(lines) != 2 || lines[0].Name != "Club-Mate 0.5 l" || lines[1].Name != "Club-Mate 0.33 l" || lines[1].Quantity != 2 {
+		t.Errorf("sale lines = %+v, want one 0.5 l and two 0.33 l", lines)
+	}
+	if s.Beverages[0].Stock != 23 || s.Beverages[1].Stock != 22 {
+		t.Errorf("stock = %.0f and %.0f, want 23 and 22", s.Beverages[0].Stock, s.Beverages[1].Stock)
+	}
+}
+
+func TestRecipeOfASize(t *testing.T) {
+	beverages := []domain.Beverage{
+		{Name: "Club-Mate", Price: 1.50, Stock: 3, Size: "0.5 l"},
+		{Name: "Tschunk", Price: 5.00, Recipe: []domain.Ingredient{{Name: "Club-Mate 0.5 l", Amount: 1}}},
+	}
+	tm, s := kiosk(t, beverages)
+	waitFor(t, tm, "Tschunk")
+
+	// Two of the three bottles are in the cart, which leaves one Tschunk.
+	press(tm, "+", "+", "down", "+", "+", "c")
+	waitFor(t, tm, "Total: €8.00")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 || s.Beverages[0].Stock != 0 {
+		t.Errorf("%d sales booked, %.0f left, want 1 and none", len(s.Sales), s.Beverages[0].Stock)
+	}
+}
+
+func TestParkAndResume(t *testing.T) {
+	tm, s := kiosk(t, inventory())
+	waitFor(t, tm, "Club-Mate")
+
+	// The first customer's Club-Mate is parked while the next one buys
+	// water.
+	press(tm, "+", "c", "z")
+	waitFor(t, tm, "Your cart is empty!", "Parked orders: 1")
+	press(tm, "s", "down", "+", "+", "c")
+	waitFor(t, tm, "Total: €1.00")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "Z")
+	waitFor(t, tm, "#1", "1 items", "€1.50")
+	press(tm, "enter")
+	waitFor(t, tm, "Total: €1.50")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 2 {
+		t.Fatalf("%d sales booked, want 2", len(s.Sales))
+	}
+	if first, second := s.Sales[0].Lines, s.Sales[1].Lines; len(first) != 1 || first[0].Name != "Water" || first[0].Quantity != 2 || len(second) != 1 || second[0].Name != "Club-Mate" {
+		t.Errorf("sales = %+v and %+v, want the water, then the parked Club-Mate", first, second)
+	}
+}
+
+func TestQuickAdd(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.QuickAdd = true
+	s := store.Memory(inventory())
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate", "e/0 quantity")
+
+	// The digits add what is in their row, the cursor stays on the first.
+	press(tm, "2", "2", "1", "c")
+	waitFor(t, tm, "Total: €2.50")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	if lines := s.Sales[0].Lines; len(lines) != 2 || lines[0].Name != "Club-Mate" || lines[0].Quantity != 1 || lines[1].Name != "Water" || lines[1].Quantity != 2 {
+		t.Errorf("sale lines = %+v, want a Club-Mate and two water", lines)
+	}
+}
+
+func TestQuickAddScrolled(t *testing.T) {
+	var beverages []domain.Beverage
+	for i := 1; i <= 30; i++ {
+		beverages = append(beverages, domain.Beverage{Name: fmt.Sprintf("Drink %02d", i), Price: 1, Stock: 10})
+	}
+	cfg := ui.DefaultConfig()
+	cfg.QuickAdd = true
+	s := store.Memory(beverages)
+	m := ui.New(cfg, s, ui.WithClock(func() time.Time { return clock }), ui.WithSize(100, 24))
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 24))
+	t.Cleanup(func() { _ = tm.Quit() })
+	waitFor(t, tm, "1  Drink 01")
+
+	// Scrolled down, the digits count from the first row on screen.
+	for range 15 {
+		press(tm, "down")
+	}
+	waitFor(t, tm, "1  Drink 06", "9  Drink 14")
+	press(tm, "1", "9", "9", "c")
+	waitFor(t, tm, "Total: €3.00")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	if lines := s.Sales[0].Lines; len(lines) != 2 || lines[0].Name != "Drink 06" || lines[0].Quantity != 1 || lines[1].Name != "Drink 14" || lines[1].Quantity != 2 {
+		t.Errorf("sale lines = %+v, want a Drink 06 and two Drink 14", lines)
+	}
+}
+
+func TestFavorites(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.Favorites.Beverages = []string{"Water", "Club-Mate"}
+	s := store.Memory(inventory())
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "F1 Water €0.50  F2 Club-Mate €1.50")
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyF1})
+	tm.Send(tea.KeyMsg{Type: tea.KeyF1})
+	tm.Send(tea.KeyMsg{Type: tea.KeyF2})
+	press(tm, "c")
+	waitFor(t, tm, "Total: €2.50")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	if lines := s.Sales[0].Lines; len(lines) != 2 || lines[0].Name != "Club-Mate" || lines[0].Quantity != 1 || lines[1].Name != "Water" || lines[1].Quantity != 2 {
+		t.Errorf("sale lines = %+v, want a Club-Mate and two water", lines)
+	}
+	cfg.Favorites.Keys = []string{"f1", "c"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("favorites on the kiosk's own keys are valid, want an error")
+	}
+}
+
+func TestOrderNote(t *testing.T) {
+	tm, s := kiosk(t, inventory())
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "c", "i", "paid later by Alex", "enter")
+	waitFor(t, tm, "Note: paid later by Alex")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt", "Note: paid later by Alex")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 || s.Sales[0].Note != "paid later by Alex" {
+		t.Errorf("sales = %+v, want one noted as paid later by Alex", s.Sales)
+	}
+}
+
+func TestAdminListsExpiringBatches(t *testing.T) {
+	s := store.Memory(inventory())
+	today := time.Now()
+	// Of the 24 Club-Mate, the 10 of the latest delivery keep for long, the
+	// 6 before them for two more days and the 8 left of the first expired.
+	s.Restocks = []store.Restock{
+		{Time: today.AddDate(0, 0, -20), Beverage: "Club-Mate", Quantity: 12, BestBefore: today.AddDate(0, 0, -1)},
+		{Time: today.AddDate(0, 0, -10), Beverage: "Club-Mate", Quantity: 6, BestBefore: today.AddDate(0, 0, 2)},
+		{Time: today.AddDate(0, 0, -1), Beverage: "Club-Mate", Quantity: 10, BestBefore: today.AddDate(1, 0, 0)},
+	}
+	soon := today.AddDate(0, 0, 2).Format(time.DateOnly)
+	tm := teatest.NewTestModel(t, ui.NewAdminModel(ui.DefaultConfig(), s), teatest.WithInitialTermSize(120, 40))
+	waitFor(t, tm, "Best before", today.AddDate(0, 0, -1).Format(time.DateOnly)+" !")
+	press(tm, "tab", "tab", "tab")
+	waitFor(t, tm, "Expiring", "Club-Mate (6 by "+soon+")", "Club-Mate (8 expired")
+	press(tm, "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+}
+
+func TestAdminStocktake(t *testing.T) {
+	s := store.Memory(inventory())
+	tm := teatest.NewTestModel(t, ui.NewAdminModel(ui.DefaultConfig(), s), teatest.WithInitialTermSize(120, 40))
+	waitFor(t, tm, "Catalog")
+
+	// Four Club-Mate are missing; the water is all there.
+	press(tm, "5", "enter", "20", "enter")
+	waitFor(t, tm, "Counted 20 of Club-Mate", "-4")
+	press(tm, "enter", "100", "enter")
+	waitFor(t, tm, "Counted 100 of Water", "ok")
+	press(tm, "a")
+	waitFor(t, tm, "Counted 2 beverages, corrected the stock of 1.")
+	press(tm, "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if s.Beverages[0].Stock != 20 || s.Beverages[1].Stock != 100 {
+		t.Errorf("stock = %.0f and %.0f, want 20 and 100", s.Beverages[0].Stock, s.Beverages[1].Stock)
+	}
+	if len(s.WriteOffs) != 1 || s.WriteOffs[0].Reason != store.WriteOffMissing || s.WriteOffs[0].Quantity != 4 {
+		t.Errorf("write-offs = %+v, want the 4 missing Club-Mate", s.WriteOffs)
+	}
+}
+
+func TestAdminWriteOff(t *testing.T) {
+	beverages := inventory()
+	beverages[0].Cost = 0.80
+	s := store.Memory(beverages)
+	tm := teatest.NewTestModel(t, ui.NewAdminModel(ui.DefaultConfig(), s), teatest.WithInitialTermSize(120, 40))
+	waitFor(t, tm, "Catalog")
+
+	press(tm, "w", "2 stolen", "enter")
+	waitFor(t, tm, "unknown reason")
+	press(tm, "w", "2 breakage dropped the crate", "enter")
+	waitFor(t, tm, "Wrote off 2 of Club-Mate (breakage).")
+	press(tm, "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if s.Beverages[0].Stock != 22 || len(s.Sales) != 0 {
+		t.Errorf("stock = %.0f with %d sales, want 22 and none", s.Beverages[0].Stock, len(s.Sales))
+	}
+	want := []store.Shrinkage{{Beverage: "Club-Mate", Reason: store.WriteOffBreakage, Quantity: 2, Cost: 1.60, Count: 1}}
+	if got := store.SumShrinkage(s.WriteOffs); !slices.Equal(got, want) || s.WriteOffs[0].Note != "dropped the crate" {
+		t.Errorf("shrinkage = %+v of %+v, want %+v", got, s.WriteOffs, want)
+	}
+}
+
+func TestCartTabEditsLines(t *testing.T) {
+	tm, s := kiosk(t, inventory())
+	waitFor(t, tm, "Club-Mate")
+
+	// One Club-Mate and one water, then on the cart tab a second water and
+	// the Club-Mate taken out.
+	press(tm, "+", "down", "+", "c")
+	waitFor(t, tm, "Subtotal", "2.00")
+	press(tm, "down", "+")
+	waitFor(t, tm, "2.50")
+	press(tm, "up", "d")
+	waitFor(t, tm, "Remove")
+	press(tm, "y")
+	waitFor(t, tm, "1.00")
+
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	if lines := s.Sales[0].Lines; len(lines) != 1 || lines[0].Name != "Water" || lines[0].Quantity != 2 {
+		t.Errorf("sale lines = %+v, want 2 water", lines)
+	}
+}
+
+func TestVoucherPaysPartOfTheSale(t *testing.T) {
+	s := store.Memory(inventory())
+	v, err := s.IssueVoucher(5, "", clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := kioskWith(t, ui.DefaultConfig(), s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "+", "c", "g")
+	waitFor(t, tm, "Voucher code:")
+	for _, r := range strings.ToLower(v.Code) {
+		press(tm, string(r))
+	}
+	press(tm, "enter")
+	waitFor(t, tm, "Receipt", "Voucher paid")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	// The voucher's own sale comes first.
+	if len(s.Sales) != 2 {
+		t.Fatalf("%d sales booked, want 2", len(s.Sales))
+	}
+	if sale := s.Sales[1]; sale.Voucher != v.Code || sale.VoucherAmount != 3 || sale.Due() != 0 {
+		t.Errorf("sale paid %.2f with voucher %q, %.2f due; want 3.00 with %s", sale.VoucherAmount, sale.Voucher, sale.Due(), v.Code)
+	}
+	if left, _ := s.VoucherByCode(v.Code); left.Balance != 2 {
+		t.Errorf("%.2f left on the voucher, want 2.00", left.Balance)
+	}
+}
+
+func TestPriceOverride(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.AdminPIN = "1234"
+	s := store.Memory(inventory())
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "+", "c", "o")
+	waitFor(t, tm, "New price:")
+	press(tm, "ctrl+u", "0", ".", "7", "5", "enter")
+	waitFor(t, tm, "Admin PIN:")
+	press(tm, "9", "9", "9", "9", "enter")
+	waitFor(t, tm, "Wrong PIN.")
+	press(tm, "1", "2", "3", "4", "enter")
+	waitFor(t, tm, "0.75*", "price set by an admin")
+
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt", "instead of")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 {
+		t.Fatalf("%d sales booked, want 1", len(s.Sales))
+	}
+	l := s.Sales[0].Lines[0]
+	if l.UnitPrice != 0.75 || l.ListPrice != 1.50 || l.Gross() != 1.50 {
+		t.Errorf("line = %+v, want 2 at 0.75 instead of 1.50", l)
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	// The tests run in English, where the comma groups thousands.
+	for in, want := range map[string]float64{
+		"1.50": 1.5, "1,50": 1.5, "1,500": 1500, "1.500": 1.5,
+		"1,234.56": 1234.56, "1.234,56": 1234.56, "2": 2, ".5": 0.5,
+	} {
+		if got, err := ui.ParseDecimal(in); err != nil || got != want {
+			t.Errorf("ParseDecimal(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "1.2.3", "1,5,0", "12,34.5", "inf", "1e5"} {
+		if got, err := ui.ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestCashDrawer(t *testing.T) {
+	drawer := t.TempDir() + "/lp0"
+	if err := os.WriteFile(drawer, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	kicked := func() bool {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			if data, _ := os.ReadFile(drawer); string(data) == "\x1bp\x00\x19\xfa" {
+				os.WriteFile(drawer, nil, 0o644)
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+	cfg := ui.DefaultConfig()
+	cfg.AdminPIN = "1234"
+	cfg.Drawer.Device = drawer
+	tm := kioskWith(t, cfg, store.Memory(inventory()))
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "c", "enter", "y")
+	waitFor(t, tm, "Receipt")
+	if !kicked() {
+		t.Error("the drawer didn't open for a cash sale")
+	}
+
+	press(tm, "x", "D")
+	waitFor(t, tm, "Open the cash drawer")
+	press(tm, "1", "2", "3", "4", "enter")
+	if !kicked() {
+		t.Error("the drawer didn't open by hand")
+	}
+	press(tm, "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+}
+
+func TestCardPayment(t *testing.T) {
+	// A SumUp reader on which the first payment is declined.
+	var mu sync.Mutex
+	var charged []float64
+	status := "FAILED"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v0.1/merchants/M1/readers/R1/checkout":
+			var body struct {
+				Total struct{ Value float64 } `json:"total_amount"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			charged = append(charged, body.Total.Value)
+			fmt.Fprintf(w, `{"data": {"client_transaction_id": "tx%d"}}`, len(charged))
+		case "/v2.1/merchants/M1/transactions":
+			fmt.Fprintf(w, `{"status": %q}`, status)
+			status = "SUCCESSFUL"
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cfg := ui.DefaultConfig()
+	cfg.Card = store.CardConfig{Provider: "sumup", APIKey: "key", Merchant: "M1", Reader: "R1", URL: srv.URL}
+	s := store.Memory(inventory())
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "+", "c", "p")
+	waitFor(t, tm, "Waiting for €3.00 on the card terminal", "didn't go through: failed")
+	press(tm, "x", "p")
+	waitFor(t, tm, "Receipt", "Paid by card.")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if !slices.Equal(charged, []float64{300, 300}) {
+		t.Errorf("charged %v cents, want 300 twice", charged)
+	}
+	if len(s.Sales) != 1 || s.Sales[0].Card == nil || s.Sales[0].Card.Reference != "tx2" {
+		t.Fatalf("sales = %+v, want one paid by card as tx2", s.Sales)
+	}
+}
+
+// droppingServer is a server for kiosks in client mode, and a SumUp card
+// reader, whose inventory loses Club-Mate on dropClubMate. The reader
+// reports the payment as successful only once the kiosk has loaded the
+// inventory without it, when dropped is closed.
+type droppingServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	version int
+	sales   []domain.Sale
+	dropped chan struct{}
+	served  bool
+}
+
+func newDroppingServer(t *testing.T) *droppingServer {
+	d := &droppingServer{version: 1, dropped: make(chan struct{})}
+	d.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		switch {
+		case r.URL.Path == "/store":
+			beverages := inventory()
+			if d.version > 1 {
+				beverages = beverages[1:]
+				if !d.served {
+					d.served = true
+					close(d.dropped)
+				}
+			}
+			json.NewEncoder(w).Encode(store.Memory(beverages))
+		case r.URL.Path == "/events":
+			if r.URL.Query().Get("since") == strconv.Itoa(d.version) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			fmt.Fprint(w, d.version)
+		case r.URL.Path == "/sales":
+			var sale domain.Sale
+			json.NewDecoder(r.Body).Decode(&sale)
+			sale.ID = len(d.sales) + 1
+			d.sales = append(d.sales, sale)
+			json.NewEncoder(w).Encode(sale)
+		case r.URL.Path == "/v0.1/merchants/M1/readers/R1/checkout":
+			fmt.Fprint(w, `{"data": {"client_transaction_id": "tx1"}}`)
+		case r.URL.Path == "/v2.1/merchants/M1/transactions":
+			status := "PENDING"
+			if d.served {
+				status = "SUCCESSFUL"
+			}
+			fmt.Fprintf(w, `{"status": %q}`, status)
+		case r.Method == http.MethodPut:
+		default:
+			json.NewEncoder(w).Encode(store.TillSession{})
+		}
+	}))
+	t.Cleanup(d.Close)
+	return d
+}
+
+func (d *droppingServer) dropClubMate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.version++
+}
+
+func (d *droppingServer) booked() []domain.Sale {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.sales)
+}
+
+func TestCardPaymentOutlivesBeverage(t *testing.T) {
+	srv := newDroppingServer(t)
+	cfg := ui.DefaultConfig()
+	cfg.Card = store.CardConfig{Provider: "sumup", APIKey: "key", Merchant: "M1", Reader: "R1", URL: srv.URL}
+	cfg.Server = store.ServerConfig{URL: srv.URL, Till: "bar"}
+	s, err := store.OpenRemote(cfg.Server, cfg.TabLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "+", "c", "p")
+	waitFor(t, tm, "Waiting for €3.00 on the card terminal")
+	srv.dropClubMate()
+	waitFor(t, tm, "Receipt", "Paid by card.")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	sales := srv.booked()
+	if len(sales) != 1 || sales[0].Card == nil || sales[0].Card.Reference != "tx1" {
+		t.Fatalf("sales = %+v, want the one paid by card", sales)
+	}
+	if l := sales[0].Lines; len(l) != 1 || l[0].Name != "Club-Mate" || l[0].Quantity != 2 || !l[0].Untracked {
+		t.Errorf("lines = %+v, want the two Club-Mate paid for, outside the stock", l)
+	}
+}
+
+func TestPaymentLink(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.PaymentLink = ui.PaymentLinkConfig{Name: "PayPal", URL: "https://paypal.me/space/{amount}EUR"}
+	s := store.Memory(inventory())
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "+", "c", "w")
+	waitFor(t, tm, "Pay €3.00 by PayPal", "▀", "https://paypal.me/space/3.00EUR")
+	press(tm, "esc", "w", "enter")
+	waitFor(t, tm, "Receipt", "Paid by PayPal")
+	press(tm, "u")
+	waitFor(t, tm, "refunded there")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 1 || s.Sales[0].Link == nil || s.Sales[0].Link.Method != "PayPal" || len(s.Sales[0].Link.Reference) != 8 {
+		t.Fatalf("sales = %+v, want one paid by PayPal with a reference", s.Sales)
+	}
+
+	// Club-Mate is dropped from the inventory while the customer pays.
+	srv := newDroppingServer(t)
+	cfg.Server = store.ServerConfig{URL: srv.URL, Till: "bar"}
+	remote, err := store.OpenRemote(cfg.Server, cfg.TabLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm = kioskWith(t, cfg, remote)
+	waitFor(t, tm, "Club-Mate")
+	press(tm, "+", "c", "w")
+	waitFor(t, tm, "Pay €1.50 by PayPal")
+	srv.dropClubMate()
+	<-srv.dropped
+	press(tm, "enter")
+	waitFor(t, tm, "Receipt", "Paid by PayPal")
+	if sales := srv.booked(); len(sales) != 1 || len(sales[0].Lines) != 1 || !sales[0].Lines[0].Untracked || sales[0].Link == nil {
+		t.Errorf("sales = %+v, want the paid Club-Mate booked outside the stock", sales)
+	}
+}
+
+// smtpServer accepts mail without auth or TLS and sends what it got to
+// mails, recipient and message.
+func smtpServer(t *testing.T, mails chan<- string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			fmt.Fprint(conn, "220 test\r\n")
+			var mail strings.Builder
+			for data := false; ; {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					break
+				}
+				switch {
+				case data && line == ".\r\n":
+					data = false
+					mails <- mail.String()
+					fmt.Fprint(conn, "250 ok\r\n")
+				case data:
+					mail.WriteString(line)
+				case strings.HasPrefix(line, "RCPT TO:"):
+					mail.WriteString(line)
+					fmt.Fprint(conn, "250 ok\r\n")
+				case strings.HasPrefix(line, "DATA"):
+					data = true
+					fmt.Fprint(conn, "354 go ahead\r\n")
+				case strings.HasPrefix(line, "QUIT"):
+					fmt.Fprint(conn, "221 bye\r\n")
+				default:
+					fmt.Fprint(conn, "250 ok\r\n")
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestMailReceipt(t *testing.T) {
+	mails := make(chan string, 1)
+	cfg := ui.DefaultConfig()
+	cfg.MailReceipts = true
+	cfg.Mail = store.MailConfig{Host: smtpServer(t, mails), From: "bar@example.org"}
+	s := store.Memory(inventory())
+	s.Members = []domain.Member{{ID: "alice", Name: "Alice", Balance: 10, Token: "A1", Email: "alice@example.org"}}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+
+	// Alice's address is there to start from.
+	press(tm, "+", "c", "t", "A1", "enter")
+	waitFor(t, tm, "Receipt", "Paid by Alice")
+	press(tm, "@")
+	waitFor(t, tm, "Mail the receipt to: alice@example.org")
+	press(tm, "enter")
+	waitFor(t, tm, "Receipt mailed to alice@example.org.")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	mail := <-mails
+	for _, want := range []string{"RCPT TO:<alice@example.org>", "Subject: Your receipt", "1x Club-Mate"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("mail lacks %q:\n%s", want, mail)
+		}
+	}
+}
+
+func TestScheduledBeverages(t *testing.T) {
+	beverages := append(inventory(),
+		// Not yet at half past nine on a Friday.
+		domain.Beverage{Name: "Beer", Price: 2.50, Stock: 20, Available: &domain.Availability{
+			Note:    "after 22:00",
+			Windows: []domain.Window{{From: "22:00", Until: "02:00"}},
+		}},
+		domain.Beverage{Name: "Eggnog", Price: 3.00, Stock: 10, Available: &domain.Availability{
+			Hide:    true,
+			Windows: []domain.Window{{Dates: []string{"2024-12-24"}}},
+		}},
+	)
+	tm, s := kiosk(t, beverages)
+	waitFor(t, tm, "Beer", "not now")
+
+	press(tm, "down", "down", "+")
+	waitFor(t, tm, "Beer is only sold after 22:00")
+	press(tm, "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if strings.Contains(tm.FinalModel(t).View(), "Eggnog") {
+		t.Error("the eggnog is shown outside its date")
+	}
+	if err := s.BookSale(domain.Sale{Time: clock, Lines: []domain.SaleLine{{Name: "Beer", Quantity: 1, UnitPrice: 2.50}}}); err == nil {
+		t.Error("the store sold beer outside its hours")
+	}
+}
+
+func TestShopOrder(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.ShopOrder = ui.ShopOrderConfig{Order: []string{"Water", "Beer"}, Pinned: []string{"Club-Mate"}, PinTopSellers: 1}
+	s := store.Memory(append(inventory(),
+		domain.Beverage{Name: "Beer", Price: 2.50, Stock: 20},
+		domain.Beverage{Name: "Apple Juice", Price: 1.00, Stock: 20},
+	))
+	// The juice sold best lately, the water long ago.
+	s.Sales = []domain.Sale{
+		{ID: 1, Time: clock.AddDate(0, -3, 0), Lines: []domain.SaleLine{{Name: "Water", Quantity: 9, UnitPrice: 0.50}}},
+		{ID: 2, Time: clock.AddDate(0, 0, -1), Lines: []domain.SaleLine{{Name: "Apple Juice", Quantity: 2, UnitPrice: 1.00}}},
+	}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Apple Juice")
+
+	rows := func(view string) []int {
+		var at []int
+		for _, name := range []string{"Club-Mate", "Apple Juice", "Water", "Beer"} {
+			at = append(at, strings.Index(view, name))
+		}
+		return at
+	}
+	// Sorted by name, the pinned ones still come first.
+	press(tm, "N", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+	at := rows(tm.FinalModel(t).View())
+	if !(at[0] < at[1] && at[1] < at[3] && at[3] < at[2]) {
+		t.Errorf("rows at %v, want Club-Mate, Apple Juice, Beer, Water", at)
+	}
+}
+
+func TestPinSizedTopSeller(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.ShopOrder = ui.ShopOrderConfig{PinTopSellers: 1}
+	s := store.Memory([]domain.Beverage{
+		{Name: "Water", Price: 0.50, Stock: 100},
+		{Name: "Club-Mate", Size: "0.33 l", Price: 1.20, Stock: 24},
+		{Name: "Club-Mate", Size: "0.5 l", Price: 1.50, Stock: 24},
+	})
+	s.Sales = []domain.Sale{
+		{ID: 1, Time: clock.AddDate(0, 0, -1), Lines: []domain.SaleLine{{Name: "Club-Mate 0.5 l", Quantity: 3, UnitPrice: 1.50}}},
+	}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate 0.5 l")
+
+	// Sorted by price, the top seller still comes first.
+	press(tm, "P", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+	view := tm.FinalModel(t).View()
+	large, small, water := strings.Index(view, "Club-Mate 0.5 l"), strings.Index(view, "Club-Mate 0.33 l"), strings.Index(view, "Water")
+	if !(large < water && water < small) {
+		t.Errorf("rows at %d, %d and %d, want Club-Mate 0.5 l, Water, Club-Mate 0.33 l", large, water, small)
+	}
+}
+
+func TestCustomerDisplay(t *testing.T) {
+	// A server that keeps the session the till sends for the display.
+	var mu sync.Mutex
+	var session store.TillSession
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/store":
+			json.NewEncoder(w).Encode(store.Memory(inventory()))
+		case r.Method == http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&session)
+			session.Version++
+		default:
+			// Rather than holding the request, the display is told to
+			// ask again soon.
+			if r.URL.Query().Get("since") == strconv.Itoa(session.Version) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			json.NewEncoder(w).Encode(session)
+		}
+	}))
+	defer srv.Close()
+	cfg := ui.DefaultConfig()
+	cfg.Server = store.ServerConfig{URL: srv.URL, Till: "bar"}
+
+	s, err := store.OpenRemote(cfg.Server, cfg.TabLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+	press(tm, "+", "+", "down", "+", "c")
+	waitFor(t, tm, "3.50")
+
+	// The display runs as a process of its own, with a store of its own.
+	ds, err := store.OpenRemote(cfg.Server, cfg.TabLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ui.NewDisplayModel(cfg, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	session.Payment = "https://pay.example/3.50"
+	mu.Unlock()
+	display := teatest.NewTestModel(t, d, teatest.WithInitialTermSize(80, 30))
+	waitFor(t, display, "2 × Club-Mate", "1 × Water", "Total €3.50", "▀")
+	press(display, "q")
+	display.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+}
+
+func TestPopout(t *testing.T) {
+	var mu sync.Mutex
+	var session store.TillSession
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/store":
+			json.NewEncoder(w).Encode(store.Memory(inventory()))
+		case r.URL.Path == "/events":
+			time.Sleep(10 * time.Millisecond)
+			w.Write([]byte("1"))
+		case r.Method == http.MethodPut:
+			json.NewDecoder(r.Body).Decode(&session)
+			session.Version++
+		default:
+			if r.URL.Query().Get("since") == strconv.Itoa(session.Version) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			json.NewEncoder(w).Encode(session)
+		}
+	}))
+	defer srv.Close()
+	cfg := ui.DefaultConfig()
+	cfg.Server = store.ServerConfig{URL: srv.URL, Till: "bar"}
+
+	s, err := store.OpenRemote(cfg.Server, cfg.TabLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+	press(tm, "+", "+")
+	waitFor(t, tm, "[−] 2 [+]")
+
+	// The pop-out runs as a process of its own, with a store of its own.
+	ps, err := store.OpenRemote(cfg.Server, cfg.TabLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ui.NewPopoutModel(cfg, ps, "menu"); err == nil {
+		t.Error("pop-out showing a menu: no error")
+	}
+	p, err := ui.NewPopoutModel(cfg, ps, ui.PopoutCart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	popout := teatest.NewTestModel(t, p, teatest.WithInitialTermSize(80, 30))
+	waitFor(t, popout, "Cart of bar", "2 × Club-Mate", "Total €3.00")
+	press(popout, "tab")
+	waitFor(t, popout, "Today: €0.00 in 0 sales", "Nothing sold today yet.")
+	press(popout, "q")
+	popout.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+}
+
+func TestOrderQueue(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.Queue.Enabled = true
+	s := store.Memory(inventory())
+	s.Configure(cfg.StoreOptions())
+	tm := kioskWith(t, cfg, s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "+", "c", "enter", "y")
+	waitFor(t, tm, "Receipt", "order number is #1")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	queue := teatest.NewTestModel(t, ui.NewQueueModel(s), teatest.WithInitialTermSize(100, 30))
+	waitFor(t, queue, "#1", "waiting", "2× Club-Mate")
+	press(queue, "enter")
+	waitFor(t, queue, "in progress")
+	press(queue, "enter", "q")
+	queue.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Orders) != 1 || s.Orders[0].Status != store.OrderDone {
+		t.Errorf("orders = %+v, want sale 1 done", s.Orders)
+	}
+}
+
+func TestPickRecentMember(t *testing.T) {
+	s := store.Memory(inventory())
+	s.Members = []domain.Member{
+		{ID: "alice", Name: "Alice", Balance: 10},
+		{ID: "bob", Name: "Bob", Balance: 10},
+		{ID: "carol", Name: "Carol", Balance: 10},
+	}
+	for _, member := range []string{"alice", "bob", "alice", "bob"} {
+		sale := domain.Sale{Time: clock, Member: member, Lines: []domain.SaleLine{{Name: "Water", Quantity: 1, UnitPrice: 0.50}}}
+		if _, err := s.RecordSale(sale); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tm := kioskWith(t, ui.DefaultConfig(), s)
+	waitFor(t, tm, "Club-Mate")
+
+	// Bob paid last, Alice before him; Carol only turns up when typed.
+	press(tm, "+", "c", "m")
+	waitFor(t, tm, "Member:", "Bob", "Alice")
+	press(tm, "tab", "enter")
+	waitFor(t, tm, "Receipt", "Paid by Alice")
+	press(tm, "x", "s", "+", "c", "m", "c", "a", "r", "enter")
+	waitFor(t, tm, "Paid by Carol")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if n := len(s.Sales); n != 6 || s.Sales[4].Member != "alice" || s.Sales[5].Member != "carol" {
+		t.Errorf("%d sales, the last two charged to %q and %q; want alice and carol", n, s.Sales[n-2].Member, s.Sales[n-1].Member)
+	}
+}
+
+func TestInactiveMemberNotOffered(t *testing.T) {
+	s := store.Memory(inventory())
+	s.Members = []domain.Member{
+		{ID: "alice", Name: "Alice", Balance: 10, Synced: true},
+		{ID: "bob", Name: "Bob", Balance: 10, Synced: true},
+	}
+	for _, member := range []string{"bob", "alice"} {
+		sale := domain.Sale{Time: clock, Member: member, Lines: []domain.SaleLine{{Name: "Water", Quantity: 1, UnitPrice: 0.50}}}
+		if _, err := s.RecordSale(sale); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice left; the directory deactivated her.
+	s.Members[0].Inactive = true
+	tm := kioskWith(t, ui.DefaultConfig(), s)
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "c", "m")
+	waitFor(t, tm, "Member:", "Bob")
+	press(tm, "enter")
+	waitFor(t, tm, "Paid by Bob")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if n := len(s.Sales); n != 3 || s.Sales[2].Member != "bob" {
+		t.Errorf("%d sales, the last charged to %q; want bob", n, s.Sales[n-1].Member)
+	}
+	if _, err := s.RecordSale(domain.Sale{Time: clock, Member: "alice", Lines: []domain.SaleLine{{Name: "Water", Quantity: 1, UnitPrice: 0.50}}}); err == nil {
+		t.Error("charged the tab of an inactive member")
+	}
+}
+
+func TestScreensaverLocks(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.AdminPIN = "1234"
+	cfg.Screensaver = ui.ScreensaverConfig{Idle: ui.Duration{Duration: time.Minute}, Lock: true}
+	var mu sync.Mutex
+	now := clock
+	m := ui.New(cfg, store.Memory(inventory()), ui.WithSize(100, 40), ui.WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}))
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 40))
+	t.Cleanup(func() { _ = tm.Quit() })
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+")
+	waitFor(t, tm, "[−] 1 [+]")
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	mu.Unlock()
+	waitFor(t, tm, "Locked")
+	press(tm, "x", "0", "0", "0", "0", "enter")
+	waitFor(t, tm, "PIN:", "Wrong PIN")
+	press(tm, "1", "2", "3", "4", "enter", "c")
+	// The cart is still there.
+	waitFor(t, tm, "Subtotal", "1.50")
+}
+
+func TestSplitCheckout(t *testing.T) {
+	tm, s := kiosk(t, inventory())
+	waitFor(t, tm, "Club-Mate")
+
+	// Of two Club-Mate and a water, the water is paid first.
+	press(tm, "+", "+", "down", "+", "c", "down", " ")
+	waitFor(t, tm, "Checking out now: €0.50")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x")
+	waitFor(t, tm, "Total: €3.00")
+	press(tm, "enter", "y")
+	waitFor(t, tm, "Receipt")
+	press(tm, "x", "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+
+	if len(s.Sales) != 2 {
+		t.Fatalf("%d sales booked, want 2", len(s.Sales))
+	}
+	for i, want := range []string{"Water", "Club-Mate"} {
+		if lines := s.Sales[i].Lines; len(lines) != 1 || lines[0].Name != want {
+			t.Errorf("sale %d lines = %+v, want just %s", i+1, lines, want)
+		}
+	}
+}
+
+func TestLockByHand(t *testing.T) {
+	cfg := ui.DefaultConfig()
+	cfg.LockPIN = "4321"
+	tm := kioskWith(t, cfg, store.Memory(inventory()))
+	waitFor(t, tm, "Club-Mate")
+
+	press(tm, "+", "L")
+	waitFor(t, tm, "Locked")
+	// Nothing gets past the lock screen but the PIN.
+	press(tm, "c", "4", "3", "2", "1", "enter", "c")
+	waitFor(t, tm, "Subtotal", "1.50")
+}
+
+func TestReleaseBanner(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(store.Release{Version: "v1.3.0", Notes: "## Split checkout\n\nAnd more."})
+	}))
+	defer srv.Close()
+	store.BuildVersion = "v1.2.1"
+	t.Cleanup(func() { store.BuildVersion = "" })
+
+	cfg := ui.DefaultConfig()
+	cfg.Updates = ui.UpdateConfig{Check: ui.Duration{Duration: time.Hour}, URL: srv.URL}
+	tm := kioskWith(t, cfg, store.Memory(inventory()))
+	waitFor(t, tm, "BubbleTender v1.3.0 is out: Split checkout")
+}
+
+func TestChecklist(t *testing.T) {
+	tm := teatest.NewTestModel(t, ui.NewChecklist([]string{"Count cash", "Restock fridge", "Lock tap"}),
+		teatest.WithInitialTermSize(80, 20))
+	waitFor(t, tm, "0 of 3 done")
+	// Ticking a task off moves on to the next.
+	press(tm, " ", " ")
+	waitFor(t, tm, "2 of 3 done")
+	press(tm, "enter")
+
+	list := tm.FinalModel(t, teatest.WithFinalTimeout(time.Second)).(ui.Checklist)
+	if !list.Confirmed {
+		t.Fatal("checklist not confirmed")
+	}
+	want := []store.ChecklistItem{{Task: "Count cash", Done: true}, {Task: "Restock fridge", Done: true}, {Task: "Lock tap"}}
+	if got := list.Items(); !slices.Equal(got, want) {
+		t.Errorf("checklist = %v, want %v", got, want)
+	}
+}
+
+func TestConfigReloadOnHangup(t *testing.T) {
+	path := t.TempDir() + "/bubbletender.json"
+	if err := os.WriteFile(path, []byte(`{"low_stock": 30}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	load := func() (ui.Config, error) { return ui.LoadConfig(path) }
+	m := ui.New(ui.DefaultConfig(), store.Memory(inventory()), ui.WithClock(func() time.Time { return clock }),
+		ui.WithSize(100, 40), ui.WithConfigReload(path, load))
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 40))
+	t.Cleanup(func() { _ = tm.Quit() })
+	waitFor(t, tm, "Club-Mate")
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("no SIGHUP here: %v", err)
+	}
+	// The new threshold takes Club-Mate's 24 bottles below it.
+	waitFor(t, tm, "Config reloaded", "Low stock: Club-Mate")
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// --- MOUSE ---
+
+// kioskTab is a tab of the kiosk: its label and the activeTab it selects.
+type kioskTab struct {
+	label string
+	index int
+}
+
+// kioskTabs are the tabs in the order they are drawn. The cart sits on the
+// right, the other tabs on the left.
+func kioskTabs() []kioskTab {
+	return []kioskTab{{tr("tab_shop"), 0}, {tr("tab_stats"), 2}, {tr("tab_cart"), 1}}
+}
+
+// updateMouse handles a click on a tab to switch to it, on a shop row to
+// select it, and on the [−] and [+] around its quantity to change that;
+// the wheel moves the selection, on the cart tab the cart's. Dialogs and
+// inputs only take keys.
+//
+// What was clicked is found on the rendered view, so the clicks always
+// agree with what is drawn, however the layout comes out.
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.editingQty || m.scanning || m.jumping || m.overriding || m.redeeming || m.picking || m.resuming || m.noting || m.confirm != nil || msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if m.activeTab == 1 {
+			m.cartTable.MoveUp(1)
+		} else {
+			m.table.MoveUp(1)
+		}
+		return m, nil
+	case tea.MouseButtonWheelDown:
+		if m.activeTab == 1 {
+			m.cartTable.MoveDown(1)
+		} else {
+			m.table.MoveDown(1)
+		}
+		return m, nil
+	case tea.MouseButtonLeft:
+	default:
+		return m, nil
+	}
+
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	if msg.Y < 0 || msg.Y >= len(lines) {
+		return m, nil
+	}
+	if tab, ok := tabAt(lines, msg.X, msg.Y); ok {
+		m.showTab(tab)
+		return m, nil
+	}
+	if m.activeTab == 0 {
+		m.clickRow(lines[msg.Y], msg.X)
+	}
+	return m, nil
+}
+
+// tabAt returns the tab drawn at x, y, borders included.
+func tabAt(lines []string, x, y int) (int, bool) {
+	tabs := kioskTabs()
+	for row, line := range lines {
+		if !strings.Contains(line, tabs[0].label) || !strings.Contains(line, tabs[len(tabs)-1].label) {
+			continue
+		}
+		if y < row-1 || y > row+1 {
+			return 0, false
+		}
+		for _, t := range tabs {
+			// The label is padded by a space and a border on each side.
+			start := ansi.StringWidth(line[:strings.Index(line, t.label)]) - 2
+			if x >= start && x < start+ansi.StringWidth(t.label)+4 {
+				return t.index, true
+			}
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// clickRow selects the shop row drawn on line and, if x is on one of its
+// click zones, changes the quantity.
+func (m *Model) clickRow(line string, x int) {
+	from, to := m.visibleRows()
+	for row := from; row < to; row++ {
+		text := strings.TrimSpace(ansi.Strip(m.plainRow(m.table.Rows()[row])))
+		i := strings.Index(line, text)
+		if i < 0 {
+			continue
+		}
+		m.table.SetCursor(row)
+		zone := func(glyph string) bool {
+			// The zones are in the last cell, the quantity.
+			j := strings.LastIndex(line[:i+len(text)], glyph)
+			if j < 0 {
+				return false
+			}
+			start := ansi.StringWidth(line[:j])
+			return x >= start && x < start+ansi.StringWidth(glyph)
+		}
+		switch {
+		case zone(glyphs.minus):
+			m.removeOne()
+		case zone(glyphs.plus):
+			m.addOne()
+		}
+		return
+	}
+}
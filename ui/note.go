@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- ORDER NOTES ---
+
+// A short note can go with the order in the cart, like who pays for it
+// later or the event it was for. It is booked with the sale, printed on the
+// receipt, and goes with the order when it is parked.
+
+func newNoteInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("note_prompt")
+	ti.CharLimit = domain.MaxNoteLength
+	ti.Width = 40
+	return ti
+}
+
+// startNote opens the note of the order for editing.
+func (m *Model) startNote() tea.Cmd {
+	m.noting = true
+	m.noteInput.SetValue(m.note)
+	m.noteInput.CursorEnd()
+	return m.noteInput.Focus()
+}
+
+// updateNote handles keys while the note is edited: enter keeps it, an
+// empty one removes it, esc leaves it as it was.
+func (m Model) updateNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Apply):
+		m.note = strings.TrimSpace(m.noteInput.Value())
+		m.stopNote()
+		return m, nil
+	case key.Matches(msg, keys.Back):
+		m.stopNote()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.noteInput, cmd = m.noteInput.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) stopNote() {
+	m.noting = false
+	m.noteInput.Blur()
+}
+
+func (m Model) noteView() string {
+	return "\n\n" + m.noteInput.View()
+}
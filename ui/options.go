@@ -0,0 +1,21 @@
+package ui
+
+import "time"
+
+// --- OPTIONS ---
+
+// Option changes how New sets up the kiosk.
+type Option func(*Model)
+
+// WithClock has the kiosk read the time from now instead of the system
+// clock: the time of its sales, the undo grace and the stats' day. Tests
+// pass a fake clock to check them at a time of their choosing.
+func WithClock(now func() time.Time) Option {
+	return func(m *Model) { m.now = now }
+}
+
+// WithSize lays the kiosk out for a terminal of width × height cells
+// before the first tea.WindowSizeMsg comes in.
+func WithSize(width, height int) Option {
+	return func(m *Model) { m.width, m.height = width, height }
+}
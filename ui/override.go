@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- PRICE OVERRIDE ---
+
+// An admin can sell a cart line at a price of its own, e.g. a dented can
+// at half price: the new unit price is typed in, then the admin PIN. The
+// sale keeps the regular price next to the one charged, see
+// domain.SaleLine.ListPrice.
+
+func newPriceInput() textinput.Model { return newDecimalInput(tr("override_prompt")) }
+
+func newPINInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("pin_prompt")
+	ti.EchoMode = textinput.EchoPassword
+	ti.CharLimit = 16
+	ti.Width = 10
+	return ti
+}
+
+// startOverride opens the price input for the selected cart line. Without
+// an admin PIN in the config, or while the till is locked down, prices
+// can't be overridden.
+func (m *Model) startOverride() tea.Cmd {
+	b, ok := m.selectedCartLine()
+	if !ok || m.config.AdminPIN == "" || m.lockdown != store.LockdownNone {
+		return nil
+	}
+	m.overriding = true
+	m.overrideErr = ""
+	m.priceInput.SetValue(formatDecimal(m.unitPrice(b)))
+	m.priceInput.CursorEnd()
+	return m.priceInput.Focus()
+}
+
+// updateOverride handles keys while the price or the PIN is entered.
+func (m Model) updateOverride(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b, ok := m.selectedCartLine()
+	if !ok || key.Matches(msg, keys.Back) {
+		m.stopOverride()
+		return m, nil
+	}
+	if !key.Matches(msg, keys.Apply) {
+		if !m.pinInput.Focused() && !decimalKey(msg) {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		if m.pinInput.Focused() {
+			m.pinInput, cmd = m.pinInput.Update(msg)
+		} else {
+			m.priceInput, cmd = m.priceInput.Update(msg)
+		}
+		m.overrideErr = ""
+		return m, cmd
+	}
+	price, err := ParseDecimal(m.priceInput.Value())
+	if err != nil || price < 0 {
+		m.overrideErr = tr("invalid_price")
+		return m, nil
+	}
+	if !m.pinInput.Focused() {
+		m.priceInput.Blur()
+		m.pinInput.SetValue("")
+		return m, m.pinInput.Focus()
+	}
+	if subtle.ConstantTimeCompare([]byte(m.pinInput.Value()), []byte(m.config.AdminPIN)) != 1 {
+		m.overrideErr = tr("wrong_pin")
+		m.pinInput.SetValue("")
+		return m, nil
+	}
+	m.overridePrice(b, domain.RoundCents(price))
+	m.stopOverride()
+	return m, nil
+}
+
+func (m *Model) stopOverride() {
+	m.overriding = false
+	m.overrideErr = ""
+	m.priceInput.Blur()
+	m.pinInput.Blur()
+}
+
+// overridePrice sells b at price from now on, or at its own price again
+// if that's what price is. The audit log has who allowed it.
+func (m *Model) overridePrice(b domain.Beverage, price float64) {
+	from, to := m.unitPrice(b), price
+	if price == b.Price {
+		delete(m.prices, b.Key())
+	} else {
+		m.prices[b.Key()] = price
+	}
+	m.store.AuditLog.Write(store.AuditEntry{
+		Time: m.now(), Actor: m.store.Actor, Event: "price_override",
+		Beverage: b.Key(), From: &from, To: &to,
+	})
+	m.updateCartRows()
+}
+
+// unitPrice is what one of b costs in this cart.
+func (m Model) unitPrice(b domain.Beverage) float64 {
+	if price, ok := m.prices[b.Key()]; ok {
+		return price
+	}
+	return b.Price
+}
+
+// cartLines are the lines of the cart at the prices charged for them.
+func (m Model) cartLines() []domain.SaleLine {
+	var lines []domain.SaleLine
+	for _, b := range m.beverages {
+		lines = append(lines, m.beverageLines(b)...)
+	}
+	return lines
+}
+
+// beverageLines are the cart's lines of b: in its bundles and at its
+// price, or with the price an admin set for it, in one line without
+// bundles.
+func (m Model) beverageLines(b domain.Beverage) []domain.SaleLine {
+	qty := m.cart[b.Key()]
+	if qty <= 0 {
+		return nil
+	}
+	if price, ok := m.prices[b.Key()]; ok {
+		return []domain.SaleLine{{Name: b.Key(), Quantity: qty, UnitPrice: price, ListPrice: b.Price}}
+	}
+	return b.Lines(qty)
+}
+
+// cartTotal is what the cart costs at the prices charged.
+func (m Model) cartTotal() float64 {
+	total := 0.0
+	for _, l := range m.cartLines() {
+		total += l.Gross()
+	}
+	return total
+}
+
+func (m Model) overrideView() string {
+	b, _ := m.selectedCartLine()
+	view := "\n\n" + trf("override_for", b.Key(), priceLabel(b)) + "\n" + m.priceInput.View()
+	if m.pinInput.Focused() {
+		view += "\n" + m.pinInput.View()
+	}
+	if m.overrideErr != "" {
+		view += "\n" + warningStyle.Render(m.overrideErr)
+	}
+	return view
+}
+
+// overriddenLabel marks a price an admin set for the cart.
+func overriddenLabel(price float64) string {
+	return fmt.Sprintf("%s*", uiLocale.money(price))
+}
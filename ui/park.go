@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- PARKED ORDERS ---
+
+// When a customer has to fetch their wallet, their order can be parked and
+// the next one started in an empty cart. The parked orders are listed on
+// the cart tab and resumed from there, the cart at hand being parked in
+// turn. Parked orders hold no stock and live only as long as the kiosk
+// runs; the audit log has what went in and out of them.
+
+// parkedCart is an order put aside with its overridden prices.
+type parkedCart struct {
+	id     int
+	cart   domain.Cart
+	prices map[string]float64
+	note   string
+	parked time.Time
+}
+
+// park puts the cart aside and starts an empty one.
+func (m *Model) park() {
+	if !m.cart.HasItems() {
+		return
+	}
+	m.parkSeq++
+	p := parkedCart{id: m.parkSeq, cart: domain.Cart{}, prices: maps.Clone(m.prices), note: m.note, parked: m.now()}
+	detail := fmt.Sprintf("parked #%d", p.id)
+	for _, b := range m.beverages {
+		if qty := m.cart[b.Key()]; qty > 0 {
+			p.cart[b.Key()] = qty
+			m.auditCart(b.Key(), qty, 0, detail)
+		}
+	}
+	m.parked = append(m.parked, p)
+	m.cart, m.prices, m.marked, m.history, m.note = domain.Cart{}, map[string]float64{}, map[string]bool{}, nil, ""
+	m.updateRows()
+}
+
+// resume brings back the parked order at i, parking the cart first if
+// anything is in it.
+func (m *Model) resume(i int) {
+	p := m.parked[i]
+	m.parked = slices.Delete(m.parked, i, i+1)
+	m.park()
+	detail := fmt.Sprintf("resumed #%d", p.id)
+	for _, b := range m.beverages {
+		if qty := p.cart[b.Key()]; qty > 0 {
+			m.cart[b.Key()] = qty
+			m.auditCart(b.Key(), 0, qty, detail)
+		}
+	}
+	m.prices, m.note = p.prices, p.note
+	m.updateRows()
+}
+
+// startResume opens the list of parked orders.
+func (m *Model) startResume() {
+	if len(m.parked) == 0 {
+		return
+	}
+	m.resuming = true
+	m.parkedRow = 0
+}
+
+// updateResume handles keys while the list of parked orders is open.
+func (m Model) updateResume(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Apply):
+		m.resuming = false
+		m.resume(m.parkedRow)
+	case key.Matches(msg, keys.Back):
+		m.resuming = false
+	case key.Matches(msg, keys.Down):
+		m.parkedRow = (m.parkedRow + 1) % len(m.parked)
+	case key.Matches(msg, keys.Up):
+		m.parkedRow = (m.parkedRow + len(m.parked) - 1) % len(m.parked)
+	}
+	return m, nil
+}
+
+// parkedTotal is what the parked order would cost at the prices shown now.
+func (m Model) parkedTotal(p parkedCart) (total float64, items int) {
+	for _, b := range m.beverages {
+		qty := p.cart[b.Key()]
+		if qty == 0 {
+			continue
+		}
+		items += qty
+		if price, ok := p.prices[b.Key()]; ok {
+			total += price * float64(qty)
+			continue
+		}
+		for _, line := range b.Lines(qty) {
+			total += line.Gross()
+		}
+	}
+	return total, items
+}
+
+func (m Model) resumeView() string {
+	view := "\n\n" + tr("parked_orders")
+	for i, p := range m.parked {
+		total, items := m.parkedTotal(p)
+		row := fmt.Sprintf("#%-3d %s  %-12s %10s", p.id, p.parked.Format("15:04"), trf("parked_items", items), uiLocale.money(total))
+		if p.note != "" {
+			row += "  " + p.note
+		}
+		marker := "  "
+		if i == m.parkedRow {
+			marker = glyphs.selected + " "
+			row = lipgloss.NewStyle().Foreground(theme.SelectedForeground).Background(theme.SelectedBackground).Render(row)
+		}
+		view += "\n" + marker + row
+	}
+	return view
+}
+
+// parkedNote says how many orders are parked, if any are.
+func (m Model) parkedNote() string {
+	if len(m.parked) == 0 {
+		return ""
+	}
+	return trf("parked_count", len(m.parked))
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"cmp"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- PAYMENT LINKS ---
+
+// PaymentLinkConfig lets customers without cash or card pay through a
+// link, like a PayPal.me link or the payment page of the space's bank.
+// Checkout shows the link for the exact amount as text and as a QR code,
+// on the customer display too; whoever is at the till confirms once the
+// payment came through, as nothing reports it back.
+type PaymentLinkConfig struct {
+	// Name is what the link is called at checkout and recorded as the
+	// method of the sale, e.g. "PayPal".
+	Name string `json:"name,omitempty"`
+	// URL is the link, with {amount} for the amount like 3.50 and
+	// {reference} for the reference of the sale, e.g.
+	// "https://paypal.me/ourspace/{amount}EUR". Empty for no link.
+	URL string `json:"url,omitempty"`
+}
+
+func (c PaymentLinkConfig) validate() error {
+	if c.URL == "" {
+		return nil
+	}
+	if !strings.Contains(c.URL, "{amount}") {
+		return errors.New("payment_link: url needs {amount}")
+	}
+	link := c.link(999.99, "XXXXXXXX")
+	if u, err := url.Parse(link); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("payment_link: url must be an http or https link, not %q", c.URL)
+	}
+	if _, err := newQRCode(link); err != nil {
+		return fmt.Errorf("payment_link: url is too long for a QR code: %w", err)
+	}
+	return nil
+}
+
+func (c PaymentLinkConfig) enabled() bool { return c.URL != "" }
+
+func (c PaymentLinkConfig) name() string { return cmp.Or(c.Name, "link") }
+
+// link is the link for amount, paid with the sale's reference.
+func (c PaymentLinkConfig) link(amount float64, ref string) string {
+	return strings.NewReplacer("{amount}", fmt.Sprintf("%.2f", amount), "{reference}", url.QueryEscape(ref)).Replace(c.URL)
+}
+
+// linkPayment is a payment by link that the kiosk waits for.
+type linkPayment struct {
+	url    string
+	ref    string
+	lines  []domain.SaleLine // what is paid for, as the link was made
+	amount float64
+}
+
+// startLink shows the link for what the cart costs.
+func (m *Model) startLink() {
+	if !m.config.PaymentLink.enabled() {
+		return
+	}
+	// The reference is short to type into the payment's note, and tells
+	// the payment apart among the others in the account.
+	ref := rand.Text()[:8]
+	amount := domain.RoundCents(m.checkoutTotal())
+	m.link = &linkPayment{url: m.config.PaymentLink.link(amount, ref), ref: ref, lines: m.checkoutLines(), amount: amount}
+	m.classify(m.link.lines)
+}
+
+// updateLink handles keys while the link is shown: enter books the sale
+// once it is paid, esc goes back to the cart.
+func (m Model) updateLink(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Apply):
+		l := m.link
+		m.link = nil
+		return m, m.book(domain.Sale{Lines: l.lines, Link: &domain.LinkPayment{Method: m.config.PaymentLink.name(), Reference: l.ref}})
+	case key.Matches(msg, keys.Back):
+		m.link = nil
+	}
+	return m, nil
+}
+
+func (m Model) linkView() string {
+	l := m.link
+	view := "\n\n" + trf("link_pay", uiLocale.money(l.amount), m.config.PaymentLink.name()) + "\n\n"
+	if q, err := newQRCode(l.url); err == nil {
+		view += q.View() + "\n"
+	}
+	return view + l.url + "\n" + trf("link_reference", l.ref) + "\n\n" + tr("link_confirm")
+}
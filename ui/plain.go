@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// --- PLAIN TEXT MODE ---
+
+// plainView renders the kiosk for braille displays and screen readers: no
+// borders, tables or layout, just labeled lines, with the line that
+// matters most right now at the top. Colors are off in this mode, see
+// Accessibility.adapt, so the styles the views use render as plain text.
+func (m Model) plainView() string {
+	var lines []string
+	if m.confirm != nil {
+		lines = append(lines, m.confirm.plainView())
+	}
+	switch m.activeTab {
+	case 2: // Stats
+		lines = append(lines, tr("tab_stats"))
+		if m.loading {
+			lines = append(lines, tr("loading_history"))
+		} else if m.showRanking {
+			lines = append(lines, m.plainRanking()...)
+		} else {
+			lines = append(lines, m.plainStats()...)
+		}
+	case 1: // Cart
+		lines = append(lines, tr("tab_cart"))
+		if m.scanning {
+			lines = append(lines, strings.TrimSpace(m.scanView()))
+		}
+		if m.redeeming {
+			lines = append(lines, strings.TrimSpace(m.voucherView()))
+		}
+		if m.picking {
+			lines = append(lines, strings.Split(strings.TrimSpace(m.pickMemberView()), "\n")...)
+		}
+		if m.noting {
+			lines = append(lines, strings.TrimSpace(m.noteView()))
+		}
+		if m.resuming {
+			lines = append(lines, strings.Split(strings.TrimSpace(m.resumeView()), "\n")...)
+		}
+		if m.overriding {
+			lines = append(lines, strings.Split(strings.TrimSpace(m.overrideView()), "\n")...)
+		}
+		view := m.cartView()
+		if m.receipt == nil && m.err == nil && m.cart.HasItems() {
+			lines = append(lines, tr("your_order"))
+			lines = append(lines, plainTable(m.cartTable)...)
+			view = m.cartSummary()
+		}
+		for _, line := range strings.Split(view, "\n") {
+			if line = strings.TrimSpace(line); line != "" && strings.Trim(line, "-") != "" {
+				lines = append(lines, line)
+			}
+		}
+	default: // Shop
+		if m.editingQty {
+			lines = append(lines, strings.Split(strings.TrimSpace(m.qtyEntryView()), "\n")...)
+		}
+		if m.jumping {
+			lines = append(lines, strings.Split(strings.TrimSpace(m.jumpView()), "\n")...)
+		}
+		if b, ok := m.selectedBeverage(); ok {
+			lines = append(lines, "> "+m.plainItem(b))
+		}
+		if favs := m.favorites(); len(favs) > 0 {
+			items := make([]string, len(favs))
+			for i, f := range favs {
+				items[i] = strings.ToUpper(f.key) + " " + m.plainItem(f.beverage)
+			}
+			lines = append(lines, trf("plain_favorites", strings.Join(items, "; ")))
+		}
+		lines = append(lines, tr("tab_shop"))
+		for row, i := range m.order {
+			marker := "  "
+			if row == m.table.Cursor() {
+				marker = "> "
+			}
+			lines = append(lines, marker+m.plainItem(m.beverages[i]))
+		}
+	}
+	for _, n := range m.notices() {
+		lines = append(lines, n.Value())
+	}
+	return strings.Join(append(lines, plainKeys(m.helpKeys().short)), "\n")
+}
+
+// plainItem is a beverage as one line of the shop.
+func (m Model) plainItem(b domain.Beverage) string {
+	line := fmt.Sprintf("%s, %s %s, %s %s, %s %d", b.Key(),
+		tr("col_price"), priceLabel(b), tr("col_stock"), m.stockLabel(b), tr("col_qty"), m.cart[b.Key()])
+	if b.IsLowStock(m.config.LowStock) {
+		line += ", " + tr("plain_low_stock")
+	}
+	return line
+}
+
+func (m Model) plainStats() []string {
+	data := m.statsData()
+	now, st := data.now, data.sales
+	lines := []string{
+		trf("stats_today", uiLocale.money(st.TodayRevenue), st.TodaySales),
+		trf("stats_week", uiLocale.money(st.WeekRevenue), st.WeekSales),
+	}
+	if m.config.MonthlyGoal > 0 {
+		lines = append(lines, m.goalLines(st, now, false)...)
+	}
+	if len(st.Sold) == 0 {
+		return append(lines, tr("nothing_sold_today"))
+	}
+	names := make([]string, 0, len(st.Sold))
+	for name := range st.Sold {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int { return st.Sold[b] - st.Sold[a] })
+	lines = append(lines, tr("units_sold_today"))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %d", name, st.Sold[name]))
+	}
+	return lines
+}
+
+func (m Model) plainRanking() []string {
+	days, ranks := m.ranking()
+	lines := []string{rankingRangeLabel(days)}
+	if len(ranks) == 0 || ranks[0].Units == 0 {
+		return append(lines, trf("nothing_sold", rankingRangeLabel(days)))
+	}
+	list := func(title string, rows []store.BeverageRank) {
+		lines = append(lines, title)
+		for i, r := range rows {
+			lines = append(lines, fmt.Sprintf("%d. %s: %d, %s", i+1, r.Name, r.Units, uiLocale.money(r.Revenue)))
+		}
+	}
+	top, slow := topAndSlow(ranks, min(rankingRows, len(ranks)/2))
+	list(tr("top_sellers"), top)
+	list(tr("slow_movers"), slow)
+	return lines
+}
+
+// plainView is the admin TUI in plain text: the prompt or the outcome of
+// the last change first, then the view with its tables as labeled lines.
+func (m AdminModel) plainView() string {
+	var lines []string
+	switch {
+	case m.prompt != promptNone:
+		lines = append(lines, m.input.View())
+	case m.err != nil:
+		lines = append(lines, m.err.Error())
+	case m.notice != "":
+		lines = append(lines, m.notice)
+	}
+	lines = append(lines, fmt.Sprintf("%s (%d of %d)", adminViewNames[m.view], m.view+1, len(adminViewNames)))
+	switch m.view {
+	case adminCatalog:
+		lines = append(lines, plainTable(m.catalog)...)
+	case adminMembers:
+		lines = append(lines, plainTable(m.members)...)
+	case adminReports:
+		lines = append(lines, m.reportView())
+	case adminStatus:
+		lines = append(lines, m.statusView())
+	case adminCount:
+		lines = append(lines, plainTable(m.count)...)
+	}
+	return strings.Join(append(lines, plainKeys(m.helpKeys().short)), "\n")
+}
+
+// plainTable writes each row of t as a line of its labeled cells, the
+// selected row first and marked.
+func plainTable(t table.Model) []string {
+	row := func(r table.Row) string {
+		cells := make([]string, len(r))
+		for i, value := range r {
+			cells[i] = t.Columns()[i].Title + " " + value
+		}
+		return strings.Join(cells, ", ")
+	}
+	var lines []string
+	if r := t.SelectedRow(); r != nil {
+		lines = append(lines, "> "+row(r))
+	}
+	for i, r := range t.Rows() {
+		marker := "  "
+		if i == t.Cursor() {
+			marker = "> "
+		}
+		lines = append(lines, marker+row(r))
+	}
+	return lines
+}
+
+// plainView is the dialog's question on one line, with the keys that
+// answer it.
+func (d confirmDialog) plainView() string {
+	return fmt.Sprintf("%s %s: %s, %s: %s", d.message,
+		keys.Confirm.Help().Key, d.yes, keys.Cancel.Help().Key, d.no)
+}
+
+// plainKeys lists the keys like the short help does, as a sentence.
+func plainKeys(bindings []key.Binding) string {
+	var parts []string
+	for _, b := range bindings {
+		if b.Enabled() {
+			parts = append(parts, b.Help().Key+" "+b.Help().Desc)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- POP-OUT WINDOW ---
+
+// The pop-out is a second terminal next to the till that stays on the
+// till's cart or on today's stats, for the staff rather than the customer.
+// Like the customer display it follows the till through the server: the
+// cart as the till sends its session, the stats as the store changes.
+
+const (
+	PopoutCart  = "cart"
+	PopoutStats = "stats"
+)
+
+// popoutTopSellers is how many beverages the stats of the pop-out list.
+const popoutTopSellers = 5
+
+// PopoutModel is the pop-out window. Tab switches between the cart and the
+// stats; it takes no other input apart from quitting.
+type PopoutModel struct {
+	display DisplayModel
+	store   *store.Store
+	version string
+	show    string
+	err     error
+	now     func() time.Time
+}
+
+// NewPopoutModel returns the pop-out of the till named by server.till,
+// showing the cart or the stats first.
+func NewPopoutModel(cfg Config, s *store.Store, show string) (PopoutModel, error) {
+	if show != PopoutCart && show != PopoutStats {
+		return PopoutModel{}, fmt.Errorf("the pop-out shows %q or %q, not %q", PopoutCart, PopoutStats, show)
+	}
+	if s.Remote == nil {
+		return PopoutModel{}, errors.New("the pop-out needs server.url set to the server the till uses")
+	}
+	d, err := NewDisplayModel(cfg, s)
+	if err != nil {
+		return PopoutModel{}, err
+	}
+	return PopoutModel{display: d, store: s, show: show, now: time.Now}, nil
+}
+
+func (m PopoutModel) Init() tea.Cmd {
+	return tea.Batch(m.display.watchSession(), watchStore(m.store.Remote, m.version))
+}
+
+func (m PopoutModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg, sessionMsg:
+		d, cmd := m.display.Update(msg)
+		m.display = d.(DisplayModel)
+		return m, cmd
+	case storeChangedMsg:
+		if v := string(msg); v != m.version {
+			m.version = v
+			m.err = m.store.Reload()
+		}
+		return m, watchStore(m.store.Remote, m.version)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "tab":
+			if m.show == PopoutCart {
+				m.show = PopoutStats
+			} else {
+				m.show = PopoutCart
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m PopoutModel) View() string {
+	var lines []string
+	if m.show == PopoutStats {
+		lines = append(lines, queueTitleStyle.Foreground(theme.Tabs).Render(tr("popout_stats")))
+		lines = append(lines, m.statsLines()...)
+	} else {
+		lines = append(lines, queueTitleStyle.Foreground(theme.Tabs).Render(trf("popout_cart", m.display.till)))
+		lines = append(lines, m.cartLines()...)
+	}
+	if err := cmp.Or(m.display.err, m.err); err != nil {
+		lines = append(lines, "", warningStyle.Render(trf("display_offline", err)))
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Faint(true).Render(tr("popout_help")))
+	return docStyle.Render(strings.Join(lines, "\n"))
+}
+
+// cartLines are the lines of the sale at hand on the till, or the receipt
+// it shows once the sale is booked.
+func (m PopoutModel) cartLines() []string {
+	s := m.display.session
+	if len(s.Lines) == 0 {
+		return []string{tr("cart_empty")}
+	}
+	nameWidth := 0
+	for _, l := range s.Lines {
+		nameWidth = max(nameWidth, lipgloss.Width(uiLocale.lineLabel(l)))
+	}
+	var lines []string
+	for _, l := range s.Lines {
+		lines = append(lines, fmt.Sprintf("%3d × %-*s  %10s", l.Quantity, nameWidth, uiLocale.lineLabel(l), uiLocale.money(l.Gross())))
+	}
+	total := fmt.Sprintf("%s %s", tr("total"), uiLocale.money(s.Total))
+	if s.Paid {
+		total += "  " + tr("popout_paid")
+	}
+	return append(lines, displayTotalStyle.Render(total))
+}
+
+// statsLines are today's figures of the store, as the till's stats tab
+// shows them but with the top sellers only.
+func (m PopoutModel) statsLines() []string {
+	now := m.now()
+	st := m.store.SalesStats(now)
+	lines := []string{
+		trf("stats_today", uiLocale.money(st.TodayRevenue), st.TodaySales),
+		trf("stats_week", uiLocale.money(st.WeekRevenue), st.WeekSales),
+		"",
+	}
+	first := now.Hour()
+	for h := range now.Hour() {
+		if st.Hourly[h] > 0 {
+			first = h
+			break
+		}
+	}
+	lines = append(lines, trf("revenue_per_hour", first),
+		lipgloss.NewStyle().Foreground(theme.Tabs).Render(sparkline(st.Hourly[first:now.Hour()+1])), "")
+	if len(st.Sold) == 0 {
+		return append(lines, tr("nothing_sold_today"))
+	}
+	names := make([]string, 0, len(st.Sold))
+	for name := range st.Sold {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int { return cmp.Or(cmp.Compare(st.Sold[b], st.Sold[a]), cmp.Compare(a, b)) })
+	names = names[:min(len(names), popoutTopSellers)]
+	nameWidth := 0
+	for _, name := range names {
+		nameWidth = max(nameWidth, lipgloss.Width(name))
+	}
+	lines = append(lines, tr("top_sellers"))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%-*s  %4d", nameWidth, name, st.Sold[name]))
+	}
+	return lines
+}
@@ -0,0 +1,431 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- QR CODES ---
+
+// A QR code encoder for links of up to a couple hundred bytes: byte mode,
+// medium error correction, versions 1 to 10. Terminals draw two modules
+// per cell with half blocks.
+
+// qrVersion is the layout of a version at error correction level M.
+type qrVersion struct {
+	ecPerBlock int
+	// blocks are the data codewords of each block.
+	blocks    []int
+	alignment []int
+}
+
+var qrVersions = []qrVersion{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v qrVersion) dataCodewords() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// qrCode is the matrix of a QR code, true for the dark modules.
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // finder, timing, alignment and format modules
+}
+
+// newQRCode encodes text in the smallest version it fits.
+func newQRCode(text string) (*qrCode, error) {
+	data := []byte(text)
+	for version := 1; version < len(qrVersions); version++ {
+		v := qrVersions[version]
+		lengthBits := 8
+		if version >= 10 {
+			lengthBits = 16
+		}
+		if 4+lengthBits+8*len(data) > 8*v.dataCodewords() {
+			continue
+		}
+		q := &qrCode{size: 17 + 4*version}
+		q.modules = make([][]bool, q.size)
+		q.function = make([][]bool, q.size)
+		for y := range q.size {
+			q.modules[y] = make([]bool, q.size)
+			q.function[y] = make([]bool, q.size)
+		}
+		q.drawFunctionPatterns(version)
+		q.drawCodewords(v.codewords(data, lengthBits))
+		q.applyBestMask()
+		return q, nil
+	}
+	return nil, fmt.Errorf("%d bytes are too long for a QR code", len(data))
+}
+
+// codewords are data in byte mode, padded to the version's capacity and
+// followed by the error correction, interleaved block by block.
+func (v qrVersion) codewords(data []byte, lengthBits int) []byte {
+	var bits qrBits
+	bits.append(0b0100, 4)
+	bits.append(len(data), lengthBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * v.dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	bytes := bits.bytes()
+
+	var blocks, ecs [][]byte
+	divisor := reedSolomonDivisor(v.ecPerBlock)
+	for _, n := range v.blocks {
+		blocks = append(blocks, bytes[:n])
+		ecs = append(ecs, reedSolomonRemainder(bytes[:n], divisor))
+		bytes = bytes[n:]
+	}
+	var out []byte
+	for i := range v.blocks[len(v.blocks)-1] {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := range v.ecPerBlock {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+type qrBits []bool
+
+func (b *qrBits) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b qrBits) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// reedSolomonDivisor is the generator polynomial of the given degree,
+// highest coefficient first without the leading 1.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 2)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrCode) drawFunctionPatterns(version int) {
+	for i := range q.size {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < q.size && y >= 0 && y < q.size {
+					d := max(abs(dx), abs(dy))
+					q.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	pos := qrVersions[version].alignment
+	for i, x := range pos {
+		for j, y := range pos {
+			// The finder patterns are in these corners.
+			if i == 0 && j == 0 || i == 0 && j == len(pos)-1 || i == len(pos)-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	// Reserve the format modules; the mask fills them in.
+	q.drawFormat(0)
+	if version >= 7 {
+		rem := version
+		for range 12 {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := version<<12 | rem
+		for i := range 18 {
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, bits>>i&1 == 1)
+			q.set(b, a, bits>>i&1 == 1)
+		}
+	}
+}
+
+// drawFormat writes the error correction level, M, and the mask.
+func (q *qrCode) drawFormat(mask int) {
+	rem := mask
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (mask<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := range 6 {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := range 8 {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// drawCodewords fills the data modules in the zigzag of two columns, from
+// the bottom right up and down again.
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range q.size {
+			for j := range 2 {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < 8*len(data) {
+					q.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+var qrMasks = []func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+func (q *qrCode) applyMask(mask int) {
+	for y := range q.size {
+		for x := range q.size {
+			if !q.function[y][x] && qrMasks[mask](x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+	q.drawFormat(mask)
+}
+
+// applyBestMask keeps the mask that leaves the fewest long runs, blocks,
+// look-alikes of the finders and imbalance of dark and light modules,
+// which are hard to scan.
+func (q *qrCode) applyBestMask() {
+	best, lowest := 0, -1
+	for mask := range qrMasks {
+		q.applyMask(mask)
+		if p := q.penalty(); lowest < 0 || p < lowest {
+			best, lowest = mask, p
+		}
+		q.applyMask(mask) // masks undo themselves
+	}
+	q.applyMask(best)
+}
+
+// penalty scores the modules as the standard does: 3 for a run of five of
+// a colour and 1 for each module longer, 3 for each 2×2 block, 40 for each
+// run like a finder pattern's and 10 for each 5% off an even balance.
+func (q *qrCode) penalty() int {
+	p, dark := 0, 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	for _, transpose := range []bool{false, true} {
+		for y := range q.size {
+			var runs qrRuns
+			color, run := false, 0
+			for x := range q.size {
+				if at(x, y, transpose) == color {
+					run++
+					if run == 5 {
+						p += 3
+					} else if run > 5 {
+						p++
+					}
+					continue
+				}
+				runs.add(run, q.size)
+				if !color {
+					p += 40 * runs.finders()
+				}
+				color, run = !color, 1
+			}
+			// The quiet zone after the line is light.
+			if color {
+				runs.add(run, q.size)
+				run = 0
+			}
+			runs.add(run+q.size, q.size)
+			p += 40 * runs.finders()
+		}
+	}
+	for y := range q.size {
+		for x := range q.size {
+			c := q.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size && c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				p += 3
+			}
+		}
+	}
+	total := q.size * q.size
+	return p + ((abs(dark*20-total*10)+total-1)/total-1)*10
+}
+
+// qrRuns are the lengths of the last seven runs of a line, the latest
+// first, taking the quiet zone before the line for part of its first run.
+type qrRuns [7]int
+
+func (r *qrRuns) add(run, size int) {
+	if r[0] == 0 {
+		run += size
+	}
+	copy(r[1:], r[:6])
+	r[0] = run
+}
+
+// finders counts how often the last runs look like a finder pattern: dark,
+// light, dark three times as long, light and dark, with light four times as
+// long on either side.
+func (r qrRuns) finders() int {
+	n, count := r[1], 0
+	if n == 0 || r[2] != n || r[3] != 3*n || r[4] != n || r[5] != n {
+		return 0
+	}
+	if r[0] >= 4*n && r[6] >= n {
+		count++
+	}
+	if r[6] >= 4*n && r[0] >= n {
+		count++
+	}
+	return count
+}
+
+func abs(n int) int { return max(n, -n) }
+
+var qrStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#000000")).Background(lipgloss.Color("#FFFFFF"))
+
+// View draws the code dark on light with a quiet zone around it, two rows
+// of modules per line.
+func (q *qrCode) View() string {
+	const quiet = 2
+	dark := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && y >= 0 && x < q.size && y < q.size && q.modules[y][x]
+	}
+	width := q.size + 2*quiet
+	var lines []string
+	for y := 0; y < width; y += 2 {
+		var s strings.Builder
+		for x := range width {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				s.WriteString("█")
+			case top:
+				s.WriteString("▀")
+			case bottom:
+				s.WriteString("▄")
+			default:
+				s.WriteString(" ")
+			}
+		}
+		lines = append(lines, qrStyle.Render(s.String()))
+	}
+	return strings.Join(lines, "\n")
+}
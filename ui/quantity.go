@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"strconv"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- QUANTITY ENTRY ---
+
+func newQtyInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("quantity_prompt")
+	ti.Placeholder = "0"
+	ti.CharLimit = 4
+	ti.Width = 6
+	return ti
+}
+
+// startQtyEntry opens the quantity input for the selected beverage, seeded
+// with the digit that was typed or, for the edit key, the current quantity.
+func (m *Model) startQtyEntry(msg tea.KeyMsg) tea.Cmd {
+	b, ok := m.selectedBeverage()
+	if !ok || m.lockdown == store.LockdownReadOnly {
+		return nil
+	}
+	value := ""
+	if isDigit(msg) {
+		value = msg.String()
+	} else if m.cart[b.Key()] > 0 {
+		value = strconv.Itoa(m.cart[b.Key()])
+	}
+	m.editingQty = true
+	m.qtyErr = ""
+	m.qtyInput.SetValue(value)
+	m.qtyInput.CursorEnd()
+	return m.qtyInput.Focus()
+}
+
+// updateQtyEntry handles keys while the quantity input is open.
+func (m Model) updateQtyEntry(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Apply):
+		b, ok := m.selectedBeverage()
+		if !ok {
+			m.stopQtyEntry()
+			return m, nil
+		}
+		qty, err := strconv.Atoi(m.qtyInput.Value())
+		switch {
+		case m.qtyInput.Value() == "":
+			qty = 0
+		case err != nil || qty < 0:
+			m.qtyErr = tr("whole_number")
+			return m, nil
+		case qty > m.cart[b.Key()] && !b.AvailableAt(m.now()):
+			m.qtyErr = trf("not_sold_now", b.Key(), b.AvailabilityNote())
+			return m, nil
+		case qty > m.available(b) && m.config.StockPolicy.Blocks():
+			m.qtyErr = trf("only_in_stock", m.available(b))
+			return m, nil
+		}
+		m.setQty(b.Key(), qty)
+		m.stopQtyEntry()
+		return m, nil
+	case key.Matches(msg, keys.Back):
+		m.stopQtyEntry()
+		return m, nil
+	}
+	// Only digits and editing keys reach the input.
+	if msg.Type == tea.KeyRunes && !isDigit(msg) {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.qtyInput, cmd = m.qtyInput.Update(msg)
+	m.qtyErr = ""
+	return m, cmd
+}
+
+func (m *Model) stopQtyEntry() {
+	m.editingQty = false
+	m.qtyErr = ""
+	m.qtyInput.Blur()
+}
+
+func (m Model) qtyEntryView() string {
+	b, _ := m.selectedBeverage()
+	view := "\n\n" + trf("qty_max", b.Key(), m.available(b)) + "\n" + m.qtyInput.View()
+	if !m.config.StockPolicy.Blocks() {
+		view = "\n\n" + trf("qty_in_stock", b.Key(), m.available(b)) + "\n" + m.qtyInput.View()
+	}
+	if m.qtyErr != "" {
+		view += "\n" + warningStyle.Render(m.qtyErr)
+	}
+	return view
+}
+
+func isDigit(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyRunes && len(msg.Runes) == 1 && msg.Runes[0] >= '0' && msg.Runes[0] <= '9'
+}
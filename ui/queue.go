@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- ORDER QUEUE ---
+
+// queuePollInterval is how often the queue terminal of a local store looks
+// for new orders. Only a changed file is read again.
+const queuePollInterval = time.Second
+
+// queueDoneShown is how many of the orders done last the queue keeps on
+// screen, so that one marked done by mistake can be taken back.
+const queueDoneShown = 5
+
+// QueueModel is the queue terminal at the bar: the open orders, oldest
+// first, and below them the ones done last. Enter moves the selected order
+// on, from queued to in progress to done; backspace moves it back.
+type QueueModel struct {
+	store   *store.Store
+	version string
+	cursor  int
+	err     error
+	now     func() time.Time
+	width   int
+	height  int
+}
+
+// NewQueueModel returns the queue terminal for the orders of s. The first
+// look for changes loads the store in full, as it may come from the cache.
+func NewQueueModel(s *store.Store) QueueModel {
+	return QueueModel{store: s, now: time.Now}
+}
+
+type queueTickMsg struct{}
+
+func (m QueueModel) watch() tea.Cmd {
+	if m.store.Remote != nil {
+		return watchStore(m.store.Remote, m.version)
+	}
+	return tea.Tick(queuePollInterval, func(time.Time) tea.Msg { return queueTickMsg{} })
+}
+
+func (m QueueModel) Init() tea.Cmd { return m.watch() }
+
+func (m QueueModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case storeChangedMsg:
+		m.version = string(msg)
+		m.err = m.store.Reload()
+		return m, m.watch()
+	case queueTickMsg:
+		if v := store.Version(m.store.Path); v != m.version {
+			m.version = v
+			m.err = m.store.Reload()
+		}
+		return m, m.watch()
+	case tea.KeyMsg:
+		orders := m.orders()
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = min(len(orders)-1, m.cursor+1)
+		case "enter", " ":
+			if m.cursor < len(orders) {
+				if next := orders[m.cursor].Status.Next(); next != "" {
+					m.err = m.store.SetOrderStatus(orders[m.cursor].Sale, next, m.now())
+				}
+			}
+		case "backspace":
+			if m.cursor < len(orders) {
+				if prev := orders[m.cursor].Status.Previous(); prev != "" {
+					m.err = m.store.SetOrderStatus(orders[m.cursor].Sale, prev, m.now())
+				}
+			}
+		}
+		m.cursor = max(0, min(m.cursor, len(m.orders())-1))
+	}
+	return m, nil
+}
+
+// orders are the rows of the queue: the open orders by the time they came
+// in, then the last ones done, latest first.
+func (m QueueModel) orders() []store.Order {
+	var open, done []store.Order
+	for _, o := range m.store.Orders {
+		if o.Status == store.OrderDone {
+			done = append(done, o)
+		} else {
+			open = append(open, o)
+		}
+	}
+	slices.SortStableFunc(open, func(a, b store.Order) int { return a.Time.Compare(b.Time) })
+	slices.SortStableFunc(done, func(a, b store.Order) int { return b.Updated.Compare(a.Updated) })
+	return append(open, done[:min(len(done), queueDoneShown)]...)
+}
+
+var (
+	queueTitleStyle      = lipgloss.NewStyle().Bold(true).MarginBottom(1)
+	queueInProgressStyle = lipgloss.NewStyle().Bold(true)
+	queueDoneStyle       = lipgloss.NewStyle().Faint(true).Strikethrough(true)
+)
+
+func (m QueueModel) View() string {
+	lines := []string{queueTitleStyle.Foreground(theme.Tabs).Render(tr("queue_title"))}
+	orders := m.orders()
+	if len(orders) == 0 {
+		lines = append(lines, tr("queue_empty"))
+	}
+	for i, o := range orders {
+		items := make([]string, len(o.Items))
+		for j, it := range o.Items {
+			items[j] = fmt.Sprintf("%d× %s", it.Quantity, it.Name)
+		}
+		who := ""
+		if j := m.store.MemberIndex(o.Member); j >= 0 {
+			who = " · " + m.store.Members[j].Name
+		}
+		row := fmt.Sprintf("#%-4d %s  %-12s %s%s", o.Sale, o.Time.Format("15:04"),
+			tr("order_"+string(o.Status)), strings.Join(items, ", "), who)
+		switch o.Status {
+		case store.OrderInProgress:
+			row = queueInProgressStyle.Render(row)
+		case store.OrderDone:
+			row = queueDoneStyle.Render(row)
+		}
+		marker := "  "
+		if i == m.cursor {
+			marker = glyphs.selected + " "
+			row = lipgloss.NewStyle().Foreground(theme.SelectedForeground).Background(theme.SelectedBackground).Render(row)
+		}
+		lines = append(lines, marker+row)
+	}
+	if m.err != nil {
+		lines = append(lines, "", warningStyle.Render(m.err.Error()))
+	}
+	lines = append(lines, "", tr("queue_help"))
+	return docStyle.Render(strings.Join(lines, "\n"))
+}
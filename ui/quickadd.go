@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- QUICK ADD ---
+
+// With quick_add, the first nine rows the shop shows are numbered and the
+// digit keys add one of the beverage in that row, wherever the cursor is.
+// As the pinned beverages and top sellers come first, those are what the
+// digits reach until the table scrolls. The quantity is still entered
+// with e.
+
+// quickAddLabel is the index cell of the row that is shown nth, empty
+// above the table's top and past the ninth.
+func quickAddLabel(nth int) string {
+	if nth < 0 || nth >= 9 {
+		return ""
+	}
+	return strconv.Itoa(nth + 1)
+}
+
+// quickAddTop returns the row the shop table shows first. The table
+// scrolls on its own and doesn't say how far, so like the low-stock
+// colors it is found in the rendered table: the first line of rows is
+// one of them drawn plainly, or else the selected one.
+func (m Model) quickAddTop() int {
+	lines := strings.Split(m.table.View(), "\n")
+	height := m.table.Height()
+	if height <= 0 || len(lines) < height {
+		return 0
+	}
+	first := lines[len(lines)-height]
+	from, to := m.visibleRows()
+	for row := from; row < to; row++ {
+		if row != m.table.Cursor() && strings.HasPrefix(first, m.plainRow(m.table.Rows()[row])) {
+			return row
+		}
+	}
+	return m.table.Cursor()
+}
+
+// quickAddTable is the shop table with its index cells numbered from the
+// row it shows first.
+func (m Model) quickAddTable() table.Model {
+	t := m.table
+	top := m.quickAddTop()
+	rows := make([]table.Row, len(t.Rows()))
+	for i, row := range t.Rows() {
+		rows[i] = slices.Clone(row)
+		rows[i][0] = quickAddLabel(i - top)
+	}
+	t.SetRows(rows)
+	return t
+}
+
+// quickAdd adds one of the beverage numbered by the digit key msg.
+func (m *Model) quickAdd(msg tea.KeyMsg) {
+	nth := int(msg.Runes[0] - '1')
+	if row := m.quickAddTop() + nth; nth < m.table.Height() && row < len(m.order) {
+		m.add(m.beverages[m.order[row]])
+	}
+}
+
+// editQtyKey is the key to enter a quantity as the help has it: with quick
+// add, the digits from 1 add rows instead, which leaves e and 0.
+func (m Model) editQtyKey() key.Binding {
+	if !m.config.QuickAdd {
+		return keys.EditQty
+	}
+	return key.NewBinding(key.WithKeys("e", "0"), key.WithHelp("e/0", keys.EditQty.Help().Desc))
+}
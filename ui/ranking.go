@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- TOP SELLERS AND SLOW MOVERS ---
+
+// WriteRankingReport writes the ranking with each beverage's share of the
+// units and revenue, and lists what didn't sell at all.
+func WriteRankingReport(out io.Writer, period string, ranks []store.BeverageRank) error {
+	var units int
+	var revenue float64
+	for _, r := range ranks {
+		units += r.Units
+		revenue += r.Revenue
+	}
+	share := func(part, whole float64) string {
+		if whole == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f%%", part/whole*100)
+	}
+
+	fmt.Fprintf(out, "Top sellers and slow movers %s\n\n", period)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "#\tBeverage\tUnits\tShare\tRevenue\tShare\t")
+	var unsold []string
+	for i, r := range ranks {
+		if r.Units == 0 {
+			unsold = append(unsold, r.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%.2f\t%s\t\n", i+1, r.Name, r.Units,
+			share(float64(r.Units), float64(units)), r.Revenue, share(r.Revenue, revenue))
+	}
+	fmt.Fprintf(w, "\tTotal\t%d\t\t%.2f\t\t\n", units, revenue)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(unsold) > 0 {
+		fmt.Fprintf(out, "\nNot sold at all: %s\n", strings.Join(unsold, ", "))
+	}
+	return nil
+}
+
+func WriteRankingCSV(out io.Writer, ranks []store.BeverageRank) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"rank", "beverage", "units", "revenue"})
+	for i, r := range ranks {
+		w.Write([]string{strconv.Itoa(i + 1), r.Name, strconv.Itoa(r.Units), Money(r.Revenue)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// rankingRanges are the periods the stats tab ranks over, in days.
+var rankingRanges = []int{1, 7, 30, 90}
+
+// rankingRows is how many top sellers and slow movers the stats tab shows.
+const rankingRows = 5
+
+func rankingRangeLabel(days int) string {
+	if days == 1 {
+		return tr("range_today")
+	}
+	return trf("range_days", days)
+}
+
+// rankingChrome is everything on the ranking view but the table rows.
+const rankingChrome = 17
+
+// updateStats handles the keys of the stats tab, which switch between the
+// dashboard and the ranking and pick the ranking's period.
+func (m *Model) updateStats(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, keys.Ranking):
+		m.showRanking = !m.showRanking
+	case !m.showRanking:
+	case key.Matches(msg, keys.PrevRange):
+		m.rankingRange = max(0, m.rankingRange-1)
+	case key.Matches(msg, keys.NextRange):
+		m.rankingRange = min(len(rankingRanges)-1, m.rankingRange+1)
+	}
+}
+
+// ranking ranks the beverages over the chosen range of days.
+func (m Model) ranking() (int, []store.BeverageRank) {
+	days := rankingRanges[m.rankingRange]
+	return days, m.statsData().ranking(m.store, days)
+}
+
+// topAndSlow splits off the n best-selling and n slowest beverages of
+// ranks, slowest first; beverages that didn't sell aren't top sellers.
+func topAndSlow(ranks []store.BeverageRank, n int) (top, slow []store.BeverageRank) {
+	slow = slices.Clone(ranks[len(ranks)-n:])
+	slices.Reverse(slow)
+	top = ranks[:n]
+	for len(top) > 0 && top[len(top)-1].Units == 0 {
+		top = top[:len(top)-1]
+	}
+	return top, slow
+}
+
+func (m Model) rankingView() string {
+	days, ranks := m.ranking()
+
+	table := func(rows []store.BeverageRank) string {
+		var b strings.Builder
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for _, r := range rows {
+			fmt.Fprintf(w, "%s\t%5d\t%9s\n", r.Name, r.Units, uiLocale.money(r.Revenue))
+		}
+		w.Flush()
+		return lipgloss.NewStyle().Align(lipgloss.Left).Render(strings.TrimSuffix(b.String(), "\n"))
+	}
+	if len(ranks) == 0 || ranks[0].Units == 0 {
+		return fmt.Sprintf("%s %s %s\n\n%s", glyphs.prev, rankingRangeLabel(days), glyphs.next, trf("nothing_sold", rankingRangeLabel(days)))
+	}
+	n := min(rankingRows, len(ranks)/2)
+	if m.height > 0 {
+		n = max(1, min(n, (m.height-rankingChrome-len(m.notices()))/2))
+	}
+	top, slow := topAndSlow(ranks, n)
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "%s %s %s\n\n", glyphs.prev, rankingRangeLabel(days), glyphs.next)
+	s.WriteString(tr("top_sellers") + "\n" + table(top) + "\n\n")
+	s.WriteString(tr("slow_movers") + "\n" + table(slow))
+	return s.String()
+}
@@ -0,0 +1,19 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- RECEIPT ---
+
+// receiptView renders the receipt from the receipt template.
+func receiptView(sale domain.Sale) string {
+	var s strings.Builder
+	if err := receiptTemplate.Execute(&s, sale); err != nil {
+		return fmt.Sprintf("receipt template: %v", err)
+	}
+	return s.String()
+}
@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- RECENT MEMBERS ---
+
+// The same few people order all evening, so instead of scanning their card
+// every time the cashier can pick them: the members who paid from their
+// tab last come first, tab cycles through them, and typing filters all
+// members by name.
+
+func newMemberInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("member_prompt")
+	ti.CharLimit = 30
+	ti.Width = 20
+	return ti
+}
+
+// startPickMember opens the member picker, with the one who paid last
+// selected.
+func (m *Model) startPickMember() tea.Cmd {
+	if m.config.RecentMembers <= 0 {
+		return nil
+	}
+	m.picking = true
+	m.memberRow = 0
+	m.memberFilter.SetValue("")
+	return m.memberFilter.Focus()
+}
+
+// updatePickMember handles keys while the member picker is open.
+func (m Model) updatePickMember(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	candidates := m.memberCandidates()
+	switch {
+	case key.Matches(msg, keys.Apply):
+		if m.memberRow >= len(candidates) {
+			return m, nil
+		}
+		m.stopPickMember()
+		cmd := m.checkout(candidates[m.memberRow].ID, "")
+		return m, cmd
+	case key.Matches(msg, keys.Back):
+		m.stopPickMember()
+		return m, nil
+	case key.Matches(msg, keys.NextMember):
+		if len(candidates) > 0 {
+			m.memberRow = (m.memberRow + 1) % len(candidates)
+		}
+		return m, nil
+	case key.Matches(msg, keys.PrevMember):
+		if len(candidates) > 0 {
+			m.memberRow = (m.memberRow + len(candidates) - 1) % len(candidates)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.memberFilter, cmd = m.memberFilter.Update(msg)
+	m.memberRow = 0
+	return m, cmd
+}
+
+func (m *Model) stopPickMember() {
+	m.picking = false
+	m.memberFilter.Blur()
+}
+
+// recentMembers are the members who paid from their tab last, most recent
+// first, as many as the config keeps. Guests whose wristband expired and
+// inactive members are left out.
+func (m Model) recentMembers() []domain.Member {
+	var recent []domain.Member
+	now := m.now()
+	for i := len(m.store.Sales) - 1; i >= 0 && len(recent) < m.config.RecentMembers; i-- {
+		sale := m.store.Sales[i]
+		if sale.Member == "" || sale.Voids != 0 || slices.ContainsFunc(recent, func(r domain.Member) bool { return r.ID == sale.Member }) {
+			continue
+		}
+		j := m.store.MemberIndex(sale.Member)
+		if j < 0 || m.store.Members[j].Expired(now) || m.store.Members[j].Inactive {
+			continue
+		}
+		recent = append(recent, m.store.Members[j])
+	}
+	return recent
+}
+
+// memberCandidates are what the picker offers: without a filter the recent
+// members, with one the members it matches, the recent ones first, then by
+// how well the name matches. There are never more than the recent ones
+// would be.
+func (m Model) memberCandidates() []domain.Member {
+	recent := m.recentMembers()
+	query := m.memberFilter.Value()
+	if strings.TrimSpace(query) == "" {
+		return recent
+	}
+	type match struct {
+		member domain.Member
+		recent int
+		score  int
+	}
+	var matches []match
+	now := m.now()
+	for _, member := range m.store.Members {
+		if member.Expired(now) || member.Inactive {
+			continue
+		}
+		score, ok := fuzzyScore(query, cmp.Or(member.Name, member.ID))
+		if !ok {
+			continue
+		}
+		rank := slices.IndexFunc(recent, func(r domain.Member) bool { return r.ID == member.ID })
+		if rank < 0 {
+			rank = len(recent)
+		}
+		matches = append(matches, match{member, rank, score})
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(a.recent, b.recent), cmp.Compare(b.score, a.score))
+	})
+	candidates := make([]domain.Member, min(len(matches), m.config.RecentMembers))
+	for i := range candidates {
+		candidates[i] = matches[i].member
+	}
+	return candidates
+}
+
+func (m Model) pickMemberView() string {
+	view := "\n\n" + m.memberFilter.View()
+	candidates := m.memberCandidates()
+	if len(candidates) == 0 {
+		if query := m.memberFilter.Value(); query != "" {
+			return view + "\n" + warningStyle.Render(trf("no_match", query))
+		}
+		return view + "\n" + tr("no_recent_members")
+	}
+	for i, member := range candidates {
+		row := fmt.Sprintf("%-20s %10s", cmp.Or(member.Name, member.ID), uiLocale.money(member.Balance))
+		marker := "  "
+		if i == m.memberRow {
+			marker = glyphs.selected + " "
+			row = lipgloss.NewStyle().Foreground(theme.SelectedForeground).Background(theme.SelectedBackground).Render(row)
+		}
+		view += "\n" + marker + row
+	}
+	return view
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- RELEASE CHECK ---
+
+// UpdateConfig looks for newer releases every Check, at URL or else on
+// GitHub. A newer one is shown in a banner on the kiosk and in the status
+// of the admin TUI, which points at the update command that installs it.
+// Without Check, nothing is looked up.
+type UpdateConfig struct {
+	Check Duration `json:"check"`
+	URL   string   `json:"url,omitempty"`
+}
+
+func (c UpdateConfig) validate() error {
+	if c.Check.Duration != 0 && c.Check.Duration < time.Hour {
+		return fmt.Errorf("updates: check at most once an hour, not every %s", c.Check)
+	}
+	return nil
+}
+
+// releaseMsg is the latest release, if it is newer than this build.
+type releaseMsg struct{ release *store.Release }
+
+// checkRelease looks up the latest release after wait. Failing lookups,
+// e.g. at a bar without internet, are tried again at the next check.
+func checkRelease(c UpdateConfig, wait time.Duration) tea.Cmd {
+	if c.Check.Duration <= 0 {
+		return nil
+	}
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		r, err := store.LatestRelease(c.URL)
+		if err != nil || !r.NewerThan(store.CurrentVersion()) {
+			return releaseMsg{}
+		}
+		return releaseMsg{&r}
+	})
+}
+
+// releaseNotice is the banner about a newer release, if there is one.
+func releaseNotice(r *store.Release) string {
+	switch {
+	case r == nil:
+		return ""
+	case r.Summary() == "":
+		return trf("release_available", r.Version)
+	}
+	return trf("release_summary", r.Version, r.Summary())
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- CONFIG RELOAD ---
+
+// The kiosk reads its config again whenever the file changes or it gets a
+// SIGHUP, and the store along with it, so that new prices, price profiles,
+// stock and themes show without a restart. What is only set up at startup,
+// like the key switch, the locale and the connection to the server, still
+// needs one.
+
+// reloadNoticeFor is how long the kiosk says it reloaded the config.
+const reloadNoticeFor = 5 * time.Second
+
+type configReloader struct {
+	path string
+	load func() (Config, error)
+	mod  time.Time
+	hup  chan os.Signal
+}
+
+type configHupMsg struct{}
+
+// WithConfigReload has the kiosk reload its config with load when the
+// file at path changes or on SIGHUP.
+func WithConfigReload(path string, load func() (Config, error)) Option {
+	return func(m *Model) {
+		r := &configReloader{path: path, load: load, hup: make(chan os.Signal, 1)}
+		if info, err := os.Stat(path); err == nil {
+			r.mod = info.ModTime()
+		}
+		signal.Notify(r.hup, syscall.SIGHUP)
+		m.reloader = r
+	}
+}
+
+func (r *configReloader) next() tea.Cmd {
+	if r == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		<-r.hup
+		return configHupMsg{}
+	}
+}
+
+// reloadIfChanged reloads the config if its file changed since it was
+// last read.
+func (m *Model) reloadIfChanged() {
+	if m.reloader == nil {
+		return
+	}
+	info, err := os.Stat(m.reloader.path)
+	if err != nil || info.ModTime().Equal(m.reloader.mod) {
+		return
+	}
+	m.reloader.mod = info.ModTime()
+	m.reloadConfig()
+}
+
+// reloadConfig reads the config and the store again. A config that doesn't
+// load keeps the one the kiosk has.
+func (m *Model) reloadConfig() {
+	cfg, err := m.reloader.load()
+	if err != nil {
+		m.reloadErr = err
+		return
+	}
+	t, err := cfg.ThemeConfig().Resolve()
+	if err != nil {
+		m.reloadErr = err
+		return
+	}
+	ApplyTheme(t)
+	s := tableStyles(cfg)
+	m.table.SetStyles(s)
+	m.cartTable.SetStyles(s)
+	m.cellStyle = s.Cell
+	if cfg.QuickAdd != m.config.QuickAdd {
+		// The rows are made again for the columns, by refresh.
+		m.table.SetRows(nil)
+		m.table.SetColumns(shopColumns(m.sortBy, m.sortDesc, m.nameWidth, cfg.QuickAdd))
+	}
+	m.config = cfg
+	m.store.Configure(cfg.StoreOptions())
+	m.reloadErr, m.reloaded = nil, m.now()
+	m.refresh(m.reloaded)
+}
+
+func (m Model) reloadNotice() (string, bool) {
+	switch {
+	case m.reloadErr != nil:
+		return trf("config_reload_failed", m.reloadErr), true
+	case !m.reloaded.IsZero() && m.now().Sub(m.reloaded) < reloadNoticeFor:
+		return tr("config_reloaded"), false
+	}
+	return "", false
+}
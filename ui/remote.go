@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- CLIENT/SERVER ---
+
+// storeChangedMsg reports a new store version from the server.
+type storeChangedMsg string
+
+// watchStore waits for the server to report a change of the store. On
+// errors it backs off rather than hammering the server; the regular poll
+// keeps the terminal roughly up to date meanwhile.
+func watchStore(r *store.RemoteStore, since string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := r.Do(http.MethodGet, "/events?since="+since, nil)
+		if err != nil {
+			time.Sleep(StorePollInterval)
+			return storeChangedMsg(since)
+		}
+		return storeChangedMsg(data)
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"cmp"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- PAYING BY TICKET OR CARD ---
+
+// Scanners type the code of a ticket or card followed by enter, so paying
+// from a tab is a text input that takes the whole code.
+
+func newScanInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("scan_prompt")
+	ti.EchoMode = textinput.EchoPassword
+	ti.CharLimit = 128
+	ti.Width = 20
+	return ti
+}
+
+func (m *Model) startScan() tea.Cmd {
+	m.scanning = true
+	m.scanErr = ""
+	m.scanInput.SetValue("")
+	return m.scanInput.Focus()
+}
+
+// updateScan handles keys while the scan input is open.
+func (m Model) updateScan(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Apply):
+		member, ok := m.store.MemberByToken(m.scanInput.Value())
+		if !ok {
+			m.scanErr = tr("unknown_token")
+			m.scanInput.SetValue("")
+			return m, nil
+		}
+		m.stopScan()
+		cmd := m.checkout(member.ID, "")
+		return m, cmd
+	case key.Matches(msg, keys.Back):
+		m.stopScan()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.scanInput, cmd = m.scanInput.Update(msg)
+	m.scanErr = ""
+	return m, cmd
+}
+
+func (m *Model) stopScan() {
+	m.scanning = false
+	m.scanErr = ""
+	m.scanInput.Blur()
+}
+
+func (m Model) scanView() string {
+	view := "\n\n" + m.scanInput.View()
+	if m.scanErr != "" {
+		view += "\n" + warningStyle.Render(m.scanErr)
+	}
+	return view
+}
+
+// payerNotice tells who paid the receipt's sale and what is left on their
+// tab.
+func (m Model) payerNotice() string {
+	i := m.store.MemberIndex(m.receipt.Member)
+	if i < 0 {
+		return ""
+	}
+	payer := m.store.Members[i]
+	return trf("paid_by", cmp.Or(payer.Name, payer.ID), PrintLocale.money(payer.Balance))
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- SCREENSAVER ---
+
+// ScreensaverConfig blanks the till once nobody has touched it for Idle,
+// so that the cart and the admin functions aren't left open on an
+// unattended bar. Show is what the screensaver shows, the "logo", i.e.
+// the board's title and the time, or today's "stats". With Lock, only the
+// lock PIN brings the till back; without it, any key does. Without Idle,
+// there is no screensaver.
+//
+// The till can also be locked by hand, see lock, which shows the same
+// screen until the PIN is entered.
+type ScreensaverConfig struct {
+	Idle Duration `json:"idle"`
+	Show string   `json:"show,omitempty"`
+	Lock bool     `json:"lock,omitempty"`
+}
+
+const (
+	screensaverLogo  = "logo"
+	screensaverStats = "stats"
+)
+
+// screensaverTick is how often the kiosk checks whether it has sat idle
+// long enough for the screensaver.
+const screensaverTick = time.Second
+
+func (c ScreensaverConfig) validate(lockPIN string) error {
+	switch c.Show {
+	case "", screensaverLogo, screensaverStats:
+	default:
+		return fmt.Errorf("screensaver: show must be %q or %q, not %q", screensaverLogo, screensaverStats, c.Show)
+	}
+	if c.Lock && lockPIN == "" {
+		return fmt.Errorf("screensaver: lock needs lock_pin or admin_pin")
+	}
+	return nil
+}
+
+func newUnlockInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("unlock_prompt")
+	ti.EchoMode = textinput.EchoPassword
+	ti.CharLimit = 16
+	ti.Width = 10
+	return ti
+}
+
+// lock locks the till until the lock PIN is entered. Without one, the
+// till can't be locked.
+func (m *Model) lock() {
+	if m.config.unlockPIN() == "" {
+		return
+	}
+	m.saver, m.locked = true, true
+	m.unlockErr = ""
+	m.unlockInput.Blur()
+}
+
+type screensaverTickMsg struct{}
+
+func (m Model) watchIdle() tea.Cmd {
+	if m.config.Screensaver.Idle.Duration <= 0 {
+		return nil
+	}
+	return tea.Tick(screensaverTick, func(time.Time) tea.Msg { return screensaverTickMsg{} })
+}
+
+// saveScreenIfIdle starts the screensaver once the kiosk has sat idle for
+// long enough. An unlock left half done goes back to the screensaver.
+func (m *Model) saveScreenIfIdle(now time.Time) {
+	if now.Sub(m.lastInput) < m.config.Screensaver.Idle.Duration || m.exporting {
+		return
+	}
+	m.saver = true
+	m.unlockErr = ""
+	m.unlockInput.Blur()
+}
+
+// updateScreensaver handles the input while the screensaver is on. The
+// key that ends it does nothing else; a locked till asks for the PIN
+// first, and nothing else gets through until it is right.
+func (m Model) updateScreensaver(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if !m.config.Screensaver.Lock && !m.locked {
+		m.saver = false
+		return m, nil
+	}
+	if !m.unlockInput.Focused() {
+		m.unlockInput.SetValue("")
+		return m, m.unlockInput.Focus()
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, keys.Apply):
+		if subtle.ConstantTimeCompare([]byte(m.unlockInput.Value()), []byte(m.config.unlockPIN())) != 1 {
+			m.unlockErr = tr("wrong_pin")
+			m.unlockInput.SetValue("")
+			return m, nil
+		}
+		m.saver, m.locked = false, false
+		m.unlockErr = ""
+		m.unlockInput.Blur()
+		return m, nil
+	case key.Matches(keyMsg, keys.Back):
+		m.unlockErr = ""
+		m.unlockInput.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.unlockInput, cmd = m.unlockInput.Update(keyMsg)
+	m.unlockErr = ""
+	return m, cmd
+}
+
+// screensaverView moves what it shows around the screen a little every
+// minute, so that nothing burns in.
+func (m Model) screensaverView() string {
+	now := m.now()
+	var lines []string
+	if m.config.Screensaver.Show == screensaverStats {
+		st := m.store.SalesStats(now)
+		lines = append(lines, trf("stats_today", uiLocale.money(st.TodayRevenue), st.TodaySales),
+			trf("stats_week", uiLocale.money(st.WeekRevenue), st.WeekSales))
+	} else {
+		lines = append(lines, boardTitleStyle.Foreground(theme.Tabs).Render(m.config.Board.Title), now.Format("15:04"))
+	}
+	switch {
+	case m.unlockInput.Focused():
+		lines = append(lines, "", m.unlockInput.View())
+		if m.unlockErr != "" {
+			lines = append(lines, warningStyle.Render(m.unlockErr))
+		}
+	case m.config.Screensaver.Lock || m.locked:
+		lines = append(lines, "", lipgloss.NewStyle().Faint(true).Render(tr("screensaver_locked")))
+	default:
+		lines = append(lines, "", lipgloss.NewStyle().Faint(true).Render(tr("screensaver_wake")))
+	}
+	view := strings.Join(lines, "\n")
+	if m.config.Accessibility == AccessibilityPlain {
+		return view
+	}
+	x, y := float64(now.Minute()%5)/4, float64(now.Minute()/5%4)/3
+	return lipgloss.Place(m.width, m.height, lipgloss.Position(x), lipgloss.Position(y), view)
+}
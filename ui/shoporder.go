@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- SHOP ORDER ---
+
+// ShopOrderConfig arranges the shop table ahead of the sorting by column,
+// so that the drinks everyone comes for are where they always are.
+type ShopOrderConfig struct {
+	// Order lists beverages in the order the shop shows them when it isn't
+	// sorted by a column, by name or, for one size of a drink, with the
+	// size. The rest follow in the order of the inventory.
+	Order []string `json:"order,omitempty"`
+	// Pinned beverages stay at the top, in this order, however the table
+	// is sorted.
+	Pinned []string `json:"pinned,omitempty"`
+	// PinTopSellers pins that many of the most-sold beverages of the last
+	// 30 days below Pinned.
+	PinTopSellers int `json:"pin_top_sellers,omitempty"`
+}
+
+// topSellerDays is how far back PinTopSellers looks.
+const topSellerDays = 30
+
+func (c ShopOrderConfig) validate() error {
+	if c.PinTopSellers < 0 {
+		return fmt.Errorf("shop_order: pin_top_sellers must not be negative")
+	}
+	return nil
+}
+
+// topSellers returns the PinTopSellers beverages that sold the most in the
+// days before now. Beverages that sold nothing aren't pinned.
+func (m Model) topSellers(now time.Time) []string {
+	n := m.config.ShopOrder.PinTopSellers
+	if n == 0 {
+		return nil
+	}
+	since := now.AddDate(0, 0, -topSellerDays)
+	var recent []domain.Sale
+	for _, sale := range m.store.Sales {
+		if !sale.Time.Before(since) {
+			recent = append(recent, sale)
+		}
+	}
+	var names []string
+	for _, r := range store.RankBeverages(m.beverages, recent) {
+		if len(names) == n || r.Units == 0 {
+			break
+		}
+		// Vouchers and dropped beverages sell too, but aren't in the shop.
+		if domain.IndexOf(m.beverages, r.Name) >= 0 {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// pinRank is where b is pinned, or after all pins if it isn't.
+func (m Model) pinRank(b domain.Beverage) int {
+	return rank(m.pins, b)
+}
+
+// orderRank is where b has its place in ShopOrderConfig.Order, or after
+// all of them if it has none.
+func (m Model) orderRank(b domain.Beverage) int {
+	return rank(m.config.ShopOrder.Order, b)
+}
+
+// rank is where b is in names, by its key or else by its name, which stands
+// for every size of a drink; len(names) if it isn't there.
+func rank(names []string, b domain.Beverage) int {
+	if i := slices.Index(names, b.Key()); i >= 0 {
+		return i
+	}
+	if i := slices.Index(names, b.Name); i >= 0 {
+		return i
+	}
+	return len(names)
+}
@@ -0,0 +1,63 @@
+package ui
+
+import "github.com/arunoruto/BubbleTender/domain"
+
+// --- SPLIT CHECKOUT ---
+
+// Lines of the cart can be marked to be checked out on their own, e.g. the
+// drinks one of a group pays for in cash while the rest goes on a tab.
+// With lines marked, checkout books just those as a sale of its own and
+// leaves the others in the cart for the next one.
+
+// toggleMarked marks the selected cart line, or unmarks it.
+func (m *Model) toggleMarked() {
+	b, ok := m.selectedCartLine()
+	if !ok {
+		return
+	}
+	if m.marked[b.Key()] {
+		delete(m.marked, b.Key())
+	} else {
+		m.marked[b.Key()] = true
+	}
+	m.updateCartRows()
+}
+
+// checkoutLines are the cart lines checkout books: the marked ones if any
+// are, else all of them.
+func (m Model) checkoutLines() []domain.SaleLine {
+	lines := m.cartLines()
+	if len(m.marked) == 0 {
+		return lines
+	}
+	var marked []domain.SaleLine
+	for _, l := range lines {
+		if m.marked[l.Name] {
+			marked = append(marked, l)
+		}
+	}
+	return marked
+}
+
+// checkoutTotal is what the lines checkout books cost.
+func (m Model) checkoutTotal() float64 {
+	total := 0.0
+	for _, l := range m.checkoutLines() {
+		total += l.Gross()
+	}
+	return total
+}
+
+// keepUnmarked takes what checkout booked out of the cart: the marked
+// lines, or with none marked, everything.
+func (m *Model) keepUnmarked() {
+	if len(m.marked) == 0 {
+		m.cart = domain.Cart{}
+		m.prices = map[string]float64{}
+	}
+	for name := range m.marked {
+		delete(m.cart, name)
+		delete(m.prices, name)
+	}
+	m.marked = map[string]bool{}
+}
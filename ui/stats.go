@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- STATS ---
+
+const (
+	// statsTopBeverages is how many beverages the units chart shows.
+	statsTopBeverages = 8
+	maxStatsBarWidth  = 30
+	// statsChrome is everything on the stats tab but the chart's rows,
+	// goalChrome what the goal adds to it.
+	statsChrome  = 16
+	goalChrome   = 5
+	goalBarWidth = 30
+)
+
+// goalView shows the month's revenue against the monthly goal: how far
+// along it is, the daily run rate so far and where that ends the month,
+// and what each remaining day has to bring in to reach the goal.
+func (m Model) goalView(st store.SalesStats, now time.Time) string {
+	return strings.Join(m.goalLines(st, now, true), "\n")
+}
+
+// goalLines are the lines of the goal view, with or without the bar.
+func (m Model) goalLines(st store.SalesStats, now time.Time, withBar bool) []string {
+	goal := m.config.MonthlyGoal
+	daysInMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	// Today counts as a day gone, so the rate doesn't jump around in the
+	// morning.
+	elapsed, left := now.Day(), daysInMonth-now.Day()
+	rate := st.MonthRevenue / float64(elapsed)
+
+	progress := min(1, st.MonthRevenue/goal)
+	lines := []string{trf("goal_progress", uiLocale.money(st.MonthRevenue), uiLocale.money(goal), progress*100)}
+	if withBar {
+		filled := int(progress * goalBarWidth)
+		lines = append(lines, lipgloss.NewStyle().Foreground(theme.Tabs).Render(strings.Repeat(glyphs.bar, filled))+
+			lipgloss.NewStyle().Faint(true).Render(strings.Repeat(glyphs.barEmpty, goalBarWidth-filled)))
+	}
+	lines = append(lines, trf("goal_run_rate", uiLocale.money(rate), uiLocale.money(rate*float64(daysInMonth))))
+	switch {
+	case st.MonthRevenue >= goal:
+		lines = append(lines, tr("goal_reached"))
+	case left > 0:
+		lines = append(lines, trf("goal_needed", uiLocale.money((goal-st.MonthRevenue)/float64(left))))
+	default:
+		lines = append(lines, trf("goal_missed", uiLocale.money(goal-st.MonthRevenue)))
+	}
+	return lines
+}
+
+// sparkline draws values as a row of blocks scaled to the largest one.
+func sparkline(values []float64) string {
+	top := slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if top > 0 {
+			i = int(v / top * float64(len(glyphs.sparks)-1))
+		}
+		b.WriteRune(glyphs.sparks[i])
+	}
+	return b.String()
+}
+
+func (m Model) statsView() string {
+	data := m.statsData()
+	now, st := data.now, data.sales
+
+	var s strings.Builder
+	s.WriteString(trf("stats_today", uiLocale.money(st.TodayRevenue), st.TodaySales) + "\n")
+	s.WriteString(trf("stats_week", uiLocale.money(st.WeekRevenue), st.WeekSales) + "\n\n")
+	if m.config.MonthlyGoal > 0 {
+		s.WriteString(m.goalView(st, now) + "\n\n")
+	}
+
+	// The sparkline runs from the first hour with sales to now.
+	first := now.Hour()
+	for h := range now.Hour() {
+		if st.Hourly[h] > 0 {
+			first = h
+			break
+		}
+	}
+	hours := st.Hourly[first : now.Hour()+1]
+	fmt.Fprintf(&s, "%s\n%s\n\n", trf("revenue_per_hour", first), lipgloss.NewStyle().Foreground(theme.Tabs).Render(sparkline(hours)))
+
+	if len(st.Sold) == 0 {
+		s.WriteString(tr("nothing_sold_today"))
+		return s.String()
+	}
+	type row struct {
+		name string
+		qty  int
+	}
+	var rows []row
+	for name, qty := range st.Sold {
+		rows = append(rows, row{name, qty})
+	}
+	slices.SortFunc(rows, func(a, b row) int { return cmp.Or(cmp.Compare(b.qty, a.qty), cmp.Compare(a.name, b.name)) })
+	fit := statsTopBeverages
+	if m.height > 0 {
+		chrome := statsChrome + len(m.notices())
+		if m.config.MonthlyGoal > 0 {
+			chrome += goalChrome
+		}
+		fit = max(1, min(fit, m.height-chrome))
+	}
+	rows = rows[:min(len(rows), fit)]
+
+	nameWidth := 0
+	for _, r := range rows {
+		nameWidth = max(nameWidth, lipgloss.Width(r.name))
+	}
+	nameWidth = min(nameWidth, m.nameWidth)
+	barWidth := max(1, min(maxStatsBarWidth, m.nameWidth+fixedColumnsWidth-nameWidth-12))
+	bar := lipgloss.NewStyle().Foreground(theme.Tabs)
+	var chart strings.Builder
+	for _, r := range rows {
+		n := max(1, r.qty*barWidth/rows[0].qty)
+		name := lipgloss.NewStyle().Width(nameWidth).MaxWidth(nameWidth).Render(r.name)
+		fmt.Fprintf(&chart, "%s %s %d\n", name, bar.Render(strings.Repeat(glyphs.bar, n)), r.qty)
+	}
+	// The window centers every line; the chart's bars have to line up.
+	s.WriteString(tr("units_sold_today") + "\n")
+	s.WriteString(lipgloss.NewStyle().Align(lipgloss.Left).Render(strings.TrimSuffix(chart.String(), "\n")))
+	return s.String()
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- STOCK POLICY ---
+
+// canAdd reports whether one more of b fits into the cart. Outside its
+// hours, none does.
+func (m Model) canAdd(b domain.Beverage) bool {
+	if !b.AvailableAt(m.now()) {
+		return false
+	}
+	return !m.config.StockPolicy.Blocks() || m.cart[b.Key()] < m.available(b)
+}
+
+// oversold returns the cart's beverages that exceed the recorded stock.
+func (m Model) oversold() []string {
+	var names []string
+	for _, b := range m.beverages {
+		if qty := m.cart[b.Key()]; qty > 0 && qty > m.available(b) {
+			names = append(names, b.Key())
+		}
+	}
+	return names
+}
+
+func (m Model) oversoldNotice() string {
+	if m.config.StockPolicy != store.StockWarn {
+		return ""
+	}
+	if names := m.oversold(); len(names) > 0 {
+		return trf("oversold", strings.Join(names, ", "))
+	}
+	return ""
+}
+
+// availabilityNotice explains why the beverage under the cursor can't be
+// added right now.
+func (m Model) availabilityNotice() string {
+	if m.activeTab == 2 {
+		return ""
+	}
+	if b, ok := m.cursorBeverage(); ok && !b.AvailableAt(m.now()) {
+		return trf("not_sold_now", b.Key(), b.AvailabilityNote())
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- STOCKTAKING ---
+
+// The count view of the admin console is for walking the fridge: each
+// beverage's counted stock is typed in next to its book stock, and the
+// cursor moves on to the next one. The differences show as they are
+// counted; accepting them corrects the stock, see store.ApplyStocktake.
+
+// countRows are the rows of the count view: every beverage with a stock of
+// its own, with what was counted so far.
+func (m AdminModel) countRows() []table.Row {
+	var rows []table.Row
+	for _, b := range m.store.Beverages {
+		if b.IsRecipe() {
+			continue
+		}
+		row := table.Row{b.Key(), b.StockLabel(), "", ""}
+		if c, ok := m.counts[b.Key()]; ok {
+			row[2] = b.Unit.Format(c.Counted)
+			switch d := c.Difference(); {
+			case d > 0:
+				row[3] = "+" + b.Unit.Format(d)
+			case d < 0:
+				row[3] = b.Unit.Format(d)
+			default:
+				row[3] = "ok"
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// updateCount handles the keys of the count view.
+func (m AdminModel) updateCount(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, adminKeys.Count):
+		return m.startPrompt(promptCount, m.selected(m.count), "Counted: ")
+	case key.Matches(msg, adminKeys.Accept):
+		m.notice, m.err = m.acceptCounts()
+		return m, nil
+	case key.Matches(msg, adminKeys.ClearCounts):
+		clear(m.counts)
+		m.updateTables()
+		m.notice = "Counts cleared."
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.count, cmd = m.count.Update(msg)
+	return m, cmd
+}
+
+// countStock records the count typed in for the prompt's beverage, against
+// its book stock right now.
+func (m AdminModel) countStock(value string) (string, error) {
+	counted, err := ParseDecimal(value)
+	if err != nil || counted < 0 {
+		return "", fmt.Errorf("invalid count %q", value)
+	}
+	i := m.store.BeverageIndex(m.target)
+	if i < 0 {
+		return "", fmt.Errorf("unknown beverage %q", m.target)
+	}
+	m.counts[m.target] = store.StockCount{Beverage: m.target, Book: m.store.Beverages[i].Stock, Counted: counted}
+	return fmt.Sprintf("Counted %g of %s.", counted, m.target), nil
+}
+
+// acceptCounts corrects the stock to the counts, in the order of the
+// inventory, and starts the next count afresh.
+func (m AdminModel) acceptCounts() (string, error) {
+	if len(m.counts) == 0 {
+		return "Nothing counted yet.", nil
+	}
+	var counts []store.StockCount
+	for _, b := range m.store.Beverages {
+		if c, ok := m.counts[b.Key()]; ok {
+			counts = append(counts, c)
+		}
+	}
+	corrected, err := m.store.ApplyStocktake(counts)
+	if err != nil {
+		return "", err
+	}
+	clear(m.counts)
+	m.updateTables()
+	return fmt.Sprintf("Counted %d beverages, corrected the stock of %d.", len(counts), corrected), nil
+}
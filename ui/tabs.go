@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- TABS ---
+
+// showTab switches to tab. State that is costly to build or to keep is set
+// up when a tab is opened and dropped when it is left, so that the kiosk
+// stays small on a Pi: the stats are counted from the sales history only
+// while the stats tab is open, and the encoded images only stay around in
+// the shop.
+func (m *Model) showTab(tab int) {
+	if tab == m.activeTab {
+		return
+	}
+	switch m.activeTab {
+	case 0: // Shop
+		clear(imageCache)
+	case 2: // Stats
+		m.stats = nil
+	}
+	m.activeTab = tab
+	if tab == 2 {
+		m.stats = newStatsTab(m.store, m.now())
+	}
+}
+
+// statsTab is what the stats tab shows, counted when the tab opens and
+// whenever the store changes rather than on every frame.
+type statsTab struct {
+	now   time.Time
+	sales store.SalesStats
+	// ranks are the rankings by period in days, counted the first time
+	// the period is viewed.
+	ranks map[int][]store.BeverageRank
+}
+
+func newStatsTab(s *store.Store, now time.Time) *statsTab {
+	return &statsTab{now: now, sales: s.SalesStats(now), ranks: map[int][]store.BeverageRank{}}
+}
+
+// statsData returns the stats tab's figures. Views rendered while the tab
+// isn't open, which the kiosk doesn't do, count them afresh.
+func (m Model) statsData() *statsTab {
+	if m.stats != nil {
+		return m.stats
+	}
+	return newStatsTab(m.store, m.now())
+}
+
+// ranking ranks the beverages over the last days, today included.
+func (t *statsTab) ranking(s *store.Store, days int) []store.BeverageRank {
+	if ranks, ok := t.ranks[days]; ok {
+		return ranks
+	}
+	today := time.Date(t.now.Year(), t.now.Month(), t.now.Day(), 0, 0, 0, 0, t.now.Location())
+	ranks := store.RankBeverages(s.Beverages, s.SalesBetween(today.AddDate(0, 0, 1-days), today.AddDate(0, 0, 1)))
+	t.ranks[days] = ranks
+	return ranks
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// --- TERMINAL ---
+
+// TerminalConfig overrides what is detected about the terminal. Every key
+// is optional; "auto" or nothing keeps the detected value.
+type TerminalConfig struct {
+	// Colors is "none", "ansi", "ansi256" or "truecolor".
+	Colors string `json:"colors,omitempty"`
+	// Unicode is "on" or "off". Without it, borders and charts are drawn
+	// in ASCII.
+	Unicode string `json:"unicode,omitempty"`
+	// Mouse is "on" or "off".
+	Mouse string `json:"mouse,omitempty"`
+	// Graphics is "kitty", "sixel" or "off", for how beverage images are
+	// drawn. Only kitty and a few sixel terminals are told apart from the
+	// environment; others need it set.
+	Graphics string `json:"graphics,omitempty"`
+	// Width and Height are used if the terminal doesn't tell its size, as
+	// on serial lines. They default to COLUMNS and LINES, or 80x24.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// TerminalProfile is how the kiosk renders on this terminal.
+type TerminalProfile struct {
+	colors        termenv.Profile
+	unicode       bool
+	mouse         bool
+	graphics      graphicsProtocol
+	width, height int
+}
+
+var colorProfiles = map[string]termenv.Profile{
+	"none":      termenv.Ascii,
+	"ansi":      termenv.ANSI,
+	"ansi256":   termenv.ANSI256,
+	"truecolor": termenv.TrueColor,
+}
+
+// dumbTerminals are the TERM values of serial terminals and the like,
+// which get neither Unicode nor the mouse.
+var dumbTerminals = map[string]bool{"": true, "dumb": true, "vt52": true, "vt100": true, "vt102": true, "vt220": true, "vt320": true, "ansi": true}
+
+func (c TerminalConfig) validate() error {
+	if _, ok := colorProfiles[c.Colors]; !ok && c.Colors != "" && c.Colors != "auto" {
+		return fmt.Errorf("unknown terminal colors %q (use none, ansi, ansi256 or truecolor)", c.Colors)
+	}
+	for name, v := range map[string]string{"unicode": c.Unicode, "mouse": c.Mouse} {
+		if v != "" && v != "auto" && v != "on" && v != "off" {
+			return fmt.Errorf("terminal %s must be auto, on or off, not %q", name, v)
+		}
+	}
+	switch c.Graphics {
+	case "", "auto", "off", string(graphicsKitty), string(graphicsSixel):
+		return nil
+	}
+	return fmt.Errorf("unknown terminal graphics %q (use kitty, sixel or off)", c.Graphics)
+}
+
+// Detect probes the terminal from the environment and applies the
+// overrides. The Linux console has colors, but its fonts lack most of the
+// glyphs, so it is drawn in ASCII.
+func (c TerminalConfig) Detect() TerminalProfile {
+	term := os.Getenv("TERM")
+	p := TerminalProfile{
+		colors:   lipgloss.ColorProfile(),
+		unicode:  utf8Locale() && !dumbTerminals[term] && term != "linux",
+		mouse:    !dumbTerminals[term],
+		graphics: detectGraphics(term),
+		width:    cmp.Or(c.Width, envInt("COLUMNS"), 80),
+		height:   cmp.Or(c.Height, envInt("LINES"), 24),
+	}
+	if colors, ok := colorProfiles[c.Colors]; ok {
+		p.colors = colors
+	}
+	switch c.Unicode {
+	case "on":
+		p.unicode = true
+	case "off":
+		p.unicode = false
+	}
+	switch c.Mouse {
+	case "on":
+		p.mouse = true
+	case "off":
+		p.mouse = false
+	}
+	switch c.Graphics {
+	case "off":
+		p.graphics = graphicsNone
+	case string(graphicsKitty), string(graphicsSixel):
+		p.graphics = graphicsProtocol(c.Graphics)
+	}
+	return p
+}
+
+// utf8Locale reports whether the locale's character set is UTF-8.
+func utf8Locale() bool {
+	lang := strings.ToLower(cmp.Or(os.Getenv("LC_ALL"), os.Getenv("LC_CTYPE"), os.Getenv("LANG")))
+	return strings.Contains(lang, "utf-8") || strings.Contains(lang, "utf8")
+}
+
+func envInt(name string) int {
+	n, _ := strconv.Atoi(os.Getenv(name))
+	return n
+}
+
+// glyphSet holds the characters the UI draws with, besides text.
+type glyphSet struct {
+	border, rounded lipgloss.Border
+	// tee is the bottom corner of a tab on the window's edges: vertical
+	// for an active tab, left and right tees for an inactive one.
+	vertical, teeLeft, teeRight string
+	sparks                      []rune
+	bar, barEmpty               string
+	sortUp, sortDown            string
+	prev, next                  string
+	ellipsis                    string
+	// separator goes between the keys in the short help.
+	separator string
+	// selected and lowStock mark table rows in the accessibility modes.
+	selected, lowStock string
+	// minus and plus are the click zones around the quantity.
+	minus, plus string
+	// marked marks the cart lines to check out on their own.
+	marked string
+}
+
+var (
+	unicodeGlyphs = glyphSet{
+		border: lipgloss.NormalBorder(), rounded: lipgloss.RoundedBorder(),
+		vertical: "│", teeLeft: "├", teeRight: "┤",
+		sparks: []rune("▁▂▃▄▅▆▇█"), bar: "█", barEmpty: "░",
+		sortUp: "▲", sortDown: "▼", prev: "◀", next: "▶", ellipsis: "…", separator: " • ",
+		selected: "▶", lowStock: "!", minus: "[−]", plus: "[+]", marked: "✓",
+	}
+	asciiGlyphs = glyphSet{
+		border: lipgloss.ASCIIBorder(), rounded: lipgloss.ASCIIBorder(),
+		vertical: "|", teeLeft: "+", teeRight: "+",
+		sparks: []rune("_.-=*#"), bar: "#", barEmpty: ".",
+		sortUp: "^", sortDown: "v", prev: "<", next: ">", ellipsis: "~", separator: " | ",
+		selected: ">", lowStock: "!", minus: "[-]", plus: "[+]", marked: "x",
+	}
+	glyphs = unicodeGlyphs
+)
+
+func newHelp() help.Model {
+	h := help.New()
+	h.ShortSeparator = glyphs.separator
+	return h
+}
+
+// arrowKeys spells out the arrows in the key help for ASCII terminals.
+var arrowKeys = strings.NewReplacer("↑", "up", "↓", "down", "←", "left", "→", "right")
+
+// ApplyTerminal sets up rendering for the profile. Like applyTheme, which
+// has to come after it, it must be called before the model is created.
+func ApplyTerminal(p TerminalProfile) {
+	lipgloss.SetColorProfile(p.colors)
+	graphics = p.graphics
+	if p.unicode {
+		glyphs = unicodeGlyphs
+		return
+	}
+	glyphs = asciiGlyphs
+	inactiveTabBorder = tabBorderWithBottom("+", "-", "+")
+	activeTabBorder = tabBorderWithBottom("+", " ", "+")
+	windowStyle = windowStyle.Border(glyphs.border).UnsetBorderTop()
+	dialogStyle = dialogStyle.Border(glyphs.rounded)
+	for _, b := range keys.bindings() {
+		b.SetHelp(arrowKeys.Replace(b.Help().Key), b.Help().Desc)
+	}
+	for _, b := range adminKeys.bindings() {
+		b.SetHelp(arrowKeys.Replace(b.Help().Key), b.Help().Desc)
+	}
+}
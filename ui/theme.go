@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// --- THEME ---
+
+// Theme is the set of colors the UI is drawn with.
+type Theme struct {
+	Tabs               lipgloss.TerminalColor
+	Border             lipgloss.TerminalColor
+	SelectedForeground lipgloss.TerminalColor
+	SelectedBackground lipgloss.TerminalColor
+	Warning            lipgloss.TerminalColor
+}
+
+// ThemeConfig is the theme section of the config file. Preset picks one of
+// the built-in themes; any color set next to it overrides the preset's.
+type ThemeConfig struct {
+	Preset             string `json:"preset"`
+	Tabs               string `json:"tabs,omitempty"`
+	Border             string `json:"border,omitempty"`
+	SelectedForeground string `json:"selected_foreground,omitempty"`
+	SelectedBackground string `json:"selected_background,omitempty"`
+	Warning            string `json:"warning,omitempty"`
+}
+
+// themePresets are the built-in themes from assets/themes, replaced by
+// ApplyAssets once the config is known.
+var themePresets = func() map[string]Theme {
+	presets, err := loadThemePresets(AssetFS(""))
+	if err != nil {
+		panic(err)
+	}
+	return presets
+}()
+
+const defaultThemePreset = "purple"
+
+// Resolve builds the theme described by the config section.
+func (c ThemeConfig) Resolve() (Theme, error) {
+	preset := c.Preset
+	if preset == "" {
+		preset = defaultThemePreset
+	}
+	theme, ok := themePresets[preset]
+	if !ok {
+		names := make([]string, 0, len(themePresets))
+		for name := range themePresets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Theme{}, fmt.Errorf("unknown theme preset %q (available: %s)", preset, strings.Join(names, ", "))
+	}
+	override := func(dst *lipgloss.TerminalColor, color string) {
+		if color != "" {
+			*dst = lipgloss.Color(color)
+		}
+	}
+	override(&theme.Tabs, c.Tabs)
+	override(&theme.Border, c.Border)
+	override(&theme.SelectedForeground, c.SelectedForeground)
+	override(&theme.SelectedBackground, c.SelectedBackground)
+	override(&theme.Warning, c.Warning)
+	return theme, nil
+}
+
+// ApplyTheme rebuilds the package-level styles from the theme. It has to be
+// called before the model is created, since the table copies its styles.
+func ApplyTheme(t Theme) {
+	theme = t
+	inactiveTabStyle = lipgloss.NewStyle().Border(inactiveTabBorder, true).BorderForeground(t.Tabs).Padding(0, 1)
+	activeTabStyle = inactiveTabStyle.Border(activeTabBorder, true)
+	windowStyle = windowStyle.BorderForeground(t.Border)
+	bannerStyle = bannerStyle.Background(t.Tabs)
+	warningStyle = warningStyle.Foreground(t.Warning)
+	lockdownStyle = lockdownStyle.Background(t.Warning)
+	lowStockRowStyle = lowStockRowStyle.Foreground(t.Warning)
+	dialogStyle = dialogStyle.BorderForeground(t.Tabs)
+	activeButtonStyle = activeButtonStyle.Foreground(t.SelectedForeground).Background(t.SelectedBackground)
+}
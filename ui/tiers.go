@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/arunoruto/BubbleTender/domain"
+)
+
+// --- PRICE TIERS ---
+
+// Beverages may have prices of their own for the tiers of price_tiers,
+// like members and staff, next to the regular price guests pay. The tier
+// key goes through the tiers and the shop and cart show the prices of the
+// one it is at. A sale charged to a member's tab is charged at the
+// member's tier, whichever the kiosk shows.
+
+func (c Config) validateTiers() error {
+	if c.MemberTier != "" && !slices.Contains(c.PriceTiers, c.MemberTier) {
+		return fmt.Errorf("member_tier %q is not one of price_tiers", c.MemberTier)
+	}
+	return nil
+}
+
+// TierOf is the price tier of member: the one of their account, or else
+// member_tier. Guests pay the regular price.
+func (c Config) TierOf(member domain.Member) string {
+	switch {
+	case member.Tier != "":
+		return member.Tier
+	case member.IsGuest():
+		return ""
+	}
+	return c.MemberTier
+}
+
+// nextTier moves the kiosk to the next price tier, after the last back to
+// the regular prices.
+func (m *Model) nextTier() {
+	if len(m.config.PriceTiers) == 0 {
+		return
+	}
+	i := slices.Index(m.config.PriceTiers, m.tier)
+	m.tier = ""
+	if i+1 < len(m.config.PriceTiers) {
+		m.tier = m.config.PriceTiers[i+1]
+	}
+	m.beverages = m.priced(m.store.Beverages)
+	m.updateRows()
+}
+
+// memberLines are the lines checkout books for a sale charged to member,
+// at their tier.
+func (m Model) memberLines(member string) []domain.SaleLine {
+	i := m.store.MemberIndex(member)
+	if i < 0 {
+		return nil
+	}
+	tier := m.config.TierOf(m.store.Members[i])
+	if tier == m.tier {
+		return nil
+	}
+	m.tier = tier
+	m.beverages = m.priced(m.store.Beverages)
+	return m.checkoutLines()
+}
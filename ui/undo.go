@@ -0,0 +1,86 @@
+package ui
+
+import "time"
+
+// --- UNDO ---
+
+// cartChange is an entry of the undo stack: the quantity a beverage had in
+// the cart before it was changed.
+type cartChange struct {
+	name string
+	qty  int
+}
+
+// setQty changes the cart and remembers the old quantity for undo.
+func (m *Model) setQty(name string, qty int) {
+	if m.cart[name] == qty {
+		return
+	}
+	m.history = append(m.history, cartChange{name: name, qty: m.cart[name]})
+	m.auditCart(name, m.cart[name], qty, "")
+	m.cart[name] = qty
+	if qty == 0 {
+		// A price set for the line went with it, and so did its mark.
+		delete(m.prices, name)
+		delete(m.marked, name)
+	}
+	m.updateRow(name)
+}
+
+// undo reverts the last quantity change.
+func (m *Model) undo() {
+	if len(m.history) == 0 {
+		return
+	}
+	last := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	m.auditCart(last.name, m.cart[last.name], last.qty, "undo")
+	m.cart[last.name] = last.qty
+	m.updateRow(last.name)
+}
+
+// canUndoSale reports whether the receipt on screen can still be undone.
+// A card payment is refunded on the terminal rather than undone, a payment
+// by link where it was paid.
+func (m Model) canUndoSale(now time.Time) bool {
+	return m.receipt != nil && m.receipt.Card == nil && m.receipt.Link == nil && now.Before(m.undoUntil)
+}
+
+// undoSale takes back the sale of the receipt on screen and puts its items
+// back into the cart, next to what a split checkout left there.
+func (m *Model) undoSale() {
+	if m.adminLocked() {
+		m.undoErr = tr("turn_key_undo")
+		return
+	}
+	if m.receipt != nil && m.receipt.Card != nil {
+		m.undoErr = tr("card_no_undo")
+		return
+	}
+	if m.receipt != nil && m.receipt.Link != nil {
+		m.undoErr = trf("link_no_undo", m.receipt.Link.Method)
+		return
+	}
+	if !m.canUndoSale(m.now()) {
+		m.undoErr = tr("too_late_undo")
+		return
+	}
+	sale := *m.receipt
+	if err := m.store.VoidSale(sale.ID, ""); err != nil {
+		m.undoErr = trf("undo_failed", err)
+		return
+	}
+	m.receipt = nil
+	m.undoErr = ""
+	m.beverages = m.priced(m.store.Beverages)
+	for _, line := range sale.Lines {
+		if !line.Untracked {
+			m.auditCart(line.Name, m.cart[line.Name], m.cart[line.Name]+line.Quantity, "sale undone")
+			m.cart[line.Name] += line.Quantity
+			if line.ListPrice != 0 {
+				m.prices[line.Name] = line.UnitPrice
+			}
+		}
+	}
+	m.updateRows()
+}
@@ -0,0 +1,14 @@
+package ui
+
+import "github.com/arunoruto/BubbleTender/domain"
+
+// --- UNITS ---
+
+// priceLabel renders the price of one item, with the portion if it isn't
+// a single piece.
+func priceLabel(b domain.Beverage) string {
+	if size := b.SizeLabel(); size != "" {
+		return uiLocale.money(b.Price) + "/" + size
+	}
+	return uiLocale.money(b.Price)
+}
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- USB EXPORT ---
+
+// USBConfig configures exporting to a USB drive from the kiosk, for tills
+// without a network path to the treasurer. Drives are picked up once the
+// system has mounted them below one of MountRoots.
+type USBConfig struct {
+	// MountRoots are where removable drives get mounted; empty disables
+	// the export.
+	MountRoots []string `json:"mount_roots"`
+}
+
+// mountsFile lists the mounted filesystems on Linux. Elsewhere it doesn't
+// exist and no drive is ever found.
+var mountsFile = "/proc/mounts"
+
+// findUSBDrive returns the mount point of a drive mounted below one of
+// roots, or "" if there is none.
+func findUSBDrive(roots []string) string {
+	if len(roots) == 0 {
+		return ""
+	}
+	f, err := os.Open(mountsFile)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		dir := unescapeMount(fields[1])
+		for _, root := range roots {
+			if strings.HasPrefix(dir, strings.TrimSuffix(root, "/")+"/") {
+				return dir
+			}
+		}
+	}
+	return ""
+}
+
+// unescapeMount undoes the octal escapes, like \040 for a space, in a
+// mount point from /proc/mounts.
+func unescapeMount(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// exportDoneMsg reports how an export to the USB drive went.
+type exportDoneMsg struct {
+	dir string
+	err error
+}
+
+// exportToUSB writes a backup of the store and the reports over all sales
+// into a new directory on the drive.
+func exportToUSB(s *store.Store, drive string, now time.Time) tea.Cmd {
+	// The store changes under the running export on the next refresh, so
+	// it works on a snapshot.
+	data, err := json.MarshalIndent(s, "", "  ")
+	var snapshot store.Store
+	if err == nil {
+		err = json.Unmarshal(data, &snapshot)
+	}
+	sales := snapshot.Sales
+	return func() tea.Msg {
+		if err != nil {
+			return exportDoneMsg{err: err}
+		}
+		dir := filepath.Join(drive, "bubbletender-"+now.Format("2006-01-02-150405"))
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			return exportDoneMsg{err: err}
+		}
+		period := "all sales"
+		if len(sales) > 0 {
+			period = sales[0].Time.Format(time.DateOnly) + " – " + now.Format(time.DateOnly)
+		}
+		files := []struct {
+			name  string
+			write func(*os.File) error
+		}{
+			{"bubbletender-data.json", func(f *os.File) error { _, err := f.Write(data); return err }},
+			{"tax-report.txt", func(f *os.File) error { return WriteTaxReport(f, period, sales) }},
+			{"sales-report.txt", func(f *os.File) error { return WriteSalesReport(f, period, sales) }},
+			{"ranking.csv", func(f *os.File) error { return WriteRankingCSV(f, store.RankBeverages(snapshot.Beverages, sales)) }},
+		}
+		for _, file := range files {
+			if err := writeSynced(filepath.Join(dir, file.name), file.write); err != nil {
+				return exportDoneMsg{dir: dir, err: err}
+			}
+		}
+		return exportDoneMsg{dir: dir}
+	}
+}
+
+// writeSynced creates a file and flushes it to the drive, so that it is
+// complete even if the stick is pulled without unmounting it.
+func writeSynced(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// usbNotice names the drive by its label, the last part of the mount
+// point, which is what people see on the stick.
+func (m Model) usbNotice() string {
+	if m.usbDrive == "" {
+		return ""
+	}
+	label := filepath.Base(m.usbDrive)
+	switch {
+	case m.exporting:
+		return trf("exporting", label)
+	case m.exportErr != nil:
+		return trf("export_failed", label, m.exportErr)
+	case m.exportedTo != "":
+		return trf("exported", label+"/"+filepath.Base(m.exportedTo))
+	}
+	return trf("usb_drive", label)
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/arunoruto/BubbleTender/store"
+)
+
+// --- INVENTORY VALUATION ---
+
+// WriteValuationReport writes the stock at cost and its total, and names
+// the beverages whose stock isn't all accounted for by costed deliveries.
+func WriteValuationReport(out io.Writer, method store.ValuationMethod, values []store.StockValue) error {
+	fmt.Fprintf(out, "Inventory valuation (%s)\n\n", method)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Beverage\tStock\tUnit cost\tValue\t")
+	var total float64
+	var uncosted []store.StockValue
+	for _, v := range values {
+		fmt.Fprintf(w, "%s\t%s\t%.4f\t%.2f\t\n", v.Beverage, v.Unit.Format(v.Stock), v.UnitCost, v.Value)
+		total += v.Value
+		if v.Uncosted > 0 {
+			uncosted = append(uncosted, v)
+		}
+	}
+	fmt.Fprintf(w, "Total\t\t\t%.2f\t\n", total)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(uncosted) > 0 {
+		fmt.Fprintln(out, "\nStock without a delivery cost to go by:")
+		for _, v := range uncosted {
+			fmt.Fprintf(out, "  %s: %s\n", v.Beverage, v.Unit.Format(v.Uncosted))
+		}
+	}
+	return nil
+}
+
+func WriteValuationCSV(out io.Writer, values []store.StockValue) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"beverage", "stock", "unit", "uncosted", "unit_cost", "value"})
+	for _, v := range values {
+		w.Write([]string{v.Beverage, strconv.FormatFloat(v.Stock, 'f', -1, 64), string(v.Unit),
+			strconv.FormatFloat(v.Uncosted, 'f', -1, 64), strconv.FormatFloat(v.UnitCost, 'f', 4, 64), Money(v.Value)})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"bufio"
+	"io"
+)
+
+// --- VENDING (MDB) ---
+
+// VendingConfig configures the bridge to the space's vending machine.
+//
+// BubbleTender acts as the machine's cashless device. MDB itself is a 9-bit
+// bus, so we don't speak it directly; instead we talk to a USB/serial MDB
+// interface that exposes the cashless role as a line-based text protocol:
+//
+//	-> C,1                     enable the cashless device
+//	-> C,START,<funds>         open a session with the member's available funds
+//	<- c,STATUS,VEND,<price>,<product>  the customer picked a product
+//	-> C,VEND,<price>          approve the vend
+//	-> C,STOP                  deny the vend / close the session
+//	<- c,VEND,SUCCESS          the product was dispensed
+//	<- c,VEND,FAIL             the machine failed to dispense
+//
+// The serial line must already be configured (e.g. with stty), as the
+// adapter's baud rate is vendor specific.
+type VendingConfig struct {
+	Device string `json:"device"`
+	// Slots maps the machine's product numbers to beverages in the
+	// inventory. Vends from unmapped slots are charged but not tracked.
+	Slots map[string]string `json:"slots,omitempty"`
+}
+
+func ScanLines(r io.Reader, ch chan<- string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		ch <- scanner.Text()
+	}
+	close(ch)
+}
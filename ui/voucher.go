@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- VOUCHERS ---
+
+// Paying with a voucher takes its code, typed or scanned; whatever the
+// voucher doesn't cover is paid at the till as usual.
+
+func newVoucherInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = tr("voucher_prompt")
+	ti.Placeholder = "XXXX-XXXX-XXXX"
+	ti.CharLimit = 32
+	ti.Width = 16
+	return ti
+}
+
+func (m *Model) startVoucher() tea.Cmd {
+	m.redeeming = true
+	m.voucherErr = ""
+	m.voucherInput.SetValue("")
+	return m.voucherInput.Focus()
+}
+
+// updateVoucher handles keys while the voucher input is open.
+func (m Model) updateVoucher(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Apply):
+		v, ok := m.store.VoucherByCode(m.voucherInput.Value())
+		switch {
+		case !ok:
+			m.voucherErr = tr("unknown_voucher")
+			return m, nil
+		case v.Balance <= 0:
+			m.voucherErr = tr("voucher_used_up")
+			return m, nil
+		}
+		m.stopVoucher()
+		cmd := m.checkout("", v.Code)
+		return m, cmd
+	case key.Matches(msg, keys.Back):
+		m.stopVoucher()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.voucherInput, cmd = m.voucherInput.Update(msg)
+	m.voucherErr = ""
+	return m, cmd
+}
+
+func (m *Model) stopVoucher() {
+	m.redeeming = false
+	m.voucherErr = ""
+	m.voucherInput.Blur()
+}
+
+func (m Model) voucherView() string {
+	view := "\n\n" + m.voucherInput.View()
+	if m.voucherErr != "" {
+		view += "\n" + warningStyle.Render(m.voucherErr)
+	}
+	return view
+}
+
+// voucherNotice tells what the receipt's voucher paid, what is still due
+// and what is left on it.
+func (m Model) voucherNotice() string {
+	v, ok := m.store.VoucherByCode(m.receipt.Voucher)
+	if !ok {
+		return ""
+	}
+	return trf("paid_by_voucher", PrintLocale.money(m.receipt.VoucherAmount), PrintLocale.money(m.receipt.Due()),
+		PrintLocale.money(v.Balance))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- UPDATES ---
+
+// updateCommand installs the latest release over this executable:
+//
+//	update [-check]
+//
+// With -check, it only says whether there is a newer one and what is new
+// in it. The daemon and the kiosks run the old version until they are
+// restarted.
+func updateCommand(cfg ui.Config, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	check := fs.Bool("check", false, "only show whether there is a newer release")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: update [-check]")
+	}
+	r, err := store.LatestRelease(cfg.Updates.URL)
+	if err != nil {
+		return err
+	}
+	current := store.CurrentVersion()
+	if !r.NewerThan(current) {
+		fmt.Printf("BubbleTender %s is up to date; the latest release is %s.\n", current, r.Version)
+		return nil
+	}
+	fmt.Printf("BubbleTender %s is out, this is %s. %s\n\n%s\n", r.Version, current, r.URL, r.Notes)
+	if *check {
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if err := r.Install(exe); err != nil {
+		return err
+	}
+	fmt.Printf("\nInstalled %s as %s. Restart serve and the kiosks to run it.\n", r.Version, exe)
+	return nil
+}
+
+// checkReleases looks for newer releases as often as updates.check says,
+// until done is closed, and logs each one it finds once.
+func checkReleases(cfg *atomic.Pointer[ui.Config], done <-chan struct{}) {
+	logged := ""
+	wait := time.Duration(0)
+	for {
+		select {
+		case <-time.After(wait):
+		case <-done:
+			return
+		}
+		c := cfg.Load().Updates
+		wait = max(c.Check.Duration, time.Hour)
+		if c.Check.Duration <= 0 {
+			continue
+		}
+		r, err := store.LatestRelease(c.URL)
+		if err != nil || !r.NewerThan(store.CurrentVersion()) || r.Version == logged {
+			continue
+		}
+		logged = r.Version
+		fmt.Fprintf(os.Stderr, "BubbleTender %s is out: %s; install it with bubbletender update\n", r.Version, r.Summary())
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- INVENTORY VALUATION ---
+
+// valuationCommand prints or exports the stock at cost, by the valuation
+// setting unless -method says otherwise:
+//
+//	valuation [-method fifo|average] [-csv] [-o FILE]
+func valuationCommand(cfg ui.Config, s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("valuation", flag.ExitOnError)
+	method := fs.String("method", string(cfg.Valuation), "fifo or average")
+	asCSV := fs.Bool("csv", false, "write the valuation as CSV")
+	output := fs.String("o", "", "write to this file instead of the terminal")
+	fs.Parse(args)
+	m := store.ValuationMethod(*method)
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	values := store.ValueStock(s.Beverages, s.Restocks, m)
+
+	write := func(out io.Writer) error {
+		if *asCSV {
+			return ui.WriteValuationCSV(out, values)
+		}
+		return ui.WriteValuationReport(out, m, values)
+	}
+	if *output == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- VENDING (MDB) ---
+
+// vendBridge holds the state of one cashless session at a time.
+type vendBridge struct {
+	store *store.Store
+	cfg   ui.Config
+	out   io.Writer
+	log   io.Writer
+
+	member  string // member of the open session, "" if none
+	product string // product of the vend waiting for its result
+	price   float64
+}
+
+func (b *vendBridge) send(parts ...string) error {
+	_, err := fmt.Fprintf(b.out, "%s\n", strings.Join(parts, ","))
+	return err
+}
+
+// startSession opens a cashless session for the member carrying token.
+func (b *vendBridge) startSession(token string) error {
+	member, ok := b.store.MemberByToken(token)
+	if !ok {
+		fmt.Fprintf(b.log, "unknown card %q\n", token)
+		return nil
+	}
+	b.member = member.ID
+	funds := domain.RoundCents(member.Balance + b.cfg.TabLimit)
+	if funds <= 0 {
+		fmt.Fprintf(b.log, "%s has reached the tab limit\n", member.Name)
+		b.member = ""
+		return nil
+	}
+	fmt.Fprintf(b.log, "session for %s (€%.2f available)\n", member.Name, funds)
+	return b.send("C", "START", fmt.Sprintf("%.2f", funds))
+}
+
+// handle processes one line received from the MDB interface.
+func (b *vendBridge) handle(line string) error {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 3 || fields[0] != "c" {
+		return nil
+	}
+	switch {
+	case fields[1] == "STATUS" && fields[2] == "VEND" && len(fields) >= 5:
+		// A failed reload leaves us with the state of the last one.
+		_ = b.store.Reload()
+		price, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil || b.member == "" || b.store.Lockdown == store.LockdownReadOnly {
+			return b.send("C", "STOP")
+		}
+		if err := b.store.CanCharge(b.member, price, b.cfg.TabLimit); err != nil {
+			fmt.Fprintf(b.log, "denied vend of %s: %v\n", fields[4], err)
+			return b.send("C", "STOP")
+		}
+		b.product, b.price = fields[4], price
+		return b.send("C", "VEND", fields[3])
+	case fields[1] == "VEND" && fields[2] == "SUCCESS":
+		if b.product == "" {
+			return nil
+		}
+		_, err := b.store.RecordSale(b.vendSale())
+		if err != nil {
+			// The product is already out of the machine, so all we can do
+			// is make sure the failed booking shows up in the log.
+			fmt.Fprintf(b.log, "booking vend of %s for %s failed: %v\n", b.product, b.member, err)
+		} else {
+			fmt.Fprintf(b.log, "%s vended %s for €%.2f\n", b.member, b.product, b.price)
+		}
+		b.member, b.product = "", ""
+		return b.send("C", "STOP")
+	case fields[1] == "VEND" && fields[2] == "FAIL":
+		b.product = ""
+		return b.send("C", "STOP")
+	case fields[1] == "STATUS" && fields[2] == "IDLE" && b.product == "":
+		// The customer walked away or cancelled on the machine.
+		b.member = ""
+	}
+	return nil
+}
+
+// vendSale builds the sale for the vend that just succeeded.
+func (b *vendBridge) vendSale() domain.Sale {
+	line := domain.SaleLine{Name: b.cfg.Vending.Slots[b.product], Quantity: 1, UnitPrice: b.price}
+	var beverage domain.Beverage
+	if i := b.store.BeverageIndex(line.Name); line.Name != "" && i >= 0 {
+		beverage = b.store.Beverages[i]
+	} else {
+		line.Name = "Vending slot " + b.product
+		line.Untracked = true
+	}
+	b.cfg.Classify(&line, beverage)
+	return domain.Sale{Time: time.Now(), Member: b.member, Lines: []domain.SaleLine{line}}
+}
+
+// vendCommand runs the MDB bridge until the interface or stdin closes.
+// Member cards are read from stdin, one token per line, which is what
+// keyboard-emulating RFID readers produce.
+func vendCommand(cfg ui.Config, load configLoader, s *store.Store, args []string) error {
+	device := cfg.Vending.Device
+	if len(args) > 0 {
+		device = args[0]
+	}
+	port, err := os.OpenFile(device, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer port.Close()
+
+	b := &vendBridge{store: s, cfg: cfg, out: port, log: os.Stderr}
+	if err := b.send("C", "1"); err != nil {
+		return err
+	}
+
+	d := startDaemon(load)
+	defer d.stop()
+	lines := make(chan string)
+	tokens := make(chan string)
+	go ui.ScanLines(port, lines)
+	go ui.ScanLines(os.Stdin, tokens)
+	d.ready()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			err = b.handle(line)
+		case token, ok := <-tokens:
+			if !ok {
+				if d.managed {
+					tokens = nil
+					continue
+				}
+				return nil
+			}
+			if token = strings.TrimSpace(token); token != "" {
+				err = b.startSession(token)
+			}
+		case <-d.hup:
+			cfg, rerr := d.reload(s)
+			if rerr != nil {
+				fmt.Fprintf(os.Stderr, "reload: %v\n", rerr)
+				continue
+			}
+			b.cfg = cfg
+		case <-d.term:
+			if b.member != "" {
+				return b.send("C", "STOP")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- VOUCHERS ---
+
+// voucherCommand sells vouchers and shows what is left on them:
+//
+//	voucher issue [-note N] <value>
+//	voucher list
+//	voucher show <code>
+//
+// issue books the sale of the voucher and prints its code.
+func voucherCommand(s *store.Store, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "issue":
+		fs := flag.NewFlagSet("voucher issue", flag.ExitOnError)
+		note := fs.String("note", "", "who or what the voucher is for")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: voucher issue [-note N] <value>")
+		}
+		value, err := ui.ParseDecimal(fs.Arg(0))
+		if err != nil || value <= 0 {
+			return fmt.Errorf("invalid value %q", fs.Arg(0))
+		}
+		v, err := s.IssueVoucher(value, *note, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s worth %s (sale #%d)\n", v.Code, ui.Money(v.Value), v.Sale)
+		return nil
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Code\tIssued\tValue\tBalance\tNote")
+		var outstanding float64
+		for _, v := range s.Vouchers {
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%.2f\t%s\n", v.Code, v.Issued.Format("2006-01-02"), v.Value, v.Balance, v.Note)
+			outstanding += v.Balance
+		}
+		fmt.Fprintf(w, "Outstanding\t\t\t%.2f\t\n", outstanding)
+		return w.Flush()
+	case "show":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: voucher show <code>")
+		}
+		v, ok := s.VoucherByCode(args[1])
+		if !ok {
+			return fmt.Errorf("unknown voucher %q", args[1])
+		}
+		fmt.Printf("%s: %.2f of %.2f left\n", v.Code, v.Balance, v.Value)
+		for _, sale := range s.Sales {
+			if sale.Voucher == v.Code {
+				fmt.Printf("  %s  sale #%d  %.2f  %s\n", sale.Time.Format("2006-01-02 15:04"), sale.ID, sale.VoucherAmount, sale.Items())
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown voucher command %q", args[0])
+}
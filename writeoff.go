@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- WRITE-OFFS ---
+
+// writeOffCommand writes off stock that went without being sold, and
+// reports what was:
+//
+//	writeoff add [-note N] <beverage> <quantity> <reason>
+//	writeoff list [-from D] [-to D]
+//	writeoff report [-from D] [-to D]
+//
+// The reasons are breakage, expired, spoiled, staff, missing and other.
+// The report is the shrinkage by beverage and reason, at cost.
+func writeOffCommand(s *store.Store, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("writeoff add", flag.ExitOnError)
+		note := fs.String("note", "", "what happened, e.g. \"dropped while restocking\"")
+		fs.Parse(args[1:])
+		if fs.NArg() != 3 {
+			return fmt.Errorf("usage: writeoff add [-note N] <beverage> <quantity> <reason>")
+		}
+		qty, err := ui.ParseDecimal(fs.Arg(1))
+		if err != nil || qty <= 0 {
+			return fmt.Errorf("invalid quantity %q", fs.Arg(1))
+		}
+		reason, err := store.ParseWriteOffReason(fs.Arg(2))
+		if err != nil {
+			return err
+		}
+		return s.WriteOff(store.WriteOff{Time: time.Now(), Beverage: fs.Arg(0), Quantity: qty, Reason: reason, Note: *note})
+	case "list", "report":
+		fs := flag.NewFlagSet("writeoff "+args[0], flag.ExitOnError)
+		dates := addDateRangeFlags(fs)
+		fs.Parse(args[1:])
+		from, to, err := dates.parse()
+		if err != nil {
+			return err
+		}
+		writeOffs := s.WriteOffsBetween(from, to)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		var total float64
+		if args[0] == "list" {
+			fmt.Fprintln(w, "Time\tBeverage\tQuantity\tReason\tCost\tNote")
+			for _, wo := range writeOffs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%s\n", wo.Time.Format("2006-01-02 15:04"), wo.Beverage, wo.Unit.Format(wo.Quantity), wo.Reason, wo.Cost, wo.Note)
+				total += wo.Cost
+			}
+			fmt.Fprintf(w, "Total\t\t\t\t%.2f\t\n", total)
+			return w.Flush()
+		}
+		fmt.Printf("Shrinkage %s\n\n", dates)
+		fmt.Fprintln(w, "Beverage\tReason\tQuantity\tTimes\tCost")
+		for _, sh := range store.SumShrinkage(writeOffs) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%.2f\n", sh.Beverage, sh.Reason, sh.Unit.Format(sh.Quantity), sh.Count, sh.Cost)
+			total += sh.Cost
+		}
+		fmt.Fprintf(w, "Total\t\t\t\t%.2f\n", total)
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown writeoff command %q", args[0])
+}
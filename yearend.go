@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/arunoruto/BubbleTender/domain"
+	"github.com/arunoruto/BubbleTender/store"
+	"github.com/arunoruto/BubbleTender/ui"
+)
+
+// --- YEAR-END EXPORT ---
+
+// The year-end export is what the treasurer presents at the annual general
+// meeting. It is signed with the club's key, so that the membership can
+// check the figures weren't edited after they left the till, and it names
+// a digest of the year's sales and ledger, so that they can be checked
+// against the store file itself.
+
+// yearEndReport is the export's figures. Balances and stock are those at
+// the time of the export; run it when the year is closed.
+type yearEndReport struct {
+	Year      int       `json:"year"`
+	Generated time.Time `json:"generated"`
+	Sales     int       `json:"sales"`
+	Gross     float64   `json:"gross"`
+	Net       float64   `json:"net"`
+	Tax       float64   `json:"tax"`
+	// TaxClasses split the totals by tax class and rate.
+	TaxClasses []yearEndTax     `json:"tax_classes"`
+	TopUps     float64          `json:"top_ups"`
+	Balances   []yearEndBalance `json:"balances"`
+	// Balance is the sum of all balances, what the club owes its members.
+	Balance float64 `json:"balance"`
+	// Vouchers is what is left on the vouchers sold, which the club owes
+	// too.
+	Vouchers   float64        `json:"vouchers"`
+	Stock      []yearEndStock `json:"stock"`
+	StockValue float64        `json:"stock_value"`
+	// Valuation is how the stock was valued, see store.ValueStock.
+	Valuation store.ValuationMethod `json:"valuation"`
+	// Digest is the SHA-256 of the year's sales and ledger entries as
+	// stored, see ledgerDigest.
+	Digest string `json:"digest"`
+}
+
+type yearEndTax struct {
+	Class string  `json:"class"`
+	Rate  float64 `json:"rate"`
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+	Gross float64 `json:"gross"`
+}
+
+type yearEndBalance struct {
+	Member  string  `json:"member"`
+	Name    string  `json:"name,omitempty"`
+	Balance float64 `json:"balance"`
+}
+
+// yearEndStock is the stock of a beverage at cost.
+type yearEndStock struct {
+	Beverage string      `json:"beverage"`
+	Stock    float64     `json:"stock"`
+	Unit     domain.Unit `json:"unit,omitempty"`
+	UnitCost float64     `json:"unit_cost,omitempty"`
+	Value    float64     `json:"value"`
+}
+
+// signedExport is the file written: the report, with the public key to check
+// the signature against. The signature is over the report's compact JSON,
+// so that the file can be indented for reading. Members should compare the
+// key's fingerprint with the one the board published.
+type signedExport struct {
+	Report    json.RawMessage `json:"report"`
+	PublicKey string          `json:"public_key"`
+	Signature string          `json:"signature"`
+}
+
+// yearEndCommand writes and checks the year-end export:
+//
+//	year-end [-year Y] [-o file]
+//	year-end verify [-key public.pem] <file>
+//	year-end keygen <private.pem>
+//
+// verify checks the signature and recomputes the digest from the store;
+// keygen makes a key pair for the signing_key setting.
+func yearEndCommand(cfg ui.Config, s *store.Store, args []string) error {
+	if len(args) > 0 && args[0] == "verify" {
+		return verifyYearEnd(s, args[1:])
+	}
+	if len(args) > 0 && args[0] == "keygen" {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: year-end keygen <private.pem>")
+		}
+		return writeSigningKey(args[1])
+	}
+	fs := flag.NewFlagSet("year-end", flag.ExitOnError)
+	year := fs.Int("year", time.Now().Year(), "the year to export")
+	out := fs.String("o", "", "file to write the export to instead of stdout")
+	fs.Parse(args)
+	if cfg.SigningKey == "" {
+		return fmt.Errorf("set signing_key in the config to sign the export")
+	}
+	key, err := readSigningKey(cfg.SigningKey)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(yearEnd(s, *year, cfg.Valuation, time.Now()))
+	if err != nil {
+		return err
+	}
+	export, err := json.MarshalIndent(signedExport{
+		Report:    data,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	export = append(export, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(export)
+		return err
+	}
+	if err := os.WriteFile(*out, export, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the %d export to %s, signed by %s\n", *year, *out, keyFingerprint(key.Public().(ed25519.PublicKey)))
+	return nil
+}
+
+// yearEnd counts the figures of year, valuing the stock by method.
+func yearEnd(s *store.Store, year int, method store.ValuationMethod, now time.Time) yearEndReport {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	sales := s.SalesBetween(from, from.AddDate(1, 0, 0))
+	r := yearEndReport{Year: year, Generated: now, Sales: len(sales), Valuation: method, Digest: ledgerDigest(s, year)}
+	for _, t := range domain.SummarizeTax(sales) {
+		r.TaxClasses = append(r.TaxClasses, yearEndTax{Class: t.Class, Rate: t.Rate,
+			Net: domain.RoundCents(t.Net), Tax: domain.RoundCents(t.Tax), Gross: domain.RoundCents(t.Gross)})
+		r.Gross += t.Gross
+		r.Net += t.Net
+		r.Tax += t.Tax
+	}
+	for _, e := range s.Ledger {
+		if e.Time.Year() == year && e.Kind == store.LedgerTopUp {
+			r.TopUps += e.Amount
+		}
+	}
+	for _, m := range s.Members {
+		r.Balances = append(r.Balances, yearEndBalance{Member: m.ID, Name: m.Name, Balance: m.Balance})
+		r.Balance += m.Balance
+	}
+	for _, v := range s.Vouchers {
+		r.Vouchers += v.Balance
+	}
+	for _, v := range store.ValueStock(s.Beverages, s.Restocks, method) {
+		r.Stock = append(r.Stock, yearEndStock{Beverage: v.Beverage, Stock: v.Stock, Unit: v.Unit,
+			UnitCost: domain.RoundCents(v.UnitCost), Value: v.Value})
+		r.StockValue += v.Value
+	}
+	r.Gross, r.Net, r.Tax = domain.RoundCents(r.Gross), domain.RoundCents(r.Net), domain.RoundCents(r.Tax)
+	r.TopUps, r.Balance, r.StockValue = domain.RoundCents(r.TopUps), domain.RoundCents(r.Balance), domain.RoundCents(r.StockValue)
+	r.Vouchers = domain.RoundCents(r.Vouchers)
+	return r
+}
+
+// ledgerDigest hashes the year's sales and ledger entries, one JSON line
+// each in the order they are stored. Anyone with the store file can
+// recompute it; voiding a sale of a day that wasn't closed changes it.
+func ledgerDigest(s *store.Store, year int) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, sale := range s.Sales {
+		if sale.Time.Year() == year {
+			enc.Encode(sale)
+		}
+	}
+	for _, e := range s.Ledger {
+		if e.Time.Year() == year {
+			enc.Encode(e)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func verifyYearEnd(s *store.Store, args []string) error {
+	fs := flag.NewFlagSet("year-end verify", flag.ExitOnError)
+	keyPath := fs.String("key", "", "PEM file with the public key the export must be signed with")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: year-end verify [-key public.pem] <file>")
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var export signedExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("reading %s: %w", fs.Arg(0), err)
+	}
+	pub, err := base64.StdEncoding.DecodeString(export.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("the export has no valid public key")
+	}
+	if *keyPath != "" {
+		want, err := readPublicKey(*keyPath)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(pub, want) {
+			return fmt.Errorf("the export is signed by %s, not by %s", keyFingerprint(pub), keyFingerprint(want))
+		}
+	}
+	var report bytes.Buffer
+	if err := json.Compact(&report, export.Report); err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(export.Signature)
+	if err != nil || !ed25519.Verify(pub, report.Bytes(), sig) {
+		return fmt.Errorf("the signature doesn't match: the export was changed after it was signed")
+	}
+	fmt.Printf("Signature OK, signed by %s\n", keyFingerprint(pub))
+
+	var r yearEndReport
+	if err := json.Unmarshal(export.Report, &r); err != nil {
+		return err
+	}
+	if digest := ledgerDigest(s, r.Year); digest != r.Digest {
+		return fmt.Errorf("the %d sales and ledger in this store don't match the export", r.Year)
+	}
+	fmt.Printf("Digest OK, the %d sales and ledger in this store match the export\n", r.Year)
+	return nil
+}
+
+// keyFingerprint is how the key is shown to people comparing it, like
+// "SHA256:3b1f…" for its hash.
+func keyFingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// readSigningKey reads a PKCS #8 Ed25519 private key, as written by keygen
+// or by "openssl genpkey -algorithm ed25519".
+func readSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	return priv, nil
+}
+
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(path + ": no PEM data")
+	}
+	return block, nil
+}
+
+// writeSigningKey makes a key pair: the private key goes to path, readable
+// by its owner only, and the public key next to it with .pub appended, to
+// be handed to the membership.
+func writeSigningKey(path string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: privDER}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s and %s; the key is %s\n", path, path+".pub", keyFingerprint(pub))
+	return nil
+}